@@ -0,0 +1,50 @@
+// Package age encrypts the data to the X25519 recipients of the age file encryption format v1 by filippo.io/age,
+// see https://age-encryption.org/v1, the files can be decrypted by the age tools with the same identity
+package age
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"filippo.io/age"
+)
+
+// Intro the first line of the age file
+const Intro = "age-encryption.org/v1\n"
+
+var (
+	errNoRecipient = errors.New("at least one recipient is required")
+	errNoIdentity  = errors.New("at least one identity is required")
+)
+
+// Encrypt create a writer that encrypts the data to all the recipients, any of their identities can decrypt it.
+// The writer must be closed to write the last chunk, and it doesn't close the underlying writer
+func Encrypt(w io.Writer, recipients ...*X25519Recipient) (io.WriteCloser, error) {
+	if len(recipients) == 0 {
+		return nil, errNoRecipient
+	}
+	rs := make([]age.Recipient, 0, len(recipients))
+	for _, r := range recipients {
+		rs = append(rs, r)
+	}
+	return age.Encrypt(w, rs...)
+}
+
+// Decrypt create a reader that decrypts the data by any of the identities, the header is verified before return,
+// and the payload is authenticated chunk by chunk while reading
+func Decrypt(r io.Reader, identities ...*X25519Identity) (io.Reader, error) {
+	if len(identities) == 0 {
+		return nil, errNoIdentity
+	}
+	is := make([]age.Identity, 0, len(identities))
+	for _, i := range identities {
+		is = append(is, i)
+	}
+	return age.Decrypt(r, is...)
+}
+
+// IsAge report whether the data starts with the intro of the age file
+func IsAge(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(Intro))
+}
@@ -0,0 +1,256 @@
+package age
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	chunkSize = 64 * 1024
+	nonceSize = 16
+	tagSize   = 16
+)
+
+func TestAge(t *testing.T) {
+	alice := mustGenerateIdentity(t)
+	bob := mustGenerateIdentity(t)
+	testCases := []struct {
+		name string
+		size int
+	}{
+		{"empty", 0},
+		{"one byte", 1},
+		{"less than a chunk", chunkSize - 1},
+		{"a chunk", chunkSize},
+		{"more than a chunk", chunkSize + 1},
+		{"two chunks", chunkSize * 2},
+		{"large", chunkSize*3 + 100},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			plain := make([]byte, tc.size)
+			rand.Read(plain)
+			data := mustEncrypt(t, plain, alice.Recipient(), bob.Recipient())
+			if !IsAge(data) {
+				t.Errorf("expect to get an age file")
+			}
+			for _, identity := range []*X25519Identity{alice, bob} {
+				r, err := Decrypt(bytes.NewReader(data), identity)
+				if err != nil {
+					t.Errorf("decrypt error => %v", err)
+					return
+				}
+				actual, err := io.ReadAll(r)
+				if err != nil {
+					t.Errorf("read the decrypted data error => %v", err)
+					return
+				}
+				if !bytes.Equal(plain, actual) {
+					t.Errorf("expect to get the plain data with %d bytes, but get %d bytes", len(plain), len(actual))
+				}
+			}
+		})
+	}
+}
+
+func TestAge_ReturnError(t *testing.T) {
+	alice := mustGenerateIdentity(t)
+	eve := mustGenerateIdentity(t)
+	plain := make([]byte, chunkSize*2+10)
+	rand.Read(plain)
+	data := mustEncrypt(t, plain, alice.Recipient())
+	headerLen := bytes.Index(data, []byte("\n--- "))
+	payloadStart := bytes.IndexByte(data[headerLen+1:], '\n') + headerLen + 2 + nonceSize
+
+	testCases := []struct {
+		name     string
+		identity *X25519Identity
+		modify   func(data []byte) []byte
+	}{
+		{"wrong identity", eve, nil},
+		{"invalid intro", alice, func(data []byte) []byte {
+			return append([]byte("age-encryption.org/v2\n"), data[len(Intro):]...)
+		}},
+		{"modified header", alice, func(data []byte) []byte {
+			return bytes.Replace(data, []byte("-> X25519 "), []byte("-> X25519 extra "), 1)
+		}},
+		{"additional stanza", alice, func(data []byte) []byte {
+			return bytes.Replace(data, []byte("-> X25519 "), []byte("-> unknown\n\n-> X25519 "), 1)
+		}},
+		{"truncated header", alice, func(data []byte) []byte {
+			return data[:headerLen]
+		}},
+		{"modified payload", alice, func(data []byte) []byte {
+			data[payloadStart+10] ^= 1
+			return data
+		}},
+		{"truncated payload", alice, func(data []byte) []byte {
+			return data[:len(data)-10]
+		}},
+		{"the last chunk is removed", alice, func(data []byte) []byte {
+			return data[:payloadStart+(chunkSize+tagSize)*2]
+		}},
+		{"appended data", alice, func(data []byte) []byte {
+			return append(data, 0)
+		}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			modified := bytes.Clone(data)
+			if tc.modify != nil {
+				modified = tc.modify(modified)
+			}
+			r, err := Decrypt(bytes.NewReader(modified), tc.identity)
+			if err == nil {
+				_, err = io.ReadAll(r)
+			}
+			if err == nil {
+				t.Errorf("expect to get an error, but get nil")
+			}
+		})
+	}
+}
+
+func TestDecrypt_Vector(t *testing.T) {
+	// the file is encrypted by the age tool, see https://github.com/FiloSottile/age/tree/main/testdata
+	identities, err := LoadIdentities("testdata/example_keys.txt")
+	if err != nil || len(identities) != 1 {
+		t.Fatalf("load the identities error => %v", err)
+	}
+	if expect := "age1cy0su9fwf3gf9mw868g5yut09p6nytfmmnktexz2ya5uqg9vl9sss4euqm"; identities[0].Recipient().String() != expect {
+		t.Errorf("expect to get the recipient %s, but get %s", expect, identities[0].Recipient())
+	}
+	data, err := os.ReadFile("testdata/example.age")
+	if err != nil {
+		t.Fatalf("read the age file error => %v", err)
+	}
+	if !IsAge(data) {
+		t.Errorf("expect to get an age file")
+	}
+	r, err := Decrypt(bytes.NewReader(data), identities...)
+	if err != nil {
+		t.Fatalf("decrypt error => %v", err)
+	}
+	actual, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read the decrypted data error => %v", err)
+	}
+	if expect := "Black lives matter."; string(actual) != expect {
+		t.Errorf("expect to get the plain data %q, but get %q", expect, actual)
+	}
+
+	data[len(data)-1] ^= 1
+	if r, err = Decrypt(bytes.NewReader(data), identities...); err == nil {
+		_, err = io.ReadAll(r)
+	}
+	if err == nil {
+		t.Errorf("expect to get an error with the modified age file, but get nil")
+	}
+}
+
+func TestEncrypt_ReturnError(t *testing.T) {
+	if _, err := Encrypt(io.Discard); !errors.Is(err, errNoRecipient) {
+		t.Errorf("expect to get error %v, but get %v", errNoRecipient, err)
+	}
+	if _, err := Decrypt(strings.NewReader(Intro)); !errors.Is(err, errNoIdentity) {
+		t.Errorf("expect to get error %v, but get %v", errNoIdentity, err)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	dir := t.TempDir()
+	identityFile := filepath.Join(dir, "identity.txt")
+	identity, err := GenerateIdentityFile(identityFile)
+	if err != nil {
+		t.Errorf("generate identity file error => %v", err)
+		return
+	}
+	if _, err = GenerateIdentityFile(identityFile); !os.IsExist(err) {
+		t.Errorf("expect to never overwrite the existing identity file, but get %v", err)
+	}
+	identities, err := LoadIdentities(identityFile)
+	if err != nil || len(identities) != 1 || identities[0].String() != identity.String() {
+		t.Errorf("expect to load the generated identity, but get %v => %v", identities, err)
+	}
+	if !strings.HasPrefix(identity.String(), "AGE-SECRET-KEY-1") {
+		t.Errorf("expect to get the upper case identity, but get a different prefix")
+	}
+	parsed, err := ParseX25519Identity(identity.String())
+	if err != nil || parsed.Recipient().String() != identity.Recipient().String() {
+		t.Errorf("expect to parse the identity => %v", err)
+	}
+
+	other := mustGenerateIdentity(t)
+	recipientsFile := filepath.Join(dir, "recipients.txt")
+	content := "# backup keys\n" + identity.Recipient().String() + "\n\n" + other.Recipient().String() + "\n"
+	if err = os.WriteFile(recipientsFile, []byte(content), 0600); err != nil {
+		t.Errorf("write recipients file error => %v", err)
+		return
+	}
+	recipients, err := LoadRecipients(recipientsFile)
+	if err != nil || len(recipients) != 2 {
+		t.Errorf("expect to load 2 recipients, but get %v => %v", recipients, err)
+	}
+	recipients, err = ParseRecipients(" " + identity.Recipient().String() + ", " + other.Recipient().String() + ",")
+	if err != nil || len(recipients) != 2 || recipients[1].String() != other.Recipient().String() {
+		t.Errorf("expect to parse 2 recipients, but get %v => %v", recipients, err)
+	}
+}
+
+func TestKeys_ReturnError(t *testing.T) {
+	dir := t.TempDir()
+	identity := mustGenerateIdentity(t)
+	emptyFile := filepath.Join(dir, "empty.txt")
+	if err := os.WriteFile(emptyFile, []byte("# nothing\n"), 0600); err != nil {
+		t.Errorf("write file error => %v", err)
+		return
+	}
+
+	if _, err := ParseRecipients(" , "); !errors.Is(err, errEmptyRecipients) {
+		t.Errorf("expect to get error %v, but get %v", errEmptyRecipients, err)
+	}
+	if _, err := ParseRecipients(identity.String()); !errors.Is(err, errInvalidRecipient) {
+		t.Errorf("expect to get error %v, but get %v", errInvalidRecipient, err)
+	}
+	if _, err := ParseX25519Identity(identity.Recipient().String()); !errors.Is(err, errInvalidIdentity) {
+		t.Errorf("expect to get error %v, but get %v", errInvalidIdentity, err)
+	}
+	if _, err := LoadRecipients(emptyFile); !errors.Is(err, errEmptyRecipients) {
+		t.Errorf("expect to get error %v, but get %v", errEmptyRecipients, err)
+	}
+	if _, err := LoadIdentities(emptyFile); !errors.Is(err, errEmptyIdentities) {
+		t.Errorf("expect to get error %v, but get %v", errEmptyIdentities, err)
+	}
+	if _, err := LoadIdentities(filepath.Join(dir, "not_found.txt")); !os.IsNotExist(err) {
+		t.Errorf("expect to get the not exist error, but get %v", err)
+	}
+}
+
+func mustGenerateIdentity(t *testing.T) *X25519Identity {
+	identity, err := GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("generate identity error => %v", err)
+	}
+	return identity
+}
+
+func mustEncrypt(t *testing.T, plain []byte, recipients ...*X25519Recipient) []byte {
+	var buf bytes.Buffer
+	w, err := Encrypt(&buf, recipients...)
+	if err != nil {
+		t.Fatalf("create encrypt writer error => %v", err)
+	}
+	if _, err = w.Write(plain); err != nil {
+		t.Fatalf("write plain data error => %v", err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatalf("close encrypt writer error => %v", err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,112 @@
+package age
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	errEmptyRecipients = errors.New("no recipient is found")
+	errEmptyIdentities = errors.New("no identity is found")
+)
+
+// ParseRecipients parse the recipients separated by commas
+func ParseRecipients(s string) (recipients []*X25519Recipient, err error) {
+	for _, r := range strings.Split(s, ",") {
+		if r = strings.TrimSpace(r); len(r) == 0 {
+			continue
+		}
+		recipient, err := ParseX25519Recipient(r)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, recipient)
+	}
+	if len(recipients) == 0 {
+		return nil, errEmptyRecipients
+	}
+	return recipients, nil
+}
+
+// LoadRecipients load the recipients from the recipients file, one recipient per line,
+// the empty lines and the lines starting with # are ignored
+func LoadRecipients(path string) (recipients []*X25519Recipient, err error) {
+	err = readLines(path, func(line string) error {
+		recipient, err := ParseX25519Recipient(line)
+		if err == nil {
+			recipients = append(recipients, recipient)
+		}
+		return err
+	})
+	if err == nil && len(recipients) == 0 {
+		err = fmt.Errorf("%w => %s", errEmptyRecipients, path)
+	}
+	return recipients, err
+}
+
+// LoadIdentities load the identities from the identity file, one identity per line,
+// the empty lines and the lines starting with # are ignored, it is compatible with the file created by age-keygen
+func LoadIdentities(path string) (identities []*X25519Identity, err error) {
+	err = readLines(path, func(line string) error {
+		identity, err := ParseX25519Identity(line)
+		if err == nil {
+			identities = append(identities, identity)
+		}
+		return err
+	})
+	if err == nil && len(identities) == 0 {
+		err = fmt.Errorf("%w => %s", errEmptyIdentities, path)
+	}
+	return identities, err
+}
+
+// GenerateIdentityFile generate a new identity and write it to a new file that only the owner can read,
+// the public key is written as a comment, the existing file is never overwritten
+func GenerateIdentityFile(path string) (*X25519Identity, error) {
+	identity, err := GenerateX25519Identity()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+	_, err = fmt.Fprintf(f, "# created: %s\n# public key: %s\n%s\n", time.Now().Format(time.RFC3339), identity.Recipient(), identity)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+func readLines(path string, fn func(line string) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return scanLines(f, fn)
+}
+
+func scanLines(r io.Reader, fn func(line string) error) error {
+	scanner := bufio.NewScanner(r)
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return fmt.Errorf("%w => line %d", err, n)
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,43 @@
+package age
+
+import (
+	"errors"
+	"fmt"
+
+	"filippo.io/age"
+)
+
+var (
+	errInvalidRecipient = errors.New("invalid X25519 recipient")
+	errInvalidIdentity  = errors.New("invalid X25519 identity")
+)
+
+// X25519Recipient the public key of an X25519 identity, the file key is wrapped to it, encoded as "age1..."
+type X25519Recipient = age.X25519Recipient
+
+// X25519Identity the private key to unwrap the file key, encoded as "AGE-SECRET-KEY-1..."
+type X25519Identity = age.X25519Identity
+
+// ParseX25519Recipient parse the Bech32 encoded recipient that starts with "age1"
+func ParseX25519Recipient(s string) (*X25519Recipient, error) {
+	r, err := age.ParseX25519Recipient(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w => %s, %w", errInvalidRecipient, s, err)
+	}
+	return r, nil
+}
+
+// GenerateX25519Identity generate a new random X25519 identity
+func GenerateX25519Identity() (*X25519Identity, error) {
+	return age.GenerateX25519Identity()
+}
+
+// ParseX25519Identity parse the Bech32 encoded identity that starts with "AGE-SECRET-KEY-1"
+func ParseX25519Identity(s string) (*X25519Identity, error) {
+	i, err := age.ParseX25519Identity(s)
+	if err != nil {
+		// never print the private key in the error
+		return nil, errInvalidIdentity
+	}
+	return i, nil
+}
@@ -1,19 +1,35 @@
 package api
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/no-src/gofs/api/apiclient"
 	"github.com/no-src/gofs/api/apiserver"
+	authapi "github.com/no-src/gofs/api/auth"
 	"github.com/no-src/gofs/api/monitor"
 	"github.com/no-src/gofs/api/task"
+	"github.com/no-src/gofs/audit"
 	"github.com/no-src/gofs/auth"
 	"github.com/no-src/gofs/conf"
 	"github.com/no-src/gofs/logger"
 	"github.com/no-src/gofs/report"
+	"github.com/no-src/gofs/tlsutil"
+	"github.com/no-src/nscache"
+	"google.golang.org/grpc/metadata"
 )
 
 const (
@@ -36,8 +52,292 @@ func TestApiServerAndClient_WithAnonymous(t *testing.T) {
 	runApiServerAndClient(t, nil)
 }
 
+func TestApiServerAndClient_TokenRefresh(t *testing.T) {
+	user, _ := auth.NewUser(1, "root", "123990", auth.FullPerm)
+	server, err := runApiServer(t, user, "", authapi.TokenOption{Secret: tokenSecret, Expires: time.Second * 2})
+	if err != nil {
+		t.Errorf("running api server error => %v", err)
+		return
+	}
+	defer server.Stop()
+
+	c := apiclient.New(apiServerHost, apiServerPort, true, certFile, "", "", tlsutil.Pin{}, user)
+	for i := 0; i < 3; i++ {
+		err = c.Start()
+		if err == nil {
+			break
+		}
+		time.Sleep(time.Second * 3)
+	}
+	if err != nil {
+		t.Errorf("start api client error => %v", err)
+		return
+	}
+	defer c.Stop()
+
+	// get info with the initial token, the refreshed token and the token of the new login after the refreshed token expires
+	for _, wait := range []time.Duration{0, time.Millisecond * 1200, time.Millisecond * 2500} {
+		time.Sleep(wait)
+		info, err := c.GetInfo()
+		if err != nil {
+			t.Errorf("get info error after waiting %s => %v", wait, err)
+			return
+		}
+		if info.GetServerAddr() != serverAddr {
+			t.Errorf("get info expect to get server addr %s, but get %s", serverAddr, info.GetServerAddr())
+		}
+	}
+}
+
+func TestApiServerAndClient_MutualTLS(t *testing.T) {
+	user, _ := auth.NewUser(1, "root", "123990", auth.FullPerm)
+	caFile, clientCertFile, clientKeyFile := newTestClientCert(t, user.UserName())
+	server, err := runApiServer(t, user, caFile, authapi.TokenOption{Secret: tokenSecret})
+	if err != nil {
+		t.Errorf("running api server error => %v", err)
+		return
+	}
+	defer server.Stop()
+
+	// the client certificate signs in as the user that matches the common name without the correct password
+	certUser, _ := auth.NewUser(1, "root", "wrong_password", auth.FullPerm)
+	c := apiclient.New(apiServerHost, apiServerPort, true, certFile, clientCertFile, clientKeyFile, tlsutil.Pin{}, certUser)
+	for i := 0; i < 3; i++ {
+		err = c.Start()
+		if err == nil {
+			break
+		}
+		time.Sleep(time.Second * 3)
+	}
+	if err != nil {
+		t.Errorf("start api client with the client certificate error => %v", err)
+		return
+	}
+	defer c.Stop()
+	if _, err = c.GetInfo(); err != nil {
+		t.Errorf("get info with the client certificate error => %v", err)
+	}
+
+	noCertClient := apiclient.New(apiServerHost, apiServerPort, true, certFile, "", "", tlsutil.Pin{}, user)
+	defer noCertClient.Stop()
+	if err = noCertClient.Start(); err == nil {
+		t.Errorf("start api client without the client certificate expect to get an error, but get nil")
+	}
+}
+
+func TestToken(t *testing.T) {
+	user, _ := auth.NewUser(1, "root", "123990", auth.FullPerm)
+	users := auth.NewUserStore([]*auth.User{user})
+	testCases := []struct {
+		name string
+		opt  authapi.TokenOption
+	}{
+		{"HS256", authapi.TokenOption{Secret: tokenSecret}},
+		{"EdDSA", authapi.TokenOption{Algorithm: authapi.EdDSA, KeyFile: newTestTokenKeyFile(t)}},
+		{"EdDSA with buntdb revocation", authapi.TokenOption{Algorithm: authapi.EdDSA, KeyFile: newTestTokenKeyFile(t), Revocation: "buntdb://:memory:"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			token, err := authapi.NewToken(users, tc.opt)
+			if err != nil {
+				t.Errorf("NewToken error => %v", err)
+				return
+			}
+			tokenStr, expires, err := token.GenerateToken(context.Background(), &authapi.LoginUser{Username: user.UserName(), Password: user.Password(), Timestamp: time.Now().Unix()})
+			if err != nil {
+				t.Errorf("GenerateToken error => %v", err)
+				return
+			}
+			if expires <= time.Now().Unix() {
+				t.Errorf("GenerateToken expect to get a future expires, but get %d", expires)
+			}
+			ctx := newTestTokenContext(tokenStr)
+			if u, err := token.IsLogin(ctx); err != nil || u.UserName() != user.UserName() {
+				t.Errorf("IsLogin expect to get user %s, but get %v => %v", user.UserName(), u, err)
+				return
+			}
+
+			newTokenStr, _, err := token.RefreshToken(ctx)
+			if err != nil {
+				t.Errorf("RefreshToken error => %v", err)
+				return
+			}
+			if _, err = token.IsLogin(ctx); err == nil {
+				t.Errorf("IsLogin expect to get an error with the refreshed token, but get nil")
+			}
+			newCtx := newTestTokenContext(newTokenStr)
+			if _, err = token.IsLogin(newCtx); err != nil {
+				t.Errorf("IsLogin with the new token error => %v", err)
+			}
+			if err = token.RevokeToken(newCtx); err != nil {
+				t.Errorf("RevokeToken error => %v", err)
+			}
+			if _, err = token.IsLogin(newCtx); err == nil {
+				t.Errorf("IsLogin expect to get an error with the revoked token, but get nil")
+			}
+		})
+	}
+}
+
+func TestToken_ReturnError(t *testing.T) {
+	user, _ := auth.NewUser(1, "root", "123990", auth.FullPerm)
+	users := auth.NewUserStore([]*auth.User{user})
+	hs256, err := authapi.NewToken(users, authapi.TokenOption{Secret: tokenSecret})
+	if err != nil {
+		t.Errorf("NewToken error => %v", err)
+		return
+	}
+	ed25519Token, err := authapi.NewToken(users, authapi.TokenOption{Algorithm: authapi.EdDSA, KeyFile: newTestTokenKeyFile(t)})
+	if err != nil {
+		t.Errorf("NewToken error => %v", err)
+		return
+	}
+	edTokenStr, _, err := ed25519Token.GenerateToken(context.Background(), &authapi.LoginUser{Username: user.UserName(), Password: user.Password(), Timestamp: time.Now().Unix()})
+	if err != nil {
+		t.Errorf("GenerateToken error => %v", err)
+		return
+	}
+	otherToken, _ := authapi.NewToken(users, authapi.TokenOption{Secret: "abcdefghij123456"})
+	otherTokenStr, _, _ := otherToken.GenerateToken(context.Background(), &authapi.LoginUser{Username: user.UserName(), Password: user.Password(), Timestamp: time.Now().Unix()})
+	hsTokenStr, _, _ := hs256.GenerateToken(context.Background(), &authapi.LoginUser{Username: user.UserName(), Password: user.Password(), Timestamp: time.Now().Unix()})
+
+	testCases := []struct {
+		name  string
+		token string
+	}{
+		{"empty token", ""},
+		{"malformed token", "abc.def"},
+		{"unexpected algorithm", edTokenStr},
+		{"invalid signature", otherTokenStr},
+		{"tampered token", hsTokenStr + "x"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := hs256.IsLogin(newTestTokenContext(tc.token)); err == nil {
+				t.Errorf("IsLogin expect to get an error, but get nil")
+			}
+		})
+	}
+
+	t.Run("token expired", func(t *testing.T) {
+		token, _ := authapi.NewToken(users, authapi.TokenOption{Secret: tokenSecret, Expires: time.Second})
+		tokenStr, _, _ := token.GenerateToken(context.Background(), &authapi.LoginUser{Username: user.UserName(), Password: user.Password(), Timestamp: time.Now().Unix()})
+		time.Sleep(time.Second * 2)
+		if _, err := token.IsLogin(newTestTokenContext(tokenStr)); err == nil {
+			t.Errorf("IsLogin expect to get an error with the expired token, but get nil")
+		}
+	})
+
+	t.Run("login locked", func(t *testing.T) {
+		cache, err := nscache.NewCache("memory:")
+		if err != nil {
+			t.Errorf("NewCache error => %v", err)
+			return
+		}
+		defer cache.Close()
+		guardUsers := auth.NewUserStore([]*auth.User{user})
+		guardUsers.SetLoginGuard(auth.NewLoginGuard(cache, auth.LoginGuardOption{MaxFailures: 2}))
+		token, _ := authapi.NewToken(guardUsers, authapi.TokenOption{Secret: tokenSecret})
+		for i := 0; i < 2; i++ {
+			if _, _, err = token.GenerateToken(context.Background(), &authapi.LoginUser{Username: user.UserName(), Password: "bad_password", Timestamp: time.Now().Unix()}); err == nil {
+				t.Errorf("GenerateToken expect to get an error with the bad password, but get nil")
+			}
+		}
+		var lockedErr *auth.LockedError
+		if _, _, err = token.GenerateToken(context.Background(), &authapi.LoginUser{Username: user.UserName(), Password: user.Password(), Timestamp: time.Now().Unix()}); !errors.As(err, &lockedErr) {
+			t.Errorf("GenerateToken expect to get a locked error, but get %v", err)
+		}
+	})
+
+	t.Run("EdDSA without key file", func(t *testing.T) {
+		if _, err := authapi.NewToken(users, authapi.TokenOption{Algorithm: authapi.EdDSA}); err == nil {
+			t.Errorf("NewToken expect to get an error, but get nil")
+		}
+	})
+
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		if _, err := authapi.NewToken(users, authapi.TokenOption{Algorithm: "RS256"}); err == nil {
+			t.Errorf("NewToken expect to get an error, but get nil")
+		}
+	})
+}
+
+func newTestTokenContext(token string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+}
+
+// newTestClientCert generate a CA and a client certificate for the user that is signed by the CA
+func newTestClientCert(t *testing.T, userName string) (caFile string, clientCertFile string, clientKeyFile string) {
+	dir := t.TempDir()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate the CA key error => %v", err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "gofs test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("create the CA certificate error => %v", err)
+	}
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate the client key error => %v", err)
+	}
+	clientTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: userName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	clientDER, err := x509.CreateCertificate(rand.Reader, clientTemplate, caTemplate, &clientKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("create the client certificate error => %v", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(clientKey)
+	if err != nil {
+		t.Fatalf("marshal the client key error => %v", err)
+	}
+	caFile = filepath.Join(dir, "ca.pem")
+	clientCertFile = filepath.Join(dir, "client.pem")
+	clientKeyFile = filepath.Join(dir, "client.key")
+	for path, block := range map[string]*pem.Block{
+		caFile:         {Type: "CERTIFICATE", Bytes: caDER},
+		clientCertFile: {Type: "CERTIFICATE", Bytes: clientDER},
+		clientKeyFile:  {Type: "PRIVATE KEY", Bytes: keyDER},
+	} {
+		if err = os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+			t.Fatalf("write the pem file error => %v", err)
+		}
+	}
+	return caFile, clientCertFile, clientKeyFile
+}
+
+func newTestTokenKeyFile(t *testing.T) string {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ed25519 key error => %v", err)
+	}
+	data, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal ed25519 key error => %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "token.pem")
+	if err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: data}), 0600); err != nil {
+		t.Fatalf("write ed25519 key error => %v", err)
+	}
+	return path
+}
+
 func runApiServerAndClient(t *testing.T, user *auth.User) {
-	server, err := runApiServer(t, user)
+	server, err := runApiServer(t, user, "", authapi.TokenOption{Secret: tokenSecret})
 	if err != nil {
 		t.Errorf("running api server error => %v", err)
 		return
@@ -50,12 +350,12 @@ func runApiServerAndClient(t *testing.T, user *auth.User) {
 	server.Stop()
 }
 
-func runApiServer(t *testing.T, user *auth.User) (apiserver.Server, error) {
+func runApiServer(t *testing.T, user *auth.User, clientCAFile string, tokenOpt authapi.TokenOption) (apiserver.Server, error) {
 	var users []*auth.User
 	if user != nil {
 		users = append(users, user)
 	}
-	srv, err := apiserver.New(apiServerHost, apiServerPort, true, certFile, keyFile, tokenSecret, users, report.NewReporter(), serverAddr, logger.NewTestLogger(), taskConfFile)
+	srv, err := apiserver.New(apiServerHost, apiServerPort, true, certFile, keyFile, clientCAFile, tokenOpt, auth.NewUserStore(users), false, report.NewReporter(), serverAddr, logger.NewTestLogger(), audit.NewEmptyLogger(), nil, nil, taskConfFile)
 	if err != nil {
 		return nil, err
 	}
@@ -76,7 +376,7 @@ func runApiServer(t *testing.T, user *auth.User) (apiserver.Server, error) {
 }
 
 func runApiClient(user *auth.User) (err error) {
-	c := apiclient.New(apiServerHost, apiServerPort, true, certFile, user)
+	c := apiclient.New(apiServerHost, apiServerPort, true, certFile, "", "", tlsutil.Pin{}, user)
 	for i := 0; i < 3; i++ {
 		err = c.Start()
 		if err == nil {
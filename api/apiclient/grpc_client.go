@@ -10,6 +10,7 @@ import (
 	"github.com/no-src/gofs/api/monitor"
 	"github.com/no-src/gofs/api/task"
 	"github.com/no-src/gofs/auth"
+	"github.com/no-src/gofs/tlsutil"
 	"golang.org/x/oauth2"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -30,22 +31,29 @@ type client struct {
 	port       int
 	enableTLS  bool
 	certFile   string
+	clientCert string
+	clientKey  string
+	pin        tlsutil.Pin
 	user       *auth.User
 	clientConn *grpc.ClientConn
 	creds      credentials.PerRPCCredentials
 }
 
-// New create the instance of the Client
-func New(host string, port int, enableTLS bool, certFile string, user *auth.User) Client {
+// New create the instance of the Client, the client presents the client certificate to the server for the mutual TLS if the clientCertFile and clientKeyFile are specified,
+// and verifies the server certificate by the pin if it is enabled
+func New(host string, port int, enableTLS bool, certFile string, clientCertFile string, clientKeyFile string, pin tlsutil.Pin, user *auth.User) Client {
 	if user == nil {
 		user = auth.GetAnonymousUser()
 	}
 	return &client{
-		host:      host,
-		port:      port,
-		enableTLS: enableTLS,
-		certFile:  certFile,
-		user:      user,
+		host:       host,
+		port:       port,
+		enableTLS:  enableTLS,
+		certFile:   certFile,
+		clientCert: clientCertFile,
+		clientKey:  clientKeyFile,
+		pin:        pin,
+		user:       user,
 	}
 }
 
@@ -60,9 +68,12 @@ func (c *client) connect() (err error) {
 	addr := fmt.Sprintf("%s:%d", c.host, c.port)
 	tranCreds := insecure.NewCredentials()
 	if c.enableTLS {
-		if tranCreds, err = credentials.NewClientTLSFromFile(c.certFile, c.host); err != nil {
+		tlsConfig, err := tlsutil.ClientConfig(false, c.certFile, c.clientCert, c.clientKey, c.pin)
+		if err != nil {
 			return err
 		}
+		tlsConfig.ServerName = c.host
+		tranCreds = credentials.NewTLS(tlsConfig)
 	}
 	clientConn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(tranCreds))
 	if err != nil {
@@ -136,28 +147,31 @@ func (c *client) SubscribeTask(clientInfo *task.ClientInfo) (task.TaskService_Su
 	return c.subscribeTask(clientInfo)
 }
 
-func (c *client) getToken() (token string, err error) {
-	reply, err := c.AuthServiceClient.Login(context.Background(), &authapi.LoginUser{
+func (c *client) login() (*authapi.LoginReply, error) {
+	return c.AuthServiceClient.Login(context.Background(), &authapi.LoginUser{
 		Username:  c.user.UserName(),
 		Password:  c.user.Password(),
 		Timestamp: time.Now().Unix(),
 	})
-	if err != nil {
-		return token, err
+}
+
+// refreshToken exchange the current token for a new one, the current token is revoked by the server
+func (c *client) refreshToken(token *oauth2.Token) (*authapi.LoginReply, error) {
+	return c.AuthServiceClient.RefreshToken(context.Background(), &emptypb.Empty{}, grpc.PerRPCCredentials(c.newCreds(oauth2.StaticTokenSource(token))))
+}
+
+func (c *client) newCreds(ts oauth2.TokenSource) credentials.PerRPCCredentials {
+	if c.enableTLS {
+		return &oauth.TokenSource{TokenSource: ts}
 	}
-	return reply.Token, nil
+	// TODO insecureTokenSource is a temporary solution, it will be removed in the future
+	return &insecureTokenSource{TokenSource: ts}
 }
 
 func (c *client) Login() (err error) {
-	token, err := c.getToken()
+	reply, err := c.login()
 	if err == nil {
-		oauth2Token := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
-		if c.enableTLS {
-			c.creds = &oauth.TokenSource{TokenSource: oauth2Token}
-		} else {
-			// TODO insecureTokenSource is a temporary solution, it will be removed in the future
-			c.creds = &insecureTokenSource{TokenSource: oauth2Token}
-		}
+		c.creds = c.newCreds(newRefreshTokenSource(c, reply))
 	}
 	return err
 }
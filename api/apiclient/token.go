@@ -3,7 +3,10 @@ package apiclient
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
+	authapi "github.com/no-src/gofs/api/auth"
 	"golang.org/x/oauth2"
 	"google.golang.org/grpc/credentials"
 )
@@ -32,3 +35,49 @@ func (ts insecureTokenSource) GetRequestMetadata(ctx context.Context, uri ...str
 func (ts insecureTokenSource) RequireTransportSecurity() bool {
 	return false
 }
+
+// refreshTokenSource supplies the token and refreshes it after half of its lifetime elapsed,
+// it logs in again if the refresh fails, so the long-lived clients keep working after the token expires.
+type refreshTokenSource struct {
+	c         *client
+	mu        sync.Mutex
+	token     *oauth2.Token
+	refreshAt time.Time
+}
+
+func newRefreshTokenSource(c *client, reply *authapi.LoginReply) *refreshTokenSource {
+	ts := &refreshTokenSource{
+		c: c,
+	}
+	ts.set(reply)
+	return ts
+}
+
+// Token returns the current token, refresh it if it is time to refresh
+func (ts *refreshTokenSource) Token() (*oauth2.Token, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.refreshAt.IsZero() || time.Now().Before(ts.refreshAt) {
+		return ts.token, nil
+	}
+	reply, err := ts.c.refreshToken(ts.token)
+	if err != nil {
+		// the token may be expired or revoked, try to login again
+		reply, err = ts.c.login()
+	}
+	if err != nil {
+		return nil, err
+	}
+	ts.set(reply)
+	return ts.token, nil
+}
+
+func (ts *refreshTokenSource) set(reply *authapi.LoginReply) {
+	ts.token = &oauth2.Token{AccessToken: reply.GetToken()}
+	ts.refreshAt = time.Time{}
+	// the server that does not return the expires never expires the token
+	if reply.GetExpires() > 0 {
+		ts.token.Expiry = time.Unix(reply.GetExpires(), 0)
+		ts.refreshAt = time.Now().Add(time.Until(ts.token.Expiry) / 2)
+	}
+}
@@ -9,10 +9,13 @@ import (
 	"github.com/no-src/gofs/api/info"
 	"github.com/no-src/gofs/api/monitor"
 	"github.com/no-src/gofs/api/task"
+	"github.com/no-src/gofs/audit"
 	"github.com/no-src/gofs/auth"
 	"github.com/no-src/gofs/internal/clist"
+	"github.com/no-src/gofs/ipfilter"
 	"github.com/no-src/gofs/logger"
 	"github.com/no-src/gofs/report"
+	"github.com/no-src/gofs/tlsutil"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
@@ -22,7 +25,8 @@ type grpcServer struct {
 	network         string
 	ip              net.IP
 	port            int
-	users           []*auth.User
+	users           *auth.UserStore
+	userRoot        bool
 	token           authapi.Token
 	certFile        string
 	keyFile         string
@@ -33,16 +37,23 @@ type grpcServer struct {
 	monitors        *sync.Map
 	monitorMessages *clist.CList
 	logger          *logger.Logger
+	audit           audit.Logger
+	filter          *ipfilter.Filter
+	limiter         *ipfilter.Limiter
 	taskConf        string
 }
 
-// New create the instance of the Server
-func New(ip string, port int, enableTLS bool, certFile string, keyFile string, tokenSecret string, users []*auth.User, reporter report.Reporter, httpServerAddr string, logger *logger.Logger, taskConf string) (Server, error) {
-	if len(users) == 0 {
+// New create the instance of the Server, the monitor messages are scoped to the root directory of the login user if the userRoot is true,
+// the client certificates are required if the clientCAFile is specified, the logins and the calls are recorded by the audit logger,
+// and the client ips are limited by the filter and the limiter
+func New(ip string, port int, enableTLS bool, certFile string, keyFile string, clientCAFile string, tokenOpt authapi.TokenOption, users *auth.UserStore, userRoot bool, reporter report.Reporter, httpServerAddr string, logger *logger.Logger, auditLogger audit.Logger, filter *ipfilter.Filter, limiter *ipfilter.Limiter, taskConf string) (Server, error) {
+	if users.Anonymous() {
 		logger.Warn("the grpc server allows anonymous access, you should set some server users by the -users or -rand_user_count flag for security reasons")
-		users = append(users, auth.GetAnonymousUser())
 	}
-	token, err := authapi.NewToken(users, tokenSecret)
+	if auditLogger == nil {
+		auditLogger = audit.NewEmptyLogger()
+	}
+	token, err := authapi.NewToken(users, tokenOpt)
 	if err != nil {
 		return nil, err
 	}
@@ -51,6 +62,7 @@ func New(ip string, port int, enableTLS bool, certFile string, keyFile string, t
 		ip:              net.ParseIP(ip),
 		port:            port,
 		users:           users,
+		userRoot:        userRoot,
 		token:           token,
 		enableTLS:       enableTLS,
 		certFile:        certFile,
@@ -60,13 +72,18 @@ func New(ip string, port int, enableTLS bool, certFile string, keyFile string, t
 		monitors:        &sync.Map{},
 		monitorMessages: clist.New(),
 		logger:          logger,
+		audit:           auditLogger,
+		filter:          filter,
+		limiter:         limiter,
 		taskConf:        taskConf,
 	}
 	creds := insecure.NewCredentials()
 	if enableTLS {
-		if creds, err = credentials.NewServerTLSFromFile(srv.certFile, srv.keyFile); err != nil {
+		tlsConfig, err := tlsutil.ServerConfig(srv.certFile, srv.keyFile, clientCAFile)
+		if err != nil {
 			return nil, err
 		}
+		creds = credentials.NewTLS(tlsConfig)
 	} else {
 		logger.Warn("the grpc server is not enable enableTLS, it is not a security connection")
 	}
@@ -101,7 +118,7 @@ func (gs *grpcServer) SendMonitorMessage(message *monitor.MonitorMessage) {
 
 func (gs *grpcServer) initRoute(s *grpc.Server) (err error) {
 	info.RegisterServer(s, gs.httpServerAddr)
-	monitor.RegisterServer(s, gs.monitors, gs.reporter, gs.token)
+	monitor.RegisterServer(s, gs.monitors, gs.reporter, gs.token, gs.users, gs.userRoot)
 	authapi.RegisterServer(s, gs.token)
 	err = task.RegisterServer(s, gs.taskConf)
 	return err
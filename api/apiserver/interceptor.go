@@ -2,42 +2,118 @@ package apiserver
 
 import (
 	"context"
+	"net"
 
 	"github.com/no-src/gofs/api/auth"
+	"github.com/no-src/gofs/audit"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 )
 
 func (gs *grpcServer) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	action := audit.ActionCall
 	if info.FullMethod == auth.AuthService_Login_FullMethodName {
-		return handler(ctx, req)
+		action = audit.ActionLogin
 	}
-	if len(gs.users) == 0 {
-		return handler(ctx, req)
+	ip := peerIP(ctx)
+	release, err := gs.acquire(ip, info.FullMethod)
+	if err != nil {
+		gs.auditLog(action, "", ip, info.FullMethod, err)
+		return nil, err
+	}
+	defer release()
+
+	if action == audit.ActionLogin {
+		resp, err = handler(ctx, req)
+		userName := ""
+		if in, ok := req.(*auth.LoginUser); ok {
+			userName = in.GetUsername()
+		}
+		gs.auditLog(action, userName, ip, info.FullMethod, err)
+		return resp, err
 	}
 	loginUser, err := gs.token.IsLogin(ctx)
 	if err != nil {
 		gs.logger.Error(err, "login failed")
-		return nil, status.Error(codes.Unauthenticated, err.Error())
+		err = status.Error(codes.Unauthenticated, err.Error())
+		gs.auditLog(action, "", ip, info.FullMethod, err)
+		return nil, err
 	}
 	if loginUser == nil {
-		return nil, status.Error(codes.Unauthenticated, "login failed")
+		err = status.Error(codes.Unauthenticated, "login failed")
+		gs.auditLog(action, "", ip, info.FullMethod, err)
+		return nil, err
 	}
-	return handler(ctx, req)
+	resp, err = handler(ctx, req)
+	gs.auditLog(action, loginUser.UserName(), ip, info.FullMethod, err)
+	return resp, err
 }
 
+// StreamServerInterceptor verify the login user of the stream, the subscription is recorded by the audit logger
+// when it is accepted or rejected, because the stream keeps open until the client disconnects
 func (gs *grpcServer) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-	if len(gs.users) == 0 {
-		return nil
+	ctx := ss.Context()
+	ip := peerIP(ctx)
+	// the open stream is counted as a concurrent connection of the client ip
+	release, err := gs.acquire(ip, info.FullMethod)
+	if err != nil {
+		gs.auditLog(audit.ActionSubscribe, "", ip, info.FullMethod, err)
+		return err
 	}
-	loginUser, err := gs.token.IsLogin(ss.Context())
+	defer release()
+
+	loginUser, err := gs.token.IsLogin(ctx)
 	if err != nil {
 		gs.logger.Error(err, "login failed")
-		return status.Error(codes.Unauthenticated, err.Error())
+		err = status.Error(codes.Unauthenticated, err.Error())
+		gs.auditLog(audit.ActionSubscribe, "", ip, info.FullMethod, err)
+		return err
 	}
 	if loginUser == nil {
-		return status.Error(codes.Unauthenticated, "login failed")
+		err = status.Error(codes.Unauthenticated, "login failed")
+		gs.auditLog(audit.ActionSubscribe, "", ip, info.FullMethod, err)
+		return err
 	}
+	gs.auditLog(audit.ActionSubscribe, loginUser.UserName(), ip, info.FullMethod, nil)
 	return handler(srv, ss)
 }
+
+// acquire check the client ip by the ip filter and the ip limiter, the release function must be called after the method returns
+func (gs *grpcServer) acquire(ip string, method string) (release func(), err error) {
+	if !gs.filter.Allow(ip) {
+		gs.logger.Warn("access deny by the ip filter, client ip is [%s], method is [%s]", ip, method)
+		return nil, status.Error(codes.PermissionDenied, "access deny")
+	}
+	release, err = gs.limiter.Acquire(ip)
+	if err != nil {
+		gs.logger.Warn("the request is rejected by the ip limiter, client ip is [%s], method is [%s], %v", ip, method, err)
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
+	return release, nil
+}
+
+// auditLog write an audit record of the grpc method, the status is the grpc status code of the error
+func (gs *grpcServer) auditLog(action string, userName string, ip string, method string, err error) {
+	gs.logger.ErrorIf(gs.audit.Log(audit.Record{
+		Action: action,
+		User:   userName,
+		IP:     ip,
+		Route:  method,
+		Status: int(status.Code(err)),
+	}), "write the audit log error")
+}
+
+// peerIP return the ip of the client in the context
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	ip := p.Addr.String()
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	return ip
+}
@@ -2,10 +2,13 @@ package auth
 
 import (
 	"context"
+	"errors"
 
+	"github.com/no-src/gofs/auth"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 // RegisterServer register the auth server
@@ -22,11 +25,34 @@ type server struct {
 }
 
 func (s *server) Login(ctx context.Context, in *LoginUser) (*LoginReply, error) {
-	token, err := s.token.GenerateToken(in)
+	token, expires, err := s.token.GenerateToken(ctx, in)
+	var lockedErr *auth.LockedError
+	if errors.As(err, &lockedErr) {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return &LoginReply{
+		Token:   token,
+		Expires: expires,
+	}, nil
+}
+
+func (s *server) RefreshToken(ctx context.Context, _ *emptypb.Empty) (*LoginReply, error) {
+	token, expires, err := s.token.RefreshToken(ctx)
 	if err != nil {
 		return nil, status.Error(codes.Unauthenticated, err.Error())
 	}
 	return &LoginReply{
-		Token: token,
+		Token:   token,
+		Expires: expires,
 	}, nil
 }
+
+func (s *server) RevokeToken(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
+	if err := s.token.RevokeToken(ctx); err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return &emptypb.Empty{}, nil
+}
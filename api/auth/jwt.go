@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/no-src/nsgo/jsonutil"
+)
+
+const (
+	// HS256 the token signing algorithm of the HMAC with SHA-256, the token secret is the key
+	HS256 = "HS256"
+	// EdDSA the token signing algorithm of the Ed25519, the key is loaded from the token key file
+	EdDSA = "EdDSA"
+
+	jwtType = "JWT"
+)
+
+var (
+	errUnsupportedTokenAlgorithm = errors.New("the token algorithm is unsupported, current only supports HS256 and EdDSA, please check the -token_algorithm flag")
+	errTokenKeyRequired          = errors.New("the EdDSA token algorithm requires an Ed25519 private key, please check the -token_key_file flag")
+	errInvalidTokenKey           = errors.New("the token key file must contain an Ed25519 private key in PKCS #8 PEM format")
+	errInvalidToken              = errors.New("invalid token")
+	errTokenSignature            = errors.New("the signature of the token is invalid")
+)
+
+// tokenHeader the JOSE header of the JWT
+type tokenHeader struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+}
+
+// tokenClaims the claims of the JWT
+type tokenClaims struct {
+	Id       string `json:"jti"`
+	Subject  string `json:"sub"`
+	UserId   int    `json:"uid"`
+	Perm     string `json:"perm"`
+	IssuedAt int64  `json:"iat"`
+	Expires  int64  `json:"exp"`
+}
+
+// signer sign and verify the JWT with the specified algorithm
+type signer interface {
+	algorithm() string
+	sign(data []byte) []byte
+	verify(data []byte, sig []byte) bool
+}
+
+type hmacSigner struct {
+	key []byte
+}
+
+func (s hmacSigner) algorithm() string {
+	return HS256
+}
+
+func (s hmacSigner) sign(data []byte) []byte {
+	h := hmac.New(sha256.New, s.key)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func (s hmacSigner) verify(data []byte, sig []byte) bool {
+	return hmac.Equal(s.sign(data), sig)
+}
+
+type ed25519Signer struct {
+	key ed25519.PrivateKey
+}
+
+func (s ed25519Signer) algorithm() string {
+	return EdDSA
+}
+
+func (s ed25519Signer) sign(data []byte) []byte {
+	return ed25519.Sign(s.key, data)
+}
+
+func (s ed25519Signer) verify(data []byte, sig []byte) bool {
+	return ed25519.Verify(s.key.Public().(ed25519.PublicKey), data, sig)
+}
+
+// CheckTokenAlgorithm check the token algorithm is supported, the empty algorithm means HS256
+func CheckTokenAlgorithm(algorithm string) error {
+	switch algorithm {
+	case "", HS256, EdDSA:
+		return nil
+	}
+	return fmt.Errorf("%w => %s", errUnsupportedTokenAlgorithm, algorithm)
+}
+
+// LoadTokenKey load the Ed25519 private key from the PKCS #8 PEM file
+func LoadTokenKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errInvalidTokenKey
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w => %v", errInvalidTokenKey, err)
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errInvalidTokenKey
+	}
+	return edKey, nil
+}
+
+func newSigner(algorithm string, secret string, keyFile string) (signer, error) {
+	switch algorithm {
+	case "", HS256:
+		return hmacSigner{key: []byte(secret)}, nil
+	case EdDSA:
+		if len(keyFile) == 0 {
+			return nil, errTokenKeyRequired
+		}
+		key, err := LoadTokenKey(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		return ed25519Signer{key: key}, nil
+	}
+	return nil, fmt.Errorf("%w => %s", errUnsupportedTokenAlgorithm, algorithm)
+}
+
+// encodeJWT sign the claims and return the compact serialization of the JWT
+func encodeJWT(s signer, claims tokenClaims) (string, error) {
+	header, err := jsonutil.Marshal(tokenHeader{Algorithm: s.algorithm(), Type: jwtType})
+	if err != nil {
+		return "", err
+	}
+	payload, err := jsonutil.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(s.sign([]byte(signingInput))), nil
+}
+
+// decodeJWT verify the signature of the JWT and return the claims, the algorithm of the token must be the same as the signer
+func decodeJWT(s signer, token string) (*tokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errInvalidToken
+	}
+	headerData, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w => %v", errInvalidToken, err)
+	}
+	var header tokenHeader
+	if err = jsonutil.Unmarshal(headerData, &header); err != nil {
+		return nil, fmt.Errorf("%w => %v", errInvalidToken, err)
+	}
+	if header.Algorithm != s.algorithm() {
+		return nil, fmt.Errorf("%w => unexpected algorithm %s", errInvalidToken, header.Algorithm)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !s.verify([]byte(parts[0]+"."+parts[1]), sig) {
+		return nil, errTokenSignature
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w => %v", errInvalidToken, err)
+	}
+	var claims tokenClaims
+	if err = jsonutil.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("%w => %v", errInvalidToken, err)
+	}
+	return &claims, nil
+}
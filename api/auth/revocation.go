@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/no-src/nscache"
+	_ "github.com/no-src/nscache/all"
+)
+
+const revokedTokenKeyPrefix = "gofs:token:revoked:"
+
+// revocationList store the revoked token ids until the tokens expire
+type revocationList struct {
+	cache nscache.NSCache
+}
+
+func newRevocationList(conn string) (*revocationList, error) {
+	cache, err := nscache.NewCache(conn)
+	if err != nil {
+		return nil, err
+	}
+	return &revocationList{
+		cache: cache,
+	}, nil
+}
+
+// revoke add the token id to the revocation list, the record is removed after the token expires
+func (r *revocationList) revoke(id string, expires int64) error {
+	ttl := time.Until(time.Unix(expires, 0))
+	if ttl <= 0 {
+		return nil
+	}
+	return r.cache.Set(revokedTokenKeyPrefix+id, expires, ttl)
+}
+
+// isRevoked check the token id is in the revocation list or not
+func (r *revocationList) isRevoked(id string) (bool, error) {
+	var expires int64
+	err := r.cache.Get(revokedTokenKeyPrefix+id, &expires)
+	if errors.Is(err, nscache.ErrNil) {
+		return false, nil
+	}
+	return err == nil, err
+}
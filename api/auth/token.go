@@ -2,125 +2,242 @@ package auth
 
 import (
 	"context"
-	"crypto/aes"
-	"crypto/cipher"
-	"encoding/base64"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"net"
 	"strings"
 	"time"
 
 	"github.com/no-src/gofs/auth"
-	"github.com/no-src/nsgo/jsonutil"
-	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
-	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/peer"
+)
+
+// DefaultTokenExpires the default lifetime of the token
+const DefaultTokenExpires = 30 * time.Minute
+
+var (
+	errLoginFailed       = errors.New("login failed")
+	errTwoFactorRequired = errors.New("the user requires the two-factor authentication, sign in by the client certificate instead of the password")
+	errEmptyToken        = errors.New("token can't be empty")
+	errTokenExpired      = errors.New("the token is expired")
+	errTokenRevoked      = errors.New("the token is revoked")
 )
 
 // Token an authentication and token component
 type Token interface {
-	// GenerateToken generate a new token by user info
-	GenerateToken(in *LoginUser) (token string, err error)
-	// IsLogin resolve the token in the context.Context
+	// GenerateToken generate a new token by user info or the user that matches the verified client certificate,
+	// return the token and the unix time that the token expires at
+	GenerateToken(ctx context.Context, in *LoginUser) (token string, expires int64, err error)
+	// RefreshToken generate a new token for the login user in the context.Context and revoke the old token
+	RefreshToken(ctx context.Context) (token string, expires int64, err error)
+	// RevokeToken revoke the token in the context.Context
+	RevokeToken(ctx context.Context) error
+	// IsLogin resolve the user that matches the verified client certificate or the token in the context.Context
 	IsLogin(ctx context.Context) (user *auth.User, err error)
 }
 
+// TokenOption the options of the Token
+type TokenOption struct {
+	// Secret the HMAC key of the HS256 algorithm
+	Secret string
+	// Algorithm the token signing algorithm, supported HS256 and EdDSA, default is HS256
+	Algorithm string
+	// KeyFile the Ed25519 private key file in PKCS #8 PEM format for the EdDSA algorithm
+	KeyFile string
+	// Expires the lifetime of the token, default is DefaultTokenExpires
+	Expires time.Duration
+	// Revocation the nscache connection string of the revocation list, default is memory:
+	Revocation string
+}
+
 type token struct {
-	users               []*auth.User
-	timeoutSeconds      int64
-	tokenExpiresSeconds int64
-	secret              string
-	iv                  string
+	users          *auth.UserStore
+	timeoutSeconds int64
+	expires        time.Duration
+	signer         signer
+	revocation     *revocationList
 }
 
 // NewToken create a default implementation of the Token
-func NewToken(users []*auth.User, secret string) (Token, error) {
-	err := checkTokenSecret(secret)
+func NewToken(users *auth.UserStore, opt TokenOption) (Token, error) {
+	if len(opt.Algorithm) == 0 || opt.Algorithm == HS256 {
+		if err := checkTokenSecret(opt.Secret); err != nil {
+			return nil, err
+		}
+	}
+	s, err := newSigner(opt.Algorithm, opt.Secret, opt.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if opt.Expires <= 0 {
+		opt.Expires = DefaultTokenExpires
+	}
+	if len(opt.Revocation) == 0 {
+		opt.Revocation = "memory:"
+	}
+	revocation, err := newRevocationList(opt.Revocation)
 	if err != nil {
 		return nil, err
 	}
 	return &token{
-		users:               users,
-		timeoutSeconds:      60,
-		tokenExpiresSeconds: 60 * 30,
-		secret:              secret,
-		iv:                  "nosrc-gofs-token",
+		users:          users,
+		timeoutSeconds: 60,
+		expires:        opt.Expires,
+		signer:         s,
+		revocation:     revocation,
 	}, nil
 }
 
-func (t *token) GenerateToken(in *LoginUser) (token string, err error) {
-	var user *auth.User
-	for _, u := range t.users {
-		if u.UserName() == in.GetUsername() && u.Password() == in.GetPassword() && in.GetTimestamp()+t.timeoutSeconds > time.Now().Unix() {
-			user = u
-		}
+func (t *token) GenerateToken(ctx context.Context, in *LoginUser) (token string, expires int64, err error) {
+	if user := t.certUser(ctx); user != nil {
+		return t.encodeToken(user)
+	}
+	user, err := t.login(ctx, in.GetUsername(), in.GetPassword())
+	if err != nil {
+		return token, expires, err
 	}
-	if user != nil {
+	if in.GetTimestamp()+t.timeoutSeconds > time.Now().Unix() {
 		return t.encodeToken(user)
 	}
-	return token, errors.New("login failed")
+	return token, expires, errLoginFailed
+}
+
+// login verify the username and the password, the failed login attempts of the username and the peer ip are limited by the login guard
+func (t *token) login(ctx context.Context, userName, password string) (*auth.User, error) {
+	if t.users.Anonymous() {
+		if user := auth.VerifyUser(t.getUsers(), userName, password); user != nil {
+			return user, nil
+		}
+		return nil, errLoginFailed
+	}
+	var ip string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ip = p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+	}
+	user, err := t.users.Login(userName, password, ip)
+	var lockedErr *auth.LockedError
+	if errors.As(err, &lockedErr) {
+		return nil, lockedErr
+	}
+	if err != nil {
+		return nil, errLoginFailed
+	}
+	if t.users.RequireTwoFactor(user) {
+		return nil, errTwoFactorRequired
+	}
+	return user, nil
+}
+
+func (t *token) RefreshToken(ctx context.Context) (token string, expires int64, err error) {
+	user, claims, err := t.verify(ctx)
+	if err != nil {
+		return token, expires, err
+	}
+	if token, expires, err = t.encodeToken(user); err != nil {
+		return token, expires, err
+	}
+	return token, expires, t.revocation.revoke(claims.Id, claims.Expires)
+}
+
+func (t *token) RevokeToken(ctx context.Context) error {
+	_, claims, err := t.verify(ctx)
+	if err != nil {
+		return err
+	}
+	return t.revocation.revoke(claims.Id, claims.Expires)
 }
 
 func (t *token) IsLogin(ctx context.Context) (user *auth.User, err error) {
+	if user = t.certUser(ctx); user != nil {
+		return user, nil
+	}
+	user, _, err = t.verify(ctx)
+	return user, err
+}
+
+// certUser return the user that matches the verified client certificate of the mutual TLS, return nil if there is no matched user
+func (t *token) certUser(ctx context.Context) *auth.User {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.PeerCertificates) == 0 {
+		return nil
+	}
+	return t.users.CertUser(tlsInfo.State.PeerCertificates[0])
+}
+
+// verify resolve and verify the token in the context.Context, return the login user and the claims of the token
+func (t *token) verify(ctx context.Context) (user *auth.User, claims *tokenClaims, err error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
-		return nil, status.Errorf(codes.Unauthenticated, "login failed")
+		return nil, nil, errLoginFailed
 	}
 	var authorization string
 	mdv := md.Get("authorization")
 	if len(mdv) > 0 {
 		authorization = strings.TrimPrefix(mdv[0], "Bearer ")
 	}
-	tu, err := t.decodeToken(authorization)
+	if len(authorization) == 0 {
+		return nil, nil, errEmptyToken
+	}
+	if claims, err = decodeJWT(t.signer, authorization); err != nil {
+		return nil, nil, err
+	}
+	if claims.Expires <= time.Now().Unix() {
+		return nil, nil, errTokenExpired
+	}
+	revoked, err := t.revocation.isRevoked(claims.Id)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if revoked {
+		return nil, nil, errTokenRevoked
 	}
-	for _, u := range t.users {
-		if u.UserName() == tu.GetUsername() && tu.Expires > time.Now().Unix() {
+	for _, u := range t.getUsers() {
+		if u.UserName() == claims.Subject {
 			user = u
 		}
 	}
-	return user, nil
+	if user == nil {
+		return nil, nil, errLoginFailed
+	}
+	return user, claims, nil
 }
 
-func (t *token) encodeToken(u *auth.User) (token string, err error) {
-	tu := TokenUser{
-		UserId:   int32(u.UserId()),
-		Username: u.UserName(),
-		Expires:  time.Now().Unix() + t.tokenExpiresSeconds,
-	}
-	data, err := jsonutil.Marshal(tu)
-	if err != nil {
-		return token, err
+// getUsers return the current users, return the anonymous user if the anonymous access is allowed
+func (t *token) getUsers() []*auth.User {
+	if t.users.Anonymous() {
+		return []*auth.User{auth.GetAnonymousUser()}
 	}
-	block, err := aes.NewCipher([]byte(t.secret))
-	if err != nil {
-		return token, err
-	}
-	stream := cipher.NewCFBEncrypter(block, []byte(t.iv))
-	dst := make([]byte, len(data))
-	stream.XORKeyStream(dst, data)
-	token = base64.StdEncoding.EncodeToString(dst)
-	return token, nil
+	return t.users.Users()
 }
 
-func (t *token) decodeToken(token string) (tu *TokenUser, err error) {
-	if len(token) == 0 {
-		return nil, errors.New("token can't be empty")
+func (t *token) encodeToken(u *auth.User) (token string, expires int64, err error) {
+	id := make([]byte, 16)
+	if _, err = rand.Read(id); err != nil {
+		return token, expires, err
 	}
-	data, err := base64.StdEncoding.DecodeString(token)
-	if err != nil {
-		return nil, err
-	}
-	block, err := aes.NewCipher([]byte(t.secret))
-	if err != nil {
-		return nil, err
+	now := time.Now()
+	expires = now.Add(t.expires).Unix()
+	claims := tokenClaims{
+		Id:       hex.EncodeToString(id),
+		Subject:  u.UserName(),
+		UserId:   u.UserId(),
+		Perm:     u.Perm().String(),
+		IssuedAt: now.Unix(),
+		Expires:  expires,
 	}
-	stream := cipher.NewCFBDecrypter(block, []byte(t.iv))
-	dst := make([]byte, len(data))
-	stream.XORKeyStream(dst, data)
-	err = jsonutil.Unmarshal(dst, &tu)
-	return tu, err
+	token, err = encodeJWT(t.signer, claims)
+	return token, expires, err
 }
 
 func checkTokenSecret(secret string) error {
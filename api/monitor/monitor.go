@@ -1,24 +1,35 @@
 package monitor
 
 import (
+	"errors"
+	"path"
+	"strings"
 	"sync"
 
 	authapi "github.com/no-src/gofs/api/auth"
 	"github.com/no-src/gofs/auth"
+	"github.com/no-src/gofs/contract"
 	"github.com/no-src/gofs/report"
+	srv "github.com/no-src/gofs/server"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
-// RegisterServer register the monitor server
-func RegisterServer(s grpc.ServiceRegistrar, monitors *sync.Map, reporter report.Reporter, token authapi.Token) {
+var errStreamLoginExpired = errors.New("the login of the monitor stream is revoked or expired")
+
+// RegisterServer register the monitor server, the messages are filtered by the acl of the users,
+// and the messages are scoped to the root directory of the login user like <username>/ if the userRoot is true
+func RegisterServer(s grpc.ServiceRegistrar, monitors *sync.Map, reporter report.Reporter, token authapi.Token, users *auth.UserStore, userRoot bool) {
 	RegisterMonitorServiceServer(s, &server{
 		monitors: monitors,
 		reporter: reporter,
 		token:    token,
+		users:    users,
+		userRoot: userRoot,
 	})
 }
 
@@ -28,6 +39,8 @@ type server struct {
 	monitors *sync.Map
 	reporter report.Reporter
 	token    authapi.Token
+	users    *auth.UserStore
+	userRoot bool
 }
 
 func (s *server) Monitor(in *emptypb.Empty, m MonitorService_MonitorServer) error {
@@ -37,23 +50,64 @@ func (s *server) Monitor(in *emptypb.Empty, m MonitorService_MonitorServer) erro
 	}
 	k := p.Addr.String()
 	var msgChan chan *MonitorMessage
+	user, err := s.token.IsLogin(m.Context())
 	v, ok := s.monitors.Load(k)
 	if ok {
 		msgChan = v.(chan *MonitorMessage)
 	} else {
 		msgChan = make(chan *MonitorMessage)
 		s.monitors.Store(k, msgChan)
-		user, _ := s.token.IsLogin(m.Context())
 		s.reporter.PutConnection(k, auth.MapperToSessionUser(user))
 	}
+	defer func() {
+		s.monitors.Delete(k)
+		s.reporter.DeleteConnection(k)
+	}()
 	for {
 		select {
 		case msg := <-msgChan:
-			m.Send(msg)
+			// the token of the stream may be revoked or expired after the stream starts, and the user may be removed,
+			// so resolve the login user again before sending, the client needs to log in again and restart the stream
+			if user, err = s.token.IsLogin(m.Context()); err != nil || user == nil {
+				return status.Errorf(codes.Unauthenticated, "%v => %v", errStreamLoginExpired, err)
+			}
+			if msg = s.scope(user, msg); msg != nil && s.allow(user, msg) {
+				m.Send(msg)
+			}
 		case <-m.Context().Done():
-			s.monitors.Delete(k)
-			s.reporter.DeleteConnection(k)
 			return nil
 		}
 	}
 }
+
+// allow report whether the user can read the path of the message, the directories are allowed
+// if they are the parent directories of the readable paths, all the messages are denied if the user is not resolved
+func (s *server) allow(user *auth.User, msg *MonitorMessage) bool {
+	if s.users.Anonymous() {
+		return true
+	}
+	if user == nil {
+		return false
+	}
+	acl := s.users.ACL()
+	routePath := path.Join(srv.SourceRoutePrefix, msg.GetFileInfo().GetPath())
+	if contract.FsDirValue(msg.GetFileInfo().GetIsDir()).Bool() {
+		return acl.Visible(user.UserName(), routePath)
+	}
+	return acl.Check(user.UserName(), routePath, auth.ReadPerm)
+}
+
+// scope return the message with the path relative to the root directory of the login user,
+// return nil if the path is outside of the user root
+func (s *server) scope(user *auth.User, msg *MonitorMessage) *MonitorMessage {
+	if !s.userRoot || user == nil {
+		return msg
+	}
+	p, ok := strings.CutPrefix(msg.GetFileInfo().GetPath(), user.UserName()+"/")
+	if !ok || len(p) == 0 {
+		return nil
+	}
+	scoped := proto.Clone(msg).(*MonitorMessage)
+	scoped.FileInfo.Path = p
+	return scoped
+}
@@ -0,0 +1,208 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	authapi "github.com/no-src/gofs/api/auth"
+	"github.com/no-src/gofs/auth"
+	"github.com/no-src/gofs/contract"
+	"github.com/no-src/gofs/report"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestMonitor(t *testing.T) {
+	alice, _ := auth.NewUser(1, "alice", "alice_password", "r")
+	bob, _ := auth.NewUser(2, "bob", "bob_password", "r")
+	acl, err := auth.ParseACL("alice|/source/docs|r,alice|/source/docs/private|-,bob|/source|r", "")
+	if err != nil {
+		t.Fatalf("ParseACL error => %v", err)
+	}
+	messages := []*MonitorMessage{
+		newTestMessage("docs", true),
+		newTestMessage("docs/a.txt", false),
+		newTestMessage("docs/private", true),
+		newTestMessage("docs/private/b.txt", false),
+		newTestMessage("other", true),
+		newTestMessage("other/c.txt", false),
+		newTestMessage("alice/d.txt", false),
+		newTestMessage("bob/e.txt", false),
+		newTestMessage("alice", true),
+	}
+
+	testCases := []struct {
+		name      string
+		users     []*auth.User
+		acl       *auth.ACL
+		userRoot  bool
+		loginUser *auth.User
+		expect    []string
+		expectErr bool
+	}{
+		{"anonymous", nil, nil, false, auth.GetAnonymousUser(), []string{"alice", "alice/d.txt", "bob/e.txt", "docs", "docs/a.txt", "docs/private", "docs/private/b.txt", "other", "other/c.txt"}, false},
+		{"acl", []*auth.User{alice, bob}, acl, false, alice, []string{"docs", "docs/a.txt"}, false},
+		{"acl allows all", []*auth.User{alice, bob}, acl, false, bob, []string{"alice", "alice/d.txt", "bob/e.txt", "docs", "docs/a.txt", "docs/private", "docs/private/b.txt", "other", "other/c.txt"}, false},
+		{"without acl", []*auth.User{alice, bob}, nil, false, alice, []string{"alice", "alice/d.txt", "bob/e.txt", "docs", "docs/a.txt", "docs/private", "docs/private/b.txt", "other", "other/c.txt"}, false},
+		{"user root", []*auth.User{alice, bob}, nil, true, alice, []string{"d.txt"}, false},
+		{"user root with acl", []*auth.User{alice, bob}, acl, true, alice, nil, false},
+		{"unresolved user", []*auth.User{alice, bob}, nil, false, nil, nil, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			users := auth.NewUserStore(tc.users)
+			users.SetACL(tc.acl)
+			s := &server{
+				monitors: &sync.Map{},
+				reporter: report.NewReporter(),
+				token:    &testToken{user: tc.loginUser},
+				users:    users,
+				userRoot: tc.userRoot,
+			}
+			actual, err := runTestMonitor(t, s, messages)
+			if tc.expectErr != (status.Code(err) == codes.Unauthenticated) {
+				t.Errorf("expect to get the unauthenticated error [%v], but get %v", tc.expectErr, err)
+			}
+			if strings.Join(actual, ",") != strings.Join(tc.expect, ",") {
+				t.Errorf("expect to receive the messages %v, but get %v", tc.expect, actual)
+			}
+		})
+	}
+}
+
+func TestMonitor_RevokeToken(t *testing.T) {
+	alice, _ := auth.NewUser(1, "alice", "alice_password", "r")
+	s := &server{
+		monitors: &sync.Map{},
+		reporter: report.NewReporter(),
+		// the token is resolved when the stream starts and before sending every message
+		token: &testToken{user: alice, validTimes: 3},
+		users: auth.NewUserStore([]*auth.User{alice}),
+	}
+	actual, err := runTestMonitor(t, s, []*MonitorMessage{
+		newTestMessage("a.txt", false),
+		newTestMessage("b.txt", false),
+		newTestMessage("c.txt", false),
+		newTestMessage("d.txt", false),
+	})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expect to stop the stream with the unauthenticated error, but get %v", err)
+	}
+	if expect := []string{"a.txt", "b.txt"}; strings.Join(actual, ",") != strings.Join(expect, ",") {
+		t.Errorf("expect to receive the messages %v before the token is revoked, but get %v", expect, actual)
+	}
+}
+
+// runTestMonitor run the monitor stream, send the messages to it and return the sorted paths of the received messages,
+// and the error that the stream returns
+func runTestMonitor(t *testing.T, s *server, messages []*MonitorMessage) ([]string, error) {
+	addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 10000}
+	ctx, cancel := context.WithCancel(peer.NewContext(context.Background(), &peer.Peer{Addr: addr}))
+	defer cancel()
+	stream := &testMonitorStream{ctx: ctx}
+	done := make(chan error)
+	go func() {
+		done <- s.Monitor(&emptypb.Empty{}, stream)
+	}()
+
+	var v any
+	for ok := false; !ok; v, ok = s.monitors.Load(addr.String()) {
+		time.Sleep(time.Millisecond)
+	}
+	msgChan := v.(chan *MonitorMessage)
+	var err error
+	stopped := false
+	// the last empty message makes sure the previous messages are handled before cancel the stream
+	for _, msg := range append(messages, newTestMessage("", false)) {
+		select {
+		case msgChan <- msg:
+		case err = <-done:
+			stopped = true
+		}
+		if stopped {
+			break
+		}
+	}
+	if !stopped {
+		cancel()
+		err = <-done
+	}
+	if _, ok := s.monitors.Load(addr.String()); ok {
+		t.Errorf("expect to remove the monitor after the stream is done")
+	}
+
+	var paths []string
+	for _, msg := range stream.received() {
+		if p := msg.GetFileInfo().GetPath(); len(p) > 0 {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	return paths, err
+}
+
+func newTestMessage(path string, isDir bool) *MonitorMessage {
+	return &MonitorMessage{
+		FileInfo: &FileInfo{
+			Path:  path,
+			IsDir: int32(contract.ParseFsDirValue(isDir)),
+		},
+	}
+}
+
+// testMonitorStream a fake stream of the monitor that records the sent messages
+type testMonitorStream struct {
+	grpc.ServerStream
+
+	ctx  context.Context
+	mu   sync.Mutex
+	msgs []*MonitorMessage
+}
+
+func (s *testMonitorStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *testMonitorStream) Send(msg *MonitorMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.msgs = append(s.msgs, msg)
+	return nil
+}
+
+func (s *testMonitorStream) received() []*MonitorMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.msgs
+}
+
+// testToken a fake token that resolves the login user only, the token is revoked after it is resolved validTimes times
+// if validTimes is greater than zero
+type testToken struct {
+	authapi.Token
+
+	user       *auth.User
+	validTimes int
+	times      int
+}
+
+func (t *testToken) IsLogin(ctx context.Context) (*auth.User, error) {
+	t.times++
+	if t.validTimes > 0 && t.times > t.validTimes {
+		return nil, errors.New("the token is revoked")
+	}
+	if t.user == nil {
+		return nil, errors.New("login failed")
+	}
+	return t.user, nil
+}
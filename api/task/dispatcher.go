@@ -11,6 +11,7 @@ import (
 	"github.com/no-src/gofs/api/task/loader"
 	"github.com/no-src/gofs/conf"
 	"github.com/no-src/gofs/flag"
+	"github.com/no-src/gofs/ipfilter"
 )
 
 // Dispatcher the task dispatcher interface
@@ -89,16 +90,9 @@ func (d *dispatcher) loadTasks() ([]*loader.TaskItem, error) {
 	return c.Tasks, nil
 }
 
+// checkAllowIP report whether the client ip matches any of the allowed ips or CIDRs, every ip is allowed if the allowIP is empty
 func (d *dispatcher) checkAllowIP(allowIP []string, clientIP string) bool {
-	if len(allowIP) == 0 {
-		return true
-	}
-	for _, ip := range allowIP {
-		if ip == clientIP {
-			return true
-		}
-	}
-	return false
+	return len(allowIP) == 0 || ipfilter.Match(allowIP, clientIP)
 }
 
 func (d *dispatcher) checkLabels(taskLabels []string, clientLabels []string) bool {
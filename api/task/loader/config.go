@@ -18,7 +18,7 @@ type TaskItem struct {
 	Conf string `json:"conf" yaml:"conf"`
 	// Labels it can only acquire the current task if the client matches all the labels
 	Labels []string `json:"labels" yaml:"labels"`
-	// AllowIP the current task only allows the specified ip to access
+	// AllowIP the current task only allows the specified ips or CIDRs to access
 	AllowIP []string `json:"allow_ip" yaml:"allow_ip"`
 }
 
@@ -0,0 +1,230 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	// ActionStart the first record that is written when the audit log is opened or rotated, it carries the last hash of the chain
+	ActionStart = "start"
+	// ActionLogin the user signs in by the login page, the Basic authorization or the grpc api
+	ActionLogin = "login"
+	// ActionTwoFactor the user passes the two-factor authentication or not
+	ActionTwoFactor = "2fa"
+	// ActionLogout the user signs out
+	ActionLogout = "logout"
+	// ActionDownload the user reads the files or the directories of the file server
+	ActionDownload = "download"
+	// ActionPush the push client pushes a file change, the Op is the action of the file change, like Write or Remove
+	ActionPush = "push"
+	// ActionFile the user manages the files by the web UI, the Op is the action of the file change, like Create, Rename, Remove or Write
+	ActionFile = "file"
+	// ActionAccess the user accesses the other routes of the file server, like the manage api
+	ActionAccess = "access"
+	// ActionSubscribe the user subscribes the grpc stream api, like the monitor messages or the tasks
+	ActionSubscribe = "subscribe"
+	// ActionCall the user calls the grpc unary api
+	ActionCall = "call"
+
+	// OpTwoFactorRequired the Op of the login record that the password is verified and the two-factor authentication is required
+	OpTwoFactorRequired = "2fa_required"
+	// OpRecoveryCode the Op of the two-factor authentication record that passes by a recovery code
+	OpRecoveryCode = "recovery_code"
+
+	// hashField the last field of the record that is written if the HMAC chain is enabled
+	hashField = `,"hash":"`
+)
+
+var (
+	errBrokenChain = errors.New("the audit log is tampered, the hash chain is broken")
+	errMissingHash = errors.New("the audit record has no hash")
+)
+
+// Record an audit record of the access to the file server, the push server or the grpc server
+type Record struct {
+	// Time the time of the access
+	Time time.Time `json:"time"`
+	// Action the kind of the access, like login, download and push
+	Action string `json:"action"`
+	// Op the detail of the action, like the action of the pushed file change
+	Op string `json:"op,omitempty"`
+	// User the username of the access, it is empty for the anonymous access
+	User string `json:"user,omitempty"`
+	// IP the ip of the client
+	IP string `json:"ip,omitempty"`
+	// Route the route of the file server or the method of the grpc server
+	Route string `json:"route,omitempty"`
+	// Path the requested path, or the path of the pushed file
+	Path string `json:"path,omitempty"`
+	// Bytes the size of the uploaded request body for the push, or the size of the response body for the others
+	Bytes int64 `json:"bytes"`
+	// Status the http status code or the grpc status code
+	Status int `json:"status"`
+	// Code the result code of the api like the contract.Code, it is omitted if the route has no result code
+	Code int `json:"code,omitempty"`
+	// Prev the hash of the last record before the start record, it is empty if the hash chain starts from the start record
+	Prev string `json:"prev,omitempty"`
+}
+
+// Logger write the audit records as JSON lines
+type Logger interface {
+	// Log write an audit record, the Time is set to now if it is zero
+	Log(r Record) error
+	// Reload replace the underlying writer to rotate the audit log, the old writer is closed and the hash chain continues in the new writer
+	Reload(w io.Writer) error
+	// Close close the underlying writer if it is an io.Closer
+	Close() error
+}
+
+type logger struct {
+	mu   sync.Mutex
+	w    io.Writer
+	key  []byte
+	prev string
+}
+
+// New create an audit Logger that writes the records to the writer, every record carries the HMAC-SHA256 hash
+// of the previous hash and the record itself if the key is not empty, then the deleted or modified records can be detected by Verify.
+// The hash chain continues from the prev that is the last hash of the existing audit log, see LastHash
+func New(w io.Writer, key []byte, prev string) (Logger, error) {
+	l := &logger{
+		w:    w,
+		key:  key,
+		prev: prev,
+	}
+	return l, l.start()
+}
+
+// NewEmptyLogger create an audit Logger that discards all the records
+func NewEmptyLogger() Logger {
+	return &logger{}
+}
+
+func (l *logger) Log(r Record) error {
+	if l.w == nil {
+		return nil
+	}
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.key) > 0 {
+		l.prev = chainHash(l.key, l.prev, data)
+		data = append(data[:len(data)-1], hashField+l.prev+`"}`...)
+	}
+	_, err = l.w.Write(append(data, '\n'))
+	return err
+}
+
+func (l *logger) Reload(w io.Writer) error {
+	l.mu.Lock()
+	old := l.w
+	l.w = w
+	l.mu.Unlock()
+	return errors.Join(closeWriter(old), l.start())
+}
+
+// start write the start record that carries the last hash of the chain
+func (l *logger) start() error {
+	l.mu.Lock()
+	prev := l.prev
+	l.mu.Unlock()
+	return l.Log(Record{Action: ActionStart, Prev: prev})
+}
+
+func (l *logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return closeWriter(l.w)
+}
+
+func closeWriter(w io.Writer) error {
+	if c, ok := w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Verify verify the HMAC chain of the audit log that is written with the key from the first record, return the count of the verified records,
+// or the line number of the first record that is modified, deleted or inserted. The start records must carry the last hash of the chain,
+// so the deleted tail of the audit log before a restart or the deleted log files can be detected
+func Verify(r io.Reader, key []byte) (count int, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	prev := ""
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := scanner.Bytes()
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+		hash, record, err := splitHash(data)
+		if err != nil {
+			return count, fmt.Errorf("%w => line %d", err, line)
+		}
+		var rec Record
+		if err = json.Unmarshal(record, &rec); err != nil {
+			return count, fmt.Errorf("%w => line %d, %v", errBrokenChain, line, err)
+		}
+		if rec.Action == ActionStart && rec.Prev != prev {
+			return count, fmt.Errorf("%w => line %d, the chain is reset unexpectedly", errBrokenChain, line)
+		}
+		expect := chainHash(key, prev, record)
+		if !hmac.Equal([]byte(expect), []byte(hash)) {
+			return count, fmt.Errorf("%w => line %d", errBrokenChain, line)
+		}
+		prev = hash
+		count++
+	}
+	return count, scanner.Err()
+}
+
+// LastHash return the hash of the last record of the audit log, return empty if the audit log is empty
+func LastHash(r io.Reader) (string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var last []byte
+	for scanner.Scan() {
+		if data := scanner.Bytes(); len(bytes.TrimSpace(data)) > 0 {
+			last = append(last[:0], data...)
+		}
+	}
+	if err := scanner.Err(); err != nil || len(last) == 0 {
+		return "", err
+	}
+	hash, _, err := splitHash(last)
+	return hash, err
+}
+
+// splitHash split the audit record into the hash and the record without the hash
+func splitHash(data []byte) (hash string, record []byte, err error) {
+	i := bytes.LastIndex(data, []byte(hashField))
+	if i < 0 || !bytes.HasSuffix(data, []byte(`"}`)) {
+		return "", nil, errMissingHash
+	}
+	return string(data[i+len(hashField) : len(data)-2]), append(append([]byte{}, data[:i]...), '}'), nil
+}
+
+// chainHash return the hex encoded HMAC-SHA256 hash of the previous hash and the record
+func chainHash(key []byte, prev string, record []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(prev))
+	mac.Write(record)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var testKey = []byte("audit_hmac_key")
+
+func TestLogger_Verify(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l, err := New(buf, testKey, "")
+	if err != nil {
+		t.Errorf("create the audit logger error => %v", err)
+		return
+	}
+	records := []Record{
+		{Action: ActionLogin, User: "alice", IP: "127.0.0.1", Route: "/login/signin", Status: 302, Code: 1},
+		{Action: ActionDownload, User: "alice", IP: "127.0.0.1", Route: "/source/*filepath", Path: "/source/hello.txt", Bytes: 5, Status: 200},
+		{Action: ActionPush, Op: "Remove", User: "alice", IP: "127.0.0.1", Route: "/w/push", Path: "hello.txt", Bytes: 128, Status: 200, Code: 1},
+	}
+	for _, r := range records {
+		if err = l.Log(r); err != nil {
+			t.Errorf("write the audit record error => %v", err)
+			return
+		}
+	}
+	firstSegment := buf.String()
+	// restart the audit log, the hash chain continues from the last hash
+	prev, err := LastHash(strings.NewReader(firstSegment))
+	if err != nil {
+		t.Errorf("read the last hash error => %v", err)
+		return
+	}
+	l, _ = New(buf, testKey, prev)
+	l.Log(records[0])
+	content := buf.String()
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+
+	// restart the audit log without the last hash
+	resetBuf := bytes.NewBufferString(firstSegment)
+	l, _ = New(resetBuf, testKey, "")
+	l.Log(records[0])
+
+	var last Record
+	if err = json.Unmarshal([]byte(lines[2]), &last); err != nil || last.Path != records[1].Path || last.Bytes != records[1].Bytes {
+		t.Errorf("expect to get the audit record %v, but actual get %v, %v", records[1], last, err)
+	}
+
+	testCases := []struct {
+		name        string
+		content     string
+		key         []byte
+		expectCount int
+		expectErr   error
+	}{
+		{"the intact audit log", content, testKey, 6, nil},
+		{"modify a record", strings.Replace(content, `"bytes":5`, `"bytes":6`, 1), testKey, 2, errBrokenChain},
+		{"delete a record", strings.Replace(content, lines[1]+"\n", "", 1), testKey, 1, errBrokenChain},
+		{"reorder the records", strings.Replace(content, lines[1]+"\n"+lines[2], lines[2]+"\n"+lines[1], 1), testKey, 1, errBrokenChain},
+		{"incorrect key", content, []byte("incorrect_key"), 0, errBrokenChain},
+		{"delete the tail before the restart", strings.Replace(content, lines[3]+"\n", "", 1), testKey, 3, errBrokenChain},
+		{"delete the first log file", strings.TrimPrefix(content, firstSegment), testKey, 0, errBrokenChain},
+		{"reset the chain", resetBuf.String(), testKey, 4, errBrokenChain},
+		{"without hash", `{"time":"2026-01-01T00:00:00Z","action":"start","bytes":0,"status":0}`, testKey, 0, errMissingHash},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			count, err := Verify(strings.NewReader(tc.content), tc.key)
+			if !errors.Is(err, tc.expectErr) {
+				t.Errorf("expect to get error %v, but actual get %v", tc.expectErr, err)
+			}
+			if count != tc.expectCount {
+				t.Errorf("expect to verify %d records, but actual get %d", tc.expectCount, count)
+			}
+		})
+	}
+}
+
+func TestLogger_WithoutKey(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l, err := New(buf, nil, "")
+	if err != nil {
+		t.Errorf("create the audit logger error => %v", err)
+		return
+	}
+	if err = l.Log(Record{Action: ActionLogout, User: "alice"}); err != nil {
+		t.Errorf("write the audit record error => %v", err)
+	}
+	if strings.Contains(buf.String(), "hash") || strings.Count(buf.String(), "\n") != 2 {
+		t.Errorf("expect to write the audit records without hash, but actual get %s", buf.String())
+	}
+	if err = NewEmptyLogger().Log(Record{Action: ActionLogin}); err != nil {
+		t.Errorf("expect the empty logger discards the records, but actual get %v", err)
+	}
+	if err = l.Close(); err != nil {
+		t.Errorf("close the audit logger error => %v", err)
+	}
+}
+
+func TestLogger_Reload(t *testing.T) {
+	oldBuf := &bytes.Buffer{}
+	l, err := New(oldBuf, testKey, "")
+	if err != nil {
+		t.Errorf("create the audit logger error => %v", err)
+		return
+	}
+	buf := &bytes.Buffer{}
+	if err = l.Reload(buf); err != nil {
+		t.Errorf("reload the audit logger error => %v", err)
+		return
+	}
+	l.Log(Record{Action: ActionLogin, User: "alice"})
+	count, err := Verify(strings.NewReader(oldBuf.String()+buf.String()), testKey)
+	if err != nil || count != 3 {
+		t.Errorf("expect the hash chain continues in the new writer, but actual verify %d records, %v", count, err)
+	}
+	if count, err = Verify(buf, testKey); !errors.Is(err, errBrokenChain) || count != 0 {
+		t.Errorf("expect the rotated audit log can't be verified without the previous one, but actual verify %d records, %v", count, err)
+	}
+}
+
+func TestLastHash(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l, err := New(buf, testKey, "")
+	if err != nil {
+		t.Errorf("create the audit logger error => %v", err)
+		return
+	}
+	l.Log(Record{Action: ActionLogin, User: "alice"})
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	last := lines[len(lines)-1]
+
+	testCases := []struct {
+		name      string
+		content   string
+		expect    string
+		expectErr error
+	}{
+		{"audit log", buf.String() + "\n\n", last[len(last)-66 : len(last)-2], nil},
+		{"empty audit log", "", "", nil},
+		{"without hash", `{"time":"2026-01-01T00:00:00Z","action":"start","bytes":0,"status":0}`, "", errMissingHash},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hash, err := LastHash(strings.NewReader(tc.content))
+			if !errors.Is(err, tc.expectErr) {
+				t.Errorf("expect to get error %v, but actual get %v", tc.expectErr, err)
+			}
+			if hash != tc.expect {
+				t.Errorf("expect to get the hash %s, but actual get %s", tc.expect, hash)
+			}
+		})
+	}
+}
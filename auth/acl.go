@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+const (
+	// ACLGroupPrefix the prefix of the group subject in the ACL rules, like @dev
+	ACLGroupPrefix = "@"
+	// ACLAnySubject the subject in the ACL rules that matches all the users
+	ACLAnySubject = "*"
+	// ACLNoPerm the permission in the ACL rules that denies all the access to the path
+	ACLNoPerm = "-"
+)
+
+var (
+	errInvalidACLRule  = errors.New("invalid acl rule, format like this, user1|/source/docs|rw,@group1|/dest|r")
+	errInvalidACLGroup = errors.New("invalid acl group, format like this, group1|user1|user2,group2|user3")
+	errInvalidACLPath  = errors.New("the path of the acl rule must be an absolute path like /source/docs")
+	errInvalidACLPerm  = errors.New("the permission of the acl rule must be the composition of 'r' 'w' 'x' or '-'")
+	errACLGroupUnknown = errors.New("the group of the acl rule is not defined in the acl groups")
+)
+
+// ACL the path-scoped permissions of the users, the path is the route path of the file server like /source/docs,
+// the rule with the longest matched path prefix takes effect, and the access is denied if there is no matched rule
+type ACL struct {
+	rules  []aclRule
+	groups map[string][]string
+}
+
+type aclRule struct {
+	subject string
+	path    string
+	perm    Perm
+}
+
+// ParseACL parse the acl rules and the acl groups, return nil if there is no acl rule,
+// the rules format like this, user1|/source/docs|rw,@group1|/dest|r,*|/source/public|r
+// the groups format like this, group1|user1|user2,group2|user3
+func ParseACL(rules string, groups string) (*ACL, error) {
+	acl := &ACL{
+		groups: make(map[string][]string),
+	}
+	for _, group := range splitNotEmpty(groups) {
+		fields := strings.Split(group, "|")
+		name := strings.TrimSpace(fields[0])
+		if len(fields) < 2 || len(name) == 0 {
+			return nil, fmt.Errorf("%w => %s", errInvalidACLGroup, group)
+		}
+		for _, member := range fields[1:] {
+			if member = strings.TrimSpace(member); len(member) > 0 {
+				acl.groups[name] = append(acl.groups[name], member)
+			}
+		}
+	}
+	for _, rule := range splitNotEmpty(rules) {
+		fields := strings.Split(rule, "|")
+		if len(fields) != 3 || len(strings.TrimSpace(fields[0])) == 0 {
+			return nil, fmt.Errorf("%w => %s", errInvalidACLRule, rule)
+		}
+		r := aclRule{
+			subject: strings.TrimSpace(fields[0]),
+			path:    strings.TrimSpace(fields[1]),
+		}
+		if !strings.HasPrefix(r.path, "/") {
+			return nil, fmt.Errorf("%w => %s", errInvalidACLPath, rule)
+		}
+		r.path = path.Clean(r.path)
+		if perm := strings.TrimSpace(fields[2]); perm != ACLNoPerm {
+			if r.perm = ToPerm(perm); !r.perm.IsValid() {
+				return nil, fmt.Errorf("%w => %s", errInvalidACLPerm, rule)
+			}
+		}
+		if group, ok := strings.CutPrefix(r.subject, ACLGroupPrefix); ok {
+			if _, exist := acl.groups[group]; !exist {
+				return nil, fmt.Errorf("%w => %s", errACLGroupUnknown, rule)
+			}
+		}
+		acl.rules = append(acl.rules, r)
+	}
+	if len(acl.rules) == 0 {
+		return nil, nil
+	}
+	return acl, nil
+}
+
+// Enabled report whether there are some acl rules, all the access is allowed if the acl is disabled
+func (acl *ACL) Enabled() bool {
+	return acl != nil && len(acl.rules) > 0
+}
+
+// Perm return the permission of the user to the path, the permissions of the matched rules
+// with the longest path prefix are merged
+func (acl *ACL) Perm(userName string, p string) (perm Perm) {
+	p = path.Clean("/" + p)
+	longest := -1
+	var r, w, x bool
+	for _, rule := range acl.rules {
+		if !acl.match(rule.subject, userName) || !hasPathPrefix(p, rule.path) || len(rule.path) < longest {
+			continue
+		}
+		if len(rule.path) > longest {
+			longest = len(rule.path)
+			r, w, x = false, false, false
+		}
+		r, w, x = r || rule.perm.R(), w || rule.perm.W(), x || rule.perm.X()
+	}
+	if r {
+		perm += ReadPerm
+	}
+	if w {
+		perm += WritePerm
+	}
+	if x {
+		perm += ExecutePerm
+	}
+	return perm
+}
+
+// Check report whether the user has the permission to the path, return true if the acl is disabled
+func (acl *ACL) Check(userName string, p string, perm Perm) bool {
+	if !acl.Enabled() {
+		return true
+	}
+	return perm.CheckTo(acl.Perm(userName, p))
+}
+
+// Visible report whether the user can see the path, that means the user can read the path,
+// or the path is a parent directory of the path that the user can read
+func (acl *ACL) Visible(userName string, p string) bool {
+	return acl.Reachable(userName, p, ReadPerm)
+}
+
+// Reachable report whether the user has the permission to the path, or the path is a parent directory
+// of the path that the user has the permission to
+func (acl *ACL) Reachable(userName string, p string, perm Perm) bool {
+	if acl.Check(userName, p, perm) {
+		return true
+	}
+	p = path.Clean("/" + p)
+	for _, rule := range acl.rules {
+		if perm.CheckTo(rule.perm) && acl.match(rule.subject, userName) && rule.path != p && hasPathPrefix(rule.path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// match report whether the subject of the rule matches the user
+func (acl *ACL) match(subject string, userName string) bool {
+	if subject == ACLAnySubject || subject == userName {
+		return true
+	}
+	if group, ok := strings.CutPrefix(subject, ACLGroupPrefix); ok {
+		for _, member := range acl.groups[group] {
+			if member == userName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasPathPrefix report whether the path is the prefix path or in the prefix directory
+func hasPathPrefix(p string, prefix string) bool {
+	return prefix == "/" || p == prefix || strings.HasPrefix(p, prefix+"/")
+}
+
+func splitNotEmpty(s string) (list []string) {
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			list = append(list, item)
+		}
+	}
+	return list
+}
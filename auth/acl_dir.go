@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"io/fs"
+	"net/http"
+	"path"
+)
+
+// aclDir an implementation of http.FileSystem that hides the files that the user can't read
+type aclDir struct {
+	fs       http.FileSystem
+	acl      *ACL
+	userName string
+	prefix   string
+}
+
+// NewACLDir returns a http.FileSystem that only shows the files that the user can read,
+// the prefix is the route prefix of the file system that is used to match the acl rules, like /source
+func NewACLDir(fs http.FileSystem, acl *ACL, userName string, prefix string) http.FileSystem {
+	if !acl.Enabled() {
+		return fs
+	}
+	return &aclDir{
+		fs:       fs,
+		acl:      acl,
+		userName: userName,
+		prefix:   prefix,
+	}
+}
+
+func (d *aclDir) Open(name string) (http.File, error) {
+	name = path.Clean("/" + name)
+	full := path.Join(d.prefix, name)
+	if !d.acl.Visible(d.userName, full) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	f, err := d.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	// only the directories are visible as the parent directories of the readable paths
+	if !stat.IsDir() && !d.acl.Check(d.userName, full, ReadPerm) {
+		f.Close()
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return &aclFile{File: f, dir: d, full: full}, nil
+}
+
+// aclFile the file of the aclDir, the entries of the directory that the user can't see are removed
+type aclFile struct {
+	http.File
+	dir  *aclDir
+	full string
+}
+
+func (f *aclFile) Readdir(count int) ([]fs.FileInfo, error) {
+	files, err := f.File.Readdir(count)
+	visible := files[:0]
+	for _, file := range files {
+		if f.dir.acl.Visible(f.dir.userName, path.Join(f.full, file.Name())) {
+			visible = append(visible, file)
+		}
+	}
+	return visible, err
+}
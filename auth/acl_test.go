@@ -0,0 +1,230 @@
+package auth
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	testACLRules  = "alice|/source/docs|rw,alice|/source/docs/private|-,@dev|/source/dev|r,@dev|/source/dev|w,*|/source/public|r,root|/|rwx"
+	testACLGroups = "dev|alice|bob"
+)
+
+func TestParseACL(t *testing.T) {
+	testCases := []struct {
+		name    string
+		rules   string
+		groups  string
+		enabled bool
+	}{
+		{"empty", "", "", false},
+		{"only groups", "", testACLGroups, false},
+		{"rules", testACLRules, testACLGroups, true},
+		{"with spaces", " alice | /source/docs/ | rw , ", "", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			acl, err := ParseACL(tc.rules, tc.groups)
+			if err != nil {
+				t.Errorf("ParseACL error => %v", err)
+				return
+			}
+			if acl.Enabled() != tc.enabled {
+				t.Errorf("ParseACL expect to get an acl enabled=%v, but get %v", tc.enabled, acl.Enabled())
+			}
+		})
+	}
+}
+
+func TestParseACL_ReturnError(t *testing.T) {
+	testCases := []struct {
+		name   string
+		rules  string
+		groups string
+		expect error
+	}{
+		{"missing perm", "alice|/source", "", errInvalidACLRule},
+		{"empty subject", "|/source|r", "", errInvalidACLRule},
+		{"relative path", "alice|source|r", "", errInvalidACLPath},
+		{"invalid perm", "alice|/source|abc", "", errInvalidACLPerm},
+		{"empty perm", "alice|/source|", "", errInvalidACLPerm},
+		{"unknown group", "@ops|/source|r", testACLGroups, errACLGroupUnknown},
+		{"invalid group", "alice|/source|r", "dev", errInvalidACLGroup},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseACL(tc.rules, tc.groups)
+			if !errors.Is(err, tc.expect) {
+				t.Errorf("ParseACL expect to get error %v, but get %v", tc.expect, err)
+			}
+		})
+	}
+}
+
+func TestACL_Check(t *testing.T) {
+	acl, err := ParseACL(testACLRules, testACLGroups)
+	if err != nil {
+		t.Fatalf("ParseACL error => %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		userName string
+		path     string
+		perm     Perm
+		expect   bool
+	}{
+		{"read the rule path", "alice", "/source/docs", ReadPerm, true},
+		{"write the sub path", "alice", "/source/docs/a/b.txt", WritePerm, true},
+		{"execute without perm", "alice", "/source/docs/a.txt", ExecutePerm, false},
+		{"denied by the longest prefix", "alice", "/source/docs/private/a.txt", ReadPerm, false},
+		{"similar prefix", "alice", "/source/docs2/a.txt", ReadPerm, false},
+		{"no matched rule", "alice", "/dest/a.txt", ReadPerm, false},
+		{"group rules are merged", "bob", "/source/dev/a.txt", ReadPerm + WritePerm, true},
+		{"group member", "alice", "/source/dev/a.txt", WritePerm, true},
+		{"any subject", "carol", "/source/public/a.txt", ReadPerm, true},
+		{"any subject without write", "carol", "/source/public/a.txt", WritePerm, false},
+		{"root path", "root", "/dest/a.txt", FullPerm, true},
+		{"unclean path", "alice", "/source/docs/../dev/../private", ReadPerm, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := acl.Check(tc.userName, tc.path, tc.perm); actual != tc.expect {
+				t.Errorf("Check expect to get %v, but get %v", tc.expect, actual)
+			}
+		})
+	}
+
+	var disabled *ACL
+	if !disabled.Check("alice", "/dest", FullPerm) {
+		t.Errorf("Check expect to allow all the access if the acl is disabled")
+	}
+}
+
+func TestACL_Visible(t *testing.T) {
+	acl, err := ParseACL(testACLRules, testACLGroups)
+	if err != nil {
+		t.Fatalf("ParseACL error => %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		userName string
+		path     string
+		expect   bool
+	}{
+		{"root", "alice", "/", true},
+		{"parent directory", "alice", "/source", true},
+		{"readable path", "alice", "/source/docs/a.txt", true},
+		{"denied path", "alice", "/source/docs/private", false},
+		{"sibling directory", "alice", "/source/other", false},
+		{"other route", "carol", "/dest", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := acl.Visible(tc.userName, tc.path); actual != tc.expect {
+				t.Errorf("Visible expect to get %v, but get %v", tc.expect, actual)
+			}
+		})
+	}
+
+	if !acl.Reachable("carol", "/source", ReadPerm) || acl.Reachable("carol", "/source", WritePerm) {
+		t.Errorf("Reachable expect to reach the parent directory of the path with the same permission only")
+	}
+}
+
+func TestNewACLDir(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"docs/a.txt", "docs/private/b.txt", "other/c.txt", "d.txt"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatalf("create the test directory error => %v", err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0600); err != nil {
+			t.Fatalf("create the test file error => %v", err)
+		}
+	}
+	acl, err := ParseACL("alice|/source/docs|r,alice|/source/docs/private|-", "")
+	if err != nil {
+		t.Fatalf("ParseACL error => %v", err)
+	}
+	dir := NewACLDir(http.Dir(root), acl, "alice", "/source")
+
+	testCases := []struct {
+		name    string
+		path    string
+		entries []string
+		expect  error
+	}{
+		{"root", "/", []string{"docs"}, nil},
+		{"readable directory", "/docs", []string{"a.txt"}, nil},
+		{"readable file", "/docs/a.txt", nil, nil},
+		{"denied directory", "/docs/private", nil, fs.ErrPermission},
+		{"denied file", "/d.txt", nil, fs.ErrPermission},
+		{"not exist", "/docs/not_exist.txt", nil, fs.ErrNotExist},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := dir.Open(tc.path)
+			if !errors.Is(err, tc.expect) {
+				t.Errorf("Open expect to get error %v, but get %v", tc.expect, err)
+				return
+			}
+			if err != nil {
+				return
+			}
+			defer f.Close()
+			stat, err := f.Stat()
+			if err != nil || !stat.IsDir() {
+				return
+			}
+			files, err := f.Readdir(-1)
+			if err != nil {
+				t.Errorf("Readdir error => %v", err)
+				return
+			}
+			if len(files) != len(tc.entries) {
+				t.Errorf("Readdir expect to get %v, but get %d entries", tc.entries, len(files))
+				return
+			}
+			for i, file := range files {
+				if file.Name() != tc.entries[i] {
+					t.Errorf("Readdir expect to get %s, but get %s", tc.entries[i], file.Name())
+				}
+			}
+		})
+	}
+
+	if NewACLDir(http.Dir(root), nil, "alice", "/source") != http.Dir(root) {
+		t.Errorf("NewACLDir expect to return the original file system if the acl is disabled")
+	}
+}
+
+func TestUserStore_SetACL(t *testing.T) {
+	acl, err := ParseACL("alice|/source|r", "")
+	if err != nil {
+		t.Fatalf("ParseACL error => %v", err)
+	}
+	var nilStore *UserStore
+	if nilStore.ACL() != nil {
+		t.Errorf("ACL expect to get nil with a nil store")
+	}
+	store := NewUserStore(nil)
+	store.SetACL(acl)
+	if store.ACL() != acl {
+		t.Errorf("ACL expect to get the acl that is set")
+	}
+	store.SetACL(nil)
+	if store.ACL().Enabled() {
+		t.Errorf("ACL expect to be disabled after it is removed")
+	}
+}
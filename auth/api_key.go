@@ -0,0 +1,241 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APIKeyPrefix the prefix of the api key, the api key is formatted like gofs_<id>_<secret>
+const APIKeyPrefix = "gofs_"
+
+var (
+	errInvalidAPIKey      = errors.New("invalid api key")
+	errAPIKeyExpired      = errors.New("the api key is expired")
+	errAPIKeyNotFound     = errors.New("the api key is not found")
+	errAPIKeyUserNotFound = errors.New("the owner of the api key is not found")
+	errInvalidAPIKeyPerm  = errors.New("the permission of the api key must be the composition of 'r' 'w' 'x' and can't exceed the permission of the owner")
+	errInvalidAPIKeyName  = errors.New("the name of the api key can't be empty")
+)
+
+// APIKey the api key info, the secret of the api key is not stored, only the hash of it
+type APIKey struct {
+	Id       string `json:"id"`
+	Name     string `json:"name"`
+	UserName string `json:"username"`
+	Perm     Perm   `json:"perm"`
+	Hash     string `json:"hash,omitempty"`
+	Created  int64  `json:"created"`
+	Expires  int64  `json:"expires"`
+}
+
+// Expired the api key is expired or not, the api key never expires if the Expires is zero
+func (k APIKey) Expired() bool {
+	return k.Expires > 0 && time.Now().Unix() >= k.Expires
+}
+
+// APIKeyStore store the api keys in memory and persist them to the api keys file if it is specified, it is safe for concurrent use
+type APIKeyStore struct {
+	mu   sync.RWMutex
+	path string
+	keys map[string]APIKey
+}
+
+// NewAPIKeyStore create an instance of the APIKeyStore, load the api keys from the path if the file exists,
+// the api keys are only kept in memory if the path is empty
+func NewAPIKeyStore(path string) (*APIKeyStore, error) {
+	s := &APIKeyStore{
+		path: path,
+		keys: make(map[string]APIKey),
+	}
+	if len(path) == 0 {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var keys []APIKey
+	if err = json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("parse the api keys file error => %s, %w", path, err)
+	}
+	for _, k := range keys {
+		s.keys[k.Id] = k
+	}
+	return s, nil
+}
+
+// Create create a new api key for the user, the perm of the api key can't exceed the perm of the user,
+// the api key never expires if the expires is zero, return the api key that is only shown once
+func (s *APIKeyStore) Create(user *User, name string, perm Perm, expires time.Duration) (key string, info APIKey, err error) {
+	if user == nil {
+		return key, info, errAPIKeyUserNotFound
+	}
+	name = strings.TrimSpace(name)
+	if len(name) == 0 {
+		return key, info, errInvalidAPIKeyName
+	}
+	if !perm.IsValid() || !perm.CheckTo(user.Perm()) {
+		return key, info, errInvalidAPIKeyPerm
+	}
+	id, err := randomAPIKeyPart(8, hex.EncodeToString)
+	if err != nil {
+		return key, info, err
+	}
+	secret, err := randomAPIKeyPart(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return key, info, err
+	}
+	now := time.Now()
+	info = APIKey{
+		Id:       id,
+		Name:     name,
+		UserName: user.UserName(),
+		Perm:     perm,
+		Hash:     hashAPIKeySecret(secret),
+		Created:  now.Unix(),
+	}
+	if expires > 0 {
+		info.Expires = now.Add(expires).Unix()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[id] = info
+	if err = s.save(); err != nil {
+		delete(s.keys, id)
+		return key, info, err
+	}
+	info.Hash = ""
+	return APIKeyPrefix + id + "_" + secret, info, nil
+}
+
+// List return the api keys of the user without the hashes, return all the api keys if the userName is empty
+func (s *APIKeyStore) List(userName string) []APIKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]APIKey, 0, len(s.keys))
+	for _, k := range s.keys {
+		if len(userName) == 0 || k.UserName == userName {
+			k.Hash = ""
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Created < keys[j].Created || (keys[i].Created == keys[j].Created && keys[i].Id < keys[j].Id)
+	})
+	return keys
+}
+
+// Revoke remove the api key with the id
+func (s *APIKeyStore) Revoke(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k, ok := s.keys[id]
+	if !ok {
+		return fmt.Errorf("%w => %s", errAPIKeyNotFound, id)
+	}
+	delete(s.keys, id)
+	if err := s.save(); err != nil {
+		s.keys[id] = k
+		return err
+	}
+	return nil
+}
+
+// Verify verify the api key and return the user of it with the perm of the api key,
+// the perm of the returned user is the intersection of the perm of the api key and the current perm of the owner
+func (s *APIKeyStore) Verify(users []*User, key string) (*User, error) {
+	id, secret, ok := strings.Cut(strings.TrimPrefix(key, APIKeyPrefix), "_")
+	if !strings.HasPrefix(key, APIKeyPrefix) || !ok {
+		return nil, errInvalidAPIKey
+	}
+	s.mu.RLock()
+	k, found := s.keys[id]
+	s.mu.RUnlock()
+	hash := hashAPIKeySecret(secret)
+	if !found || subtle.ConstantTimeCompare([]byte(k.Hash), []byte(hash)) != 1 {
+		return nil, errInvalidAPIKey
+	}
+	if k.Expired() {
+		return nil, errAPIKeyExpired
+	}
+	for _, user := range users {
+		if user.UserName() == k.UserName {
+			return &User{
+				userId:   user.UserId(),
+				userName: user.UserName(),
+				perm:     intersectPerm(k.Perm, user.Perm()),
+			}, nil
+		}
+	}
+	return nil, errAPIKeyUserNotFound
+}
+
+// save write all the api keys to the api keys file, the caller must hold the lock
+func (s *APIKeyStore) save() error {
+	if len(s.path) == 0 {
+		return nil
+	}
+	keys := make([]APIKey, 0, len(s.keys))
+	for _, k := range s.keys {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Id < keys[j].Id
+	})
+	data, err := json.MarshalIndent(keys, "", "    ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err = os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	if err = os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func randomAPIKeyPart(size int, encode func([]byte) string) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encode(b), nil
+}
+
+func hashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// intersectPerm return the permissions that both the p and the t have
+func intersectPerm(p Perm, t Perm) Perm {
+	var r Perm
+	if p.R() && t.R() {
+		r += ReadPerm
+	}
+	if p.W() && t.W() {
+		r += WritePerm
+	}
+	if p.X() && t.X() {
+		r += ExecutePerm
+	}
+	return r
+}
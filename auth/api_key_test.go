@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPIKeyStore(t *testing.T) {
+	alice, _ := NewUser(1, "alice", "alice_password", "rw")
+	bob, _ := NewUser(2, "bob", "bob_password", "r")
+	users := []*User{alice, bob}
+	path := filepath.Join(t.TempDir(), "keys", "api_keys.json")
+
+	store, err := NewAPIKeyStore(path)
+	if err != nil {
+		t.Fatalf("NewAPIKeyStore error => %v", err)
+	}
+	aliceKey, aliceInfo, err := store.Create(alice, "ci", "w", time.Hour)
+	if err != nil {
+		t.Fatalf("Create error => %v", err)
+	}
+	if !strings.HasPrefix(aliceKey, APIKeyPrefix) || len(aliceInfo.Hash) > 0 || aliceInfo.Expires <= time.Now().Unix() {
+		t.Errorf("Create get an unexpected api key => %s %+v", aliceKey, aliceInfo)
+	}
+	bobKey, _, err := store.Create(bob, "backup", "r", 0)
+	if err != nil {
+		t.Fatalf("Create error => %v", err)
+	}
+
+	// reload the api keys from the file
+	store, err = NewAPIKeyStore(path)
+	if err != nil {
+		t.Fatalf("NewAPIKeyStore reload error => %v", err)
+	}
+	if keys := store.List(""); len(keys) != 2 {
+		t.Errorf("List expect to get 2 api keys, but get %d", len(keys))
+	}
+	if keys := store.List("alice"); len(keys) != 1 || keys[0].Name != "ci" || len(keys[0].Hash) > 0 {
+		t.Errorf("List expect to get the api key of alice without the hash, but get %+v", keys)
+	}
+
+	user, err := store.Verify(users, aliceKey)
+	if err != nil {
+		t.Fatalf("Verify error => %v", err)
+	}
+	if user.UserName() != "alice" || user.Perm() != "w" {
+		t.Errorf("Verify expect to get alice with the w perm, but get %s with the %s perm", user.UserName(), user.Perm())
+	}
+
+	// the perm of the api key is limited by the current perm of the owner
+	readOnlyAlice, _ := NewUser(1, "alice", "alice_password", "r")
+	if user, err = store.Verify([]*User{readOnlyAlice}, aliceKey); err != nil || user.Perm().IsValid() {
+		t.Errorf("Verify expect to get a user without the valid perm, but get %v => %v", user, err)
+	}
+
+	if err = store.Revoke(aliceInfo.Id); err != nil {
+		t.Errorf("Revoke error => %v", err)
+	}
+	if _, err = store.Verify(users, aliceKey); !errors.Is(err, errInvalidAPIKey) {
+		t.Errorf("Verify expect to get error %v with the revoked api key, but get %v", errInvalidAPIKey, err)
+	}
+	if _, err = store.Verify(users, bobKey); err != nil {
+		t.Errorf("Verify error => %v", err)
+	}
+	if _, err = store.Verify([]*User{alice}, bobKey); !errors.Is(err, errAPIKeyUserNotFound) {
+		t.Errorf("Verify expect to get error %v, but get %v", errAPIKeyUserNotFound, err)
+	}
+}
+
+func TestAPIKeyStore_ReturnError(t *testing.T) {
+	alice, _ := NewUser(1, "alice", "alice_password", "rw")
+	store, _ := NewAPIKeyStore("")
+
+	createCases := []struct {
+		name   string
+		user   *User
+		key    string
+		perm   Perm
+		expect error
+	}{
+		{"nil user", nil, "ci", "r", errAPIKeyUserNotFound},
+		{"empty name", alice, " ", "r", errInvalidAPIKeyName},
+		{"invalid perm", alice, "ci", "a", errInvalidAPIKeyPerm},
+		{"perm exceeded", alice, "ci", "rwx", errInvalidAPIKeyPerm},
+	}
+	for _, tc := range createCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, _, err := store.Create(tc.user, tc.key, tc.perm, 0); !errors.Is(err, tc.expect) {
+				t.Errorf("Create expect to get error %v, but get %v", tc.expect, err)
+			}
+		})
+	}
+
+	key, _, err := store.Create(alice, "ci", "r", time.Second)
+	if err != nil {
+		t.Fatalf("Create error => %v", err)
+	}
+	verifyCases := []struct {
+		name   string
+		key    string
+		wait   time.Duration
+		expect error
+	}{
+		{"without prefix", strings.TrimPrefix(key, APIKeyPrefix), 0, errInvalidAPIKey},
+		{"without secret", APIKeyPrefix + "abc", 0, errInvalidAPIKey},
+		{"wrong secret", key + "x", 0, errInvalidAPIKey},
+		{"expired", key, time.Second * 2, errAPIKeyExpired},
+	}
+	for _, tc := range verifyCases {
+		t.Run(tc.name, func(t *testing.T) {
+			time.Sleep(tc.wait)
+			if _, err := store.Verify([]*User{alice}, tc.key); !errors.Is(err, tc.expect) {
+				t.Errorf("Verify expect to get error %v, but get %v", tc.expect, err)
+			}
+		})
+	}
+
+	if err = store.Revoke("not_found"); !errors.Is(err, errAPIKeyNotFound) {
+		t.Errorf("Revoke expect to get error %v, but get %v", errAPIKeyNotFound, err)
+	}
+}
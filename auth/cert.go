@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"crypto/x509"
+)
+
+// CertNames return the identities of the client certificate that can be mapped to a user,
+// the common name of the subject comes first, then the email addresses, DNS names and URIs of the subject alternative names
+func CertNames(cert *x509.Certificate) (names []string) {
+	if cert == nil {
+		return nil
+	}
+	if len(cert.Subject.CommonName) > 0 {
+		names = append(names, cert.Subject.CommonName)
+	}
+	names = append(names, cert.EmailAddresses...)
+	names = append(names, cert.DNSNames...)
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	return names
+}
+
+// CertUser return the user whose username matches the first possible identity of the client certificate, return nil if there is no matched user
+func CertUser(users []*User, cert *x509.Certificate) *User {
+	for _, name := range CertNames(cert) {
+		for _, user := range users {
+			if user.UserName() == name {
+				return user
+			}
+		}
+	}
+	return nil
+}
+
+// CertUser return the user that matches the identity of the client certificate, see CertUser
+func (s *UserStore) CertUser(cert *x509.Certificate) *User {
+	return CertUser(s.Users(), cert)
+}
@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+	"testing"
+)
+
+func TestCertUser(t *testing.T) {
+	alice, _ := NewUser(1, "alice", "alice_password", "rw")
+	bob, _ := NewUser(2, "bob@example.com", "bob_password", "r")
+	node, _ := NewUser(3, "node1.example.com", "node_password", "rwx")
+	uri, _ := NewUser(4, "spiffe://example.com/gofs", "uri_password", "r")
+	store := NewUserStore([]*User{alice, bob, node, uri})
+	spiffe, _ := url.Parse("spiffe://example.com/gofs")
+
+	testCases := []struct {
+		name   string
+		cert   *x509.Certificate
+		expect *User
+	}{
+		{"common name", &x509.Certificate{Subject: pkix.Name{CommonName: "alice"}}, alice},
+		{"email address", &x509.Certificate{Subject: pkix.Name{CommonName: "unknown"}, EmailAddresses: []string{"bob@example.com"}}, bob},
+		{"dns name", &x509.Certificate{DNSNames: []string{"node0.example.com", "node1.example.com"}}, node},
+		{"uri", &x509.Certificate{URIs: []*url.URL{spiffe}}, uri},
+		{"common name first", &x509.Certificate{Subject: pkix.Name{CommonName: "alice"}, EmailAddresses: []string{"bob@example.com"}}, alice},
+		{"no matched user", &x509.Certificate{Subject: pkix.Name{CommonName: "carol"}}, nil},
+		{"nil cert", nil, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := store.CertUser(tc.cert)
+			if actual != tc.expect {
+				t.Errorf("CertUser expect to get user %v, but get %v", tc.expect, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/no-src/nscache"
+)
+
+const (
+	// DefaultLoginLockout the default lockout duration of the first lockout
+	DefaultLoginLockout = time.Minute
+	// DefaultLoginMaxLockout the default max lockout duration of the exponential backoff
+	DefaultLoginMaxLockout = time.Hour
+
+	loginUserKeyPrefix = "gofs:login:user:"
+	loginIPKeyPrefix   = "gofs:login:ip:"
+)
+
+var (
+	errLoginFailed         = errors.New("the username or password is incorrect")
+	errTwoFactorFailed     = errors.New("the authentication code or recovery code is incorrect")
+	errEmptyLockoutSubject = errors.New("the username or ip to unlock can't be empty")
+)
+
+// LoginGuardOption the options of the LoginGuard
+type LoginGuardOption struct {
+	// MaxFailures the consecutive failures of a username that trigger a lockout, zero means unlimited
+	MaxFailures int
+	// MaxIPFailures the consecutive failures of an ip that trigger a lockout, zero means unlimited,
+	// it is usually greater than the MaxFailures because many users may share an ip
+	MaxIPFailures int
+	// Lockout the lockout duration of the first lockout, it is doubled for every next lockout, default is DefaultLoginLockout
+	Lockout time.Duration
+	// MaxLockout the max lockout duration, default is DefaultLoginMaxLockout
+	MaxLockout time.Duration
+	// OnLockout the callback when a username or an ip is locked out
+	OnLockout func(lockout Lockout)
+}
+
+// Lockout the lockout of a username or an ip
+type Lockout struct {
+	// UserName the locked username, it is empty if the ip is locked
+	UserName string
+	// IP the locked ip, it is empty if the username is locked
+	IP string
+	// Failures the consecutive failures that trigger the lockout
+	Failures int
+	// Until the lockout ends at
+	Until time.Time
+}
+
+// LockedError the login attempt is rejected because the username or the ip is locked out
+type LockedError struct {
+	// RetryAfter the remaining lockout duration
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry after %s", e.RetryAfter.Round(time.Second))
+}
+
+// RetryAfterSeconds return the remaining lockout seconds that are rounded up, like the value of the Retry-After header
+func (e *LockedError) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+// loginRecord the failed login state of a username or an ip
+type loginRecord struct {
+	Failures    int   `json:"failures"`
+	Lockouts    int   `json:"lockouts"`
+	LockedUntil int64 `json:"locked_until"`
+}
+
+// LoginGuard limit the failed login attempts per username and per ip, lock out the username or the ip for a while
+// after too many consecutive failures with exponential backoff, the state is stored in the cache that can be shared by multiple servers
+type LoginGuard struct {
+	cache nscache.NSCache
+	opt   LoginGuardOption
+}
+
+// NewLoginGuard create an instance of the LoginGuard that stores the state in the cache
+func NewLoginGuard(cache nscache.NSCache, opt LoginGuardOption) *LoginGuard {
+	if opt.Lockout <= 0 {
+		opt.Lockout = DefaultLoginLockout
+	}
+	if opt.MaxLockout <= 0 {
+		opt.MaxLockout = DefaultLoginMaxLockout
+	}
+	if opt.MaxLockout < opt.Lockout {
+		opt.MaxLockout = opt.Lockout
+	}
+	return &LoginGuard{
+		cache: cache,
+		opt:   opt,
+	}
+}
+
+// Check return a LockedError if the username or the ip is locked out
+func (g *LoginGuard) Check(userName, ip string) error {
+	var retryAfter time.Duration
+	for _, key := range g.keys(userName, ip) {
+		r, err := g.get(key)
+		if err != nil {
+			return err
+		}
+		if d := time.Until(time.Unix(r.LockedUntil, 0)); d > retryAfter {
+			retryAfter = d
+		}
+	}
+	if retryAfter > 0 {
+		return &LockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// Fail record a failed login attempt of the username from the ip, lock out the username or the ip if the failures reach the limit
+func (g *LoginGuard) Fail(userName, ip string) error {
+	var errs []error
+	now := time.Now()
+	for _, key := range g.keys(userName, ip) {
+		r, err := g.get(key)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		isUser := key == loginUserKeyPrefix+userName
+		maxFailures := g.opt.MaxIPFailures
+		if isUser {
+			maxFailures = g.opt.MaxFailures
+		}
+		r.Failures++
+		if maxFailures > 0 && r.Failures >= maxFailures {
+			lockout := g.lockout(r.Lockouts)
+			r.Lockouts++
+			r.LockedUntil = now.Add(lockout).Unix()
+			if g.opt.OnLockout != nil {
+				l := Lockout{Failures: r.Failures, Until: now.Add(lockout)}
+				if isUser {
+					l.UserName = userName
+				} else {
+					l.IP = ip
+				}
+				g.opt.OnLockout(l)
+			}
+			r.Failures = 0
+		}
+		// keep the record until the next lockout can't be longer any more, then reset the backoff
+		errs = append(errs, g.cache.Set(key, r, time.Until(time.Unix(r.LockedUntil, 0))+g.opt.MaxLockout))
+	}
+	return errors.Join(errs...)
+}
+
+// Succeed reset the failed login state of the username after a successful login, the failed login state of the ip
+// is kept until it expires, otherwise a valid account can reset the failures of the ip between the guesses of other accounts
+func (g *LoginGuard) Succeed(userName string) error {
+	if len(userName) == 0 {
+		return nil
+	}
+	return g.cache.Remove(loginUserKeyPrefix + userName)
+}
+
+// Unlock remove the lockout and the failed login state of the username or the ip, the empty one is ignored
+func (g *LoginGuard) Unlock(userName, ip string) error {
+	keys := g.keys(userName, ip)
+	if len(keys) == 0 {
+		return errEmptyLockoutSubject
+	}
+	var errs []error
+	for _, key := range keys {
+		errs = append(errs, g.cache.Remove(key))
+	}
+	return errors.Join(errs...)
+}
+
+// lockout return the lockout duration of the next lockout, the duration is doubled for every lockout until it reaches the max lockout
+func (g *LoginGuard) lockout(lockouts int) time.Duration {
+	d := g.opt.Lockout
+	for i := 0; i < lockouts && d < g.opt.MaxLockout; i++ {
+		d *= 2
+	}
+	return min(d, g.opt.MaxLockout)
+}
+
+func (g *LoginGuard) get(key string) (r loginRecord, err error) {
+	err = g.cache.Get(key, &r)
+	if errors.Is(err, nscache.ErrNil) {
+		err = nil
+	}
+	return r, err
+}
+
+func (g *LoginGuard) keys(userName, ip string) (keys []string) {
+	if len(userName) > 0 {
+		keys = append(keys, loginUserKeyPrefix+userName)
+	}
+	if len(ip) > 0 {
+		keys = append(keys, loginIPKeyPrefix+ip)
+	}
+	return keys
+}
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/no-src/nscache"
+	_ "github.com/no-src/nscache/memory"
+)
+
+func TestLoginGuard(t *testing.T) {
+	var lockouts []Lockout
+	guard := newTestLoginGuard(t, LoginGuardOption{
+		MaxFailures:   3,
+		MaxIPFailures: 4,
+		Lockout:       time.Minute,
+		MaxLockout:    3 * time.Minute,
+		OnLockout: func(lockout Lockout) {
+			lockouts = append(lockouts, lockout)
+		},
+	})
+
+	testCases := []struct {
+		name       string
+		fn         func() error
+		expectLock bool
+	}{
+		{"first failure", func() error { return guard.Fail("alice", "127.0.0.1") }, false},
+		{"second failure", func() error { return guard.Fail("alice", "127.0.0.2") }, false},
+		{"third failure locks the username", func() error { return guard.Fail("alice", "127.0.0.3") }, true},
+		{"unlock the username", func() error { return guard.Unlock("alice", "") }, false},
+		{"the failures of ip are kept", func() error { return guard.Fail("bob", "127.0.0.1") }, false},
+		{"the failures of ip are less than the limit", func() error { return guard.Fail("carol", "127.0.0.1") }, false},
+		{"login success keeps the failures of ip", func() error { return guard.Succeed("carol") }, false},
+		{"fourth failure locks the ip", func() error { return guard.Fail("dave", "127.0.0.1") }, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			lockouts = nil
+			if err := tc.fn(); err != nil {
+				t.Errorf("expect to get no error, but actual get %v", err)
+				return
+			}
+			if tc.expectLock != (len(lockouts) > 0) {
+				t.Errorf("expect to lock out [%v], but actual get %v", tc.expectLock, lockouts)
+			}
+		})
+	}
+
+	var lockedErr *LockedError
+	if err := guard.Check("eve", "127.0.0.1"); !errors.As(err, &lockedErr) || lockedErr.RetryAfter <= 0 {
+		t.Errorf("expect the ip is locked out, but actual get %v", err)
+	}
+	if err := guard.Check("alice", "127.0.0.9"); err != nil {
+		t.Errorf("expect the unlocked username can log in, but actual get %v", err)
+	}
+	if err := guard.Unlock("", ""); !errors.Is(err, errEmptyLockoutSubject) {
+		t.Errorf("expect to get error %v, but actual get %v", errEmptyLockoutSubject, err)
+	}
+}
+
+func TestLoginGuard_Backoff(t *testing.T) {
+	guard := newTestLoginGuard(t, LoginGuardOption{
+		Lockout:    time.Minute,
+		MaxLockout: 5 * time.Minute,
+	})
+	testCases := []struct {
+		lockouts int
+		expect   time.Duration
+	}{
+		{0, time.Minute},
+		{1, 2 * time.Minute},
+		{2, 4 * time.Minute},
+		{3, 5 * time.Minute},
+		{100, 5 * time.Minute},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expect.String(), func(t *testing.T) {
+			if actual := guard.lockout(tc.lockouts); actual != tc.expect {
+				t.Errorf("expect to get lockout %s, but actual get %s", tc.expect, actual)
+			}
+		})
+	}
+}
+
+func TestUserStore_Login(t *testing.T) {
+	users, err := ParseUsers("alice|alice_password|rw")
+	if err != nil {
+		t.Errorf("parse users error => %v", err)
+		return
+	}
+	store := NewUserStore(users)
+	if _, err = store.Login("alice", "bad_password", "127.0.0.1"); !errors.Is(err, errLoginFailed) {
+		t.Errorf("expect to get error %v, but actual get %v", errLoginFailed, err)
+	}
+
+	store.SetLoginGuard(newTestLoginGuard(t, LoginGuardOption{MaxFailures: 2}))
+	testCases := []struct {
+		name     string
+		password string
+		expect   error
+	}{
+		{"login success", "alice_password", nil},
+		{"first failure", "bad_password", errLoginFailed},
+		{"login success resets the failures", "alice_password", nil},
+		{"first failure again", "bad_password", errLoginFailed},
+		{"second failure locks out", "bad_password", errLoginFailed},
+		{"the correct password is rejected when locked out", "alice_password", &LockedError{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			user, err := store.Login("alice", tc.password, "127.0.0.1")
+			var lockedErr *LockedError
+			switch {
+			case tc.expect == nil && (err != nil || user == nil):
+				t.Errorf("expect to login success, but actual get %v", err)
+			case errors.As(tc.expect, &lockedErr) && !errors.As(err, &lockedErr):
+				t.Errorf("expect to get a locked error, but actual get %v", err)
+			case errors.Is(tc.expect, errLoginFailed) && !errors.Is(err, errLoginFailed):
+				t.Errorf("expect to get error %v, but actual get %v", errLoginFailed, err)
+			}
+		})
+	}
+}
+
+func newTestLoginGuard(t *testing.T, opt LoginGuardOption) *LoginGuard {
+	cache, err := nscache.NewCache("memory:")
+	if err != nil {
+		t.Fatalf("create the cache error => %v", err)
+	}
+	t.Cleanup(func() { cache.Close() })
+	return NewLoginGuard(cache, opt)
+}
@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// Bcrypt the bcrypt password hash algorithm
+	Bcrypt = "bcrypt"
+	// Argon2id the argon2id password hash algorithm, encoded in the PHC string format
+	Argon2id = "argon2id"
+	// DefaultHashAlgorithm the default password hash algorithm
+	DefaultHashAlgorithm = Bcrypt
+)
+
+const (
+	argon2Prefix  = "$argon2id$"
+	argon2Time    = 3
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+)
+
+var (
+	errUnsupportedHashAlgorithm = errors.New("unsupported password hash algorithm, the available algorithms are bcrypt and argon2id")
+	errUnsupportedHash          = errors.New("unsupported password hash, only the bcrypt and argon2id hashes are supported")
+	errInvalidArgon2Hash        = errors.New("invalid argon2id password hash")
+)
+
+var argon2Encoding = base64.RawStdEncoding
+
+// unsupportedHashPrefixes the prefixes of the other password hashes that are generated by the htpasswd and crypt
+var unsupportedHashPrefixes = []string{"$apr1$", "{SHA}", "$1$", "$5$", "$6$", "$argon2i$", "$argon2d$"}
+
+// HashPassword hash the password by the specified algorithm, the result can be used as the password of the users,
+// use the DefaultHashAlgorithm if the algorithm is empty
+func HashPassword(password string, algorithm string) (string, error) {
+	if len(algorithm) == 0 {
+		algorithm = DefaultHashAlgorithm
+	}
+	if err := CheckHashAlgorithm(algorithm); err != nil {
+		return "", err
+	}
+	switch strings.ToLower(algorithm) {
+	case Bcrypt:
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		return string(hash), err
+	default:
+		salt := make([]byte, argon2SaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+		return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2Prefix, argon2.Version, argon2Memory, argon2Time, argon2Threads,
+			argon2Encoding.EncodeToString(salt), argon2Encoding.EncodeToString(key)), nil
+	}
+}
+
+// CheckHashAlgorithm check the password hash algorithm is supported or not, the empty algorithm means the DefaultHashAlgorithm
+func CheckHashAlgorithm(algorithm string) error {
+	switch strings.ToLower(algorithm) {
+	case "", Bcrypt, Argon2id:
+		return nil
+	default:
+		return fmt.Errorf("%w => %s", errUnsupportedHashAlgorithm, algorithm)
+	}
+}
+
+// isHashedPassword report whether the password is a supported password hash
+func isHashedPassword(password string) bool {
+	return isBcryptHash(password) || strings.HasPrefix(password, argon2Prefix)
+}
+
+func isBcryptHash(password string) bool {
+	return strings.HasPrefix(password, "$2a$") || strings.HasPrefix(password, "$2b$") || strings.HasPrefix(password, "$2y$")
+}
+
+// checkPasswordHash check the password is a valid password hash if it looks like a password hash,
+// the other hashes of the htpasswd like MD5 and SHA1 are rejected instead of being used as the plain password
+func checkPasswordHash(password string) error {
+	switch {
+	case isBcryptHash(password):
+		_, err := bcrypt.Cost([]byte(password))
+		return err
+	case strings.HasPrefix(password, argon2Prefix):
+		_, _, _, _, _, err := parseArgon2Hash(password)
+		return err
+	}
+	for _, prefix := range unsupportedHashPrefixes {
+		if strings.HasPrefix(password, prefix) {
+			return fmt.Errorf("%w => %s", errUnsupportedHash, prefix)
+		}
+	}
+	return nil
+}
+
+// verifyPassword compare the password with the stored password or password hash in constant time
+func verifyPassword(stored string, password string) bool {
+	switch {
+	case isBcryptHash(stored):
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil
+	case strings.HasPrefix(stored, argon2Prefix):
+		memory, time, threads, salt, key, err := parseArgon2Hash(stored)
+		if err != nil {
+			return false
+		}
+		actual := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(key)))
+		return subtle.ConstantTimeCompare(actual, key) == 1
+	default:
+		return subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+	}
+}
+
+// parseArgon2Hash parse the argon2id hash in the PHC string format, like $argon2id$v=19$m=65536,t=3,p=4$salt$key
+func parseArgon2Hash(hash string) (memory uint32, time uint32, threads uint8, salt []byte, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return 0, 0, 0, nil, nil, errInvalidArgon2Hash
+	}
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return 0, 0, 0, nil, nil, errInvalidArgon2Hash
+	}
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil || memory == 0 || time == 0 || threads == 0 {
+		return 0, 0, 0, nil, nil, errInvalidArgon2Hash
+	}
+	if salt, err = argon2Encoding.DecodeString(parts[4]); err != nil {
+		return 0, 0, 0, nil, nil, errInvalidArgon2Hash
+	}
+	if key, err = argon2Encoding.DecodeString(parts[5]); err != nil || len(key) == 0 {
+		return 0, 0, 0, nil, nil, errInvalidArgon2Hash
+	}
+	return memory, time, threads, salt, key, nil
+}
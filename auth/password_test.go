@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHashPassword(t *testing.T) {
+	testCases := []struct {
+		name      string
+		algorithm string
+	}{
+		{"default", ""},
+		{"bcrypt", Bcrypt},
+		{"argon2id", Argon2id},
+		{"upper case", "ARGON2ID"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hash, err := HashPassword("gofs_password", tc.algorithm)
+			if err != nil {
+				t.Errorf("HashPassword error => %v", err)
+				return
+			}
+			if !isHashedPassword(hash) {
+				t.Errorf("HashPassword expect to get a password hash, but get %s", hash)
+			}
+			if err = checkPasswordHash(hash); err != nil {
+				t.Errorf("checkPasswordHash error => %v", err)
+			}
+			if !verifyPassword(hash, "gofs_password") {
+				t.Errorf("verifyPassword expect to get true with the correct password")
+			}
+			if verifyPassword(hash, "gofs_password1") {
+				t.Errorf("verifyPassword expect to get false with the incorrect password")
+			}
+		})
+	}
+}
+
+func TestHashPassword_ReturnError(t *testing.T) {
+	_, err := HashPassword("gofs_password", "md5")
+	if !errors.Is(err, errUnsupportedHashAlgorithm) {
+		t.Errorf("HashPassword expect to get error %v, but get %v", errUnsupportedHashAlgorithm, err)
+	}
+}
+
+func TestCheckPasswordHash(t *testing.T) {
+	testCases := []struct {
+		name     string
+		password string
+		expect   error
+	}{
+		{"plain password", "gofs_password", nil},
+		{"bcrypt", "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy", nil},
+		{"argon2id", "$argon2id$v=19$m=65536,t=3,p=4$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5", nil},
+		{"apr1", "$apr1$salt$hash", errUnsupportedHash},
+		{"sha1", "{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=", errUnsupportedHash},
+		{"sha512 crypt", "$6$salt$hash", errUnsupportedHash},
+		{"argon2i", "$argon2i$v=19$m=65536,t=3,p=4$salt$key", errUnsupportedHash},
+		{"invalid argon2id version", "$argon2id$v=16$m=65536,t=3,p=4$c2FsdA$a2V5", errInvalidArgon2Hash},
+		{"invalid argon2id params", "$argon2id$v=19$m=0,t=3,p=4$c2FsdA$a2V5", errInvalidArgon2Hash},
+		{"invalid argon2id salt", "$argon2id$v=19$m=65536,t=3,p=4$!$a2V5", errInvalidArgon2Hash},
+		{"missing argon2id key", "$argon2id$v=19$m=65536,t=3,p=4$c2FsdA", errInvalidArgon2Hash},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkPasswordHash(tc.password)
+			if !errors.Is(err, tc.expect) {
+				t.Errorf("checkPasswordHash expect to get error %v, but get %v", tc.expect, err)
+			}
+		})
+	}
+
+	if checkPasswordHash("$2a$10$invalid") == nil {
+		t.Errorf("checkPasswordHash expect to get an error with the invalid bcrypt hash, but get nil")
+	}
+}
+
+func TestUser_WithHashedPassword(t *testing.T) {
+	user := newUserNoError(t, 1, "gofs", "gofs_password", FullPerm)
+	hashed, err := user.WithHashedPassword(Argon2id)
+	if err != nil {
+		t.Errorf("WithHashedPassword error => %v", err)
+		return
+	}
+	if user.Password() != "gofs_password" {
+		t.Errorf("WithHashedPassword expect to keep the original user unchanged, but get %s", user.Password())
+	}
+	if !hashed.VerifyPassword("gofs_password") || hashed.UserName() != user.UserName() || hashed.Perm() != user.Perm() {
+		t.Errorf("WithHashedPassword expect to get a user with the hashed password, but get %s", hashed.String())
+	}
+
+	// parse the string of the hashed user again, the commas in the argon2id hash are kept
+	userStr, err := ParseStringUsers([]*User{hashed, user})
+	if err != nil {
+		t.Errorf("ParseStringUsers error => %v", err)
+		return
+	}
+	users, err := ParseUsers(userStr)
+	if err != nil {
+		t.Errorf("ParseUsers error => %v", err)
+		return
+	}
+	if len(users) != 2 || users[0].Password() != hashed.Password() || !users[1].VerifyPassword("gofs_password") {
+		t.Errorf("ParseUsers expect to get the hashed user and the plain user, but get %v", users)
+	}
+
+	same, err := hashed.WithHashedPassword(Bcrypt)
+	if err != nil || same.Password() != hashed.Password() {
+		t.Errorf("WithHashedPassword expect to keep the password hash unchanged, but get %s %v", same.Password(), err)
+	}
+}
@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// TOTPDigits the digits of the TOTP code
+	TOTPDigits = 6
+	// TOTPPeriod the time step of the TOTP code
+	TOTPPeriod = 30 * time.Second
+	// RecoveryCodeCount the count of the recovery codes that are generated for a user
+	RecoveryCodeCount = 10
+
+	// totpSkew the accepted time steps before and after the current time step for the clock skew
+	totpSkew = 1
+	// totpSecretSize the byte size of the TOTP secret, 160 bits is recommended by RFC 4226
+	totpSecretSize = 20
+	// recoveryCodeSize the byte size of the recovery code
+	recoveryCodeSize = 10
+)
+
+var (
+	errInvalidTOTPSecret        = errors.New("the TOTP secret must be a base32 string of at least 80 bits")
+	errInvalidRecoveryCodeHash  = errors.New("the recovery code hash must be a hex encoded sha256 hash")
+	errRecoveryCodeNotFound     = errors.New("the recovery code is not found in the users file")
+	errRecoveryCodeNotPersisted = errors.New("the used recovery code can't be removed from the users file")
+
+	totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+)
+
+// GenerateTOTPSecret generate a random base32 encoded TOTP secret
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, totpSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// TOTPCode return the TOTP code of the secret at the time t, see RFC 6238
+func TOTPCode(secret string, t time.Time) (string, error) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return totpCode(key, totpCounter(t)), nil
+}
+
+// VerifyTOTP report whether the code is the TOTP code of the secret at the time t, one time step of the clock skew is accepted
+func VerifyTOTP(secret string, code string, t time.Time) bool {
+	_, ok := matchTOTP(secret, code, t)
+	return ok
+}
+
+// TOTPURL return the otpauth url of the secret that can be added to the authenticator apps by a QR code
+func TOTPURL(issuer, userName, secret string) string {
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", issuer)
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", fmt.Sprint(TOTPDigits))
+	v.Set("period", fmt.Sprint(int(TOTPPeriod.Seconds())))
+	return fmt.Sprintf("otpauth://totp/%s:%s?%s", url.PathEscape(issuer), url.PathEscape(userName), v.Encode())
+}
+
+// GenerateRecoveryCodes generate some random one-time recovery codes, return the codes and the hashes of them
+func GenerateRecoveryCodes(count int) (codes []string, hashes []string, err error) {
+	for i := 0; i < count; i++ {
+		b := make([]byte, recoveryCodeSize)
+		if _, err = rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(totpEncoding.EncodeToString(b))
+		code = code[:len(code)/2] + "-" + code[len(code)/2:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// matchTOTP return the time step counter of the matched TOTP code
+func matchTOTP(secret string, code string, t time.Time) (counter uint64, ok bool) {
+	key, err := decodeTOTPSecret(secret)
+	code = strings.TrimSpace(code)
+	if err != nil || len(code) != TOTPDigits {
+		return 0, false
+	}
+	current := totpCounter(t)
+	for i := -totpSkew; i <= totpSkew; i++ {
+		c := current + uint64(i)
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, c)), []byte(code)) == 1 {
+			return c, true
+		}
+	}
+	return 0, false
+}
+
+func totpCounter(t time.Time) uint64 {
+	return uint64(t.Unix() / int64(TOTPPeriod.Seconds()))
+}
+
+// totpCode generate the HOTP code of the counter, see RFC 4226
+func totpCode(key []byte, counter uint64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < TOTPDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", TOTPDigits, value%mod)
+}
+
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(strings.TrimRight(secret, "="), " ", ""))
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil || len(key) < 10 {
+		return nil, errInvalidTOTPSecret
+	}
+	return key, nil
+}
+
+// hashRecoveryCode return the hex encoded sha256 hash of the normalized recovery code,
+// the recovery codes are random enough to be hashed without a salt like the api keys
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+func checkRecoveryCodeHash(hash string) error {
+	if b, err := hex.DecodeString(hash); err != nil || len(b) != sha256.Size {
+		return errInvalidRecoveryCodeHash
+	}
+	return nil
+}
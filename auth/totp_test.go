@@ -0,0 +1,293 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testTOTPSecret the base32 encoded secret "12345678901234567890" of the RFC 6238 test vectors
+const testTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	testCases := []struct {
+		unix   int64
+		expect string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expect, func(t *testing.T) {
+			actual, err := TOTPCode(testTOTPSecret, time.Unix(tc.unix, 0))
+			if err != nil {
+				t.Errorf("TOTPCode error => %v", err)
+				return
+			}
+			if actual != tc.expect {
+				t.Errorf("expect to get TOTP code %s, but actual get %s", tc.expect, actual)
+			}
+		})
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	testCases := []struct {
+		name   string
+		secret string
+		code   string
+		t      time.Time
+		expect bool
+	}{
+		{"current time step", testTOTPSecret, "050471", now, true},
+		{"lowercase secret with spaces", "gezd gnbv gy3t qojq gezd gnbv gy3t qojq", "050471", now, true},
+		{"previous time step", testTOTPSecret, "050471", now.Add(TOTPPeriod), true},
+		{"next time step", testTOTPSecret, "050471", now.Add(-TOTPPeriod), true},
+		{"too late", testTOTPSecret, "050471", now.Add(2 * TOTPPeriod), false},
+		{"too early", testTOTPSecret, "050471", now.Add(-2 * TOTPPeriod), false},
+		{"incorrect code", testTOTPSecret, "123456", now, false},
+		{"short code", testTOTPSecret, "50471", now, false},
+		{"invalid secret", "1", "050471", now, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := VerifyTOTP(tc.secret, tc.code, tc.t); actual != tc.expect {
+				t.Errorf("expect to get %v, but actual get %v", tc.expect, actual)
+			}
+		})
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Errorf("GenerateTOTPSecret error => %v", err)
+		return
+	}
+	now := time.Now()
+	code, err := TOTPCode(secret, now)
+	if err != nil {
+		t.Errorf("TOTPCode error => %v", err)
+		return
+	}
+	if !VerifyTOTP(secret, code, now) {
+		t.Errorf("expect to verify the TOTP code of the generated secret")
+	}
+	url := TOTPURL("gofs", "alice", secret)
+	if !strings.HasPrefix(url, "otpauth://totp/gofs:alice?") || !strings.Contains(url, "secret="+secret) {
+		t.Errorf("get an unexpected otpauth url => %s", url)
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, hashes, err := GenerateRecoveryCodes(RecoveryCodeCount)
+	if err != nil {
+		t.Errorf("GenerateRecoveryCodes error => %v", err)
+		return
+	}
+	if len(codes) != RecoveryCodeCount || len(hashes) != RecoveryCodeCount {
+		t.Errorf("expect to get %d recovery codes, but actual get %d", RecoveryCodeCount, len(codes))
+		return
+	}
+	user, _ := NewUser(1, "alice", "alice_password", "rw")
+	user, err = user.WithTwoFactor(testTOTPSecret, hashes)
+	if err != nil {
+		t.Errorf("WithTwoFactor error => %v", err)
+		return
+	}
+	// the recovery code is case-insensitive and the separator is optional
+	code := strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))
+	if hash, ok := user.VerifyRecoveryCode(code); !ok || hash != hashes[0] {
+		t.Errorf("expect to verify the recovery code %s", code)
+	}
+	if _, ok := user.withoutRecoveryCode(hashes[0]).VerifyRecoveryCode(codes[0]); ok {
+		t.Errorf("expect the removed recovery code is invalid")
+	}
+	if _, err = user.WithTwoFactor(testTOTPSecret, []string{"abc"}); !errors.Is(err, errInvalidRecoveryCodeHash) {
+		t.Errorf("expect to get error %v, but actual get %v", errInvalidRecoveryCodeHash, err)
+	}
+	if _, err = user.WithTwoFactor("123", nil); !errors.Is(err, errInvalidTOTPSecret) {
+		t.Errorf("expect to get error %v, but actual get %v", errInvalidTOTPSecret, err)
+	}
+}
+
+func TestUserStore_VerifyTwoFactor(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	codes, hashes, _ := GenerateRecoveryCodes(2)
+	usersFile := filepath.Join(t.TempDir(), "users")
+	content := "# users\nalice:alice_password:rw:" + testTOTPSecret + ":" + strings.Join(hashes, ",") + "\nbob:bob_password:r\n"
+	if err := os.WriteFile(usersFile, []byte(content), 0600); err != nil {
+		t.Errorf("write the users file error => %v", err)
+		return
+	}
+	users, err := ParseUsersFile(usersFile)
+	if err != nil {
+		t.Errorf("ParseUsersFile error => %v", err)
+		return
+	}
+	store := NewUserStore(users)
+	store.SetLoginGuard(newTestLoginGuard(t, LoginGuardOption{MaxFailures: 3}))
+	store.SetUsersFile(usersFile)
+
+	testCases := []struct {
+		name           string
+		code           string
+		t              time.Time
+		expectOk       bool
+		expectRecovery bool
+	}{
+		{"TOTP code", "050471", now, true, false},
+		{"reuse the TOTP code", "050471", now, false, false},
+		{"the TOTP code of the next time step", "005924", time.Unix(1234567890, 0), true, false},
+		{"the TOTP code of an earlier time step", "050471", time.Unix(1111111111, 0), false, false},
+		{"recovery code", codes[0], now, true, true},
+		{"reuse the recovery code", codes[0], now, false, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			user, recoveryCode, err := store.VerifyTwoFactor("alice", tc.code, "127.0.0.1", tc.t)
+			if tc.expectOk != (err == nil && user != nil) {
+				t.Errorf("expect to verify the code [%v], but actual get %v", tc.expectOk, err)
+			}
+			if tc.expectRecovery != (len(recoveryCode) > 0) {
+				t.Errorf("expect to use the recovery code [%v], but actual get %s", tc.expectRecovery, recoveryCode)
+			}
+		})
+	}
+
+	// the failure of reusing the recovery code is the first one
+	for i := 0; i < 2; i++ {
+		store.VerifyTwoFactor("alice", "000000", "127.0.0.1", now)
+	}
+	var lockedErr *LockedError
+	if _, _, err = store.VerifyTwoFactor("alice", codes[1], "127.0.0.1", now); !errors.As(err, &lockedErr) {
+		t.Errorf("expect the user is locked out after too many failures, but actual get %v", err)
+	}
+	if _, _, err = store.VerifyTwoFactor("bob", "050471", "127.0.0.2", now); !errors.Is(err, errTwoFactorFailed) {
+		t.Errorf("expect the user without two-factor authentication can't pass, but actual get %v", err)
+	}
+
+	users, err = ParseUsersFile(usersFile)
+	if err != nil {
+		t.Errorf("ParseUsersFile error => %v", err)
+		return
+	}
+	if _, ok := users[0].VerifyRecoveryCode(codes[0]); ok {
+		t.Errorf("expect the used recovery code is removed from the users file")
+	}
+	if _, ok := users[0].VerifyRecoveryCode(codes[1]); !ok {
+		t.Errorf("expect the unused recovery code is kept in the users file")
+	}
+}
+
+func TestUserStore_Login_TwoFactor(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	users, err := ParseUsers("alice|alice_password|rw")
+	if err != nil {
+		t.Errorf("parse users error => %v", err)
+		return
+	}
+	if users[0], err = users[0].WithTwoFactor(testTOTPSecret, nil); err != nil {
+		t.Errorf("enable the two-factor authentication error => %v", err)
+		return
+	}
+	store := NewUserStore(users)
+	store.SetLoginGuard(newTestLoginGuard(t, LoginGuardOption{MaxFailures: 3}))
+
+	testCases := []struct {
+		name       string
+		login      bool
+		code       string
+		expectLock bool
+	}{
+		{"login with the password", true, "", false},
+		{"first wrong code", false, "000000", false},
+		{"second wrong code", false, "000000", false},
+		{"login with the password again does not reset the failures", true, "", false},
+		{"third wrong code locks out", false, "000000", false},
+		{"the correct code is rejected when locked out", false, "050471", true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.login {
+				if _, err := store.Login("alice", "alice_password", "127.0.0.1"); err != nil {
+					t.Errorf("expect to login success, but actual get %v", err)
+				}
+				return
+			}
+			_, _, err := store.VerifyTwoFactor("alice", tc.code, "127.0.0.1", now)
+			var lockedErr *LockedError
+			if tc.expectLock != errors.As(err, &lockedErr) {
+				t.Errorf("expect to be locked out [%v], but actual get %v", tc.expectLock, err)
+			}
+		})
+	}
+}
+
+func TestUserStore_RequireTwoFactor(t *testing.T) {
+	users, err := ParseUsers("alice|alice_password|r|" + testTOTPSecret + ",bob|bob_password|r,carol|carol_password|rw,dave|dave_password|rwx")
+	if err != nil {
+		t.Errorf("ParseUsers error => %v", err)
+		return
+	}
+	store := NewUserStore(users)
+	store.SetTwoFactorPerm(ToPerm("wx"))
+	expect := map[string]bool{"alice": true, "bob": false, "carol": true, "dave": true}
+	for _, user := range users {
+		t.Run(user.UserName(), func(t *testing.T) {
+			if actual := store.RequireTwoFactor(user); actual != expect[user.UserName()] {
+				t.Errorf("expect to require the two-factor authentication [%v], but actual get %v", expect[user.UserName()], actual)
+			}
+		})
+	}
+	if users[0].String() != "alice|alice_password|r|"+testTOTPSecret {
+		t.Errorf("expect the TOTP secret is kept in the user string, but actual get %s", users[0].String())
+	}
+}
+
+func TestUserStore_VerifyTwoFactor_RecoveryCodeNotPersisted(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	codes, hashes, _ := GenerateRecoveryCodes(1)
+	usersFile := filepath.Join(t.TempDir(), "users")
+	content := "alice:alice_password:rw:" + testTOTPSecret + ":" + strings.Join(hashes, ",") + "\n"
+	if err := os.WriteFile(usersFile, []byte(content), 0600); err != nil {
+		t.Fatalf("write the users file error => %v", err)
+	}
+	users, err := ParseUsersFile(usersFile)
+	if err != nil {
+		t.Fatalf("ParseUsersFile error => %v", err)
+	}
+
+	testCases := []struct {
+		name      string
+		usersFile string
+	}{
+		{"without users file", ""},
+		{"users file not found", filepath.Join(t.TempDir(), "not_exist")},
+		{"recovery code not in users file", writeUsersFile(t, "alice:alice_password:rw\n")},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := NewUserStore(users)
+			store.SetUsersFile(tc.usersFile)
+			if _, _, err := store.VerifyTwoFactor("alice", codes[0], "127.0.0.1", now); !errors.Is(err, errRecoveryCodeNotPersisted) {
+				t.Errorf("expect to get error %v, but actual get %v", errRecoveryCodeNotPersisted, err)
+			}
+			// the recovery code is still valid after the users file is fixed
+			store.SetUsersFile(usersFile)
+			if _, recoveryCode, err := store.VerifyTwoFactor("alice", codes[0], "127.0.0.1", now); err != nil || len(recoveryCode) == 0 {
+				t.Errorf("expect to verify the recovery code, but actual get %v", err)
+			}
+			if err = os.WriteFile(usersFile, []byte(content), 0600); err != nil {
+				t.Fatalf("restore the users file error => %v", err)
+			}
+		})
+	}
+}
@@ -1,23 +1,30 @@
 package auth
 
 import (
+	"crypto/subtle"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/no-src/nsgo/randutil"
 )
 
 // User a login user info
 type User struct {
-	userId   int
-	userName string
-	password string
-	perm     Perm
+	userId        int
+	userName      string
+	password      string
+	perm          Perm
+	totpSecret    string
+	recoveryCodes []string
 }
 
 // String return format user info
 func (user *User) String() string {
+	if len(user.totpSecret) > 0 {
+		return fmt.Sprintf("%s|%s|%s|%s", user.userName, user.password, user.perm, user.totpSecret)
+	}
 	return fmt.Sprintf("%s|%s|%s", user.userName, user.password, user.perm)
 }
 
@@ -36,11 +43,79 @@ func (user *User) Password() string {
 	return user.password
 }
 
+// VerifyPassword report whether the password is the password of the user, the password of the user may be a password hash
+func (user *User) VerifyPassword(password string) bool {
+	return verifyPassword(user.password, password)
+}
+
+// WithHashedPassword returns a copy of the user with the password hashed by the specified algorithm
+func (user *User) WithHashedPassword(algorithm string) (*User, error) {
+	if isHashedPassword(user.password) {
+		return user, nil
+	}
+	hash, err := HashPassword(user.password, algorithm)
+	if err != nil {
+		return nil, err
+	}
+	u := *user
+	u.password = hash
+	return &u, nil
+}
+
 // Perm return user permission
 func (user *User) Perm() Perm {
 	return user.perm
 }
 
+// TwoFactorEnabled the user is enrolled in the TOTP two-factor authentication or not
+func (user *User) TwoFactorEnabled() bool {
+	return len(user.totpSecret) > 0
+}
+
+// VerifyTOTP report whether the code is the TOTP code of the user at the time t
+func (user *User) VerifyTOTP(code string, t time.Time) bool {
+	return user.TwoFactorEnabled() && VerifyTOTP(user.totpSecret, code, t)
+}
+
+// VerifyRecoveryCode report whether the code is an unused recovery code of the user, return the hash of the matched recovery code
+func (user *User) VerifyRecoveryCode(code string) (hash string, ok bool) {
+	h := hashRecoveryCode(code)
+	for _, rc := range user.recoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(rc), []byte(h)) == 1 {
+			hash, ok = rc, true
+		}
+	}
+	return hash, ok
+}
+
+// WithTwoFactor returns a copy of the user that is enrolled in the TOTP two-factor authentication with the secret and the recovery code hashes
+func (user *User) WithTwoFactor(totpSecret string, recoveryCodes []string) (*User, error) {
+	if _, err := decodeTOTPSecret(totpSecret); err != nil {
+		return nil, err
+	}
+	for _, hash := range recoveryCodes {
+		if err := checkRecoveryCodeHash(hash); err != nil {
+			return nil, err
+		}
+	}
+	u := *user
+	u.totpSecret = totpSecret
+	u.recoveryCodes = recoveryCodes
+	return &u, nil
+}
+
+// withoutRecoveryCode returns a copy of the user without the used recovery code
+func (user *User) withoutRecoveryCode(hash string) *User {
+	u := *user
+	u.recoveryCodes = nil
+	for _, rc := range user.recoveryCodes {
+		if rc != hash {
+			u.recoveryCodes = append(u.recoveryCodes, rc)
+		}
+	}
+	return &u
+}
+
 // NewUser create a new user
 func NewUser(userId int, userName string, password string, perm string) (*User, error) {
 	if userId <= 0 {
@@ -74,8 +149,17 @@ func isValidUser(user User) error {
 	if strings.ContainsAny(user.UserName(), ",|") {
 		return errors.New("userName can't contain ',' or '|' ")
 	}
-	if strings.ContainsAny(user.Password(), ",|") {
+	if isHashedPassword(user.Password()) {
+		if strings.Contains(user.Password(), "|") {
+			return errors.New("password can't contain '|' ")
+		}
+		if err := checkPasswordHash(user.Password()); err != nil {
+			return err
+		}
+	} else if strings.ContainsAny(user.Password(), ",|") {
 		return errors.New("password can't contain ',' or '|' ")
+	} else if err := checkPasswordHash(user.Password()); err != nil {
+		return err
 	}
 	if !user.perm.IsValid() {
 		return errors.New("user is no permission")
@@ -83,18 +167,19 @@ func isValidUser(user User) error {
 	return nil
 }
 
-// ParseUsers parse users string to User List
-// For example: user1|password1|rwx,user2|password2|rwx
+// ParseUsers parse users string to User List, the password can be a bcrypt or argon2id password hash,
+// the optional fourth field is the TOTP secret of the two-factor authentication
+// For example: user1|password1|rwx,user2|password2|rwx|JBSWY3DPEHPK3PXP
 func ParseUsers(userStr string) (users []*User, err error) {
 	if len(userStr) == 0 {
 		return users, nil
 	}
-	all := strings.Split(userStr, ",")
+	all := splitUsers(userStr)
 	userCount := 0
 	for _, userStr := range all {
 		userInfo := strings.Split(userStr, "|")
 		fieldLen := len(userInfo)
-		if fieldLen >= 2 && fieldLen <= 3 {
+		if fieldLen >= 2 && fieldLen <= 4 {
 			userName := strings.TrimSpace(userInfo[0])
 			password := strings.TrimSpace(userInfo[1])
 			if len(userName) > 0 && len(password) > 0 {
@@ -107,6 +192,11 @@ func ParseUsers(userStr string) (users []*User, err error) {
 				if err != nil {
 					return nil, err
 				}
+				if fieldLen > 3 {
+					if user, err = user.WithTwoFactor(strings.TrimSpace(userInfo[3]), nil); err != nil {
+						return nil, err
+					}
+				}
 				users = append(users, user)
 			}
 		} else {
@@ -116,6 +206,19 @@ func ParseUsers(userStr string) (users []*User, err error) {
 	return users, nil
 }
 
+// splitUsers split the users string by commas, the commas in the parameters of the argon2id hash are kept,
+// like user1|$argon2id$v=19$m=65536,t=3,p=4$salt$key|rwx
+func splitUsers(userStr string) (all []string) {
+	for _, s := range strings.Split(userStr, ",") {
+		if n := len(all); n > 0 && (strings.HasPrefix(s, "t=") || strings.HasPrefix(s, "p=")) && strings.Contains(all[n-1], argon2Prefix) {
+			all[n-1] += "," + s
+			continue
+		}
+		all = append(all, s)
+	}
+	return all
+}
+
 // RandomUser generate some user with random username and password
 // count is user count you want
 // userLen is the length of random username, max length is 20
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var errInvalidUserRoot = errors.New("the username can't be used as the name of the user root directory")
+
+// UserRoot return the isolated root directory of the user in the base directory, like <base>/<username>
+func UserRoot(base string, userName string) (string, error) {
+	if err := checkUserRootName(userName); err != nil {
+		return "", err
+	}
+	return filepath.Join(base, userName), nil
+}
+
+// CheckUserRoot check all the usernames can be used as the names of the user root directories
+func CheckUserRoot(users []*User) error {
+	for _, user := range users {
+		if err := checkUserRootName(user.UserName()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkUserRootName(userName string) error {
+	if len(userName) == 0 || userName == "." || userName == ".." || strings.ContainsAny(userName, `/\:`) || !filepath.IsLocal(userName) {
+		return fmt.Errorf("%w => %s", errInvalidUserRoot, userName)
+	}
+	return nil
+}
+
+// userRootDir an implementation of http.FileSystem that only serves the files in the user root directory
+type userRootDir struct {
+	fs       http.FileSystem
+	userName string
+}
+
+// NewUserRootDir returns a http.FileSystem that serves the files in the root directory of the user like /<username>,
+// the root directory of the returned file system is the user root directory
+func NewUserRootDir(fs http.FileSystem, userName string) http.FileSystem {
+	return &userRootDir{
+		fs:       fs,
+		userName: userName,
+	}
+}
+
+func (d *userRootDir) Open(name string) (http.File, error) {
+	if err := checkUserRootName(d.userName); err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return d.fs.Open(path.Join("/", d.userName, path.Clean("/"+name)))
+}
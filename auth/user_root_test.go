@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUserRoot(t *testing.T) {
+	testCases := []struct {
+		name     string
+		userName string
+		expect   string
+		err      error
+	}{
+		{"valid username", "alice", filepath.Join("source", "alice"), nil},
+		{"username with dot", "alice.backup", filepath.Join("source", "alice.backup"), nil},
+		{"empty username", "", "", errInvalidUserRoot},
+		{"current directory", ".", "", errInvalidUserRoot},
+		{"parent directory", "..", "", errInvalidUserRoot},
+		{"with slash", "alice/bob", "", errInvalidUserRoot},
+		{"with backslash", `alice\bob`, "", errInvalidUserRoot},
+		{"with colon", "c:", "", errInvalidUserRoot},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := UserRoot("source", tc.userName)
+			if !errors.Is(err, tc.err) {
+				t.Errorf("UserRoot expect to get error %v, but get %v", tc.err, err)
+				return
+			}
+			if actual != tc.expect {
+				t.Errorf("UserRoot expect to get %s, but get %s", tc.expect, actual)
+			}
+		})
+	}
+}
+
+func TestCheckUserRoot(t *testing.T) {
+	users := []*User{newUserNoError(t, 1, "alice", "alice_pwd", DefaultPerm)}
+	if err := CheckUserRoot(users); err != nil {
+		t.Errorf("CheckUserRoot error => %v", err)
+	}
+	users = append(users, newUserNoError(t, 2, "..", "bob_pwd", DefaultPerm))
+	if err := CheckUserRoot(users); !errors.Is(err, errInvalidUserRoot) {
+		t.Errorf("CheckUserRoot expect to get error %v, but get %v", errInvalidUserRoot, err)
+	}
+}
+
+func TestNewUserRootDir(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"alice/a.txt", "bob/b.txt"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatalf("create the test directory error => %v", err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0600); err != nil {
+			t.Fatalf("create the test file error => %v", err)
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		userName string
+		path     string
+		expect   error
+	}{
+		{"user root", "alice", "/", nil},
+		{"user file", "alice", "/a.txt", nil},
+		{"other user file", "alice", "/bob/b.txt", fs.ErrNotExist},
+		{"parent directory", "alice", "/../bob/b.txt", fs.ErrNotExist},
+		{"invalid username", "..", "/bob/b.txt", fs.ErrPermission},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := NewUserRootDir(http.Dir(root), tc.userName).Open(tc.path)
+			if !errors.Is(err, tc.expect) {
+				t.Errorf("Open expect to get error %v, but get %v", tc.expect, err)
+			}
+			if err == nil {
+				f.Close()
+			}
+		})
+	}
+}
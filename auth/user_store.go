@@ -0,0 +1,277 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	errRemoveAllUsers      = errors.New("can't remove all the users of the server that is started with users, restart the server to allow the anonymous access")
+	errAddUsersToAnonymous = errors.New("can't add users to the server that is started without users, restart the server to require the users")
+)
+
+// UserStore store the server accounts, the acl, the login guard and the two-factor policy, it is safe for concurrent use and supports to replace all the accounts at runtime
+type UserStore struct {
+	mu    sync.RWMutex
+	users []*User
+	acl   *ACL
+	guard *LoginGuard
+	// twoFactorPerm the users that have any of the permissions require the two-factor authentication
+	twoFactorPerm Perm
+	// totpUsed the last used TOTP time step counter of the users to prevent the replay attack
+	totpUsed map[string]uint64
+	// usersFile the users file that the used recovery codes are removed from
+	usersFile string
+	// anonymous allow the anonymous access, it is decided by the users at startup and never changed
+	anonymous bool
+}
+
+// NewUserStore create an instance of the UserStore with the specified users,
+// the anonymous access is allowed if there is no user, and it can't be changed at runtime
+func NewUserStore(users []*User) *UserStore {
+	return &UserStore{
+		users:     users,
+		anonymous: len(users) == 0,
+	}
+}
+
+// Anonymous report whether the anonymous access is allowed, it is decided by the users at startup
+func (s *UserStore) Anonymous() bool {
+	return s == nil || s.anonymous
+}
+
+// Users return a snapshot of all the current users
+func (s *UserStore) Users() []*User {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	users := make([]*User, len(s.users))
+	copy(users, s.users)
+	return users
+}
+
+// Len return the count of the current users
+func (s *UserStore) Len() int {
+	if s == nil {
+		return 0
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.users)
+}
+
+// First return the first user, return nil if there is no user
+func (s *UserStore) First() *User {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.users) == 0 {
+		return nil
+	}
+	return s.users[0]
+}
+
+// CheckReplace check whether the specified users can replace the current users,
+// return an error if the replacement would change the anonymous access that is decided at startup
+func (s *UserStore) CheckReplace(users []*User) error {
+	if s.anonymous && len(users) > 0 {
+		return errAddUsersToAnonymous
+	}
+	if !s.anonymous && len(users) == 0 {
+		return errRemoveAllUsers
+	}
+	return nil
+}
+
+// Replace replace all the current users with the specified users, keep the current users and return an error
+// if the replacement would change the anonymous access, see CheckReplace
+func (s *UserStore) Replace(users []*User) error {
+	if err := s.CheckReplace(users); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users = users
+	return nil
+}
+
+// ACL return the current acl, return nil if the acl is disabled
+func (s *UserStore) ACL() *ACL {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.acl
+}
+
+// SetACL replace the current acl with the specified acl
+func (s *UserStore) SetACL(acl *ACL) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.acl = acl
+}
+
+// LoginGuard return the current login guard, return nil if the login attempts are unlimited
+func (s *UserStore) LoginGuard() *LoginGuard {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.guard
+}
+
+// SetLoginGuard replace the current login guard with the specified login guard
+func (s *UserStore) SetLoginGuard(guard *LoginGuard) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.guard = guard
+}
+
+// SetUsersFile set the users file that the users with the recovery codes are loaded from,
+// the recovery codes are not accepted if the users file is not set
+func (s *UserStore) SetUsersFile(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.usersFile = path
+}
+
+// Login verify the username and the password of the login attempt from the ip,
+// the failed attempts are limited by the login guard if it is set, return a LockedError if the username or the ip is locked out,
+// the failures of the user that requires the two-factor authentication are reset by the VerifyTwoFactor instead
+func (s *UserStore) Login(userName, password, ip string) (*User, error) {
+	guard := s.LoginGuard()
+	if guard != nil {
+		if err := guard.Check(userName, ip); err != nil {
+			return nil, err
+		}
+	}
+	user := VerifyUser(s.Users(), userName, password)
+	if guard == nil {
+		if user == nil {
+			return nil, errLoginFailed
+		}
+		return user, nil
+	}
+	if user == nil {
+		return nil, errors.Join(errLoginFailed, guard.Fail(userName, ip))
+	}
+	// the login is not finished until the second factor passes, so keep the failures that are counted by the VerifyTwoFactor
+	if s.RequireTwoFactor(user) {
+		return user, nil
+	}
+	return user, guard.Succeed(userName)
+}
+
+// VerifySessionUser return the current user of the signed in session user, return nil if the user is removed
+// or the password of the user is changed, then the session should be signed out
+func (s *UserStore) VerifySessionUser(sessionUser SessionUser) *User {
+	for _, user := range s.Users() {
+		if user.UserId() == sessionUser.UserId && user.UserName() == sessionUser.UserName &&
+			subtle.ConstantTimeCompare([]byte(user.Password()), []byte(sessionUser.Password)) == 1 {
+			return user
+		}
+	}
+	return nil
+}
+
+// SetTwoFactorPerm require the two-factor authentication for the users that have any of the permissions, the empty perm means no requirement
+func (s *UserStore) SetTwoFactorPerm(perm Perm) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.twoFactorPerm = perm
+}
+
+// RequireTwoFactor report whether the user must pass the two-factor authentication to sign in,
+// the users that are enrolled or have any of the permissions of the two-factor policy are required
+func (s *UserStore) RequireTwoFactor(user *User) bool {
+	if user == nil {
+		return false
+	}
+	if user.TwoFactorEnabled() || s == nil {
+		return user.TwoFactorEnabled()
+	}
+	s.mu.RLock()
+	p := s.twoFactorPerm
+	s.mu.RUnlock()
+	up := user.Perm()
+	return (p.R() && up.R()) || (p.W() && up.W()) || (p.X() && up.X())
+}
+
+// VerifyTwoFactor verify the TOTP code or the recovery code of the user that has passed the password verification at the time t,
+// the TOTP code can't be reused and the recovery code is removed from the user and the users file after used, the recovery code
+// is rejected if it can't be removed from the users file, return the hash of the used recovery code,
+// the failed attempts are limited by the login guard like the Login
+func (s *UserStore) VerifyTwoFactor(userName, code, ip string, t time.Time) (user *User, recoveryCode string, err error) {
+	guard := s.LoginGuard()
+	if guard != nil {
+		if err = guard.Check(userName, ip); err != nil {
+			return nil, "", err
+		}
+	}
+	user, recoveryCode, ok, err := s.verifyTwoFactor(userName, code, t)
+	if err != nil {
+		return nil, "", err
+	}
+	if !ok {
+		err = errTwoFactorFailed
+		if guard != nil {
+			err = errors.Join(err, guard.Fail(userName, ip))
+		}
+		return nil, "", err
+	}
+	if guard != nil {
+		err = guard.Succeed(userName)
+	}
+	return user, recoveryCode, err
+}
+
+func (s *UserStore) verifyTwoFactor(userName, code string, t time.Time) (user *User, recoveryCode string, ok bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index := -1
+	for i, u := range s.users {
+		if u.UserName() == userName && u.TwoFactorEnabled() {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, "", false, nil
+	}
+	user = s.users[index]
+	if counter, ok := matchTOTP(user.totpSecret, code, t); ok {
+		if last, used := s.totpUsed[userName]; used && counter <= last {
+			return nil, "", false, nil
+		}
+		if s.totpUsed == nil {
+			s.totpUsed = make(map[string]uint64)
+		}
+		s.totpUsed[userName] = counter
+		return user, "", true, nil
+	}
+	if hash, ok := user.VerifyRecoveryCode(code); ok {
+		// remove the recovery code from the users file before accepting it, otherwise it is restored by the next reload
+		if len(s.usersFile) == 0 {
+			return nil, "", false, errRecoveryCodeNotPersisted
+		}
+		if err = RemoveRecoveryCode(s.usersFile, userName, hash); err != nil {
+			return nil, "", false, fmt.Errorf("%w => %w", errRecoveryCodeNotPersisted, err)
+		}
+		// replace the user in memory at once, the users file is reloaded later
+		users := make([]*User, len(s.users))
+		copy(users, s.users)
+		users[index] = user.withoutRecoveryCode(hash)
+		s.users = users
+		return users[index], hash, true, nil
+	}
+	return nil, "", false, nil
+}
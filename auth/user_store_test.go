@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUserStore(t *testing.T) {
+	users, err := ParseUsers("user1|password1|rwx,user2|password2|r")
+	if err != nil {
+		t.Errorf("parse users error => %v", err)
+		return
+	}
+	store := NewUserStore(users)
+	if store.Len() != 2 {
+		t.Errorf("expect to get 2 users, but actual get %d", store.Len())
+	}
+	if store.First().UserName() != "user1" {
+		t.Errorf("expect the first user is user1, but actual get %s", store.First().UserName())
+	}
+
+	newUsers, err := ParseUsers("user3|password3|rw")
+	if err != nil {
+		t.Errorf("parse users error => %v", err)
+		return
+	}
+	if err = store.Replace(newUsers); err != nil {
+		t.Errorf("replace the users error => %v", err)
+	}
+	all := store.Users()
+	if len(all) != 1 || all[0].UserName() != "user3" {
+		t.Errorf("expect to get the replaced users, but actual get %v", all)
+	}
+
+	if err = store.Replace(nil); !errors.Is(err, errRemoveAllUsers) {
+		t.Errorf("expect to get error %v, but actual get %v", errRemoveAllUsers, err)
+	}
+	if store.Len() != 1 || store.Anonymous() {
+		t.Errorf("expect to keep the current users, but actual get %v", store.Users())
+	}
+}
+
+func TestUserStore_Anonymous(t *testing.T) {
+	users, err := ParseUsers("user1|password1|rwx")
+	if err != nil {
+		t.Errorf("parse users error => %v", err)
+		return
+	}
+	store := NewUserStore(nil)
+	if !store.Anonymous() {
+		t.Errorf("expect to allow the anonymous access if there is no user at startup")
+	}
+	if err = store.Replace(users); !errors.Is(err, errAddUsersToAnonymous) {
+		t.Errorf("expect to get error %v, but actual get %v", errAddUsersToAnonymous, err)
+	}
+	if err = store.Replace(nil); err != nil {
+		t.Errorf("replace the users error => %v", err)
+	}
+	if store.Len() != 0 || !store.Anonymous() {
+		t.Errorf("expect to keep the anonymous access, but actual get %v", store.Users())
+	}
+	if NewUserStore(users).Anonymous() {
+		t.Errorf("expect to deny the anonymous access if there are users at startup")
+	}
+}
+
+func TestUserStore_VerifySessionUser(t *testing.T) {
+	users, err := ParseUsers("user1|password1|rwx,user2|password2|r")
+	if err != nil {
+		t.Errorf("parse users error => %v", err)
+		return
+	}
+	store := NewUserStore(users)
+	sessionUser := *MapperToSessionUser(users[0])
+
+	changedUsers, err := ParseUsers("user1|new_password1|rwx,user2|password2|r")
+	if err != nil {
+		t.Errorf("parse users error => %v", err)
+		return
+	}
+	removedUsers, err := ParseUsers("user2|password2|r")
+	if err != nil {
+		t.Errorf("parse users error => %v", err)
+		return
+	}
+	permUsers, err := ParseUsers("user1|password1|r,user2|password2|r")
+	if err != nil {
+		t.Errorf("parse users error => %v", err)
+		return
+	}
+
+	testCases := []struct {
+		name   string
+		users  []*User
+		expect bool
+	}{
+		{"the user is not changed", users, true},
+		{"the password is changed", changedUsers, false},
+		{"the user is removed", removedUsers, false},
+		{"the permission is changed", permUsers, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := store.Replace(tc.users); err != nil {
+				t.Errorf("replace the users error => %v", err)
+			}
+			if actual := store.VerifySessionUser(sessionUser); tc.expect != (actual != nil) {
+				t.Errorf("expect to verify the session user [%v], but actual get %v", tc.expect, actual)
+			}
+		})
+	}
+	if user := store.VerifySessionUser(sessionUser); user == nil || user.Perm() != ToPerm("r") {
+		t.Errorf("expect to get the current permission of the session user, but actual get %v", user)
+	}
+}
+
+func TestUserStore_Nil(t *testing.T) {
+	var store *UserStore
+	if store.Len() != 0 || store.First() != nil || store.Users() != nil || !store.Anonymous() {
+		t.Errorf("expect a nil user store has no user")
+	}
+}
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// dummyPasswordHash is verified when the user is not found, so the response time does not reveal whether the user exists
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, _ := HashPassword("gofs", DefaultHashAlgorithm)
+	return hash
+})
+
+// ParseUsersFile parse the users file that is compatible with the htpasswd file, one user per line in the format of
+// username:password[:perm[:totp_secret[:recovery_code_hashes]]], the password should be a bcrypt or argon2id password hash,
+// the recovery code hashes are separated by commas, the empty lines and the lines starting with # are ignored
+func ParseUsersFile(path string) (users []*User, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, ":")
+		if len(fields) < 2 || len(fields) > 5 {
+			return nil, fmt.Errorf("invalid user info => %s line %d", path, n)
+		}
+		perm := ""
+		if len(fields) > 2 {
+			perm = strings.TrimSpace(fields[2])
+		}
+		user, err := NewUser(len(users)+1, strings.TrimSpace(fields[0]), strings.TrimSpace(fields[1]), perm)
+		if err == nil && len(fields) > 3 {
+			var recoveryCodes []string
+			if len(fields) > 4 {
+				recoveryCodes = splitRecoveryCodes(fields[4])
+			}
+			user, err = user.WithTwoFactor(strings.TrimSpace(fields[3]), recoveryCodes)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w => %s line %d", err, path, n)
+		}
+		users = append(users, user)
+	}
+	return users, scanner.Err()
+}
+
+// LoadUsers parse the users string and the users file, the users file is optional
+func LoadUsers(userStr string, usersFile string) (users []*User, err error) {
+	if users, err = ParseUsers(userStr); err != nil {
+		return nil, err
+	}
+	if len(usersFile) == 0 {
+		return users, nil
+	}
+	fileUsers, err := ParseUsersFile(usersFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range fileUsers {
+		user.userId = len(users) + 1
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// VerifyUser find the user with the username and the password, all the comparisons are in constant time,
+// return nil if the username or password is incorrect
+func VerifyUser(users []*User, userName string, password string) (found *User) {
+	matched := false
+	for _, user := range users {
+		if subtle.ConstantTimeCompare([]byte(user.UserName()), []byte(userName)) == 1 {
+			matched = true
+			if user.VerifyPassword(password) && found == nil {
+				found = user
+			}
+		}
+	}
+	if !matched {
+		verifyPassword(dummyPasswordHash(), password)
+	}
+	return found
+}
+
+// RemoveRecoveryCode remove the used recovery code hash of the user from the users file, the file is replaced atomically,
+// return an error if the recovery code hash of the user is not found in the users file
+func RemoveRecoveryCode(path string, userName string, hash string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(string(data), "\n")
+	found := false
+	for i, line := range lines {
+		fields := strings.Split(strings.TrimSpace(line), ":")
+		if len(fields) != 5 || strings.TrimSpace(fields[0]) != userName {
+			continue
+		}
+		var codes []string
+		for _, code := range splitRecoveryCodes(fields[4]) {
+			if code != hash {
+				codes = append(codes, code)
+			} else {
+				found = true
+			}
+		}
+		fields[4] = strings.Join(codes, ",")
+		lines[i] = strings.Join(fields, ":")
+	}
+	if !found {
+		return errRecoveryCodeNotFound
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, []byte(strings.Join(lines, "\n")), stat.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func splitRecoveryCodes(s string) (codes []string) {
+	for _, code := range strings.Split(s, ",") {
+		if code = strings.TrimSpace(code); len(code) > 0 {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
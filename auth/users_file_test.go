@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testBcryptHash = "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy"
+
+func writeUsersFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "users")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("write the users file error => %v", err)
+	}
+	return path
+}
+
+func TestParseUsersFile(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		count   int
+		perm    Perm
+	}{
+		{"empty file", "", 0, ""},
+		{"comments and empty lines", "# gofs users\n\n  \n", 0, ""},
+		{"htpasswd format", "gofs:" + testBcryptHash + "\n", 1, DefaultPerm},
+		{"with perm", "# gofs users\ngofs:" + testBcryptHash + ":rw\nroot:" + testBcryptHash + ":rwx\n", 2, ReadPerm + WritePerm},
+		{"windows line ending", "gofs:" + testBcryptHash + ":rw\r\n", 1, ReadPerm + WritePerm},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			users, err := ParseUsersFile(writeUsersFile(t, tc.content))
+			if err != nil {
+				t.Errorf("ParseUsersFile error => %v", err)
+				return
+			}
+			if len(users) != tc.count {
+				t.Errorf("ParseUsersFile expect to get %d users, but get %d", tc.count, len(users))
+				return
+			}
+			if tc.count > 0 && (users[0].UserName() != "gofs" || users[0].Perm() != tc.perm || users[0].UserId() != 1) {
+				t.Errorf("ParseUsersFile expect to get user gofs with perm %s, but get %s", tc.perm, users[0].String())
+			}
+		})
+	}
+}
+
+func TestParseUsersFile_ReturnError(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+	}{
+		{"missing password", "gofs\n"},
+		{"too many fields", "gofs:" + testBcryptHash + ":rw:x\n"},
+		{"empty password", "gofs:\n"},
+		{"invalid perm", "gofs:" + testBcryptHash + ":abc\n"},
+		{"unsupported hash", "gofs:$apr1$salt$hash\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ParseUsersFile(writeUsersFile(t, tc.content)); err == nil {
+				t.Errorf("ParseUsersFile expect to get an error but get nil")
+			}
+		})
+	}
+
+	if _, err := ParseUsersFile(filepath.Join(t.TempDir(), "not_found")); err == nil {
+		t.Errorf("ParseUsersFile expect to get an error with the file not found but get nil")
+	}
+}
+
+func TestLoadUsers(t *testing.T) {
+	users, err := LoadUsers("root|toor|rwx", writeUsersFile(t, "gofs:"+testBcryptHash+":r\n"))
+	if err != nil {
+		t.Errorf("LoadUsers error => %v", err)
+		return
+	}
+	if len(users) != 2 || users[0].UserName() != "root" || users[1].UserName() != "gofs" || users[1].UserId() != 2 {
+		t.Errorf("LoadUsers expect to get the users of the users string and the users file, but get %v", users)
+	}
+
+	if _, err = LoadUsers("root|toor|rwx", filepath.Join(t.TempDir(), "not_found")); err == nil {
+		t.Errorf("LoadUsers expect to get an error with the users file not found but get nil")
+	}
+}
+
+func TestVerifyUser(t *testing.T) {
+	hash, err := HashPassword("gofs_password", Bcrypt)
+	if err != nil {
+		t.Fatalf("HashPassword error => %v", err)
+	}
+	users := []*User{
+		newUserNoError(t, 1, "root", "toor", FullPerm),
+		newUserNoError(t, 2, "gofs", hash, DefaultPerm),
+		newUserNoError(t, 3, "gofs", "another_password", FullPerm),
+	}
+
+	testCases := []struct {
+		name     string
+		userName string
+		password string
+		expect   int
+	}{
+		{"plain password", "root", "toor", 1},
+		{"hashed password", "gofs", "gofs_password", 2},
+		{"same username", "gofs", "another_password", 3},
+		{"incorrect password", "root", "root", 0},
+		{"user not found", "guest", "toor", 0},
+		{"empty username", "", "", 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			user := VerifyUser(users, tc.userName, tc.password)
+			userId := 0
+			if user != nil {
+				userId = user.UserId()
+			}
+			if userId != tc.expect {
+				t.Errorf("VerifyUser expect to get user %d, but get %d", tc.expect, userId)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/no-src/gofs/conf"
+	"github.com/no-src/nsgo/fsutil"
+)
+
+var (
+	errCheckConf          = errors.New("the config check failed")
+	errInitConfFileExists = errors.New("the config file already exists")
+)
+
+// initConf write an annotated starter config file of the specified mode
+func initConf(c conf.Config) error {
+	data, err := conf.Template(c.InitConf)
+	if err != nil {
+		return err
+	}
+	path := c.Conf
+	if len(path) == 0 {
+		path = conf.DefaultTemplatePath
+	}
+	exist, err := fsutil.FileExist(path)
+	if err != nil {
+		return err
+	}
+	if exist {
+		return fmt.Errorf("%w => [%s]", errInitConfFileExists, path)
+	}
+	if err = os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	innerLogger.Info("write the %s config file success => [%s]", c.InitConf, path)
+	return nil
+}
+
+// checkConf load the config and run all the validation, print all the problems at once
+func checkConf(c conf.Config) error {
+	if err := parseConfigFile(&c); err != nil {
+		return err
+	}
+	var errs []error
+	if err := c.ApplyEnv(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.ResolveSecrets(); err != nil {
+		errs = append(errs, err)
+	}
+	initFileServer(&c)
+	errs = append(errs, c.Check()...)
+	if len(errs) == 0 {
+		innerLogger.Info("the config is valid")
+		return nil
+	}
+	for i, err := range errs {
+		innerLogger.Log("[%d] %v", i+1, err)
+	}
+	return fmt.Errorf("%w, found %d problems", errCheckConf, len(errs))
+}
+
+// printConf print the effective config with the secrets masked
+func printConf(c conf.Config, out func(format string, args ...any)) error {
+	s, err := conf.ToString("."+c.ConfFormat, c.Mask())
+	if err != nil {
+		return err
+	}
+	out("%s", s)
+	return nil
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/no-src/gofs/api/apiclient"
+	"github.com/no-src/gofs/auth"
+	"github.com/no-src/gofs/conf"
+	"github.com/no-src/gofs/core"
+	"github.com/no-src/gofs/driver/minio"
+	"github.com/no-src/gofs/driver/sftp"
+	"github.com/no-src/gofs/encrypt"
+	"github.com/no-src/gofs/internal/rate"
+	"github.com/no-src/gofs/logger"
+	"github.com/no-src/gofs/retry"
+	"github.com/no-src/gofs/server/client"
+	"github.com/no-src/gofs/tlsutil"
+)
+
+var errMinIOUserRequired = errors.New("a user is required to decrypt the files of the MinIO server")
+
+// decrypt decrypt the files of the decrypt path, the decrypt path is a local path or a VFS url of the
+// sftp server, the MinIO server or the remote disk server, the remote files are decrypted without being downloaded first
+func decrypt(c conf.Config, logger *logger.Logger) error {
+	fsys, root, err := newDecryptFS(c, logger)
+	if err != nil {
+		return err
+	}
+	dec, err := encrypt.NewDecryptFS(encrypt.NewOption(c, logger), fsys, root)
+	if err != nil {
+		return err
+	}
+	return dec.Decrypt()
+}
+
+// newDecryptFS create the file system of the decrypt path and returns the root path in the file system,
+// returns a nil file system for the local path
+func newDecryptFS(c conf.Config, logger *logger.Logger) (fsys http.FileSystem, root string, err error) {
+	vfs := core.NewVFS(c.DecryptPath)
+	if vfs.IsDisk() {
+		return nil, "", nil
+	}
+	users, err := auth.ParseUsers(c.Users)
+	if err != nil {
+		return nil, "", err
+	}
+	var user *auth.User
+	if len(users) > 0 {
+		user = users[0]
+	}
+	r := retry.New(c.RetryCount, c.RetryWait.Duration(), c.RetryAsync, logger)
+	maxTranRate := rate.NewLimit(c.MaxTranRate.Bytes())
+	switch {
+	case vfs.Is(core.SFTP):
+		fsys, err = sftp.NewDir(vfs.RemotePath().Base(), vfs.Addr(), vfs.SSHConfig(), r, maxTranRate, logger)
+		return fsys, "/", err
+	case vfs.Is(core.MinIO):
+		if user == nil {
+			return nil, "", errMinIOUserRequired
+		}
+		fsys, err = minio.NewDir(vfs.RemotePath().Bucket(), vfs.Addr(), vfs.Secure(), user.UserName(), user.Password(), r, maxTranRate, logger)
+		return fsys, vfs.RemotePath().Base(), err
+	case vfs.Is(core.RemoteDisk):
+		fsys, err = newRemoteDiskFS(c, vfs, user, logger)
+		return fsys, vfs.RemotePath().Base(), err
+	}
+	if err = vfs.Err(); err == nil {
+		err = errors.New("unsupported decrypt path => " + c.DecryptPath)
+	}
+	return nil, "", err
+}
+
+// newRemoteDiskFS create the file system of the source of the remote disk server,
+// the address of the file server is got from the api server
+func newRemoteDiskFS(c conf.Config, vfs core.VFS, user *auth.User, logger *logger.Logger) (http.FileSystem, error) {
+	pin := tlsutil.Pin{Fingerprint: c.TLSPin, KnownHostsFile: c.TLSKnownHostsFile, Host: vfs.Host()}
+	apiClient := apiclient.New(vfs.Host(), vfs.Port(), c.EnableTLS, c.TLSCertFile, c.TLSClientCertFile, c.TLSClientKeyFile, pin, user)
+	if err := apiClient.Start(); err != nil {
+		return nil, err
+	}
+	defer func() {
+		logger.ErrorIf(apiClient.Stop(), "stop the api client error")
+	}()
+	info, err := apiClient.GetInfo()
+	if err != nil {
+		return nil, err
+	}
+	httpClient, err := tlsutil.NewHttpClient(c.TLSInsecureSkipVerify, c.TLSCertFile, c.TLSClientCertFile, c.TLSClientKeyFile, pin, c.EnableHTTP3)
+	if err != nil {
+		return nil, err
+	}
+	return client.NewDir(info.ServerAddr, httpClient, user, logger), nil
+}
@@ -1,10 +1,13 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"io"
 	"os"
+	"time"
 
+	"github.com/no-src/gofs/age"
+	"github.com/no-src/gofs/audit"
 	"github.com/no-src/gofs/auth"
 	"github.com/no-src/gofs/checksum"
 	"github.com/no-src/gofs/conf"
@@ -14,6 +17,7 @@ import (
 	"github.com/no-src/gofs/fs"
 	"github.com/no-src/gofs/ignore"
 	"github.com/no-src/gofs/internal/about"
+	"github.com/no-src/gofs/internal/rate"
 	"github.com/no-src/gofs/internal/signal"
 	"github.com/no-src/gofs/internal/version"
 	"github.com/no-src/gofs/logger"
@@ -25,7 +29,6 @@ import (
 	"github.com/no-src/gofs/server/httpfs"
 	"github.com/no-src/gofs/sync"
 	"github.com/no-src/gofs/wait"
-	"github.com/no-src/nsgo/fsutil"
 )
 
 // Run running the gofs program
@@ -74,23 +77,26 @@ func runWithConfig(c conf.Config, result result.Result) {
 		result.DoneWithError(err)
 	}()
 
-	cp := &c
-
-	if err = parseConfigFile(cp); err != nil {
+	// write a starter config file or check the config, and exit
+	if len(c.InitConf) > 0 || c.CheckConf {
+		if len(c.InitConf) > 0 {
+			err = initConf(c)
+		} else {
+			err = checkConf(c)
+		}
+		innerLogger.ErrorIf(err, "handle the config error")
 		result.InitDoneWithError(err)
 		return
 	}
 
-	// if current is subprocess, then reset the "-kill_ppid" and "-daemon"
-	if c.IsSubprocess {
-		c.KillPPid = false
-		c.IsDaemon = false
-	}
+	// keep the config parsed from the command-line flags to reload the config file later
+	base := c
+	cp := &c
 
-	switchDebug := false
-	if c.DryRun && c.LogLevel != int(debugLogLevel) {
-		c.LogLevel = int(debugLogLevel)
-		switchDebug = true
+	switchDebug, err := loadConfig(cp)
+	if err != nil {
+		result.InitDoneWithError(err)
+		return
 	}
 
 	// init the default logger
@@ -112,12 +118,20 @@ func runWithConfig(c conf.Config, result result.Result) {
 		return
 	}
 
-	if err = initDefaultValue(cp, logger); err != nil {
+	var randomUsers string
+	if randomUsers, err = initDefaultValue(cp, logger); err != nil {
 		logger.Error(err, "init default value of config error")
 		result.InitDoneWithError(err)
 		return
 	}
 
+	jobs, err := c.ResolveJobs()
+	if err != nil {
+		logger.Error(err, "resolve the jobs error")
+		result.InitDoneWithError(err)
+		return
+	}
+
 	// kill parent process
 	daemon := daemon.New(logger)
 	if c.KillPPid {
@@ -126,20 +140,26 @@ func runWithConfig(c conf.Config, result result.Result) {
 
 	// start the daemon
 	if c.IsDaemon {
-		var args []string
-		args, err = c.ToArgs()
+		var args, env []string
+		args, env, err = c.ToArgs()
 		if err != nil {
 			result.InitDoneWithError(err)
 			return
 		}
+		// pass the config file to the worker for reloading
+		if len(c.Conf) > 0 {
+			args = append(args, "-conf="+c.Conf)
+		}
 
-		ns, ss := signal.Notify(daemon.Shutdown, logger)
+		ns, ss := signal.NotifyWithReload(daemon.Shutdown, func() error {
+			return errors.Join(rotateLogger(logger, c), daemon.Reload())
+		}, logger)
 		go func() {
 			result.RegisterNotifyHandler(ns)
 		}()
 		result.InitDone()
 		w := wait.NewWaitDone()
-		go daemon.Run(args, c.DaemonPid, c.DaemonDelay.Duration(), c.DaemonMonitorDelay.Duration(), w)
+		go daemon.Run(args, env, c.DaemonPid, c.DaemonDelay.Duration(), c.DaemonMonitorDelay.Duration(), w)
 		err = w.Wait()
 		ss()
 		return
@@ -147,9 +167,15 @@ func runWithConfig(c conf.Config, result result.Result) {
 
 	// if enable daemon, start a worker to process the following
 
-	userList, err := auth.ParseUsers(c.Users)
+	userList, err := auth.LoadUsers(c.Users, c.UsersFile)
+	if err != nil {
+		logger.Error(err, "load users error => [%s] [%s]", c.Users, c.UsersFile)
+		result.InitDoneWithError(err)
+		return
+	}
+	acl, err := auth.ParseACL(c.ACL, c.ACLGroups)
 	if err != nil {
-		logger.Error(err, "parse users error => [%s]", c.Users)
+		logger.Error(err, "parse acl error => [%s] [%s]", c.ACL, c.ACLGroups)
 		result.InitDoneWithError(err)
 		return
 	}
@@ -162,23 +188,21 @@ func runWithConfig(c conf.Config, result result.Result) {
 	}
 	defer webLogger.Close()
 
-	// create retry
-	r := retry.New(c.RetryCount, c.RetryWait.Duration(), c.RetryAsync, logger)
-
-	reporter := report.NewReporter()
-	// start a file web server
-	if err = startWebServer(c, webLogger, userList, r, reporter, logger); err != nil {
+	// init the event log
+	eventLogger, err := initEventLogger(c)
+	if err != nil {
 		result.InitDoneWithError(err)
 		return
 	}
+	defer eventLogger.Close()
 
-	// init the event log
-	eventLogger, err := initEventLogger(c)
+	// init the audit log
+	auditLogger, err := initAuditLogger(c)
 	if err != nil {
 		result.InitDoneWithError(err)
 		return
 	}
-	defer eventLogger.Close()
+	defer auditLogger.Close()
 
 	pi, err := ignore.NewPathIgnore(c.IgnoreConf, c.IgnoreDeletedPath, logger)
 	if err != nil {
@@ -187,17 +211,89 @@ func runWithConfig(c conf.Config, result result.Result) {
 		return
 	}
 
+	rl := &reloader{
+		base:        base,
+		current:     c,
+		randomUsers: randomUsers,
+		users:       auth.NewUserStore(userList),
+		retry:       retry.New(c.RetryCount, c.RetryWait.Duration(), c.RetryAsync, logger),
+		tranRate:    rate.NewLimit(c.MaxTranRate.Bytes()),
+		pi:          pi,
+		logger:      logger,
+		webLogger:   webLogger,
+		eventLogger: eventLogger,
+		auditLogger: auditLogger,
+	}
+	rl.users.SetACL(acl)
+	rl.users.SetTwoFactorPerm(auth.ToPerm(c.TOTPRequiredPerm))
+	rl.users.SetUsersFile(c.UsersFile)
+
+	// limit the failed login attempts of the servers
+	if (c.LoginMaxFailures > 0 || c.LoginMaxIPFailures > 0) && (c.EnableFileServer || c.Source.Server()) {
+		var stop func()
+		if stop, err = initLoginGuard(c, rl.users, logger); err != nil {
+			logger.Error(err, "init the login guard error")
+			result.InitDoneWithError(err)
+			return
+		}
+		defer stop()
+	}
+
+	if len(c.UsersFile) > 0 {
+		var stop func()
+		if stop, err = rl.watchUsersFile(c.UsersFile); err != nil {
+			logger.Error(err, "watch the users file error => %s", c.UsersFile)
+			result.InitDoneWithError(err)
+			return
+		}
+		defer stop()
+	}
+
+	reporter := report.NewReporter()
+	var jm *jobManager
+	if len(jobs) > 0 {
+		// if the source is not specified, exit when all the jobs are stopped and the jobs can't be restarted by the manage api
+		runJobsOnly := len(c.Source.Original()) == 0
+		jm = newJobManager(jobs, runJobsOnly && !(c.EnableFileServer && c.EnableManage), rl.users, eventLogger, auditLogger, reporter, logger)
+	}
+
+	// start a file web server
+	if err = startWebServer(c, webLogger, rl, jm, reporter, logger); err != nil {
+		result.InitDoneWithError(err)
+		return
+	}
+
+	if jm != nil {
+		logger.Info("starting %d jobs...", len(jobs))
+		logger.ErrorIf(jm.StartAll(), "start the jobs error")
+		defer jm.StopAll()
+
+		// only run the jobs if the source is not specified
+		if len(c.Source.Original()) == 0 {
+			defer logger.Info("gofs exited")
+			ns, ss := signal.NotifyWithReload(jm.Shutdown, rl.reload, logger)
+			go func() {
+				result.RegisterNotifyHandler(ns)
+			}()
+			result.InitDone()
+			jm.Wait()
+			ss()
+			return
+		}
+	}
+
 	// init the monitor
-	m, err := initMonitor(c, userList, eventLogger, r, pi, reporter, logger)
+	m, err := initMonitor(c, rl.users, rl.eventLogger, rl.auditLogger, rl.retry, rl.tranRate, rl.pi, reporter, logger)
 	if err != nil {
 		result.InitDoneWithError(err)
 		return
 	}
+	rl.setMonitor(m)
 
 	// start monitor
 	logger.Info("monitor is starting...")
 	defer logger.Info("gofs exited")
-	ns, ss := signal.Notify(m.Shutdown, logger)
+	ns, ss := signal.NotifyWithReload(m.Shutdown, rl.reload, logger)
 	go func() {
 		result.RegisterNotifyHandler(ns)
 	}()
@@ -212,6 +308,35 @@ func runWithConfig(c conf.Config, result result.Result) {
 	ss()
 }
 
+// loadConfig parse the config file and reset some config for the current process
+func loadConfig(cp *conf.Config) (switchDebug bool, err error) {
+	if err = parseConfigFile(cp); err != nil {
+		return false, err
+	}
+
+	if err = cp.ApplyEnv(); err != nil {
+		innerLogger.Error(err, "apply the environment variables error")
+		return false, err
+	}
+
+	if err = cp.ResolveSecrets(); err != nil {
+		innerLogger.Error(err, "resolve the secrets error")
+		return false, err
+	}
+
+	// if current is subprocess, then reset the "-kill_ppid" and "-daemon"
+	if cp.IsSubprocess {
+		cp.KillPPid = false
+		cp.IsDaemon = false
+	}
+
+	if cp.DryRun && cp.LogLevel != int(debugLogLevel) {
+		cp.LogLevel = int(debugLogLevel)
+		switchDebug = true
+	}
+	return switchDebug, nil
+}
+
 func parseConfigFile(cp *conf.Config) error {
 	if len(cp.Conf) > 0 {
 		if err := conf.Parse(cp.Conf, cp); err != nil {
@@ -236,19 +361,55 @@ func executeOnce(c conf.Config, logger *logger.Logger) (exit bool, err error) {
 		return true, nil
 	}
 
+	// print the effective config
+	if c.PrintConf {
+		return true, logger.ErrorIf(printConf(c, logger.Log), "print the config error")
+	}
+
+	// generate an identity file for the public-key encryption
+	if len(c.GenIdentity) > 0 {
+		identity, err := age.GenerateIdentityFile(c.GenIdentity)
+		if err != nil {
+			logger.Error(err, "generate the identity file error => %s", c.GenIdentity)
+			return true, err
+		}
+		logger.Log("public key: %s", identity.Recipient())
+		return true, nil
+	}
+
+	// print the password hash
+	if c.HashPassword {
+		return true, logger.ErrorIf(hashPassword(c.HashAlgorithm, os.Stdin, os.Stdout), "hash password error")
+	}
+
+	// enroll a user in the two-factor authentication
+	if len(c.TOTPEnroll) > 0 {
+		return true, logger.ErrorIf(enrollTOTP(c.TOTPEnroll, os.Stdout), "enroll the two-factor authentication error")
+	}
+
+	// verify the audit log
+	if len(c.AuditVerify) > 0 {
+		return true, logger.ErrorIf(verifyAuditLog(c.AuditVerify, c.AuditHMACKey, os.Stdout), "verify the audit log error => %s", c.AuditVerify)
+	}
+
 	// clear the deleted files
 	if c.ClearDeletedPath {
 		return true, logger.ErrorIf(fs.ClearDeletedFile(c.Dest.Path().Base(), logger), "clear the deleted files error")
 	}
 
-	// decrypt the specified file or directory
-	if c.Decrypt {
-		dec, err := encrypt.NewDecrypt(encrypt.NewOption(c, logger))
+	// rewrite the encryption files by the new key
+	if c.ReEncrypt {
+		re, err := encrypt.NewReEncrypt(encrypt.NewOption(c, logger))
 		if err != nil {
-			logger.Error(err, "init decrypt component error")
+			logger.Error(err, "init reencrypt component error")
 			return true, err
 		}
-		return true, logger.ErrorIf(dec.Decrypt(), "decrypt error")
+		return true, logger.ErrorIf(re.ReEncrypt(), "reencrypt error")
+	}
+
+	// decrypt the specified file or directory
+	if c.Decrypt {
+		return true, logger.ErrorIf(decrypt(c, logger), "decrypt error")
 	}
 
 	// calculate checksum
@@ -258,12 +419,36 @@ func executeOnce(c conf.Config, logger *logger.Logger) (exit bool, err error) {
 	return false, nil
 }
 
+// initLoginGuard init the login guard of the users, the login failures are stored in the same storage as the session
+func initLoginGuard(c conf.Config, users *auth.UserStore, logger *logger.Logger) (stop func(), err error) {
+	cache, err := server.NewSessionCache(c.SessionConnection)
+	if err != nil {
+		return nil, err
+	}
+	users.SetLoginGuard(auth.NewLoginGuard(cache, auth.LoginGuardOption{
+		MaxFailures:   c.LoginMaxFailures,
+		MaxIPFailures: c.LoginMaxIPFailures,
+		Lockout:       c.LoginLockout.Duration(),
+		MaxLockout:    c.LoginMaxLockout.Duration(),
+		OnLockout: func(lockout auth.Lockout) {
+			logger.Warn("login locked out, username=%s ip=%s failures=%d until=%s", lockout.UserName, lockout.IP, lockout.Failures, lockout.Until.Format(time.RFC3339))
+		},
+	}))
+	return func() {
+		logger.ErrorIf(cache.Close(), "close the login guard cache error")
+	}, nil
+}
+
 // startWebServer start a file web server
-func startWebServer(c conf.Config, webLogger *logger.Logger, userList []*auth.User, r retry.Retry, reporter report.Reporter, logger *logger.Logger) error {
+func startWebServer(c conf.Config, webLogger *logger.Logger, rl *reloader, jm *jobManager, reporter report.Reporter, logger *logger.Logger) error {
 	if c.EnableFileServer {
+		var jobs server.JobManager
+		if jm != nil {
+			jobs = jm
+		}
 		waitInit := wait.NewWaitDone()
 		go func() {
-			httpfs.StartFileServer(server.NewServerOption(c, waitInit, userList, webLogger, r, reporter))
+			httpfs.StartFileServer(server.NewServerOption(c, waitInit, rl.users, webLogger, rl.auditLogger, rl.retry, rl.tranRate, reporter, rl.Reload, jobs))
 		}()
 		return logger.ErrorIf(waitInit.Wait(), "start the file server [%s] error", c.FileServerAddr)
 	}
@@ -271,16 +456,16 @@ func startWebServer(c conf.Config, webLogger *logger.Logger, userList []*auth.Us
 }
 
 // initMonitor init the monitor
-func initMonitor(c conf.Config, userList []*auth.User, eventWriter io.Writer, r retry.Retry, pi ignore.PathIgnore, reporter report.Reporter, logger *logger.Logger) (monitor.Monitor, error) {
+func initMonitor(c conf.Config, users *auth.UserStore, eventLogger *logger.Logger, auditLogger audit.Logger, r retry.Retry, tranRate *rate.Limit, pi ignore.PathIgnore, reporter report.Reporter, logger *logger.Logger) (monitor.Monitor, error) {
 	// create syncer
-	syncer, err := sync.NewSync(sync.NewSyncOption(c, userList, r, pi, reporter, logger))
+	syncer, err := sync.NewSync(sync.NewSyncOption(c, users, r, tranRate, pi, reporter, auditLogger, logger))
 	if err != nil {
 		logger.Error(err, "create the instance of Sync error")
 		return nil, err
 	}
 
 	// create monitor
-	m, err := monitor.NewMonitor(monitor.NewMonitorOption(c, syncer, r, userList, eventWriter, pi, reporter, logger), RunWithConfigContent)
+	m, err := monitor.NewMonitor(monitor.NewMonitorOption(c, syncer, r, users, eventLogger, pi, reporter, logger), RunWithConfigContent)
 	if err != nil {
 		logger.Error(err, "create the instance of Monitor error")
 		return nil, err
@@ -294,19 +479,23 @@ func initMonitor(c conf.Config, userList []*auth.User, eventWriter io.Writer, r
 	return m, nil
 }
 
-// initDefaultValue init default value of config
-func initDefaultValue(cp *conf.Config, logger *logger.Logger) error {
+// initDefaultValue init default value of config, return the generated random users
+func initDefaultValue(cp *conf.Config, logger *logger.Logger) (randomUsers string, err error) {
 	initFileServer(cp)
 
-	if err := generateRandomUser(cp, logger); err != nil {
-		return err
+	if randomUsers, err = generateRandomUser(cp, logger); err != nil {
+		return randomUsers, err
 	}
 
-	if err := checkTLS(*cp); err != nil {
-		return err
+	if err = initAutoTLS(*cp, logger); err != nil {
+		return randomUsers, err
 	}
 
-	return nil
+	if err = cp.CheckTLS(); err != nil {
+		return randomUsers, err
+	}
+
+	return randomUsers, nil
 }
 
 // initFileServer init config about the file server
@@ -321,44 +510,40 @@ func initFileServer(cp *conf.Config) {
 	}
 }
 
-// generateRandomUser check and generate some random user
-func generateRandomUser(cp *conf.Config, logger *logger.Logger) error {
+// generateRandomUser check and generate some random user, return the generated random users,
+// the plain passwords are printed once and only the password hashes are kept in the config
+func generateRandomUser(cp *conf.Config, logger *logger.Logger) (randUserStr string, err error) {
 	if cp.RandomUserCount > 0 && cp.EnableFileServer {
 		userList, err := auth.RandomUser(cp.RandomUserCount, cp.RandomUserNameLen, cp.RandomPasswordLen, cp.RandomDefaultPerm)
 		if err != nil {
-			return err
+			return randUserStr, err
 		}
-		randUserStr, err := auth.ParseStringUsers(userList)
+		plainUserStr, err := auth.ParseStringUsers(userList)
 		if err != nil {
-			return err
+			return randUserStr, err
 		}
-		if len(cp.Users) > 0 {
-			cp.Users = fmt.Sprintf("%s,%s", cp.Users, randUserStr)
-		} else {
-			cp.Users = randUserStr
+		for i, user := range userList {
+			if userList[i], err = user.WithHashedPassword(cp.HashAlgorithm); err != nil {
+				return randUserStr, err
+			}
+		}
+		randUserStr, err = auth.ParseStringUsers(userList)
+		if err != nil {
+			return randUserStr, err
 		}
-		logger.Info("generate random users success => [%s]", cp.Users)
+		cp.Users = joinUsers(cp.Users, randUserStr)
+		logger.Info("generate random users success => [%s]", plainUserStr)
 	}
-	return nil
+	return randUserStr, nil
 }
 
-// checkTLS check cert and key file of the TLS
-func checkTLS(c conf.Config) error {
-	if c.EnableTLS && (c.Source.Server() || c.EnableFileServer) {
-		exist, err := fsutil.FileExist(c.TLSCertFile)
-		if err != nil {
-			return err
-		}
-		if !exist {
-			return fmt.Errorf("cert file is not found for tls => [%s], for more information, see -tls and -tls_cert_file flags", c.TLSCertFile)
-		}
-		exist, err = fsutil.FileExist(c.TLSKeyFile)
-		if err != nil {
-			return err
-		}
-		if !exist {
-			return fmt.Errorf("key file is not found for tls => [%s], for more information, see -tls and -tls_key_file flags", c.TLSKeyFile)
-		}
+// joinUsers append the extra users to the users
+func joinUsers(users, extra string) string {
+	if len(extra) == 0 {
+		return users
 	}
-	return nil
+	if len(users) > 0 {
+		return fmt.Sprintf("%s,%s", users, extra)
+	}
+	return extra
 }
@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/no-src/gofs/audit"
+	"github.com/no-src/gofs/auth"
+	"github.com/no-src/gofs/conf"
+	"github.com/no-src/gofs/ignore"
+	"github.com/no-src/gofs/internal/rate"
+	"github.com/no-src/gofs/logger"
+	"github.com/no-src/gofs/monitor"
+	"github.com/no-src/gofs/report"
+	"github.com/no-src/gofs/retry"
+	"github.com/no-src/gofs/wait"
+	"github.com/no-src/nsgo/timeutil"
+)
+
+var (
+	errJobNotFound   = errors.New("the job is not found")
+	errJobRunning    = errors.New("the job is running")
+	errJobNotRunning = errors.New("the job is not running")
+)
+
+// syncJob a sync job that running in the current process
+type syncJob struct {
+	conf.JobConfig
+
+	m         monitor.Monitor
+	logger    *logger.Logger
+	startTime timeutil.Time
+	// starting the monitor is being started without holding the lock of the jobManager
+	starting bool
+	// stopping the job is stopped while it is starting, shut it down after it is started
+	stopping bool
+}
+
+// jobManager run the sync jobs in the current process, the jobs share the users, event logger, reporter and logger
+type jobManager struct {
+	mu           sync.Mutex
+	jobs         map[string]*syncJob
+	names        []string
+	running      sync.WaitGroup
+	runningCount int
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+	autoShutdown bool
+	users        *auth.UserStore
+	eventLogger  *logger.Logger
+	auditLogger  audit.Logger
+	reporter     report.Reporter
+	logger       *logger.Logger
+}
+
+// newJobManager create an instance of the jobManager,
+// if autoShutdown is true, shut down the jobManager when all the jobs are stopped
+func newJobManager(jobs []conf.JobConfig, autoShutdown bool, users *auth.UserStore, eventLogger *logger.Logger, auditLogger audit.Logger, reporter report.Reporter, logger *logger.Logger) *jobManager {
+	jm := &jobManager{
+		jobs:         make(map[string]*syncJob, len(jobs)),
+		shutdown:     make(chan struct{}),
+		autoShutdown: autoShutdown,
+		users:        users,
+		eventLogger:  eventLogger,
+		auditLogger:  auditLogger,
+		reporter:     reporter,
+		logger:       logger,
+	}
+	for _, jc := range jobs {
+		jm.jobs[jc.Name] = &syncJob{
+			JobConfig: jc,
+			logger:    logger.WithTag("job:" + jc.Name),
+		}
+		jm.names = append(jm.names, jc.Name)
+	}
+	return jm
+}
+
+// StartAll start all the jobs
+func (jm *jobManager) StartAll() (err error) {
+	for _, name := range jm.names {
+		err = errors.Join(err, jm.Start(name))
+	}
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	if jm.autoShutdown && jm.runningCount == 0 {
+		jm.Shutdown()
+	}
+	return err
+}
+
+// Start start the job, the monitor is started without holding the lock because it may connect to the remote server,
+// so a slow job does not block the other jobs
+func (jm *jobManager) Start(name string) error {
+	jm.mu.Lock()
+	job := jm.jobs[name]
+	if job == nil {
+		jm.mu.Unlock()
+		return fmt.Errorf("%w => %s", errJobNotFound, name)
+	}
+	if job.m != nil || job.starting {
+		jm.mu.Unlock()
+		return fmt.Errorf("%w => %s", errJobRunning, name)
+	}
+	job.starting = true
+	job.stopping = false
+	jm.running.Add(1)
+	jm.mu.Unlock()
+
+	m, w, err := jm.startMonitor(job)
+
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	job.starting = false
+	if err != nil {
+		jm.running.Done()
+		return err
+	}
+	job.m = m
+	job.startTime = timeutil.Now()
+	jm.runningCount++
+	jm.putJob(job, report.JobRunning, nil)
+	if job.stopping {
+		job.logger.ErrorIf(m.Shutdown(), "shutdown the job error")
+	}
+	go jm.wait(job, w)
+	return nil
+}
+
+// startMonitor init and start the monitor of the job
+func (jm *jobManager) startMonitor(job *syncJob) (monitor.Monitor, wait.Wait, error) {
+	c := job.Config
+	pi, err := ignore.NewPathIgnore(c.IgnoreConf, c.IgnoreDeletedPath, job.logger)
+	if err != nil {
+		return nil, nil, jm.failed(job, err, "init ignore config error")
+	}
+	r := retry.New(c.RetryCount, c.RetryWait.Duration(), c.RetryAsync, job.logger)
+	m, err := initMonitor(c, jm.users, jm.eventLogger, jm.auditLogger, r, rate.NewLimit(c.MaxTranRate.Bytes()), pi, report.NewJobReporter(jm.reporter, job.Name), job.logger)
+	if err != nil {
+		return nil, nil, jm.failed(job, err, "init the monitor error")
+	}
+	job.logger.Info("job is starting...")
+	w, err := m.Start()
+	if err != nil {
+		job.logger.ErrorIf(m.Close(), "close the monitor error")
+		return nil, nil, jm.failed(job, err, "start to monitor failed")
+	}
+	return m, w, nil
+}
+
+// wait wait for the job to exit and update the job status
+func (jm *jobManager) wait(job *syncJob, w wait.Wait) {
+	defer jm.running.Done()
+	err := job.logger.ErrorIf(w.Wait(), "job running failed")
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	job.logger.ErrorIf(job.m.Close(), "close the monitor error")
+	job.m = nil
+	jm.runningCount--
+	status := report.JobStopped
+	if err != nil {
+		status = report.JobFailed
+	}
+	jm.putJob(job, status, err)
+	job.logger.Info("job exited")
+	if jm.autoShutdown && jm.runningCount == 0 {
+		jm.Shutdown()
+	}
+}
+
+func (jm *jobManager) Stop(name string) error {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	job := jm.jobs[name]
+	if job == nil {
+		return fmt.Errorf("%w => %s", errJobNotFound, name)
+	}
+	if job.starting {
+		job.stopping = true
+		return nil
+	}
+	if job.m == nil {
+		return fmt.Errorf("%w => %s", errJobNotRunning, name)
+	}
+	return job.m.Shutdown()
+}
+
+// StopAll stop all the running jobs and wait for them to exit
+func (jm *jobManager) StopAll() {
+	jm.mu.Lock()
+	for _, name := range jm.names {
+		if job := jm.jobs[name]; job.starting {
+			job.stopping = true
+		} else if job.m != nil {
+			job.logger.ErrorIf(job.m.Shutdown(), "shutdown the job error")
+		}
+	}
+	jm.mu.Unlock()
+	jm.running.Wait()
+}
+
+// Shutdown notify the jobManager to exit, the caller of Wait will be released
+func (jm *jobManager) Shutdown() error {
+	jm.shutdownOnce.Do(func() {
+		close(jm.shutdown)
+	})
+	return nil
+}
+
+// Wait wait for the shutdown notify
+func (jm *jobManager) Wait() {
+	<-jm.shutdown
+}
+
+func (jm *jobManager) failed(job *syncJob, err error, desc string) error {
+	job.logger.Error(err, desc)
+	jm.putJob(job, report.JobFailed, err)
+	return fmt.Errorf("%s => %s, %w", desc, job.Name, err)
+}
+
+func (jm *jobManager) putJob(job *syncJob, status report.JobStatus, err error) {
+	stat := report.JobStat{
+		Name:      job.Name,
+		Source:    job.Config.Source.Original(),
+		Dest:      job.Config.Dest.Original(),
+		Status:    status,
+		StartTime: job.startTime,
+	}
+	if status != report.JobRunning {
+		stat.StopTime = timeutil.Now()
+	}
+	if err != nil {
+		stat.Error = err.Error()
+	}
+	jm.reporter.PutJob(stat)
+}
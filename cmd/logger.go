@@ -1,6 +1,14 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/no-src/gofs/audit"
 	"github.com/no-src/gofs/conf"
 	"github.com/no-src/gofs/logger"
 	"github.com/no-src/log"
@@ -11,6 +19,8 @@ import (
 
 var (
 	innerLogger = logger.InnerLogger()
+
+	errAuditLogNotFound = errors.New("the audit log file is not found, see the -audit_verify flag")
 )
 
 const (
@@ -58,7 +68,7 @@ func initWebServerLogger(c conf.Config) (*logger.Logger, error) {
 }
 
 // initEventLogger init the event logger
-func initEventLogger(c conf.Config) (log.Logger, error) {
+func initEventLogger(c conf.Config) (*logger.Logger, error) {
 	var eventLogger = log.NewEmptyLogger()
 	if c.EnableEventLog {
 		eventFileLogger, err := log.NewFileLoggerWithOption(option.NewFileLoggerOption(level.Level(c.LogLevel), c.LogDir, "event_", c.LogFlush, c.LogFlushInterval.Duration(), c.LogSplitDate))
@@ -68,5 +78,90 @@ func initEventLogger(c conf.Config) (log.Logger, error) {
 		}
 		eventLogger = eventFileLogger.WithFormatter(formatter.New(c.LogFormat))
 	}
-	return eventLogger, nil
+	return logger.NewLogger(eventLogger, eventLogger), nil
+}
+
+// initAuditLogger init the audit logger, the audit records are written to the log file with the "audit_" prefix
+func initAuditLogger(c conf.Config) (audit.Logger, error) {
+	if !c.EnableAuditLog {
+		return audit.NewEmptyLogger(), nil
+	}
+	var prev string
+	if len(c.AuditHMACKey) > 0 {
+		var err error
+		if prev, err = lastAuditHash(c.LogDir); err != nil {
+			innerLogger.Error(err, "read the last hash of the audit log error")
+			return nil, err
+		}
+	}
+	auditFileLogger, err := initAuditFileLogger(c)
+	if err != nil {
+		return nil, err
+	}
+	auditLogger, err := audit.New(auditFileLogger, []byte(c.AuditHMACKey), prev)
+	if err != nil {
+		innerLogger.Error(err, "write the audit log error")
+		auditFileLogger.Close()
+		return nil, err
+	}
+	return auditLogger, nil
+}
+
+// initAuditFileLogger init the file logger that the audit records are written to as they are
+func initAuditFileLogger(c conf.Config) (log.Logger, error) {
+	auditFileLogger, err := log.NewFileLoggerWithOption(option.NewFileLoggerOption(level.DebugLevel, c.LogDir, "audit_", c.LogFlush, c.LogFlushInterval.Duration(), c.LogSplitDate))
+	if err != nil {
+		innerLogger.Error(err, "init the audit file logger error")
+	}
+	return auditFileLogger, err
+}
+
+// lastAuditHash return the hash of the last record in the latest audit log file of the log directory,
+// the hash chain of the audit log continues from it after restarting
+func lastAuditHash(logDir string) (string, error) {
+	files, err := filepath.Glob(filepath.Join(logDir, "audit_*"))
+	if err != nil {
+		return "", err
+	}
+	// the audit log files are named by date, the latest file is the last one
+	sort.Strings(files)
+	for i := len(files) - 1; i >= 0; i-- {
+		f, err := os.Open(files[i])
+		if err != nil {
+			return "", err
+		}
+		hash, err := audit.LastHash(f)
+		f.Close()
+		if err != nil || len(hash) > 0 {
+			return hash, err
+		}
+	}
+	return "", nil
+}
+
+// verifyAuditLog verify the HMAC chain of the audit log files that match the pattern and print the count of the verified records,
+// the files are verified in order as a whole because the chain continues in the next file when the log file is split by date
+func verifyAuditLog(pattern string, key string, w io.Writer) error {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("%w => %s", errAuditLogNotFound, pattern)
+	}
+	var readers []io.Reader
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		readers = append(readers, f)
+	}
+	count, err := audit.Verify(io.MultiReader(readers...), []byte(key))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%d audit records are verified, files=%d\n", count, len(files))
+	return err
 }
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/no-src/gofs/audit"
+	"github.com/no-src/gofs/auth"
+	"github.com/no-src/gofs/conf"
+	"github.com/no-src/gofs/ignore"
+	"github.com/no-src/gofs/internal/rate"
+	"github.com/no-src/gofs/logger"
+	"github.com/no-src/gofs/monitor"
+	"github.com/no-src/gofs/retry"
+	"github.com/no-src/gofs/server"
+	"github.com/no-src/log"
+)
+
+// reloader re-read the config file and apply the changes that can be applied at runtime,
+// the other changes are reported as restart required
+type reloader struct {
+	mu          sync.Mutex
+	base        conf.Config
+	current     conf.Config
+	randomUsers string
+	users       *auth.UserStore
+	retry       retry.Retry
+	tranRate    *rate.Limit
+	pi          ignore.PathIgnore
+	m           monitor.Monitor
+	logger      *logger.Logger
+	webLogger   *logger.Logger
+	eventLogger *logger.Logger
+	auditLogger audit.Logger
+}
+
+// liveReloadKeys the flag names of the config that can be applied at runtime
+var liveReloadKeys = map[string]bool{
+	"ignore_conf":        true,
+	"ignore_deleted":     true,
+	"max_tran_rate":      true,
+	"sync_delay":         true,
+	"sync_delay_events":  true,
+	"sync_delay_time":    true,
+	"retry_count":        true,
+	"retry_wait":         true,
+	"retry_async":        true,
+	"log_level":          true,
+	"log_file":           true,
+	"log_dir":            true,
+	"log_flush":          true,
+	"log_flush_interval": true,
+	"log_event":          true,
+	"log_sample_rate":    true,
+	"log_format":         true,
+	"log_split_date":     true,
+	"users":              true,
+	"acl":                true,
+	"acl_groups":         true,
+	"totp_required_perm": true,
+}
+
+// setMonitor set the monitor to apply the sync delay settings, the monitor is initialized after the file server
+func (r *reloader) setMonitor(m monitor.Monitor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.m = m
+}
+
+// Reload re-read the config file, rotate the log files and apply the changes that can be applied at runtime
+func (r *reloader) Reload() (result server.ReloadResult, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	nc := r.base
+	if _, err = loadConfig(&nc); err != nil {
+		return result, err
+	}
+	initFileServer(&nc)
+	nc.Users = joinUsers(nc.Users, r.randomUsers)
+
+	// the users file path is not changed at runtime, it is watched since the startup
+	userList, err := auth.LoadUsers(nc.Users, r.current.UsersFile)
+	if err != nil {
+		return result, err
+	}
+	if err = r.users.CheckReplace(userList); err != nil {
+		return result, err
+	}
+	acl, err := auth.ParseACL(nc.ACL, nc.ACLGroups)
+	if err != nil {
+		return result, err
+	}
+
+	// always recreate the loggers to rotate the log files
+	nl, err := initDefaultLogger(nc)
+	if err != nil {
+		return result, err
+	}
+	nwl, err := initWebServerLogger(nc)
+	if err != nil {
+		return result, errors.Join(err, nl.Close())
+	}
+	nel, err := initEventLogger(nc)
+	if err != nil {
+		return result, errors.Join(err, nl.Close(), nwl.Close())
+	}
+	if err = r.pi.Reload(nc.IgnoreConf, nc.IgnoreDeletedPath); err != nil {
+		return result, errors.Join(err, nl.Close(), nwl.Close(), nel.Close())
+	}
+	// the audit log can't be disabled or enabled at runtime
+	var naw log.Logger
+	if r.current.EnableAuditLog {
+		if naw, err = initAuditFileLogger(nc); err != nil {
+			return result, errors.Join(err, nl.Close(), nwl.Close(), nel.Close())
+		}
+	}
+
+	r.logger.ErrorIf(r.logger.Reload(nl), "reload the default logger error")
+	r.logger.ErrorIf(r.webLogger.Reload(nwl), "reload the web server logger error")
+	r.logger.ErrorIf(r.eventLogger.Reload(nel), "reload the event logger error")
+	if naw != nil {
+		r.logger.ErrorIf(r.auditLogger.Reload(naw), "reload the audit logger error")
+	}
+	r.logger.ErrorIf(r.users.Replace(userList), "replace the users error")
+	r.users.SetACL(acl)
+	r.users.SetTwoFactorPerm(auth.ToPerm(nc.TOTPRequiredPerm))
+	r.tranRate.Set(nc.MaxTranRate.Bytes())
+	r.retry.Reset(nc.RetryCount, nc.RetryWait.Duration(), nc.RetryAsync)
+	if r.m != nil {
+		r.m.SetSyncDelay(nc.EnableSyncDelay, nc.SyncDelayEvents, nc.SyncDelayTime.Duration())
+	}
+
+	for _, k := range conf.Diff(r.current, nc) {
+		if liveReloadKeys[k] {
+			result.Applied = append(result.Applied, k)
+		} else {
+			result.RestartRequired = append(result.RestartRequired, k)
+		}
+	}
+	applyLiveConfig(&r.current, nc)
+
+	r.logger.Info("reload the config success, applied => %v", result.Applied)
+	if len(result.RestartRequired) > 0 {
+		r.logger.Warn("some changes require a restart to take effect => %v", result.RestartRequired)
+	}
+	return result, nil
+}
+
+// reload is used to receive the reload signal
+func (r *reloader) reload() error {
+	_, err := r.Reload()
+	return err
+}
+
+// applyLiveConfig copy the config that can be applied at runtime from nc to c
+func applyLiveConfig(c *conf.Config, nc conf.Config) {
+	c.IgnoreConf = nc.IgnoreConf
+	c.IgnoreDeletedPath = nc.IgnoreDeletedPath
+	c.MaxTranRate = nc.MaxTranRate
+	c.EnableSyncDelay = nc.EnableSyncDelay
+	c.SyncDelayEvents = nc.SyncDelayEvents
+	c.SyncDelayTime = nc.SyncDelayTime
+	c.RetryCount = nc.RetryCount
+	c.RetryWait = nc.RetryWait
+	c.RetryAsync = nc.RetryAsync
+	c.LogLevel = nc.LogLevel
+	c.EnableFileLogger = nc.EnableFileLogger
+	c.LogDir = nc.LogDir
+	c.LogFlush = nc.LogFlush
+	c.LogFlushInterval = nc.LogFlushInterval
+	c.EnableEventLog = nc.EnableEventLog
+	c.LogSampleRate = nc.LogSampleRate
+	c.LogFormat = nc.LogFormat
+	c.LogSplitDate = nc.LogSplitDate
+	c.Users = nc.Users
+	c.ACL = nc.ACL
+	c.ACLGroups = nc.ACLGroups
+	c.TOTPRequiredPerm = nc.TOTPRequiredPerm
+}
+
+// rotateLogger recreate the logger with the specified config to rotate the log files
+func rotateLogger(l *logger.Logger, c conf.Config) error {
+	nl, err := initDefaultLogger(c)
+	if err != nil {
+		return err
+	}
+	return l.Reload(nl)
+}
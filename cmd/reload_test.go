@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/no-src/gofs/auth"
+	"github.com/no-src/gofs/conf"
+	"github.com/no-src/gofs/ignore"
+	"github.com/no-src/gofs/internal/rate"
+	"github.com/no-src/gofs/logger"
+	"github.com/no-src/gofs/retry"
+)
+
+func TestReloader_Reload(t *testing.T) {
+	testLogger := logger.NewTestLogger()
+	defer testLogger.Close()
+
+	confFile := filepath.Join(t.TempDir(), "gofs.yaml")
+	writeConf := func(content string) {
+		if err := os.WriteFile(confFile, []byte(content), 0600); err != nil {
+			t.Fatalf("write the config file error => %v", err)
+		}
+	}
+	writeConf("log_level: 0\n")
+
+	r := newTestReloader(t, confFile, testLogger)
+
+	testCases := []struct {
+		name    string
+		conf    string
+		applied []string
+	}{
+		{"change the live config", "log_level: 0\nretry_count: 3\ntotp_required_perm: w\n", []string{"retry_count", "totp_required_perm"}},
+		{"reload the same config", "log_level: 0\nretry_count: 3\ntotp_required_perm: w\n", nil},
+		{"revert the live config", "log_level: 0\n", []string{"retry_count", "totp_required_perm"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			writeConf(tc.conf)
+			result, err := r.Reload()
+			if err != nil {
+				t.Fatalf("Reload error => %v", err)
+			}
+			slices.Sort(result.Applied)
+			if !slices.Equal(result.Applied, tc.applied) {
+				t.Errorf("Reload expect to apply %v, but get %v", tc.applied, result.Applied)
+			}
+			if len(result.RestartRequired) > 0 {
+				t.Errorf("Reload expect no restart required, but get %v", result.RestartRequired)
+			}
+		})
+	}
+}
+
+func TestReloader_Reload_KeepUsers(t *testing.T) {
+	testLogger := logger.NewTestLogger()
+	defer testLogger.Close()
+
+	dir := t.TempDir()
+	usersFile := filepath.Join(dir, "users.txt")
+	writeUsers := func(userNames ...string) {
+		var content string
+		for _, userName := range userNames {
+			hash, err := auth.HashPassword(userName+"_password", auth.DefaultHashAlgorithm)
+			if err != nil {
+				t.Fatalf("hash the password error => %v", err)
+			}
+			content += userName + ":" + hash + ":rw\n"
+		}
+		if err := os.WriteFile(usersFile, []byte(content), 0600); err != nil {
+			t.Fatalf("write the users file error => %v", err)
+		}
+	}
+	writeUsers("alice")
+	confFile := filepath.Join(dir, "gofs.yaml")
+	if err := os.WriteFile(confFile, []byte("log_level: 0\nusers_file: "+usersFile+"\n"), 0600); err != nil {
+		t.Fatalf("write the config file error => %v", err)
+	}
+	r := newTestReloader(t, confFile, testLogger)
+
+	expectUser := func(expect string) {
+		t.Helper()
+		if users := r.users.Users(); len(users) != 1 || users[0].UserName() != expect {
+			t.Errorf("expect to get the user %s, but get %v", expect, users)
+		}
+	}
+	expectUser("alice")
+
+	// the users file is emptied, like a half-finished save of the editor
+	writeUsers()
+	r.reloadUsers()
+	expectUser("alice")
+	if _, err := r.Reload(); err == nil {
+		t.Errorf("Reload expect to get an error if all the users are removed")
+	}
+	expectUser("alice")
+
+	writeUsers("bob")
+	r.reloadUsers()
+	expectUser("bob")
+	if _, err := r.Reload(); err != nil {
+		t.Errorf("Reload error => %v", err)
+	}
+	expectUser("bob")
+}
+
+func newTestReloader(t *testing.T, confFile string, testLogger *logger.Logger) *reloader {
+	base := conf.Config{Conf: confFile}
+	c := base
+	if _, err := loadConfig(&c); err != nil {
+		t.Fatalf("load the config error => %v", err)
+	}
+	userList, err := auth.LoadUsers(c.Users, c.UsersFile)
+	if err != nil {
+		t.Fatalf("load the users error => %v", err)
+	}
+	pi, err := ignore.NewPathIgnore("", false, testLogger)
+	if err != nil {
+		t.Fatalf("init the ignore component error => %v", err)
+	}
+	return &reloader{
+		base:        base,
+		current:     c,
+		users:       auth.NewUserStore(userList),
+		retry:       retry.New(c.RetryCount, c.RetryWait.Duration(), c.RetryAsync, testLogger),
+		tranRate:    rate.NewLimit(0),
+		pi:          pi,
+		logger:      logger.NewTestLogger(),
+		webLogger:   logger.NewTestLogger(),
+		eventLogger: logger.NewTestLogger(),
+	}
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"net"
+	"os"
+
+	"github.com/no-src/gofs/conf"
+	"github.com/no-src/gofs/logger"
+	"github.com/no-src/gofs/tlsutil"
+)
+
+// initAutoTLS generate the local CA and the server certificate if the tls_auto is enabled for the server,
+// and print the fingerprint that the clients can pin
+func initAutoTLS(c conf.Config, logger *logger.Logger) error {
+	if !c.TLSAuto || !c.EnableTLS || !(c.Source.Server() || c.EnableFileServer) {
+		return nil
+	}
+	fingerprint, issued, err := tlsutil.AutoCert(c.TLSCertFile, c.TLSKeyFile, autoTLSHosts(c))
+	if err != nil {
+		return err
+	}
+	if issued {
+		logger.Info("the server certificate is issued by the local CA => %s", c.TLSCertFile)
+	}
+	logger.Info("the SHA-256 fingerprint of the server certificate chain, pin it by the -tls_pin flag on the client => %s", fingerprint)
+	return nil
+}
+
+// autoTLSHosts return the hosts that the server certificate is issued for, contain the listen hosts,
+// the local host name and all the ips of the local network interfaces
+func autoTLSHosts(c conf.Config) []string {
+	var hosts []string
+	exist := make(map[string]bool)
+	add := func(host string) {
+		if len(host) > 0 && !exist[host] {
+			exist[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+	if c.Source.Server() {
+		add(c.Source.Host())
+	}
+	if host, _, err := net.SplitHostPort(c.FileServerAddr); err == nil {
+		add(host)
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		add(hostname)
+	}
+	add("localhost")
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok {
+				add(ipNet.IP.String())
+			}
+		}
+	}
+	return hosts
+}
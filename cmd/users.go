@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/no-src/gofs/auth"
+	"golang.org/x/term"
+)
+
+// usersFileReloadDelay wait for the editors to finish writing the users file before reloading it
+const usersFileReloadDelay = time.Second
+
+var errEmptyPassword = errors.New("the password can't be empty")
+
+// hashPassword read a password from the reader and write the password hash to the writer,
+// the password is read without echo if the reader is a terminal
+func hashPassword(algorithm string, r io.Reader, w io.Writer) (err error) {
+	var password string
+	if f, ok := r.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		fmt.Fprint(os.Stderr, "Password: ")
+		data, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return err
+		}
+		password = string(data)
+	} else {
+		password, err = bufio.NewReader(r).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		password = strings.TrimRight(password, "\r\n")
+	}
+	if len(password) == 0 {
+		return errEmptyPassword
+	}
+	hash, err := auth.HashPassword(password, algorithm)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, hash)
+	return err
+}
+
+// enrollTOTP generate a TOTP secret and the recovery codes for the user, print the otpauth url, the recovery codes
+// and the fields that are appended to the line of the user in the users file
+func enrollTOTP(userName string, w io.Writer) error {
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return err
+	}
+	codes, hashes, err := auth.GenerateRecoveryCodes(auth.RecoveryCodeCount)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "secret: %s\n", secret)
+	fmt.Fprintf(w, "url: %s\n", auth.TOTPURL("gofs", userName, secret))
+	fmt.Fprintln(w, "recovery codes (every code can be used once, keep them in a safe place):")
+	for _, code := range codes {
+		fmt.Fprintf(w, "  %s\n", code)
+	}
+	fmt.Fprintln(w, "append the fields to the line of the user in the users file, like username:password_hash:perm:fields")
+	_, err = fmt.Fprintf(w, "%s:%s\n", secret, strings.Join(hashes, ","))
+	return err
+}
+
+// watchUsersFile watch the users file and reload the users when it is changed, the parent directory is watched
+// to handle the editors that replace the file by renaming, returns a function to stop watching
+func (r *reloader) watchUsersFile(usersFile string) (stop func(), err error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err = watcher.Add(filepath.Dir(usersFile)); err != nil {
+		return nil, errors.Join(err, watcher.Close())
+	}
+	name := filepath.Clean(usersFile)
+	go func() {
+		var timer *time.Timer
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != name || event.Has(fsnotify.Chmod) {
+					continue
+				}
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(usersFileReloadDelay, r.reloadUsers)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				r.logger.Error(err, "watch the users file error => %s", usersFile)
+			}
+		}
+	}()
+	return func() {
+		r.logger.ErrorIf(watcher.Close(), "close the users file watcher error")
+	}, nil
+}
+
+// reloadUsers reload the users from the current users and users file, keep the old users if the users file is invalid
+// or the reloaded users are empty, like the users file is truncated by a half-finished save
+func (r *reloader) reloadUsers() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	userList, err := auth.LoadUsers(r.current.Users, r.current.UsersFile)
+	if err == nil {
+		err = r.users.Replace(userList)
+	}
+	if err != nil {
+		r.logger.Error(err, "reload the users file error, keep the current users => %s", r.current.UsersFile)
+		return
+	}
+	r.logger.Info("reload the users file success => %s", r.current.UsersFile)
+}
@@ -0,0 +1,475 @@
+package conf
+
+import (
+	"crypto/aes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/no-src/gofs/age"
+	"github.com/no-src/gofs/auth"
+	"github.com/no-src/gofs/core"
+	"github.com/no-src/gofs/ignore"
+	"github.com/no-src/gofs/internal/secret"
+	"github.com/no-src/gofs/ipfilter"
+	"github.com/no-src/gofs/keyring"
+	"github.com/no-src/gofs/logger"
+	"github.com/no-src/gofs/quota"
+	"github.com/no-src/gofs/tlsutil"
+	"github.com/no-src/log/formatter"
+	"github.com/no-src/nsgo/fsutil"
+	"github.com/no-src/nsgo/hashutil"
+	"github.com/robfig/cron/v3"
+)
+
+var (
+	errSourceRequired             = errors.New("the source is required, see the -source flag")
+	errFileSystemUnsupported      = errors.New("the source and dest pair is unsupported")
+	errInvalidChunkSize           = errors.New("the chunk size must greater than zero, see the -chunk_size flag")
+	errInvalidLogSampleRate       = errors.New("the log sample rate must range from 0 to 1, see the -log_sample_rate flag")
+	errInvalidLogFormat           = errors.New("the log format is unsupported, see the -log_format flag")
+	errEncryptPathRequired        = errors.New("the encrypt path is required, see the -encrypt_path flag")
+	errDecryptPathRequired        = errors.New("the decrypt path is required, see the -decrypt_path flag")
+	errEmptyPassphrase            = errors.New("the passphrase can't be empty when the keyfile is specified")
+	errKeyFileNotFound            = errors.New("the keyfile is not found, see the -keyfile flag")
+	errIdentityNotFound           = errors.New("the identity file is not found, see the -decrypt_identity flag")
+	errNameWithRecipients         = errors.New("the -encrypt_name flag requires the -encrypt_secret flag, it is unsupported with the recipients")
+	errManageWithoutServer        = errors.New("the -manage flag requires the -server flag")
+	errReportWithoutManage        = errors.New("the -report flag requires the -manage flag")
+	errPushServerWithoutServer    = errors.New("the -push_server flag requires the -server flag")
+	errHTTP3WithoutTLS            = errors.New("the -http3 flag requires the -tls flag")
+	errSyncCronWithTaskClient     = errors.New("the usage of the -sync_cron flag is incompatible with enabling the -task_client flag")
+	errTaskClientSource           = errors.New("the -task_client flag requires a remote disk client source")
+	errReEncryptRemotePath        = errors.New("the re-encryption only supports the local decrypt path")
+	errRotateKeyRequired          = errors.New("the key rotation requires the re-encryption with a keyfile, see the -reencrypt and -keyfile flags")
+	errServerDecryptWithoutServer = errors.New("the -server_decrypt flag requires the -server flag")
+	errServerDecryptWithoutUsers  = errors.New("the -server_decrypt flag requires some server users, see the -users, -users_file or -rand_user_count flag")
+	errUsersFileNotFound          = errors.New("the users file is not found, see the -users_file flag")
+	errInvalidHashAlgorithm       = errors.New("the password hash algorithm is unsupported, see the -hash_algorithm flag")
+	errACLWithoutUsers            = errors.New("the -acl flag requires some server users, see the -users, -users_file or -rand_user_count flag")
+	errInvalidTOTPRequiredPerm    = errors.New("the permission of the two-factor policy must be the composition of 'r' 'w' 'x', see the -totp_required_perm flag")
+	errAPIKeysWithoutUsers        = errors.New("the -api_keys_file flag requires some server users, see the -users, -users_file or -rand_user_count flag")
+	errPushUserRootWithoutUsers   = errors.New("the -push_user_root flag requires some server users, see the -users, -users_file or -rand_user_count flag")
+	errPushUserRootWithoutPush    = errors.New("the -push_user_root flag requires the -push_server flag")
+	errPushUserQuotaWithoutRoot   = errors.New("the -push_user_quota and -push_user_quota_files flags require the -push_user_root flag")
+	errPushQuotaWithoutPush       = errors.New("the -push_quota, -push_quota_files and -push_min_free_space flags require the -push_server flag")
+	errInvalidPushQuota           = errors.New("the file count quota of the push server can't be negative, see the -push_user_quota_files and -push_quota_files flags")
+	errFreeSpaceUnsupported       = errors.New("the -push_min_free_space flag is unsupported on the current platform")
+	errClientCAWithoutTLS         = errors.New("the -tls_client_ca_file flag requires the -tls flag")
+	errClientCAFileNotFound       = errors.New("the client CA bundle file is not found, see the -tls_client_ca_file flag")
+	errClientCertWithoutTLS       = errors.New("the -tls_client_cert_file and -tls_client_key_file flags require the -tls flag")
+	errClientCertPairRequired     = errors.New("the -tls_client_cert_file and -tls_client_key_file flags must be specified together")
+	errClientCertFileNotFound     = errors.New("the client certificate file is not found, see the -tls_client_cert_file and -tls_client_key_file flags")
+	errTLSAutoWithoutTLS          = errors.New("the -tls_auto flag requires the -tls flag")
+	errTLSPinWithoutTLS           = errors.New("the -tls_pin and -tls_known_hosts_file flags require the -tls flag")
+	errInvalidTLSPin              = errors.New("the pinned fingerprint must be the SHA-256 of the certificate in hex, see the -tls_pin flag")
+	errInvalidTokenAlgorithm      = errors.New("the token algorithm is unsupported, current only supports HS256 and EdDSA, see the -token_algorithm flag")
+	errTokenKeyFileNotFound       = errors.New("the EdDSA token algorithm requires an existing Ed25519 private key file, see the -token_key_file flag")
+	errInvalidTokenExpires        = errors.New("the token expires can't be negative, see the -token_expires flag")
+	errInvalidLoginMaxFailures    = errors.New("the login max failures can't be negative, see the -login_max_failures and -login_max_ip_failures flags")
+	errInvalidSessionTimeout      = errors.New("the session timeouts can't be negative, see the -session_idle_timeout and -session_absolute_timeout flags")
+	errInvalidLoginLockout        = errors.New("the login lockout durations can't be negative, see the -login_lockout and -login_max_lockout flags")
+	errInvalidIPRules             = errors.New("the ip rules must be the ips or CIDRs separated by comma")
+	errInvalidIPLimit             = errors.New("the ip limits can't be negative, see the -ip_max_conns, -ip_max_rate and -ip_rate_burst flags")
+	errAuditKeyWithoutAuditLog    = errors.New("the -audit_hmac_key flag requires the -audit_log or -audit_verify flag")
+	errAuditVerifyWithoutKey      = errors.New("the -audit_verify flag requires the -audit_hmac_key flag")
+)
+
+// Check validate the config and return all the problems, the jobs are validated one by one
+func (c Config) Check() (errs []error) {
+	errs = c.check()
+	jobs, err := c.ResolveJobs()
+	if err != nil {
+		return append(errs, err)
+	}
+	for _, job := range jobs {
+		for _, err = range job.Config.check() {
+			errs = append(errs, fmt.Errorf("%w => job %s", err, job.Name))
+		}
+	}
+	return errs
+}
+
+func (c Config) check() (errs []error) {
+	add := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// re-encryption and decryption
+	if c.RotateKey && (!c.ReEncrypt || len(c.KeyFile) == 0) {
+		add(errRotateKeyRequired)
+	}
+	if c.ReEncrypt || c.Decrypt {
+		if len(c.DecryptPath) == 0 {
+			add(errDecryptPathRequired)
+		} else if decryptPath := core.NewVFS(c.DecryptPath); decryptPath.Err() != nil {
+			add(checkVFS("decrypt_path", decryptPath))
+		} else if c.ReEncrypt && !decryptPath.IsDisk() {
+			add(errReEncryptRemotePath)
+		}
+		if c.ReEncrypt || len(c.DecryptIdentity) == 0 || len(c.DecryptSecret) > 0 {
+			add(c.checkSecret("decrypt_secret", c.DecryptSecret))
+		}
+		if c.Decrypt && len(c.DecryptIdentity) > 0 {
+			add(checkFileExist(c.DecryptIdentity, errIdentityNotFound))
+		}
+		if c.ReEncrypt {
+			add(c.checkSecret("encrypt_secret", c.EncryptSecret))
+			add(c.checkKDF())
+		} else if len(c.KeyFile) > 0 {
+			// the keyfile is created by the re-encryption if it does not exist
+			add(checkFileExist(c.KeyFile, errKeyFileNotFound))
+		}
+		return errs
+	}
+	add(checkVFS("source", c.Source))
+	add(checkVFS("dest", c.Dest))
+	if len(c.Source.Original()) == 0 && len(c.Jobs) == 0 {
+		add(errSourceRequired)
+	} else if len(c.Source.Original()) > 0 && c.Source.Err() == nil && c.Dest.Err() == nil && !c.Checksum && !c.ClearDeletedPath {
+		add(checkFileSystem(c.Source, c.Dest))
+	}
+	if len(c.IgnoreConf) > 0 {
+		if _, err := ignore.NewPathIgnore(c.IgnoreConf, c.IgnoreDeletedPath, logger.NewEmptyLogger()); err != nil {
+			add(fmt.Errorf("invalid ignore config => [%s], %w", c.IgnoreConf, err))
+		}
+	}
+	if c.ChunkSize.Bytes() <= 0 {
+		add(errInvalidChunkSize)
+	}
+	if _, err := hashutil.NewHash(c.ChecksumAlgorithm); err != nil {
+		add(fmt.Errorf("%w => checksum_algorithm", err))
+	}
+	if len(strings.TrimSpace(c.SyncCron)) > 0 {
+		add(checkCron(c.SyncCron))
+	}
+
+	// log
+	if c.LogSampleRate < 0 || c.LogSampleRate > 1 {
+		add(errInvalidLogSampleRate)
+	}
+	if c.LogFormat != formatter.TextFormatter && c.LogFormat != formatter.JsonFormatter {
+		add(fmt.Errorf("%w => %s", errInvalidLogFormat, c.LogFormat))
+	}
+
+	// file server
+	if c.EnableManage && !c.EnableFileServer {
+		add(errManageWithoutServer)
+	}
+	if c.EnableReport && !c.EnableManage {
+		add(errReportWithoutManage)
+	}
+	if c.EnablePushServer && !c.EnableFileServer && !c.Source.Server() {
+		add(errPushServerWithoutServer)
+	}
+	if c.PushUserRoot {
+		if !c.EnablePushServer {
+			add(errPushUserRootWithoutPush)
+		}
+		if len(c.Users) == 0 && len(c.UsersFile) == 0 && c.RandomUserCount <= 0 {
+			add(errPushUserRootWithoutUsers)
+		} else if users, err := auth.LoadUsers(c.Users, c.UsersFile); err == nil {
+			add(auth.CheckUserRoot(users))
+		}
+	}
+	if (c.PushUserQuota.Bytes() > 0 || c.PushUserQuotaFiles > 0) && !c.PushUserRoot {
+		add(errPushUserQuotaWithoutRoot)
+	}
+	if (c.PushQuota.Bytes() > 0 || c.PushQuotaFiles > 0 || c.PushMinFreeSpace.Bytes() > 0) && !c.EnablePushServer {
+		add(errPushQuotaWithoutPush)
+	}
+	if c.PushUserQuotaFiles < 0 || c.PushQuotaFiles < 0 {
+		add(errInvalidPushQuota)
+	}
+	if c.PushMinFreeSpace.Bytes() > 0 {
+		if _, err := quota.FreeSpace(os.TempDir()); errors.Is(err, errors.ErrUnsupported) {
+			add(errFreeSpaceUnsupported)
+		}
+	}
+	if c.EnableServerDecrypt {
+		if !c.EnableFileServer {
+			add(errServerDecryptWithoutServer)
+		}
+		if len(c.Users) == 0 && len(c.UsersFile) == 0 && c.RandomUserCount <= 0 {
+			add(errServerDecryptWithoutUsers)
+		}
+	}
+	if c.EnableHTTP3 && !c.EnableTLS {
+		add(errHTTP3WithoutTLS)
+	}
+	add(c.CheckTLS())
+	add(c.checkMutualTLS())
+	add(c.checkTLSPin())
+
+	// login user
+	// the unresolved secret reference is reported by ResolveSecrets
+	if _, err := auth.ParseUsers(c.Users); err != nil && !secret.IsRef(c.Users) {
+		add(fmt.Errorf("%w => users", err))
+	}
+	if len(c.UsersFile) > 0 {
+		if err := checkFileExist(c.UsersFile, errUsersFileNotFound); err != nil {
+			add(err)
+		} else if _, err = auth.ParseUsersFile(c.UsersFile); err != nil {
+			add(fmt.Errorf("%w => users_file", err))
+		}
+	}
+	if _, err := auth.ParseACL(c.ACL, c.ACLGroups); err != nil {
+		add(fmt.Errorf("%w => acl", err))
+	} else if len(c.ACL) > 0 && len(c.Users) == 0 && len(c.UsersFile) == 0 && c.RandomUserCount <= 0 {
+		add(errACLWithoutUsers)
+	}
+	if len(c.TOTPRequiredPerm) > 0 && !auth.ToPerm(c.TOTPRequiredPerm).IsValid() {
+		add(fmt.Errorf("%w => %s", errInvalidTOTPRequiredPerm, c.TOTPRequiredPerm))
+	}
+	if len(c.APIKeysFile) > 0 {
+		if len(c.Users) == 0 && len(c.UsersFile) == 0 && c.RandomUserCount <= 0 {
+			add(errAPIKeysWithoutUsers)
+		} else if _, err := auth.NewAPIKeyStore(c.APIKeysFile); err != nil {
+			add(err)
+		}
+	}
+	if c.Source.Server() {
+		add(c.checkToken())
+	}
+	if c.LoginMaxFailures < 0 || c.LoginMaxIPFailures < 0 {
+		add(errInvalidLoginMaxFailures)
+	}
+	if c.LoginLockout.Duration() < 0 || c.LoginMaxLockout.Duration() < 0 {
+		add(errInvalidLoginLockout)
+	}
+	if c.SessionIdleTimeout.Duration() < 0 || c.SessionAbsoluteTimeout.Duration() < 0 {
+		add(errInvalidSessionTimeout)
+	}
+	ipRules := []struct {
+		key   string
+		rules string
+	}{
+		{"trusted_proxies", c.TrustedProxies},
+		{"server_allow_ip", c.ServerAllowIP},
+		{"server_deny_ip", c.ServerDenyIP},
+		{"push_allow_ip", c.PushAllowIP},
+		{"push_deny_ip", c.PushDenyIP},
+		{"grpc_allow_ip", c.GRPCAllowIP},
+		{"grpc_deny_ip", c.GRPCDenyIP},
+	}
+	for _, r := range ipRules {
+		if _, err := ipfilter.ParseRules(r.rules); err != nil {
+			add(fmt.Errorf("%w => %s, %v", errInvalidIPRules, r.key, err))
+		}
+	}
+	if c.IPMaxConns < 0 || c.IPMaxRate < 0 || c.IPRateBurst < 0 {
+		add(errInvalidIPLimit)
+	}
+	if len(c.AuditHMACKey) > 0 && !c.EnableAuditLog && len(c.AuditVerify) == 0 {
+		add(errAuditKeyWithoutAuditLog)
+	}
+	if len(c.AuditVerify) > 0 && len(c.AuditHMACKey) == 0 {
+		add(errAuditVerifyWithoutKey)
+	}
+	if c.HashPassword || c.RandomUserCount > 0 {
+		if auth.CheckHashAlgorithm(c.HashAlgorithm) != nil {
+			add(fmt.Errorf("%w => %s", errInvalidHashAlgorithm, c.HashAlgorithm))
+		}
+	}
+
+	// encrypt
+	if c.Encrypt {
+		if len(c.EncryptPath) == 0 {
+			add(errEncryptPathRequired)
+		}
+		if c.hasRecipients() {
+			add(c.checkRecipients())
+		} else {
+			add(c.checkSecret("encrypt_secret", c.EncryptSecret))
+		}
+	}
+	if c.Encrypt || (c.Source.Server() && len(c.KeyFile) > 0) {
+		add(c.checkKDF())
+	}
+
+	// task
+	if c.EnableTaskClient {
+		if len(strings.TrimSpace(c.SyncCron)) > 0 {
+			add(errSyncCronWithTaskClient)
+		}
+		if !c.Source.Is(core.RemoteDisk) || c.Source.Server() {
+			add(errTaskClientSource)
+		}
+	}
+	return errs
+}
+
+// checkMutualTLS check the client CA bundle of the server and the client certificate of the client for the mutual TLS
+func (c Config) checkMutualTLS() error {
+	if len(c.TLSClientCAFile) > 0 {
+		if !c.EnableTLS {
+			return errClientCAWithoutTLS
+		}
+		if err := checkFileExist(c.TLSClientCAFile, errClientCAFileNotFound); err != nil {
+			return err
+		}
+	}
+	if len(c.TLSClientCertFile) == 0 && len(c.TLSClientKeyFile) == 0 {
+		return nil
+	}
+	if !c.EnableTLS {
+		return errClientCertWithoutTLS
+	}
+	if len(c.TLSClientCertFile) == 0 || len(c.TLSClientKeyFile) == 0 {
+		return errClientCertPairRequired
+	}
+	if err := checkFileExist(c.TLSClientCertFile, errClientCertFileNotFound); err != nil {
+		return err
+	}
+	return checkFileExist(c.TLSClientKeyFile, errClientCertFileNotFound)
+}
+
+// checkToken check the signing algorithm, key file and lifetime of the grpc api token
+func (c Config) checkToken() error {
+	switch c.TokenAlgorithm {
+	case "", "HS256":
+	case "EdDSA":
+		if err := checkFileExist(c.TokenKeyFile, errTokenKeyFileNotFound); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w => %s", errInvalidTokenAlgorithm, c.TokenAlgorithm)
+	}
+	if c.TokenExpires.Duration() < 0 {
+		return errInvalidTokenExpires
+	}
+	return nil
+}
+
+// CheckTLS check the cert file and key file exist if the tls is enabled for the server, they are generated on start if the tls_auto is enabled
+func (c Config) CheckTLS() error {
+	if c.TLSAuto && !c.EnableTLS {
+		return errTLSAutoWithoutTLS
+	}
+	if c.EnableTLS && !c.TLSAuto && (c.Source.Server() || c.EnableFileServer) {
+		exist, err := fsutil.FileExist(c.TLSCertFile)
+		if err != nil {
+			return err
+		}
+		if !exist {
+			return fmt.Errorf("cert file is not found for tls => [%s], for more information, see -tls and -tls_cert_file flags", c.TLSCertFile)
+		}
+		exist, err = fsutil.FileExist(c.TLSKeyFile)
+		if err != nil {
+			return err
+		}
+		if !exist {
+			return fmt.Errorf("key file is not found for tls => [%s], for more information, see -tls and -tls_key_file flags", c.TLSKeyFile)
+		}
+	}
+	return nil
+}
+
+// checkTLSPin check the pinned fingerprint of the server certificate for the client
+func (c Config) checkTLSPin() error {
+	if len(c.TLSPin) == 0 && len(c.TLSKnownHostsFile) == 0 {
+		return nil
+	}
+	if !c.EnableTLS {
+		return errTLSPinWithoutTLS
+	}
+	if len(c.TLSPin) > 0 {
+		if _, err := tlsutil.ParseFingerprint(c.TLSPin); err != nil {
+			return fmt.Errorf("%w => %s", errInvalidTLSPin, c.TLSPin)
+		}
+	}
+	return nil
+}
+
+func checkVFS(key string, vfs core.VFS) error {
+	if err := vfs.Err(); err != nil {
+		return fmt.Errorf("invalid %s => [%s], %w", key, vfs.Original(), err)
+	}
+	return nil
+}
+
+// checkFileSystem check the source and dest pair is supported, keep it in sync with the sync.NewSync
+func checkFileSystem(source, dest core.VFS) error {
+	if (source.IsDisk() && dest.IsDisk()) ||
+		source.Is(core.RemoteDisk) ||
+		dest.Is(core.RemoteDisk) ||
+		(source.IsDisk() && (dest.Is(core.SFTP) || dest.Is(core.MinIO))) ||
+		((source.Is(core.SFTP) || source.Is(core.MinIO)) && dest.IsDisk()) {
+		return nil
+	}
+	return fmt.Errorf("%w source=>%s dest=>%s", errFileSystemUnsupported, source.Type().String(), dest.Type().String())
+}
+
+func checkCron(spec string) error {
+	parser := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+	if _, err := parser.Parse(strings.TrimSpace(spec)); err != nil {
+		return fmt.Errorf("invalid sync cron => [%s], %w", spec, err)
+	}
+	return nil
+}
+
+// checkSecret check the secret is a passphrase if the keyfile is specified, otherwise check it is a valid AES key
+func (c Config) checkSecret(key string, secret string) error {
+	if len(c.KeyFile) == 0 {
+		return checkAESSecret(key, secret)
+	}
+	if len(secret) == 0 {
+		return fmt.Errorf("%w => %s", errEmptyPassphrase, key)
+	}
+	return nil
+}
+
+// checkKDF check the key derivation function that is used to create the new key if the keyfile is specified
+func (c Config) checkKDF() error {
+	if len(c.KeyFile) == 0 {
+		return nil
+	}
+	if err := keyring.CheckKDF(c.KDF); err != nil {
+		return fmt.Errorf("%w, see the -kdf flag", err)
+	}
+	return nil
+}
+
+func (c Config) hasRecipients() bool {
+	return len(c.EncryptRecipients) > 0 || len(c.EncryptRecipientsFile) > 0
+}
+
+// checkRecipients check the recipients of the public-key encryption are valid
+func (c Config) checkRecipients() error {
+	if c.EncryptName {
+		return errNameWithRecipients
+	}
+	if len(c.EncryptRecipients) > 0 {
+		if _, err := age.ParseRecipients(c.EncryptRecipients); err != nil {
+			return fmt.Errorf("%w, see the -encrypt_recipients flag", err)
+		}
+	}
+	if len(c.EncryptRecipientsFile) > 0 {
+		if _, err := age.LoadRecipients(c.EncryptRecipientsFile); err != nil {
+			return fmt.Errorf("%w, see the -encrypt_recipients_file flag", err)
+		}
+	}
+	return nil
+}
+
+func checkFileExist(path string, notFound error) error {
+	exist, err := fsutil.FileExist(path)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		return fmt.Errorf("%w => [%s]", notFound, path)
+	}
+	return nil
+}
+
+func checkAESSecret(key string, secret string) error {
+	length := len(secret)
+	if length == 16 || length == 24 || length == 32 {
+		return nil
+	}
+	return fmt.Errorf("%w => %s", aes.KeySizeError(length), key)
+}
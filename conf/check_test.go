@@ -0,0 +1,413 @@
+package conf
+
+import (
+	"crypto/aes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/no-src/gofs/core"
+	"github.com/no-src/gofs/keyring"
+	"github.com/no-src/log/formatter"
+	"github.com/no-src/nsgo/hashutil"
+)
+
+const testRecipient = "age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"
+
+func newTestCheckConfig() Config {
+	return Config{
+		Source:            core.NewVFS("./source"),
+		Dest:              core.NewVFS("./dest"),
+		ChunkSize:         core.NewSize(1024),
+		ChecksumAlgorithm: hashutil.DefaultHash,
+		LogSampleRate:     1,
+		LogFormat:         formatter.TextFormatter,
+	}
+}
+
+func TestConfig_Check(t *testing.T) {
+	usersFile := filepath.Join(t.TempDir(), "users")
+	if err := os.WriteFile(usersFile, []byte("# users\ngofs:$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy:rw\n"), 0600); err != nil {
+		t.Fatalf("write the users file error => %v", err)
+	}
+	testCases := []struct {
+		name   string
+		modify func(c *Config)
+	}{
+		{"disk to disk", func(c *Config) {}},
+		{"disk to sftp", func(c *Config) {
+			c.Dest = core.NewVFS("sftp://127.0.0.1:22?remote_path=/dest&ssh_user=sftp_user&ssh_pass=sftp_pwd")
+		}},
+		{"with sync cron", func(c *Config) { c.SyncCron = "*/30 * * * * *" }},
+		{"with encrypt", func(c *Config) {
+			c.Encrypt = true
+			c.EncryptPath = "./source"
+			c.EncryptSecret = "mysecret_16bytes"
+		}},
+		{"with manage", func(c *Config) {
+			c.EnableFileServer = true
+			c.EnableManage = true
+			c.EnableReport = true
+		}},
+		{"only jobs", func(c *Config) {
+			c.Source = core.NewEmptyVFS()
+			c.Dest = core.NewEmptyVFS()
+			c.Jobs = []Job{{"name": "job", "source": "./source", "dest": "./dest"}}
+		}},
+		{"decrypt", func(c *Config) {
+			*c = Config{Decrypt: true, DecryptPath: "./dest", DecryptSecret: "mysecret_16bytes"}
+		}},
+		{"decrypt remote path", func(c *Config) {
+			*c = Config{Decrypt: true, DecryptPath: "sftp://127.0.0.1:22?remote_path=/dest", DecryptSecret: "mysecret_16bytes"}
+		}},
+		{"with server decrypt", func(c *Config) {
+			c.EnableFileServer = true
+			c.EnableServerDecrypt = true
+			c.Users = "gofs|password|r"
+		}},
+		{"with users file", func(c *Config) {
+			c.EnableFileServer = true
+			c.EnableServerDecrypt = true
+			c.UsersFile = usersFile
+		}},
+		{"with acl", func(c *Config) {
+			c.Users = "alice|password|rw"
+			c.ACL = "alice|/source/docs|rw,@dev|/dest|r"
+			c.ACLGroups = "dev|alice"
+		}},
+		{"push user root", func(c *Config) {
+			c.EnableFileServer = true
+			c.EnablePushServer = true
+			c.PushUserRoot = true
+			c.PushUserQuota = core.NewSize(1024)
+			c.PushUserQuotaFiles = 100
+			c.Users = "alice|password|rw"
+			c.UsersFile = usersFile
+		}},
+		{"with login lockout", func(c *Config) {
+			c.LoginMaxFailures = 5
+			c.LoginMaxIPFailures = 20
+			c.LoginLockout = core.Duration(time.Minute)
+			c.LoginMaxLockout = core.Duration(time.Hour)
+		}},
+		{"with session timeout", func(c *Config) {
+			c.SessionIdleTimeout = core.Duration(30 * time.Minute)
+			c.SessionAbsoluteTimeout = core.Duration(24 * time.Hour)
+		}},
+		{"with ip access", func(c *Config) {
+			c.TrustedProxies = "127.0.0.1,10.0.0.0/8"
+			c.ServerAllowIP = "192.168.0.0/16,::1"
+			c.ServerDenyIP = "192.168.1.100"
+			c.PushAllowIP = "192.168.1.0/24"
+			c.GRPCDenyIP = "fd00::/8"
+			c.IPMaxConns = 10
+			c.IPMaxRate = 2.5
+			c.IPRateBurst = 5
+		}},
+		{"with audit log", func(c *Config) {
+			c.EnableAuditLog = true
+			c.AuditHMACKey = "audit_key"
+		}},
+		{"with totp required perm", func(c *Config) {
+			c.Users = "alice|password|rw"
+			c.TOTPRequiredPerm = "wx"
+		}},
+		{"with api keys file", func(c *Config) {
+			c.Users = "alice|password|rw"
+			c.APIKeysFile = filepath.Join(os.TempDir(), "gofs_not_found.keys")
+		}},
+		{"with mutual tls", func(c *Config) {
+			c.EnableTLS = true
+			c.TLSClientCAFile = usersFile
+			c.TLSClientCertFile = usersFile
+			c.TLSClientKeyFile = usersFile
+		}},
+		{"with tls auto", func(c *Config) {
+			c.EnableTLS = true
+			c.EnableFileServer = true
+			c.TLSAuto = true
+			c.TLSCertFile = filepath.Join(os.TempDir(), "gofs_not_found.pem")
+			c.TLSKeyFile = filepath.Join(os.TempDir(), "gofs_not_found.key")
+		}},
+		{"with tls pin", func(c *Config) {
+			c.EnableTLS = true
+			c.TLSPin = strings.Repeat("AB:", 31) + "AB"
+			c.TLSKnownHostsFile = filepath.Join(os.TempDir(), "gofs_known_hosts")
+		}},
+		{"push quota", func(c *Config) {
+			c.EnableFileServer = true
+			c.EnablePushServer = true
+			c.PushQuota = core.NewSize(1024)
+			c.PushQuotaFiles = 100
+		}},
+		{"hash password", func(c *Config) {
+			c.HashPassword = true
+			c.HashAlgorithm = "argon2id"
+		}},
+		{"encrypt with keyfile", func(c *Config) {
+			c.Encrypt = true
+			c.EncryptPath = "./source"
+			c.EncryptSecret = "a passphrase of any length"
+			c.KeyFile = filepath.Join(os.TempDir(), "gofs_not_found.key")
+			c.KDF = keyring.DefaultKDF
+		}},
+		{"encrypt with recipients", func(c *Config) {
+			c.Encrypt = true
+			c.EncryptPath = "./source"
+			c.EncryptRecipients = testRecipient
+		}},
+		{"reencrypt", func(c *Config) {
+			*c = Config{ReEncrypt: true, DecryptPath: "./dest", DecryptSecret: "old passphrase", EncryptSecret: "new passphrase",
+				KeyFile: filepath.Join(os.TempDir(), "gofs_not_found.key"), KDF: keyring.Scrypt}
+		}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestCheckConfig()
+			tc.modify(&c)
+			if errs := c.Check(); len(errs) > 0 {
+				t.Errorf("Check: expect to get no error, but get %v", errs)
+			}
+		})
+	}
+}
+
+func TestConfig_Check_ReturnError(t *testing.T) {
+	testCases := []struct {
+		name   string
+		modify func(c *Config)
+		expect error
+	}{
+		{"source required", func(c *Config) { c.Source = core.NewEmptyVFS() }, errSourceRequired},
+		{"file system unsupported", func(c *Config) {
+			c.Source = core.NewVFS("sftp://127.0.0.1:22?remote_path=/source")
+			c.Dest = core.NewVFS("minio://127.0.0.1:9000?remote_path=bucket")
+		}, errFileSystemUnsupported},
+		{"invalid chunk size", func(c *Config) { c.ChunkSize = core.NewSize(0) }, errInvalidChunkSize},
+		{"invalid log sample rate", func(c *Config) { c.LogSampleRate = 2 }, errInvalidLogSampleRate},
+		{"invalid log format", func(c *Config) { c.LogFormat = "xml" }, errInvalidLogFormat},
+		{"manage without server", func(c *Config) { c.EnableManage = true }, errManageWithoutServer},
+		{"report without manage", func(c *Config) { c.EnableReport = true }, errReportWithoutManage},
+		{"push server without server", func(c *Config) { c.EnablePushServer = true }, errPushServerWithoutServer},
+		{"http3 without tls", func(c *Config) { c.EnableHTTP3 = true }, errHTTP3WithoutTLS},
+		{"encrypt path required", func(c *Config) {
+			c.Encrypt = true
+			c.EncryptSecret = "mysecret_16bytes"
+		}, errEncryptPathRequired},
+		{"invalid encrypt secret size", func(c *Config) {
+			c.Encrypt = true
+			c.EncryptPath = "./source"
+			c.EncryptSecret = "secret"
+		}, aes.KeySizeError(6)},
+		{"decrypt path required", func(c *Config) {
+			c.Decrypt = true
+			c.DecryptSecret = "mysecret_16bytes"
+		}, errDecryptPathRequired},
+		{"empty passphrase", func(c *Config) {
+			c.Encrypt = true
+			c.EncryptPath = "./source"
+			c.KeyFile = "./gofs.key"
+			c.KDF = keyring.DefaultKDF
+		}, errEmptyPassphrase},
+		{"unsupported kdf", func(c *Config) {
+			c.Encrypt = true
+			c.EncryptPath = "./source"
+			c.EncryptSecret = "passphrase"
+			c.KeyFile = "./gofs.key"
+			c.KDF = "pbkdf2"
+		}, errors.Unwrap(keyring.CheckKDF("pbkdf2"))},
+		{"decrypt keyfile not found", func(c *Config) {
+			*c = Config{Decrypt: true, DecryptPath: "./dest", DecryptSecret: "passphrase", KeyFile: filepath.Join(os.TempDir(), "gofs_not_found.key")}
+		}, errKeyFileNotFound},
+		{"name encryption with recipients", func(c *Config) {
+			c.Encrypt = true
+			c.EncryptPath = "./source"
+			c.EncryptRecipients = testRecipient
+			c.EncryptName = true
+		}, errNameWithRecipients},
+		{"identity not found", func(c *Config) {
+			*c = Config{Decrypt: true, DecryptPath: "./dest", DecryptIdentity: filepath.Join(os.TempDir(), "gofs_not_found.txt")}
+		}, errIdentityNotFound},
+		{"reencrypt remote path", func(c *Config) {
+			*c = Config{ReEncrypt: true, DecryptPath: "minio://127.0.0.1:9000?remote_path=bucket", DecryptSecret: "old passphrase", EncryptSecret: "new passphrase",
+				KeyFile: filepath.Join(os.TempDir(), "gofs_not_found.key"), KDF: keyring.Scrypt}
+		}, errReEncryptRemotePath},
+		{"rotate key without reencrypt", func(c *Config) {
+			*c = Config{Decrypt: true, DecryptPath: "./dest", DecryptSecret: "old passphrase", RotateKey: true}
+		}, errRotateKeyRequired},
+		{"rotate key without keyfile", func(c *Config) {
+			*c = Config{ReEncrypt: true, DecryptPath: "./dest", DecryptSecret: "mysecret_16bytes", EncryptSecret: "mysecret_16bytes", RotateKey: true}
+		}, errRotateKeyRequired},
+		{"server decrypt without server", func(c *Config) {
+			c.EnableServerDecrypt = true
+			c.Users = "gofs|password|r"
+		}, errServerDecryptWithoutServer},
+		{"server decrypt without users", func(c *Config) {
+			c.EnableFileServer = true
+			c.EnableServerDecrypt = true
+		}, errServerDecryptWithoutUsers},
+		{"users file not found", func(c *Config) {
+			c.UsersFile = filepath.Join(os.TempDir(), "gofs_not_found.users")
+		}, errUsersFileNotFound},
+		{"acl without users", func(c *Config) {
+			c.ACL = "alice|/source/docs|rw"
+		}, errACLWithoutUsers},
+		{"negative login max failures", func(c *Config) {
+			c.LoginMaxFailures = -1
+		}, errInvalidLoginMaxFailures},
+		{"negative login max ip failures", func(c *Config) {
+			c.LoginMaxIPFailures = -1
+		}, errInvalidLoginMaxFailures},
+		{"negative login lockout", func(c *Config) {
+			c.LoginLockout = core.Duration(-time.Minute)
+		}, errInvalidLoginLockout},
+		{"negative login max lockout", func(c *Config) {
+			c.LoginMaxLockout = core.Duration(-time.Minute)
+		}, errInvalidLoginLockout},
+		{"negative session idle timeout", func(c *Config) {
+			c.SessionIdleTimeout = core.Duration(-time.Minute)
+		}, errInvalidSessionTimeout},
+		{"negative session absolute timeout", func(c *Config) {
+			c.SessionAbsoluteTimeout = core.Duration(-time.Minute)
+		}, errInvalidSessionTimeout},
+		{"invalid trusted proxies", func(c *Config) {
+			c.TrustedProxies = "localhost"
+		}, errInvalidIPRules},
+		{"invalid push deny ip", func(c *Config) {
+			c.PushDenyIP = "10.0.0.0/33"
+		}, errInvalidIPRules},
+		{"negative ip max conns", func(c *Config) {
+			c.IPMaxConns = -1
+		}, errInvalidIPLimit},
+		{"negative ip max rate", func(c *Config) {
+			c.IPMaxRate = -0.5
+		}, errInvalidIPLimit},
+		{"audit hmac key without audit log", func(c *Config) {
+			c.AuditHMACKey = "audit_key"
+		}, errAuditKeyWithoutAuditLog},
+		{"audit verify without hmac key", func(c *Config) {
+			c.AuditVerify = "audit.log"
+		}, errAuditVerifyWithoutKey},
+		{"invalid totp required perm", func(c *Config) {
+			c.TOTPRequiredPerm = "abc"
+		}, errInvalidTOTPRequiredPerm},
+		{"api keys file without users", func(c *Config) {
+			c.APIKeysFile = filepath.Join(os.TempDir(), "gofs_not_found.keys")
+		}, errAPIKeysWithoutUsers},
+		{"push user root without push server", func(c *Config) {
+			c.PushUserRoot = true
+			c.Users = "alice|password|rw"
+		}, errPushUserRootWithoutPush},
+		{"push user root without users", func(c *Config) {
+			c.EnableFileServer = true
+			c.EnablePushServer = true
+			c.PushUserRoot = true
+		}, errPushUserRootWithoutUsers},
+		{"push user quota without user root", func(c *Config) {
+			c.PushUserQuota = core.NewSize(1024)
+		}, errPushUserQuotaWithoutRoot},
+		{"push user quota files without user root", func(c *Config) {
+			c.PushUserQuotaFiles = 100
+		}, errPushUserQuotaWithoutRoot},
+		{"push quota without push server", func(c *Config) {
+			c.PushMinFreeSpace = core.NewSize(1024)
+		}, errPushQuotaWithoutPush},
+		{"negative push quota files", func(c *Config) {
+			c.EnableFileServer = true
+			c.EnablePushServer = true
+			c.PushQuotaFiles = -1
+		}, errInvalidPushQuota},
+		{"client CA without tls", func(c *Config) {
+			c.TLSClientCAFile = "check.go"
+		}, errClientCAWithoutTLS},
+		{"client CA file not found", func(c *Config) {
+			c.EnableTLS = true
+			c.TLSClientCAFile = filepath.Join(os.TempDir(), "gofs_not_found_ca.pem")
+		}, errClientCAFileNotFound},
+		{"client cert without tls", func(c *Config) {
+			c.TLSClientCertFile = "check.go"
+			c.TLSClientKeyFile = "check.go"
+		}, errClientCertWithoutTLS},
+		{"client cert without key", func(c *Config) {
+			c.EnableTLS = true
+			c.TLSClientCertFile = "check.go"
+		}, errClientCertPairRequired},
+		{"client cert file not found", func(c *Config) {
+			c.EnableTLS = true
+			c.TLSClientCertFile = filepath.Join(os.TempDir(), "gofs_not_found_client.pem")
+			c.TLSClientKeyFile = "check.go"
+		}, errClientCertFileNotFound},
+		{"tls auto without tls", func(c *Config) {
+			c.TLSAuto = true
+		}, errTLSAutoWithoutTLS},
+		{"tls pin without tls", func(c *Config) {
+			c.TLSKnownHostsFile = filepath.Join(os.TempDir(), "gofs_known_hosts")
+		}, errTLSPinWithoutTLS},
+		{"invalid tls pin", func(c *Config) {
+			c.EnableTLS = true
+			c.TLSPin = "AB:CD"
+		}, errInvalidTLSPin},
+		{"unsupported token algorithm", func(c *Config) {
+			c.Source = core.NewVFS("rs://127.0.0.1:8105?mode=server&path=./source")
+			c.TokenAlgorithm = "RS256"
+		}, errInvalidTokenAlgorithm},
+		{"token key file not found", func(c *Config) {
+			c.Source = core.NewVFS("rs://127.0.0.1:8105?mode=server&path=./source")
+			c.TokenAlgorithm = "EdDSA"
+			c.TokenKeyFile = filepath.Join(os.TempDir(), "gofs_not_found_token.pem")
+		}, errTokenKeyFileNotFound},
+		{"negative token expires", func(c *Config) {
+			c.Source = core.NewVFS("rs://127.0.0.1:8105?mode=server&path=./source")
+			c.TokenExpires = core.Duration(-time.Minute)
+		}, errInvalidTokenExpires},
+		{"unsupported hash algorithm", func(c *Config) {
+			c.HashPassword = true
+			c.HashAlgorithm = "md5"
+		}, errInvalidHashAlgorithm},
+		{"reencrypt secret required", func(c *Config) {
+			*c = Config{ReEncrypt: true, DecryptPath: "./dest", DecryptSecret: "mysecret_16bytes"}
+		}, aes.KeySizeError(0)},
+		{"sync cron with task client", func(c *Config) {
+			c.Source = core.NewVFS("rs://127.0.0.1:8105")
+			c.EnableTaskClient = true
+			c.SyncCron = "*/30 * * * * *"
+		}, errSyncCronWithTaskClient},
+		{"task client source", func(c *Config) { c.EnableTaskClient = true }, errTaskClientSource},
+		{"job source required", func(c *Config) {
+			c.Jobs = []Job{{"name": "job"}}
+		}, errJobSourceRequired},
+		{"job check", func(c *Config) {
+			c.Jobs = []Job{{"name": "job", "source": "./source", "dest": "./dest", "log_sample_rate": 3}}
+		}, errInvalidLogSampleRate},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestCheckConfig()
+			tc.modify(&c)
+			errs := c.Check()
+			if !errors.Is(errors.Join(errs...), tc.expect) {
+				t.Errorf("Check: expect to get error %v, but get %v", tc.expect, errs)
+			}
+		})
+	}
+}
+
+func TestConfig_Check_ReturnAllErrors(t *testing.T) {
+	c := newTestCheckConfig()
+	c.Source = core.NewVFS("rs://127.0.0.1:8105?path=./source" + string([]byte{127}))
+	c.SyncCron = "invalid cron"
+	c.ChecksumAlgorithm = "unknown"
+	c.IgnoreConf = filepath.Join(os.TempDir(), "gofs_not_found.ignore")
+	c.Users = "invalid_user"
+	c.EnableFileServer = true
+	c.EnableTLS = true
+	c.TLSCertFile = filepath.Join(os.TempDir(), "gofs_not_found.pem")
+
+	errs := c.Check()
+	if len(errs) != 6 {
+		t.Errorf("Check: expect to get 6 errors, but get %d => %v", len(errs), errs)
+	}
+}
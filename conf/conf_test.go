@@ -3,6 +3,7 @@ package conf
 import (
 	"errors"
 	"os"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -18,8 +19,9 @@ func TestConfig_ToArgs(t *testing.T) {
 		SyncDelayTime:     core.Duration(time.Second * 3),
 		Source:            core.NewVFS("rs://127.0.0.1:8105?mode=server&local_sync_disabled=true&path=./source&fs_server=https://127.0.0.1"),
 		SessionConnection: "memory:",
+		Jobs:              []Job{{"name": "job", "source": "./source"}},
 	}
-	args, err := c.ToArgs()
+	args, _, err := c.ToArgs()
 	if err != nil {
 		t.Errorf("parse config to arguments error, %v", err)
 		return
@@ -55,6 +57,9 @@ func TestConfig_ToArgs(t *testing.T) {
 	for _, arg := range args[1:] {
 		kv := strings.SplitN(arg, "=", 2)
 		k := kv[0]
+		if k == "-jobs" {
+			t.Errorf("expect to ignore the jobs in the arguments")
+		}
 		v := kv[1]
 		for _, tc := range testCases {
 			if k == tc.k && v != tc.v {
@@ -74,8 +79,58 @@ func TestConfig_ToArgs_MarshalError(t *testing.T) {
 	yamlutil.Marshal = func(v any) ([]byte, error) {
 		return nil, errMarshal
 	}
-	_, err := c.ToArgs()
+	_, _, err := c.ToArgs()
 	if err != errMarshal {
 		t.Errorf("expect to get error %v, but actual get %v", errMarshal, err)
 	}
 }
+
+func TestConfig_ToArgs_WithSecrets(t *testing.T) {
+	sftpDest := "sftp://127.0.0.1:22?remote_path=/dest&ssh_user=sftp_user&ssh_pass=sftp_pwd"
+	c := Config{
+		Source:        core.NewVFS("./source"),
+		Dest:          core.NewVFS(sftpDest),
+		Users:         "gofs|password|rwx",
+		TokenSecret:   "token_secret",
+		EncryptSecret: "env:GOFS_TEST_ENCRYPT_SECRET",
+	}
+	args, env, err := c.ToArgs()
+	if err != nil {
+		t.Errorf("parse config to arguments error, %v", err)
+		return
+	}
+	for _, arg := range args {
+		for _, s := range []string{"password", "token_secret=token_secret", "sftp_pwd"} {
+			if strings.Contains(arg, s) {
+				t.Errorf("expect to hide the secrets in the arguments, but get %s", arg)
+			}
+		}
+	}
+
+	expectArgs := []string{
+		"-source=./source",
+		"-dest=env:GOFS_SECRET_DEST",
+		"-users=env:GOFS_SECRET_USERS",
+		"-token_secret=env:GOFS_SECRET_TOKEN_SECRET",
+		"-encrypt_secret=env:GOFS_TEST_ENCRYPT_SECRET",
+		"-decrypt_secret=",
+	}
+	for _, expect := range expectArgs {
+		if !slices.Contains(args, expect) {
+			t.Errorf("expect to get the argument %s, but not found", expect)
+		}
+	}
+
+	expectEnv := []string{
+		"GOFS_SECRET_USERS=gofs|password|rwx",
+		"GOFS_SECRET_TOKEN_SECRET=token_secret",
+		"GOFS_SECRET_DEST=" + sftpDest,
+	}
+	if !slices.Equal(env, expectEnv) {
+		t.Errorf("expect to get the environment variables %v, but get %v", expectEnv, env)
+	}
+
+	if c.Users != "gofs|password|rwx" {
+		t.Errorf("expect the config is not changed, but get users %s", c.Users)
+	}
+}
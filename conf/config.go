@@ -15,6 +15,14 @@ type Config struct {
 	PrintVersion bool   `json:"-" yaml:"-"`
 	PrintAbout   bool   `json:"-" yaml:"-"`
 	Conf         string `json:"-" yaml:"-"`
+	CheckConf    bool   `json:"-" yaml:"-"`
+	PrintConf    bool   `json:"-" yaml:"-"`
+	ConfFormat   string `json:"-" yaml:"-"`
+	InitConf     string `json:"-" yaml:"-"`
+	GenIdentity  string `json:"-" yaml:"-"`
+	HashPassword bool   `json:"-" yaml:"-"`
+	TOTPEnroll   string `json:"-" yaml:"-"`
+	AuditVerify  string `json:"-" yaml:"-"`
 
 	// file sync
 	Source                core.VFS  `json:"source" yaml:"source"`
@@ -56,6 +64,8 @@ type Config struct {
 	LogSampleRate    float64       `json:"log_sample_rate" yaml:"log_sample_rate"`
 	LogFormat        string        `json:"log_format" yaml:"log_format"`
 	LogSplitDate     bool          `json:"log_split_date" yaml:"log_split_date"`
+	EnableAuditLog   bool          `json:"audit_log" yaml:"audit_log"`
+	AuditHMACKey     string        `json:"audit_hmac_key" yaml:"audit_hmac_key"`
 
 	// daemon
 	IsDaemon           bool          `json:"daemon" yaml:"daemon"`
@@ -66,14 +76,35 @@ type Config struct {
 	IsSubprocess       bool          `json:"sub" yaml:"sub"`
 
 	// file server
-	EnableFileServer         bool   `json:"server" yaml:"server"`
-	FileServerAddr           string `json:"server_addr" yaml:"server_addr"`
-	EnableFileServerCompress bool   `json:"server_compress" yaml:"server_compress"`
-	EnableManage             bool   `json:"manage" yaml:"manage"`
-	ManagePrivate            bool   `json:"manage_private" yaml:"manage_private"`
-	EnablePushServer         bool   `json:"push_server" yaml:"push_server"`
-	EnableReport             bool   `json:"report" yaml:"report"`
-	SessionConnection        string `json:"session_connection" yaml:"session_connection"`
+	EnableFileServer         bool          `json:"server" yaml:"server"`
+	FileServerAddr           string        `json:"server_addr" yaml:"server_addr"`
+	EnableFileServerCompress bool          `json:"server_compress" yaml:"server_compress"`
+	EnableManage             bool          `json:"manage" yaml:"manage"`
+	ManagePrivate            bool          `json:"manage_private" yaml:"manage_private"`
+	EnablePushServer         bool          `json:"push_server" yaml:"push_server"`
+	PushUserRoot             bool          `json:"push_user_root" yaml:"push_user_root"`
+	PushUserQuota            core.Size     `json:"push_user_quota" yaml:"push_user_quota"`
+	PushUserQuotaFiles       int           `json:"push_user_quota_files" yaml:"push_user_quota_files"`
+	PushQuota                core.Size     `json:"push_quota" yaml:"push_quota"`
+	PushQuotaFiles           int           `json:"push_quota_files" yaml:"push_quota_files"`
+	PushMinFreeSpace         core.Size     `json:"push_min_free_space" yaml:"push_min_free_space"`
+	EnableReport             bool          `json:"report" yaml:"report"`
+	EnableServerDecrypt      bool          `json:"server_decrypt" yaml:"server_decrypt"`
+	SessionConnection        string        `json:"session_connection" yaml:"session_connection"`
+	SessionIdleTimeout       core.Duration `json:"session_idle_timeout" yaml:"session_idle_timeout"`
+	SessionAbsoluteTimeout   core.Duration `json:"session_absolute_timeout" yaml:"session_absolute_timeout"`
+
+	// ip access
+	TrustedProxies string  `json:"trusted_proxies" yaml:"trusted_proxies"`
+	ServerAllowIP  string  `json:"server_allow_ip" yaml:"server_allow_ip"`
+	ServerDenyIP   string  `json:"server_deny_ip" yaml:"server_deny_ip"`
+	PushAllowIP    string  `json:"push_allow_ip" yaml:"push_allow_ip"`
+	PushDenyIP     string  `json:"push_deny_ip" yaml:"push_deny_ip"`
+	GRPCAllowIP    string  `json:"grpc_allow_ip" yaml:"grpc_allow_ip"`
+	GRPCDenyIP     string  `json:"grpc_deny_ip" yaml:"grpc_deny_ip"`
+	IPMaxConns     int     `json:"ip_max_conns" yaml:"ip_max_conns"`
+	IPMaxRate      float64 `json:"ip_max_rate" yaml:"ip_max_rate"`
+	IPRateBurst    int     `json:"ip_rate_burst" yaml:"ip_rate_burst"`
 
 	// http protocol
 	EnableHTTP3 bool `json:"http3" yaml:"http3"`
@@ -83,14 +114,34 @@ type Config struct {
 	TLSCertFile           string `json:"tls_cert_file" yaml:"tls_cert_file"`
 	TLSKeyFile            string `json:"tls_key_file" yaml:"tls_key_file"`
 	TLSInsecureSkipVerify bool   `json:"tls_insecure_skip_verify" yaml:"tls_insecure_skip_verify"`
+	TLSClientCAFile       string `json:"tls_client_ca_file" yaml:"tls_client_ca_file"`
+	TLSClientCertFile     string `json:"tls_client_cert_file" yaml:"tls_client_cert_file"`
+	TLSClientKeyFile      string `json:"tls_client_key_file" yaml:"tls_client_key_file"`
+	TLSAuto               bool   `json:"tls_auto" yaml:"tls_auto"`
+	TLSPin                string `json:"tls_pin" yaml:"tls_pin"`
+	TLSKnownHostsFile     string `json:"tls_known_hosts_file" yaml:"tls_known_hosts_file"`
 
 	// login user
-	Users             string `json:"users" yaml:"users"`
-	RandomUserCount   int    `json:"rand_user_count" yaml:"rand_user_count"`
-	RandomUserNameLen int    `json:"rand_user_len" yaml:"rand_user_len"`
-	RandomPasswordLen int    `json:"rand_pwd_len" yaml:"rand_pwd_len"`
-	RandomDefaultPerm string `json:"rand_perm" yaml:"rand_perm"`
-	TokenSecret       string `json:"token_secret" yaml:"token_secret"`
+	Users              string        `json:"users" yaml:"users"`
+	UsersFile          string        `json:"users_file" yaml:"users_file"`
+	HashAlgorithm      string        `json:"hash_algorithm" yaml:"hash_algorithm"`
+	ACL                string        `json:"acl" yaml:"acl"`
+	ACLGroups          string        `json:"acl_groups" yaml:"acl_groups"`
+	APIKeysFile        string        `json:"api_keys_file" yaml:"api_keys_file"`
+	TOTPRequiredPerm   string        `json:"totp_required_perm" yaml:"totp_required_perm"`
+	RandomUserCount    int           `json:"rand_user_count" yaml:"rand_user_count"`
+	RandomUserNameLen  int           `json:"rand_user_len" yaml:"rand_user_len"`
+	RandomPasswordLen  int           `json:"rand_pwd_len" yaml:"rand_pwd_len"`
+	RandomDefaultPerm  string        `json:"rand_perm" yaml:"rand_perm"`
+	TokenSecret        string        `json:"token_secret" yaml:"token_secret"`
+	TokenAlgorithm     string        `json:"token_algorithm" yaml:"token_algorithm"`
+	TokenKeyFile       string        `json:"token_key_file" yaml:"token_key_file"`
+	TokenExpires       core.Duration `json:"token_expires" yaml:"token_expires"`
+	TokenRevocation    string        `json:"token_revocation" yaml:"token_revocation"`
+	LoginMaxFailures   int           `json:"login_max_failures" yaml:"login_max_failures"`
+	LoginMaxIPFailures int           `json:"login_max_ip_failures" yaml:"login_max_ip_failures"`
+	LoginLockout       core.Duration `json:"login_lockout" yaml:"login_lockout"`
+	LoginMaxLockout    core.Duration `json:"login_max_lockout" yaml:"login_max_lockout"`
 
 	// checksum
 	Checksum bool `json:"checksum" yaml:"checksum"`
@@ -99,25 +150,46 @@ type Config struct {
 	Encrypt       bool   `json:"encrypt" yaml:"encrypt"`
 	EncryptPath   string `json:"encrypt_path" yaml:"encrypt_path"`
 	EncryptSecret string `json:"encrypt_secret" yaml:"encrypt_secret"`
+	EncryptName   bool   `json:"encrypt_name" yaml:"encrypt_name"`
+
+	// public-key encryption
+	EncryptRecipients     string `json:"encrypt_recipients" yaml:"encrypt_recipients"`
+	EncryptRecipientsFile string `json:"encrypt_recipients_file" yaml:"encrypt_recipients_file"`
+	DecryptIdentity       string `json:"decrypt_identity" yaml:"decrypt_identity"`
 
 	// decrypt
 	Decrypt       bool   `json:"decrypt" yaml:"decrypt"`
 	DecryptPath   string `json:"decrypt_path" yaml:"decrypt_path"`
 	DecryptSecret string `json:"decrypt_secret" yaml:"decrypt_secret"`
 	DecryptOut    string `json:"decrypt_out" yaml:"decrypt_out"`
+	DecryptLegacy bool   `json:"decrypt_legacy" yaml:"decrypt_legacy"`
+
+	// key derivation
+	ReEncrypt bool   `json:"reencrypt" yaml:"reencrypt"`
+	RotateKey bool   `json:"rotate_key" yaml:"rotate_key"`
+	KeyFile   string `json:"keyfile" yaml:"keyfile"`
+	KDF       string `json:"kdf" yaml:"kdf"`
 
 	// task
 	TaskConf            string `json:"task_conf" yaml:"task_conf"`
 	EnableTaskClient    bool   `json:"task_client" yaml:"task_client"`
 	TaskClientLabels    string `json:"task_client_labels" yaml:"task_client_labels"`
 	TaskClientMaxWorker int    `json:"task_client_max_worker" yaml:"task_client_max_worker"`
+
+	// jobs
+	Jobs []Job `json:"jobs,omitempty" yaml:"jobs,omitempty"`
 }
 
 // ToArgs parse the Config to program arguments and the first argument is the current program name
-func (c Config) ToArgs() (args []string, err error) {
+// The jobs are not supported by the command-line arguments, they are read from the config file only
+// The plain text secrets are not exposed in the arguments, they are returned as the environment variables
+// and replaced with the secret references in the arguments
+func (c Config) ToArgs() (args []string, env []string, err error) {
+	c.Jobs = nil
+	env = c.hideSecrets()
 	data, err := yamlutil.Marshal(c)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	exeFile, err := os.Executable()
 	if err == nil {
@@ -137,5 +209,5 @@ func (c Config) ToArgs() (args []string, err error) {
 			args = append(args, line)
 		}
 	}
-	return args, err
+	return args, env, err
 }
@@ -0,0 +1,23 @@
+package conf
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Diff compare the two configs and return the flag names of the changed fields, the fields that ignored by json are not compared
+func Diff(old, new Config) (keys []string) {
+	ov := reflect.ValueOf(old)
+	nv := reflect.ValueOf(new)
+	t := ov.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if len(name) == 0 || name == "-" {
+			continue
+		}
+		if !reflect.DeepEqual(ov.Field(i).Interface(), nv.Field(i).Interface()) {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
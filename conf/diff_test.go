@@ -0,0 +1,44 @@
+package conf
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/no-src/gofs/core"
+)
+
+func TestDiff(t *testing.T) {
+	old := Config{
+		Source:     core.NewVFS("./source"),
+		Dest:       core.NewVFS("./dest"),
+		RetryCount: 15,
+		RetryWait:  core.Duration(time.Second * 5),
+		LogLevel:   1,
+		Conf:       "./gofs.yaml",
+	}
+	testCases := []struct {
+		name   string
+		modify func(c *Config)
+		expect []string
+	}{
+		{"no change", func(c *Config) {}, nil},
+		{"ignore the json ignored field", func(c *Config) { c.Conf = "./gofs.json" }, nil},
+		{"change one field", func(c *Config) { c.RetryCount = 10 }, []string{"retry_count"}},
+		{"change multiple fields", func(c *Config) {
+			c.Dest = core.NewVFS("./dest2")
+			c.RetryWait = core.Duration(time.Second)
+			c.LogLevel = 0
+		}, []string{"dest", "retry_wait", "log_level"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := old
+			tc.modify(&c)
+			actual := Diff(old, c)
+			if !reflect.DeepEqual(actual, tc.expect) {
+				t.Errorf("expect to get %v, actual get %v", tc.expect, actual)
+			}
+		})
+	}
+}
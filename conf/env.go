@@ -0,0 +1,73 @@
+package conf
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix the prefix of the environment variables that override the config
+const EnvPrefix = "GOFS_"
+
+var errUnsupportedEnvField = errors.New("the field is not supported by the environment variable")
+
+// EnvName return the name of the environment variable that overrides the specified config key, like GOFS_SOURCE
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(key)
+}
+
+// ApplyEnv override the config with the GOFS_<KEY> environment variables,
+// the environment variables take precedence over the config file and the command-line flags, the jobs are not supported
+func (c *Config) ApplyEnv() error {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if len(key) == 0 || key == "-" || key == "jobs" {
+			continue
+		}
+		value, ok := os.LookupEnv(EnvName(key))
+		if !ok {
+			continue
+		}
+		if err := setFieldValue(v.Field(i), value); err != nil {
+			return fmt.Errorf("apply the environment variable error => %s, %w", EnvName(key), err)
+		}
+	}
+	return nil
+}
+
+func setFieldValue(field reflect.Value, value string) error {
+	if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return errUnsupportedEnvField
+	}
+	return nil
+}
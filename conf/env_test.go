@@ -0,0 +1,79 @@
+package conf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/no-src/gofs/core"
+)
+
+func TestConfig_ApplyEnv(t *testing.T) {
+	t.Setenv("GOFS_SOURCE", "./env_source")
+	t.Setenv("GOFS_SYNC_ONCE", "true")
+	t.Setenv("GOFS_RETRY_COUNT", "3")
+	t.Setenv("GOFS_RETRY_WAIT", "10s")
+	t.Setenv("GOFS_CHUNK_SIZE", "2MiB")
+	t.Setenv("GOFS_LOG_SAMPLE_RATE", "0.5")
+	t.Setenv("GOFS_USERS", "env:GOFS_TEST_USERS")
+	t.Setenv("GOFS_JOBS", "ignored")
+
+	c := Config{
+		Source:     core.NewVFS("./source"),
+		Dest:       core.NewVFS("./dest"),
+		RetryCount: 15,
+	}
+	if err := c.ApplyEnv(); err != nil {
+		t.Errorf("ApplyEnv: unexpected error => %v", err)
+		return
+	}
+
+	if c.Source.Original() != "./env_source" {
+		t.Errorf("ApplyEnv: expect to get source ./env_source, but actual get %s", c.Source.Original())
+	}
+	if c.Dest.Original() != "./dest" {
+		t.Errorf("ApplyEnv: expect to keep dest ./dest, but actual get %s", c.Dest.Original())
+	}
+	if !c.SyncOnce {
+		t.Errorf("ApplyEnv: expect to get sync_once true, but actual get false")
+	}
+	if c.RetryCount != 3 {
+		t.Errorf("ApplyEnv: expect to get retry_count 3, but actual get %d", c.RetryCount)
+	}
+	if c.RetryWait.Duration() != 10*time.Second {
+		t.Errorf("ApplyEnv: expect to get retry_wait 10s, but actual get %s", c.RetryWait.Duration())
+	}
+	if c.ChunkSize.Bytes() != 2*1024*1024 {
+		t.Errorf("ApplyEnv: expect to get chunk_size 2MiB, but actual get %d", c.ChunkSize.Bytes())
+	}
+	if c.LogSampleRate != 0.5 {
+		t.Errorf("ApplyEnv: expect to get log_sample_rate 0.5, but actual get %v", c.LogSampleRate)
+	}
+	if c.Users != "env:GOFS_TEST_USERS" {
+		t.Errorf("ApplyEnv: expect to get the secret reference of users, but actual get %s", c.Users)
+	}
+	if c.Jobs != nil {
+		t.Errorf("ApplyEnv: expect to ignore the jobs, but actual get %v", c.Jobs)
+	}
+}
+
+func TestConfig_ApplyEnv_ReturnError(t *testing.T) {
+	testCases := []struct {
+		name  string
+		value string
+	}{
+		{"GOFS_SYNC_ONCE", "yes_or_no"},
+		{"GOFS_RETRY_COUNT", "three"},
+		{"GOFS_RETRY_WAIT", "10x"},
+		{"GOFS_CHUNK_SIZE", "2XiB"},
+		{"GOFS_LOG_SAMPLE_RATE", "half"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(tc.name, tc.value)
+			var c Config
+			if err := c.ApplyEnv(); err == nil {
+				t.Errorf("ApplyEnv: expect to get an error but get nil")
+			}
+		})
+	}
+}
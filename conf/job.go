@@ -0,0 +1,90 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/no-src/gofs/core"
+	"github.com/no-src/nsgo/yamlutil"
+)
+
+const jobNameKey = "name"
+
+var (
+	errJobNameRequired   = errors.New("the job name is required")
+	errJobNameDuplicated = errors.New("the job name is duplicated")
+	errJobSourceRequired = errors.New("the job source is required")
+	errJobServerMode     = errors.New("the job does not support the server mode")
+)
+
+// Job a sync job in the config file, the "name" field is required, and the other fields are the same as the Config
+type Job map[string]any
+
+// JobConfig the resolved config of a sync job
+type JobConfig struct {
+	// Name the unique name of the job
+	Name string
+	// Config the job config that merged on top of the shared config
+	Config Config
+}
+
+// Name return the name of the job
+func (j Job) Name() string {
+	name, _ := j[jobNameKey].(string)
+	return name
+}
+
+// ResolveJobs merge the fields of every job on top of the shared config,
+// the source, dest and jobs of the shared config are not inherited
+func (c Config) ResolveJobs() (jobs []JobConfig, err error) {
+	base := c
+	base.Source = core.NewEmptyVFS()
+	base.Dest = core.NewEmptyVFS()
+	base.Jobs = nil
+	names := make(map[string]bool, len(c.Jobs))
+	for i, job := range c.Jobs {
+		name := job.Name()
+		if len(name) == 0 {
+			return nil, fmt.Errorf("%w => jobs[%d]", errJobNameRequired, i)
+		}
+		if names[name] {
+			return nil, fmt.Errorf("%w => %s", errJobNameDuplicated, name)
+		}
+		names[name] = true
+
+		jc := base
+		data, err := yamlutil.Marshal(normalizeJob(job))
+		if err != nil {
+			return nil, err
+		}
+		if err = yamlutil.Unmarshal(data, &jc); err != nil {
+			return nil, fmt.Errorf("parse the job config error => %s, %w", name, err)
+		}
+		jc.Jobs = nil
+		if err = jc.ResolveSecrets(); err != nil {
+			return nil, fmt.Errorf("%w => %s", err, name)
+		}
+		if len(jc.Source.Original()) == 0 {
+			return nil, fmt.Errorf("%w => %s", errJobSourceRequired, name)
+		}
+		if jc.Source.Server() {
+			return nil, fmt.Errorf("%w => %s", errJobServerMode, name)
+		}
+		jobs = append(jobs, JobConfig{Name: name, Config: jc})
+	}
+	return jobs, nil
+}
+
+// normalizeJob convert the integral float numbers that parsed from the json config to integers,
+// so that they can be parsed as the integer or size fields
+func normalizeJob(job Job) map[string]any {
+	m := make(map[string]any, len(job))
+	for k, v := range job {
+		if f, ok := v.(float64); ok && f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+			v = int64(f)
+		}
+		m[k] = v
+	}
+	return m
+}
@@ -0,0 +1,111 @@
+package conf
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+const jobsYamlConfigPath = "./example/gofs-jobs.yaml"
+
+func TestConfig_ResolveJobs(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		ext     string
+	}{
+		{"yaml configuration", `
+retry_count: 15
+retry_wait: 5s
+source: ./source
+jobs:
+  - name: job1
+    source: ./job1
+    dest: ./job1_dest
+  - name: job2
+    source: ./job2
+    chunk_size: 2048
+    retry_count: 3
+`, ".yaml"},
+		{"json configuration", `{
+"retry_count": 15,
+"retry_wait": "5s",
+"source": "./source",
+"jobs": [
+  {"name": "job1", "source": "./job1", "dest": "./job1_dest"},
+  {"name": "job2", "source": "./job2", "chunk_size": 2048, "retry_count": 3}
+]}`, ".json"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var c Config
+			if err := ParseContent([]byte(tc.content), tc.ext, &c); err != nil {
+				t.Errorf("parse config error => %v", err)
+				return
+			}
+			jobs, err := c.ResolveJobs()
+			if err != nil {
+				t.Errorf("resolve jobs error => %v", err)
+				return
+			}
+			if len(jobs) != 2 {
+				t.Errorf("expect to get 2 jobs, actual get %d", len(jobs))
+				return
+			}
+			job1, job2 := jobs[0], jobs[1]
+			if job1.Name != "job1" || job2.Name != "job2" {
+				t.Errorf("expect to get job names job1 and job2, actual get %s and %s", job1.Name, job2.Name)
+			}
+			if job1.Config.RetryCount != 15 || job1.Config.RetryWait.Duration() != time.Second*5 {
+				t.Errorf("expect to inherit the shared retry config, actual get %d %s", job1.Config.RetryCount, job1.Config.RetryWait.Duration())
+			}
+			if job2.Config.RetryCount != 3 || job2.Config.ChunkSize.Bytes() != 2048 {
+				t.Errorf("expect to override the shared config, actual get %d %d", job2.Config.RetryCount, job2.Config.ChunkSize.Bytes())
+			}
+			if job1.Config.Dest.Original() != "./job1_dest" || len(job2.Config.Dest.Original()) > 0 {
+				t.Errorf("expect to get the job dest without inheriting, actual get %s and %s", job1.Config.Dest.Original(), job2.Config.Dest.Original())
+			}
+			if len(job1.Config.Jobs) > 0 {
+				t.Errorf("expect to get a job config without jobs")
+			}
+		})
+	}
+}
+
+func TestConfig_ResolveJobs_WithExample(t *testing.T) {
+	var c Config
+	if err := Parse(jobsYamlConfigPath, &c); err != nil {
+		t.Errorf("parse config error => %v", err)
+		return
+	}
+	jobs, err := c.ResolveJobs()
+	if err != nil {
+		t.Errorf("resolve jobs error => %v", err)
+		return
+	}
+	if len(jobs) != len(c.Jobs) {
+		t.Errorf("expect to get %d jobs, actual get %d", len(c.Jobs), len(jobs))
+	}
+}
+
+func TestConfig_ResolveJobs_ReturnError(t *testing.T) {
+	testCases := []struct {
+		name   string
+		jobs   []Job
+		expect error
+	}{
+		{"job name required", []Job{{"source": "./source"}}, errJobNameRequired},
+		{"job name duplicated", []Job{{"name": "job", "source": "./source"}, {"name": "job", "source": "./source"}}, errJobNameDuplicated},
+		{"job source required", []Job{{"name": "job", "dest": "./dest"}}, errJobSourceRequired},
+		{"job server mode", []Job{{"name": "job", "source": "rs://127.0.0.1:8105?mode=server&path=./source"}}, errJobServerMode},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := Config{Jobs: tc.jobs}
+			_, err := c.ResolveJobs()
+			if !errors.Is(err, tc.expect) {
+				t.Errorf("expect to get error %v, actual get %v", tc.expect, err)
+			}
+		})
+	}
+}
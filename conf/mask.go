@@ -0,0 +1,59 @@
+package conf
+
+import (
+	"github.com/no-src/gofs/core"
+)
+
+// SecretMask the mask to replace the secrets
+const SecretMask = "******"
+
+// Mask return a copy of the config that the secrets are replaced with the SecretMask
+func (c Config) Mask() Config {
+	for _, f := range c.secretFields() {
+		if len(*f.value) > 0 {
+			*f.value = SecretMask
+		}
+	}
+	if len(c.SessionConnection) > 0 {
+		c.SessionConnection = SecretMask
+	}
+	for _, f := range c.secretVFSFields() {
+		if path := f.vfs.Original(); path != core.MaskVFSSecret(path, SecretMask) {
+			*f.vfs = core.NewVFS(core.MaskVFSSecret(path, SecretMask))
+		}
+	}
+	if len(c.Jobs) > 0 {
+		jobs := make([]Job, 0, len(c.Jobs))
+		for _, job := range c.Jobs {
+			jobs = append(jobs, job.mask())
+		}
+		c.Jobs = jobs
+	}
+	return c
+}
+
+// mask return a copy of the job that the secrets are replaced with the SecretMask
+func (j Job) mask() Job {
+	var c Config
+	secretKeys := map[string]bool{"session_connection": true}
+	for _, f := range c.secretFields() {
+		secretKeys[f.key] = true
+	}
+	vfsKeys := make(map[string]bool)
+	for _, f := range c.secretVFSFields() {
+		vfsKeys[f.key] = true
+	}
+
+	job := make(Job, len(j))
+	for k, v := range j {
+		if s, ok := v.(string); ok && len(s) > 0 {
+			if secretKeys[k] {
+				v = SecretMask
+			} else if vfsKeys[k] {
+				v = core.MaskVFSSecret(s, SecretMask)
+			}
+		}
+		job[k] = v
+	}
+	return job
+}
@@ -0,0 +1,54 @@
+package conf
+
+import (
+	"testing"
+
+	"github.com/no-src/gofs/core"
+)
+
+func TestConfig_Mask(t *testing.T) {
+	sftpDest := "sftp://127.0.0.1:22?remote_path=/dest&ssh_user=sftp_user&ssh_pass=sftp_pwd"
+	maskedSFTPDest := "sftp://127.0.0.1:22?remote_path=/dest&ssh_user=sftp_user&ssh_pass=" + SecretMask
+	c := Config{
+		Source:            core.NewVFS("./source"),
+		Dest:              core.NewVFS(sftpDest),
+		Users:             "gofs|password|rwx",
+		TokenSecret:       "token_secret",
+		EncryptSecret:     "encrypt_secret",
+		AuditHMACKey:      "audit_key",
+		SessionConnection: "redis://127.0.0.1:6379?password=redis_password",
+		Jobs: []Job{
+			{"name": "job", "source": "./source", "dest": sftpDest, "encrypt_secret": "job_secret", "retry_count": 3},
+		},
+	}
+	m := c.Mask()
+
+	testCases := []struct {
+		name   string
+		actual any
+		expect any
+	}{
+		{"source", m.Source.Original(), "./source"},
+		{"dest", m.Dest.Original(), maskedSFTPDest},
+		{"users", m.Users, SecretMask},
+		{"token_secret", m.TokenSecret, SecretMask},
+		{"encrypt_secret", m.EncryptSecret, SecretMask},
+		{"decrypt_secret", m.DecryptSecret, ""},
+		{"audit_hmac_key", m.AuditHMACKey, SecretMask},
+		{"session_connection", m.SessionConnection, SecretMask},
+		{"job name", m.Jobs[0]["name"], "job"},
+		{"job dest", m.Jobs[0]["dest"], maskedSFTPDest},
+		{"job encrypt_secret", m.Jobs[0]["encrypt_secret"], SecretMask},
+		{"job retry_count", m.Jobs[0]["retry_count"], 3},
+		{"origin users", c.Users, "gofs|password|rwx"},
+		{"origin dest", c.Dest.Original(), sftpDest},
+		{"origin job encrypt_secret", c.Jobs[0]["encrypt_secret"], "job_secret"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.actual != tc.expect {
+				t.Errorf("Mask: expect to get %v, but actual get %v", tc.expect, tc.actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package conf
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/no-src/gofs/core"
+	"github.com/no-src/gofs/internal/secret"
+)
+
+// secretEnvPrefix the prefix of the environment variables that pass the secrets to the subprocess
+const secretEnvPrefix = "GOFS_SECRET_"
+
+type secretField struct {
+	key   string
+	value *string
+}
+
+type secretVFSField struct {
+	key string
+	vfs *core.VFS
+}
+
+// secretFields return the fields that contain the secrets, they support the secret references
+func (c *Config) secretFields() []secretField {
+	return []secretField{
+		{"users", &c.Users},
+		{"token_secret", &c.TokenSecret},
+		{"encrypt_secret", &c.EncryptSecret},
+		{"decrypt_secret", &c.DecryptSecret},
+		{"audit_hmac_key", &c.AuditHMACKey},
+	}
+}
+
+// secretVFSFields return the VFS fields that may contain the secrets in the parameters
+func (c *Config) secretVFSFields() []secretVFSField {
+	return []secretVFSField{
+		{"source", &c.Source},
+		{"dest", &c.Dest},
+	}
+}
+
+// ResolveSecrets resolve the secret references like env:NAME and file:/path in the secret fields,
+// the secret references in the parameters of the source and dest are resolved by the VFS
+func (c *Config) ResolveSecrets() error {
+	for _, f := range c.secretFields() {
+		value, err := secret.Resolve(*f.value)
+		if err != nil {
+			return fmt.Errorf("resolve the secret error => %s, %w", f.key, err)
+		}
+		*f.value = value
+	}
+	for _, f := range c.secretVFSFields() {
+		path := f.vfs.Original()
+		if secret.IsRef(path) {
+			value, err := secret.Resolve(path)
+			if err != nil {
+				return fmt.Errorf("resolve the secret error => %s, %w", f.key, err)
+			}
+			*f.vfs = core.NewVFS(value)
+			path = value
+		}
+		if _, err := core.CheckVFSSecret(path); err != nil {
+			return fmt.Errorf("%w => %s", err, f.key)
+		}
+	}
+	return nil
+}
+
+// hideSecrets replace the plain text secrets with the references to the environment variables,
+// and return the environment variables that store the secrets
+func (c *Config) hideSecrets() (env []string) {
+	for _, f := range c.secretFields() {
+		if len(*f.value) > 0 && !secret.IsRef(*f.value) {
+			name := secretEnvName(f.key)
+			env = append(env, name+"="+*f.value)
+			*f.value = secret.EnvRef(name)
+		}
+	}
+	for _, f := range c.secretVFSFields() {
+		path := f.vfs.Original()
+		if plain, _ := core.CheckVFSSecret(path); plain {
+			name := secretEnvName(f.key)
+			env = append(env, name+"="+path)
+			*f.vfs = core.NewVFS(secret.EnvRef(name))
+		}
+	}
+	return env
+}
+
+func secretEnvName(key string) string {
+	return secretEnvPrefix + strings.ToUpper(key)
+}
@@ -0,0 +1,104 @@
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/no-src/gofs/core"
+)
+
+func TestConfig_ResolveSecrets(t *testing.T) {
+	usersFile := filepath.Join(t.TempDir(), "users")
+	if err := os.WriteFile(usersFile, []byte("gofs|password|rwx\n"), 0600); err != nil {
+		t.Errorf("write the users file error => %v", err)
+		return
+	}
+	sftpDest := "sftp://127.0.0.1:22?remote_path=/dest&ssh_user=sftp_user&ssh_pass=env:GOFS_TEST_SSH_PASS"
+	t.Setenv("GOFS_TEST_TOKEN_SECRET", "token_secret")
+	t.Setenv("GOFS_TEST_SSH_PASS", "sftp_pwd")
+	t.Setenv("GOFS_TEST_SOURCE", "./source")
+
+	c := Config{
+		Source:        core.NewVFS("env:GOFS_TEST_SOURCE"),
+		Dest:          core.NewVFS(sftpDest),
+		Users:         "file:" + usersFile,
+		TokenSecret:   "env:GOFS_TEST_TOKEN_SECRET",
+		EncryptSecret: "encrypt_secret",
+	}
+	if err := c.ResolveSecrets(); err != nil {
+		t.Errorf("ResolveSecrets: unexpected error => %v", err)
+		return
+	}
+
+	testCases := []struct {
+		name   string
+		actual string
+		expect string
+	}{
+		{"source", c.Source.Original(), "./source"},
+		{"dest", c.Dest.Original(), sftpDest},
+		{"ssh_pass", c.Dest.SSHConfig().Password, "sftp_pwd"},
+		{"users", c.Users, "gofs|password|rwx"},
+		{"token_secret", c.TokenSecret, "token_secret"},
+		{"encrypt_secret", c.EncryptSecret, "encrypt_secret"},
+		{"decrypt_secret", c.DecryptSecret, ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.actual != tc.expect {
+				t.Errorf("ResolveSecrets: expect to get %s, but actual get %s", tc.expect, tc.actual)
+			}
+		})
+	}
+}
+
+func TestConfig_ResolveSecrets_ReturnError(t *testing.T) {
+	testCases := []struct {
+		name string
+		c    Config
+	}{
+		{"users env not found", Config{Users: "env:GOFS_TEST_NOT_FOUND"}},
+		{"token secret file not found", Config{TokenSecret: "file:" + filepath.Join(t.TempDir(), "not_found")}},
+		{"source env not found", Config{Source: core.NewVFS("env:GOFS_TEST_NOT_FOUND")}},
+		{"ssh_pass env not found", Config{Dest: core.NewVFS("sftp://127.0.0.1:22?remote_path=/dest&ssh_pass=env:GOFS_TEST_NOT_FOUND")}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.c.ResolveSecrets(); err == nil {
+				t.Errorf("ResolveSecrets: expect to get an error but get nil")
+			}
+		})
+	}
+}
+
+func TestConfig_ToArgs_ResolveSecrets(t *testing.T) {
+	sftpDest := "sftp://127.0.0.1:22?remote_path=/dest&ssh_user=sftp_user&ssh_pass=sftp_pwd"
+	c := Config{
+		Dest:  core.NewVFS(sftpDest),
+		Users: "gofs|password|rwx",
+	}
+	_, env, err := c.ToArgs()
+	if err != nil {
+		t.Errorf("parse config to arguments error, %v", err)
+		return
+	}
+	for _, kv := range env {
+		name, value, _ := strings.Cut(kv, "=")
+		t.Setenv(name, value)
+	}
+
+	// the subprocess parses the secret references from the arguments
+	sub := Config{
+		Dest:  core.NewVFS("env:GOFS_SECRET_DEST"),
+		Users: "env:GOFS_SECRET_USERS",
+	}
+	if err = sub.ResolveSecrets(); err != nil {
+		t.Errorf("ResolveSecrets: unexpected error => %v", err)
+		return
+	}
+	if sub.Users != c.Users || sub.Dest.Original() != sftpDest || sub.Dest.SSHConfig().Password != "sftp_pwd" {
+		t.Errorf("expect the subprocess to get the same secrets, but get users=%s dest=%s", sub.Users, sub.Dest.Original())
+	}
+}
@@ -0,0 +1,41 @@
+package conf
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+//go:embed template/*.yaml
+var templates embed.FS
+
+const (
+	templateDir = "template"
+	templateExt = ".yaml"
+
+	// DefaultTemplatePath the default path to write the starter config template
+	DefaultTemplatePath = "./gofs.yaml"
+)
+
+var errUnsupportedTemplateMode = errors.New("unsupported config template mode")
+
+// TemplateModes return the supported modes of the starter config templates
+func TemplateModes() (modes []string) {
+	entries, _ := fs.ReadDir(templates, templateDir)
+	for _, entry := range entries {
+		modes = append(modes, strings.TrimSuffix(entry.Name(), templateExt))
+	}
+	return modes
+}
+
+// Template return the annotated starter config template of the specified mode in yaml format
+func Template(mode string) ([]byte, error) {
+	data, err := templates.ReadFile(path.Join(templateDir, mode+templateExt))
+	if err != nil {
+		return nil, fmt.Errorf("%w => %s, supported modes: %s", errUnsupportedTemplateMode, mode, strings.Join(TemplateModes(), ", "))
+	}
+	return data, nil
+}
@@ -0,0 +1,39 @@
+package conf
+
+import (
+	"testing"
+)
+
+func TestTemplate(t *testing.T) {
+	modes := TemplateModes()
+	if len(modes) == 0 {
+		t.Errorf("TemplateModes: expect to get the template modes, but get nothing")
+		return
+	}
+	for _, mode := range modes {
+		t.Run(mode, func(t *testing.T) {
+			data, err := Template(mode)
+			if err != nil {
+				t.Errorf("Template: unexpected error => %v", err)
+				return
+			}
+			var c Config
+			if err = ParseContent(data, templateExt, &c); err != nil {
+				t.Errorf("Template: parse the template error => %v", err)
+				return
+			}
+			if len(c.Source.Original()) == 0 && len(c.Jobs) == 0 {
+				t.Errorf("Template: expect to get the source or the jobs in the template")
+			}
+			if _, err = c.ResolveJobs(); err != nil {
+				t.Errorf("Template: resolve the jobs error => %v", err)
+			}
+		})
+	}
+}
+
+func TestTemplate_ReturnError(t *testing.T) {
+	if _, err := Template("not_found"); err == nil {
+		t.Errorf("Template: expect to get an error but get nil")
+	}
+}
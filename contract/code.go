@@ -28,6 +28,18 @@ const (
 	Modified Code = -9
 	// ChunkModified the chunk is modified
 	ChunkModified Code = -10
+	// QuotaExceeded the storage quota is exceeded
+	QuotaExceeded Code = -11
+	// NoSpace the free space of the disk is not enough
+	NoSpace Code = -12
+	// LoginLocked the login attempt is rejected because of too many failed login attempts
+	LoginLocked Code = -13
+	// InvalidCSRFToken the state-changing request is rejected because the CSRF token is missing or invalid
+	InvalidCSRFToken Code = -14
+	// TooManyRequests the request is rejected because the client ip exceeds the connection limit or the request rate limit
+	TooManyRequests Code = -15
+	// AlreadyExists the file or the directory already exists
+	AlreadyExists Code = -16
 )
 
 const (
@@ -55,6 +67,18 @@ const (
 	ModifiedDesc = "modified"
 	// ChunkModifiedDesc the description of ChunkModified code
 	ChunkModifiedDesc = "chunk modified"
+	// QuotaExceededDesc the description of QuotaExceeded code
+	QuotaExceededDesc = "quota exceeded"
+	// NoSpaceDesc the description of NoSpace code
+	NoSpaceDesc = "no space left"
+	// LoginLockedDesc the description of LoginLocked code
+	LoginLockedDesc = "login locked"
+	// InvalidCSRFTokenDesc the description of InvalidCSRFToken code
+	InvalidCSRFTokenDesc = "invalid csrf token"
+	// TooManyRequestsDesc the description of TooManyRequests code
+	TooManyRequestsDesc = "too many requests"
+	// AlreadyExistsDesc the description of AlreadyExists code
+	AlreadyExistsDesc = "already exists"
 )
 
 // String return the code description name
@@ -85,6 +109,18 @@ func (code Code) String() string {
 		desc = ModifiedDesc
 	case ChunkModified:
 		desc = ChunkModifiedDesc
+	case QuotaExceeded:
+		desc = QuotaExceededDesc
+	case NoSpace:
+		desc = NoSpaceDesc
+	case LoginLocked:
+		desc = LoginLockedDesc
+	case InvalidCSRFToken:
+		desc = InvalidCSRFTokenDesc
+	case TooManyRequests:
+		desc = TooManyRequestsDesc
+	case AlreadyExists:
+		desc = AlreadyExistsDesc
 	default:
 		desc = UnknownDesc
 	}
@@ -20,6 +20,12 @@ func TestCode(t *testing.T) {
 		{ChunkNotModified, ChunkNotModifiedDesc},
 		{Modified, ModifiedDesc},
 		{ChunkModified, ChunkModifiedDesc},
+		{QuotaExceeded, QuotaExceededDesc},
+		{NoSpace, NoSpaceDesc},
+		{LoginLocked, LoginLockedDesc},
+		{InvalidCSRFToken, InvalidCSRFTokenDesc},
+		{TooManyRequests, TooManyRequestsDesc},
+		{AlreadyExists, AlreadyExistsDesc},
 	}
 
 	for _, tc := range testCases {
@@ -0,0 +1,47 @@
+package contract
+
+import (
+	"io/fs"
+	"path"
+	"time"
+)
+
+// fileStat an implementation of fs.FileInfo that is described by the FileInfo
+type fileStat struct {
+	fi FileInfo
+}
+
+// Stat returns a fs.FileInfo describing the current FileInfo
+func (fi FileInfo) Stat() fs.FileInfo {
+	return fileStat{fi: fi}
+}
+
+func (s fileStat) Name() string {
+	return path.Base(s.fi.Path)
+}
+
+func (s fileStat) Size() int64 {
+	return s.fi.Size
+}
+
+func (s fileStat) Mode() fs.FileMode {
+	if s.fi.IsDir.Bool() {
+		return fs.ModeDir
+	}
+	if len(s.fi.LinkTo) > 0 {
+		return fs.ModeSymlink
+	}
+	return 0
+}
+
+func (s fileStat) ModTime() time.Time {
+	return time.Unix(s.fi.MTime, 0)
+}
+
+func (s fileStat) IsDir() bool {
+	return s.fi.IsDir.Bool()
+}
+
+func (s fileStat) Sys() any {
+	return nil
+}
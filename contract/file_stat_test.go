@@ -0,0 +1,45 @@
+package contract
+
+import (
+	"io/fs"
+	"testing"
+	"time"
+)
+
+func TestFileInfo_Stat(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		name  string
+		fi    FileInfo
+		mode  fs.FileMode
+		isDir bool
+	}{
+		{"hello.txt", FileInfo{Path: "/workspace/hello.txt", IsDir: FsNotDir, Size: 1024, MTime: now.Unix()}, 0, false},
+		{"workspace", FileInfo{Path: "/workspace", IsDir: FsIsDir, MTime: now.Unix()}, fs.ModeDir, true},
+		{"link.txt", FileInfo{Path: "/workspace/link.txt", IsDir: FsNotDir, MTime: now.Unix(), LinkTo: "hello.txt"}, fs.ModeSymlink, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stat := tc.fi.Stat()
+			if stat.Name() != tc.name {
+				t.Errorf("Stat().Name() expect: %s, but actual: %s", tc.name, stat.Name())
+			}
+			if stat.Size() != tc.fi.Size {
+				t.Errorf("Stat().Size() expect: %d, but actual: %d", tc.fi.Size, stat.Size())
+			}
+			if stat.Mode() != tc.mode {
+				t.Errorf("Stat().Mode() expect: %v, but actual: %v", tc.mode, stat.Mode())
+			}
+			if stat.IsDir() != tc.isDir {
+				t.Errorf("Stat().IsDir() expect: %v, but actual: %v", tc.isDir, stat.IsDir())
+			}
+			if stat.ModTime().Unix() != tc.fi.MTime {
+				t.Errorf("Stat().ModTime() expect: %d, but actual: %d", tc.fi.MTime, stat.ModTime().Unix())
+			}
+			if stat.Sys() != nil {
+				t.Errorf("Stat().Sys() expect: nil, but actual: %v", stat.Sys())
+			}
+		})
+	}
+}
@@ -25,6 +25,7 @@ type VFS struct {
 	localSyncDisabled bool
 	secure            bool
 	sshConf           SSHConfig
+	err               error
 }
 
 const (
@@ -51,6 +52,11 @@ const (
 	minIOServerDefaultPort  = 9000
 )
 
+// Original the original path that is used to create the VFS
+func (vfs *VFS) Original() string {
+	return vfs.original
+}
+
 // Path the local file path
 func (vfs *VFS) Path() Path {
 	return vfs.path
@@ -126,6 +132,11 @@ func (vfs *VFS) SSHConfig() SSHConfig {
 	return vfs.sshConf
 }
 
+// Err return the error of parsing the path, the VFS is unknown if the error is not nil
+func (vfs *VFS) Err() error {
+	return vfs.err
+}
+
 // NewDiskVFS create an instance of VFS for the local disk file system
 func NewDiskVFS(path string) VFS {
 	vfs := VFS{
@@ -161,7 +172,10 @@ func NewVFS(path string) VFS {
 		_, vfs.host, vfs.port, vfs.path, vfs.remotePath, vfs.server, vfs.fsServer, vfs.localSyncDisabled, vfs.secure, _, err = parse(path, vfs.fsType)
 	}
 	if err != nil {
-		return NewEmptyVFS()
+		// keep the original path and the error to report the invalid path
+		vfs = NewEmptyVFS()
+		vfs.original = path
+		vfs.err = err
 	}
 	return vfs
 }
@@ -237,12 +251,12 @@ func parse(path string, fsType VFSType) (scheme string, host string, port int, l
 	if len(usernameFromUrl) > 0 {
 		sshConf.Username = usernameFromUrl
 	}
-	sshConf.Password = strings.TrimSpace(parseUrl.Query().Get(paramSSHPassword))
+	sshConf.Password = resolveSecretParam(parseUrl.Query(), paramSSHPassword)
 	keyFromUrl := strings.TrimSpace(parseUrl.Query().Get(paramSSHKey))
 	if len(keyFromUrl) > 0 {
 		sshConf.Key = keyFromUrl
 	}
-	sshConf.KeyPass = strings.TrimSpace(parseUrl.Query().Get(paramSSHKeyPassphrase))
+	sshConf.KeyPass = resolveSecretParam(parseUrl.Query(), paramSSHKeyPassphrase)
 	sshConf.HostKey = strings.TrimSpace(parseUrl.Query().Get(paramSSHHostKey))
 
 	if fsType == SFTP {
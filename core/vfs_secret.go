@@ -0,0 +1,65 @@
+package core
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/no-src/gofs/internal/secret"
+	"github.com/no-src/gofs/logger"
+)
+
+// secretParams the parameters of the VFS that contain the secrets, they support the secret references
+var secretParams = []string{paramSSHPassword, paramSSHKeyPassphrase}
+
+// CheckVFSSecret check that all the secret references in the parameters of the VFS path can be resolved,
+// and report whether the path contains any plain text secret
+func CheckVFSSecret(path string) (plain bool, err error) {
+	parseUrl, parseErr := url.Parse(path)
+	if parseErr != nil {
+		return false, nil
+	}
+	query := parseUrl.Query()
+	for _, param := range secretParams {
+		value := strings.TrimSpace(query.Get(param))
+		if len(value) == 0 {
+			continue
+		}
+		if !secret.IsRef(value) {
+			plain = true
+			continue
+		}
+		if _, err = secret.Resolve(value); err != nil {
+			return plain, fmt.Errorf("resolve the secret of the parameter error => %s, %w", param, err)
+		}
+	}
+	return plain, nil
+}
+
+// resolveSecretParam resolve the secret reference in the specified parameter,
+// return empty string if it can not be resolved, the error is reported by CheckVFSSecret
+func resolveSecretParam(query url.Values, param string) string {
+	value, err := secret.Resolve(strings.TrimSpace(query.Get(param)))
+	if err != nil {
+		logger.InnerLogger().Error(err, "resolve the secret of the parameter error => %s", param)
+		return ""
+	}
+	return value
+}
+
+// MaskVFSSecret replace the values of the secret parameters in the VFS path with the mask
+func MaskVFSSecret(path string, mask string) string {
+	before, rawQuery, found := strings.Cut(path, "?")
+	if !found {
+		return path
+	}
+	params := strings.Split(rawQuery, "&")
+	for i, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		if slices.Contains(secretParams, key) {
+			params[i] = key + "=" + mask
+		}
+	}
+	return before + "?" + strings.Join(params, "&")
+}
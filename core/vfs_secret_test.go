@@ -0,0 +1,74 @@
+package core
+
+import (
+	"testing"
+)
+
+const (
+	testVFSSFTPDestPathWithSecretRef         = "sftp://127.0.0.1:22?remote_path=/home/remote/dest&ssh_user=sftp_user&ssh_pass=env:GOFS_TEST_SSH_PASS&ssh_key_pass=env:GOFS_TEST_SSH_KEY_PASS"
+	testVFSSFTPDestPathWithNotFoundSecretRef = "sftp://127.0.0.1:22?remote_path=/home/remote/dest&ssh_user=sftp_user&ssh_pass=env:GOFS_TEST_SSH_PASS_NOT_FOUND"
+)
+
+func TestNewVFS_WithSecretRef(t *testing.T) {
+	t.Setenv("GOFS_TEST_SSH_PASS", "sftp_pwd")
+	t.Setenv("GOFS_TEST_SSH_KEY_PASS", "123456")
+	vfs := NewVFS(testVFSSFTPDestPathWithSecretRef)
+	sshConf := vfs.SSHConfig()
+	if sshConf.Password != "sftp_pwd" || sshConf.KeyPass != "123456" {
+		t.Errorf("NewVFS: expect to resolve the secret references, but actual get password=%s key_pass=%s", sshConf.Password, sshConf.KeyPass)
+	}
+	if vfs.Original() != testVFSSFTPDestPathWithSecretRef {
+		t.Errorf("NewVFS: expect to keep the secret references in the original path, but actual get %s", vfs.Original())
+	}
+}
+
+func TestCheckVFSSecret(t *testing.T) {
+	t.Setenv("GOFS_TEST_SSH_PASS", "sftp_pwd")
+	t.Setenv("GOFS_TEST_SSH_KEY_PASS", "123456")
+	testCases := []struct {
+		name      string
+		path      string
+		expect    bool
+		expectErr bool
+	}{
+		{"empty", "", false, false},
+		{"disk", "./source", false, false},
+		{"no secret", testVFSMinIODestPath, false, false},
+		{"plain secret", testVFSSFTPDestPath, true, false},
+		{"secret reference", testVFSSFTPDestPathWithSecretRef, false, false},
+		{"secret reference not found", testVFSSFTPDestPathWithNotFoundSecretRef, false, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			plain, err := CheckVFSSecret(tc.path)
+			if (err != nil) != tc.expectErr {
+				t.Errorf("CheckVFSSecret: expect error %v, but actual get %v", tc.expectErr, err)
+				return
+			}
+			if plain != tc.expect {
+				t.Errorf("CheckVFSSecret: expect to get %v, but actual get %v", tc.expect, plain)
+			}
+		})
+	}
+}
+
+func TestMaskVFSSecret(t *testing.T) {
+	testCases := []struct {
+		name   string
+		path   string
+		expect string
+	}{
+		{"empty", "", ""},
+		{"disk", "./source", "./source"},
+		{"no secret", testVFSMinIODestPath, testVFSMinIODestPath},
+		{"plain secret", "sftp://127.0.0.1:22?remote_path=/dest&ssh_user=sftp_user&ssh_pass=sftp_pwd&ssh_key_pass=123456", "sftp://127.0.0.1:22?remote_path=/dest&ssh_user=sftp_user&ssh_pass=***&ssh_key_pass=***"},
+		{"secret reference", testVFSSFTPDestPathWithSecretRef, "sftp://127.0.0.1:22?remote_path=/home/remote/dest&ssh_user=sftp_user&ssh_pass=***&ssh_key_pass=***"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := MaskVFSSecret(tc.path, "***"); actual != tc.expect {
+				t.Errorf("MaskVFSSecret: expect to get %s, but actual get %s", tc.expect, actual)
+			}
+		})
+	}
+}
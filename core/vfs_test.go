@@ -141,7 +141,13 @@ func TestNewVFS_ReturnError(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.path, func(t *testing.T) {
-			compareVFS(t, tc.expect, NewVFS(tc.path))
+			// keep the original path of the invalid VFS
+			tc.expect.original = tc.path
+			actual := NewVFS(tc.path)
+			compareVFS(t, tc.expect, actual)
+			if actual.Err() == nil {
+				t.Errorf("NewVFS: expect to get an error but get nil")
+			}
 		})
 	}
 }
@@ -2,8 +2,11 @@ package daemon
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/no-src/gofs/logger"
@@ -14,10 +17,13 @@ import (
 // SubprocessTag mark the current process is subprocess
 const SubprocessTag = "sub"
 
+var errSubprocessNotFound = errors.New("the worker subprocess is not found")
+
 // Daemon support to running daemon process and create subprocess for working
 type Daemon struct {
-	shutdown chan struct{}
-	logger   *logger.Logger
+	shutdown   chan struct{}
+	subprocess atomic.Pointer[os.Process]
+	logger     *logger.Logger
 }
 
 // New create an instance of Daemon
@@ -31,8 +37,9 @@ func New(l *logger.Logger) *Daemon {
 	}
 }
 
-// Run running as a daemon process, and create a subprocess for working, the first argument must be an absolute path of the program name
-func (d *Daemon) Run(args []string, recordPid bool, daemonDelay time.Duration, monitorDelay time.Duration, wd wait.Done) {
+// Run running as a daemon process, and create a subprocess for working, the first argument must be an absolute path of the program name,
+// the env is appended to the environment variables of the subprocess
+func (d *Daemon) Run(args []string, env []string, recordPid bool, daemonDelay time.Duration, monitorDelay time.Duration, wd wait.Done) {
 	defer func() {
 		if r := recover(); r != nil {
 			err := fmt.Errorf("daemon process error. %v", r)
@@ -47,7 +54,8 @@ func (d *Daemon) Run(args []string, recordPid bool, daemonDelay time.Duration, m
 			d.logger.Info("daemon exited by shutdown")
 			return
 		}
-		p, err := d.startSubprocess(args)
+		p, err := d.startSubprocess(args, env)
+		d.subprocess.Store(p)
 		if err == nil && p != nil {
 			if recordPid {
 				if err = d.writePidFile(os.Getppid(), os.Getpid(), p.Pid); err != nil {
@@ -64,7 +72,7 @@ func (d *Daemon) Run(args []string, recordPid bool, daemonDelay time.Duration, m
 }
 
 // startSubprocess start a subprocess for working
-func (d *Daemon) startSubprocess(args []string) (*os.Process, error) {
+func (d *Daemon) startSubprocess(args []string, env []string) (*os.Process, error) {
 	attr := &os.ProcAttr{Files: []*os.File{os.Stdin, os.Stdout, os.Stderr}}
 	// try to check stdin
 	// if compile with [-ldflags="-H windowsgui"] on Windows system, stdin will get error
@@ -74,6 +82,7 @@ func (d *Daemon) startSubprocess(args []string) (*os.Process, error) {
 			attr = &os.ProcAttr{Files: []*os.File{nil, nil, nil}}
 		}
 	}
+	attr.Env = append(os.Environ(), env...)
 	// use "-sub" to tag sub process
 	args = append(args, "-"+SubprocessTag)
 	p, err := os.StartProcess(args[0], args, attr)
@@ -167,6 +176,16 @@ func (d *Daemon) Shutdown() (err error) {
 	return err
 }
 
+// Reload forward the SIGHUP signal to the current worker subprocess to reload the config
+func (d *Daemon) Reload() error {
+	p := d.subprocess.Load()
+	if p == nil {
+		return errSubprocessNotFound
+	}
+	d.logger.Info("[%d] forward the reload signal to the subprocess", p.Pid)
+	return p.Signal(syscall.SIGHUP)
+}
+
 func (d *Daemon) waitShutdown(du time.Duration) (isShutdown bool) {
 	select {
 	case <-d.shutdown:
@@ -1,6 +1,7 @@
 package driver
 
 import (
+	"io"
 	"io/fs"
 	"net/http"
 	"time"
@@ -36,6 +37,8 @@ type Driver interface {
 	GetFileTime(path string) (cTime time.Time, aTime time.Time, mTime time.Time, err error)
 	// Write write src file to dest file
 	Write(src string, dest string) error
+	// WriteStream write the stream of unknown size to dest file, the open function is called again to retry
+	WriteStream(dest string, open func() (io.ReadCloser, error)) error
 	// ReadLink returns the destination of the named symbolic link
 	ReadLink(path string) (string, error)
 }
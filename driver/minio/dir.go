@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/minio/minio-go/v7"
+	"github.com/no-src/gofs/internal/rate"
 	"github.com/no-src/gofs/logger"
 	"github.com/no-src/gofs/retry"
 )
@@ -19,7 +20,7 @@ type Dir struct {
 }
 
 // NewDir returns a http.FileSystem instance for MinIO
-func NewDir(bucketName string, endpoint string, secure bool, userName string, password string, r retry.Retry, maxTranRate int64, logger *logger.Logger) (http.FileSystem, error) {
+func NewDir(bucketName string, endpoint string, secure bool, userName string, password string, r retry.Retry, maxTranRate *rate.Limit, logger *logger.Logger) (http.FileSystem, error) {
 	bucketName = strings.TrimSpace(bucketName)
 	if len(bucketName) == 0 {
 		return nil, errors.New("the bucket can't be empty")
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"mime"
 	"net/http"
@@ -24,6 +25,9 @@ import (
 	"github.com/no-src/nsgo/fsutil"
 )
 
+// streamPartSize the part size to upload the stream of unknown size, the minimum part size of MinIO is 5 MiB
+const streamPartSize = 16 * 1024 * 1024
+
 // minIODriver a MinIO driver component, support auto reconnect
 type minIODriver struct {
 	client        *minio.Client
@@ -38,16 +42,16 @@ type minIODriver struct {
 	online        bool
 	autoReconnect bool
 	ctx           context.Context
-	maxTranRate   int64
+	maxTranRate   *rate.Limit
 	logger        *logger.Logger
 }
 
 // NewMinIODriver get a MinIO driver
-func NewMinIODriver(endpoint string, bucketName string, secure bool, userName string, password string, autoReconnect bool, r retry.Retry, maxTranRate int64, logger *logger.Logger) driver.Driver {
+func NewMinIODriver(endpoint string, bucketName string, secure bool, userName string, password string, autoReconnect bool, r retry.Retry, maxTranRate *rate.Limit, logger *logger.Logger) driver.Driver {
 	return newMinIODriver(endpoint, bucketName, secure, userName, password, autoReconnect, r, maxTranRate, logger)
 }
 
-func newMinIODriver(endpoint string, bucketName string, secure bool, userName string, password string, autoReconnect bool, r retry.Retry, maxTranRate int64, logger *logger.Logger) *minIODriver {
+func newMinIODriver(endpoint string, bucketName string, secure bool, userName string, password string, autoReconnect bool, r retry.Retry, maxTranRate *rate.Limit, logger *logger.Logger) *minIODriver {
 	return &minIODriver{
 		driverName:    "minio",
 		endpoint:      endpoint,
@@ -202,7 +206,7 @@ func (c *minIODriver) Open(path string) (f http.File, err error) {
 		var obj *minio.Object
 		obj, err = c.client.GetObject(c.ctx, c.bucketName, path, minio.GetObjectOptions{})
 		if err == nil {
-			f = rate.NewFile(newFile(obj, c.client, c.bucketName, path), c.maxTranRate, c.logger)
+			f = rate.NewFile(newFile(obj, c.client, c.bucketName, path), c.maxTranRate.Bytes(), c.logger)
 		}
 		return err
 	})
@@ -304,6 +308,23 @@ func (c *minIODriver) Write(src string, dest string) (err error) {
 	})
 }
 
+func (c *minIODriver) WriteStream(dest string, open func() (io.ReadCloser, error)) (err error) {
+	return c.reconnectIfLost(func() error {
+		var r io.ReadCloser
+		r, err = open()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		// the size is unknown, upload the stream by multipart with a limited part size to reduce the memory usage
+		_, err = c.client.PutObject(c.ctx, c.bucketName, dest, rate.NewReader(r, c.maxTranRate.Bytes(), c.logger), -1, minio.PutObjectOptions{
+			ContentType: "application/octet-stream",
+			PartSize:    streamPartSize,
+		})
+		return err
+	})
+}
+
 func (c *minIODriver) Client() *minio.Client {
 	return c.client
 }
@@ -347,7 +368,7 @@ func (c *minIODriver) fPutObject(ctx context.Context, bucketName, objectName, fi
 			opts.ContentType = "application/octet-stream"
 		}
 	}
-	return c.client.PutObject(ctx, bucketName, objectName, rate.NewReader(fileReader, c.maxTranRate, c.logger), fileSize, opts)
+	return c.client.PutObject(ctx, bucketName, objectName, rate.NewReader(fileReader, c.maxTranRate.Bytes(), c.logger), fileSize, opts)
 }
 
 func (c *minIODriver) trimPath(path string) string {
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/no-src/gofs/core"
+	"github.com/no-src/gofs/internal/rate"
 	"github.com/no-src/gofs/logger"
 	"github.com/no-src/gofs/retry"
 )
@@ -19,7 +20,7 @@ type Dir struct {
 }
 
 // NewDir returns a http.FileSystem instance for sftp
-func NewDir(root string, address string, sshConfig core.SSHConfig, r retry.Retry, maxTranRate int64, logger *logger.Logger) (http.FileSystem, error) {
+func NewDir(root string, address string, sshConfig core.SSHConfig, r retry.Retry, maxTranRate *rate.Limit, logger *logger.Logger) (http.FileSystem, error) {
 	root = strings.TrimSpace(root)
 	if len(root) == 0 {
 		root = "."
@@ -29,16 +29,16 @@ type sftpDriver struct {
 	mu            sync.RWMutex
 	online        bool
 	autoReconnect bool
-	maxTranRate   int64
+	maxTranRate   *rate.Limit
 	logger        *logger.Logger
 }
 
 // NewSFTPDriver get a sftp driver
-func NewSFTPDriver(remoteAddr string, sshConfig core.SSHConfig, autoReconnect bool, r retry.Retry, maxTranRate int64, logger *logger.Logger) driver.Driver {
+func NewSFTPDriver(remoteAddr string, sshConfig core.SSHConfig, autoReconnect bool, r retry.Retry, maxTranRate *rate.Limit, logger *logger.Logger) driver.Driver {
 	return newSFTPDriver(remoteAddr, sshConfig, autoReconnect, r, maxTranRate, logger)
 }
 
-func newSFTPDriver(remoteAddr string, sshConfig core.SSHConfig, autoReconnect bool, r retry.Retry, maxTranRate int64, logger *logger.Logger) *sftpDriver {
+func newSFTPDriver(remoteAddr string, sshConfig core.SSHConfig, autoReconnect bool, r retry.Retry, maxTranRate *rate.Limit, logger *logger.Logger) *sftpDriver {
 	return &sftpDriver{
 		driverName:    "sftp",
 		remoteAddr:    remoteAddr,
@@ -256,7 +256,7 @@ func (sd *sftpDriver) Open(path string) (f http.File, err error) {
 		var sftpFile *sftp.File
 		sftpFile, err = sd.client.Open(path)
 		if err == nil {
-			f = rate.NewFile(newFile(sftpFile, sd, path), sd.maxTranRate, sd.logger)
+			f = rate.NewFile(newFile(sftpFile, sd, path), sd.maxTranRate.Bytes(), sd.logger)
 		}
 		return err
 	})
@@ -336,7 +336,30 @@ func (sd *sftpDriver) Write(src string, dest string) (err error) {
 		}
 		defer destFile.Close()
 
-		_, err = io.Copy(destFile, rate.NewReader(srcFile, sd.maxTranRate, sd.logger))
+		_, err = io.Copy(destFile, rate.NewReader(srcFile, sd.maxTranRate.Bytes(), sd.logger))
+		return err
+	})
+	return err
+}
+
+func (sd *sftpDriver) WriteStream(dest string, open func() (io.ReadCloser, error)) (err error) {
+	err = sd.reconnectIfLost(func() error {
+		var r io.ReadCloser
+		r, err = open()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		var destFile *sftp.File
+		// the stream is written from the beginning, truncate the existing content
+		destFile, err = sd.client.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+		if err != nil {
+			return err
+		}
+		defer destFile.Close()
+
+		_, err = io.Copy(destFile, rate.NewReader(r, sd.maxTranRate.Bytes(), sd.logger))
 		return err
 	})
 	return err
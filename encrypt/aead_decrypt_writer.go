@@ -0,0 +1,63 @@
+package encrypt
+
+import (
+	"crypto/cipher"
+	"fmt"
+	"io"
+)
+
+type aeadDecryptWriter struct {
+	w         io.Writer
+	aead      cipher.AEAD
+	header    []byte
+	chunkSize int
+	buf       []byte
+	counter   uint64
+}
+
+func (w *aeadDecryptWriter) Write(p []byte) (nn int, err error) {
+	w.buf = append(w.buf, p...)
+	// keep at least one chunk in the buffer until Close, because the last chunk must be opened with the last chunk flag
+	for len(w.buf) > w.chunkSize {
+		if err = w.open(w.buf[:w.chunkSize], false); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[:copy(w.buf, w.buf[w.chunkSize:])]
+	}
+	return len(p), nil
+}
+
+func (w *aeadDecryptWriter) open(chunk []byte, last bool) error {
+	nonce := chunkNonce(w.aead.NonceSize(), w.counter, last)
+	plain, err := w.aead.Open(nil, nonce, chunk, w.header)
+	if err != nil {
+		return fmt.Errorf("%w => chunk %d", errAuthFailed, w.counter)
+	}
+	w.counter++
+	_, err = w.w.Write(plain)
+	return err
+}
+
+// Close open the last chunk, return an error if the content is truncated or tampered
+func (w *aeadDecryptWriter) Close() error {
+	if len(w.buf) < w.aead.Overhead() {
+		return errTruncated
+	}
+	return w.open(w.buf, true)
+}
+
+// newAEADDecryptWriter create a decryption writer of the format v2, the header must be read from the content before
+func newAEADDecryptWriter(w io.Writer, h *formatHeader, key []byte) (io.WriteCloser, error) {
+	aead, err := h.newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	chunkSize := int(h.chunkSize) + aead.Overhead()
+	return &aeadDecryptWriter{
+		w:         w,
+		aead:      aead,
+		header:    h.Bytes(),
+		chunkSize: chunkSize,
+		buf:       make([]byte, 0, chunkSize*2),
+	}, nil
+}
@@ -0,0 +1,87 @@
+package encrypt
+
+import (
+	"archive/zip"
+	"bufio"
+	"crypto/cipher"
+	"io"
+)
+
+type aeadEncryptWriter struct {
+	bw        *bufio.Writer
+	zw        *zip.Writer
+	aead      cipher.AEAD
+	header    []byte
+	chunkSize int
+	buf       []byte
+	counter   uint64
+}
+
+func (w *aeadEncryptWriter) Write(p []byte) (nn int, err error) {
+	for len(p) > 0 {
+		// seal the full chunk only when more data is coming, so the last chunk is always sealed in Close
+		if len(w.buf) == w.chunkSize {
+			if err = w.seal(false); err != nil {
+				return nn, err
+			}
+		}
+		n := min(w.chunkSize-len(w.buf), len(p))
+		w.buf = append(w.buf, p[:n]...)
+		p = p[n:]
+		nn += n
+	}
+	return nn, nil
+}
+
+func (w *aeadEncryptWriter) seal(last bool) error {
+	nonce := chunkNonce(w.aead.NonceSize(), w.counter, last)
+	_, err := w.bw.Write(w.aead.Seal(nil, nonce, w.buf, w.header))
+	w.buf = w.buf[:0]
+	w.counter++
+	return err
+}
+
+func (w *aeadEncryptWriter) Close() error {
+	if err := w.seal(true); err != nil {
+		return err
+	}
+	if err := w.bw.Flush(); err != nil {
+		return err
+	}
+	return w.zw.Close()
+}
+
+// newAEADEncryptWriter create an encryption writer of the format v2, the content is encrypted with AES-256-GCM
+// in authenticated chunks by a per-file key derived from the key and a random salt,
+// the key id is recorded in the header, it is empty if the key is not from the keyfile
+func newAEADEncryptWriter(w io.Writer, name string, key []byte, keyID []byte) (io.WriteCloser, error) {
+	h, err := newFormatHeader(keyID)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := h.newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	zw := zip.NewWriter(w)
+	ew, err := zw.CreateHeader(&zip.FileHeader{
+		Name:   name,
+		Method: zip.Store,
+	})
+	if err != nil {
+		return nil, err
+	}
+	header := h.Bytes()
+	bw := bufio.NewWriter(ew)
+	if _, err = bw.Write(header); err != nil {
+		return nil, err
+	}
+	return &aeadEncryptWriter{
+		bw:        bw,
+		zw:        zw,
+		aead:      aead,
+		header:    header,
+		chunkSize: int(h.chunkSize),
+		buf:       make([]byte, 0, h.chunkSize),
+	}, nil
+}
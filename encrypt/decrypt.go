@@ -2,10 +2,17 @@ package encrypt
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"io/fs"
+	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
+	"github.com/no-src/gofs/age"
+	"github.com/no-src/gofs/keyring"
 	"github.com/no-src/nsgo/fsutil"
 )
 
@@ -13,23 +20,57 @@ var (
 	errDecryptOutNotDir = errors.New("the decrypt output path must be directory")
 	errIllegalPath      = errors.New("illegal file path")
 	errNotSubDir        = errors.New("the encrypt path is not a subdirectory of the source path")
+	errKeyFileRequired  = errors.New("the file is encrypted by a key of the keyfile, see the -keyfile flag")
 )
 
 // Decrypt the decryption component
 type Decrypt struct {
-	opt Option
+	opt        Option
+	kr         *keyring.KeyRing
+	names      nameCiphers
+	identities []*age.X25519Identity
+	fsys       http.FileSystem
+	root       string
 }
 
-// NewDecrypt create a decryption component
+// NewDecrypt create a decryption component to decrypt the files of the local decrypt path
 func NewDecrypt(opt Option) (*Decrypt, error) {
+	return NewDecryptFS(opt, nil, "")
+}
+
+// NewDecryptFS create a decryption component to decrypt the files of the root in the file system,
+// such as the file system of the sftp server or the MinIO server, the local decrypt path is used if the file system is nil
+func NewDecryptFS(opt Option, fsys http.FileSystem, root string) (*Decrypt, error) {
+	dec := &Decrypt{
+		opt:  opt,
+		fsys: fsys,
+		root: root,
+	}
 	if opt.Decrypt {
-		if err := checkAESKey(opt.DecryptSecret); err != nil {
+		var err error
+		if len(opt.DecryptIdentity) > 0 {
+			if dec.identities, err = age.LoadIdentities(opt.DecryptIdentity); err != nil {
+				return nil, err
+			}
+			// the secret is optional if all the files are encrypted to the recipients
+			if len(opt.DecryptSecret) == 0 {
+				return dec, nil
+			}
+		}
+		if len(opt.KeyFile) == 0 {
+			err = checkAESKey(opt.DecryptSecret)
+		} else {
+			dec.kr, err = keyring.Load(opt.KeyFile)
+		}
+		if err != nil {
+			return nil, err
+		}
+		// the file names are decrypted automatically if they are encrypted
+		if dec.names, err = newNameCiphers(opt.DecryptSecret, dec.kr); err != nil {
 			return nil, err
 		}
 	}
-	return &Decrypt{
-		opt: opt,
-	}, nil
+	return dec, nil
 }
 
 // Decrypt uses the decryption option to decrypt the files
@@ -50,23 +91,67 @@ func (dec *Decrypt) Decrypt() error {
 	if !isDir {
 		return errDecryptOutNotDir
 	}
-	return filepath.WalkDir(dec.opt.DecryptPath, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
+	fsys, root := dec.fsys, dec.root
+	if fsys == nil {
+		if fsys, root, err = localDir(dec.opt.DecryptPath); err != nil {
 			return err
 		}
-		if d.IsDir() {
-			return nil
-		}
-		r, err := newDecryptReader(path, dec.opt.DecryptSecret, dec.opt.Logger)
-		if err != nil {
-			return err
-		}
-		rel, err := filepath.Rel(dec.opt.DecryptPath, path)
-		if err != nil {
-			return err
+	}
+	root = path.Clean("/" + root)
+	return walkDir(fsys, root, func(name string, info fs.FileInfo) error {
+		rel := strings.TrimPrefix(strings.TrimPrefix(name, root), "/")
+		// the decrypt path is the file itself
+		if len(rel) == 0 {
+			rel = path.Base(name)
 		}
-		outPath := filepath.Join(dec.opt.DecryptOut, rel)
-		outPath = filepath.Dir(outPath)
-		return r.WriteTo(outPath)
+		return dec.decryptFile(fsys, name, filepath.FromSlash(rel))
+	})
+}
+
+// decryptFile decrypt the encryption file of the file system, the file is read by the random access,
+// so the remote file is decrypted without downloading it to the local disk first
+func (dec *Decrypt) decryptFile(fsys http.FileSystem, name string, rel string) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	ra := newReaderAt(f)
+	isAge, err := isAgeReaderAt(ra)
+	if err != nil {
+		return err
+	}
+	if isAge {
+		return dec.decryptAgeFile(io.NewSectionReader(ra, 0, stat.Size()), name, rel)
+	}
+	if len(dec.opt.DecryptSecret) == 0 {
+		return fmt.Errorf("%w => %s", errSecretRequired, name)
+	}
+	r, err := newDecryptReader(ra, stat.Size(), dec.opt.DecryptSecret, dec.kr, dec.names, dec.opt.DecryptLegacy, dec.opt.Logger)
+	if err != nil {
+		return err
+	}
+	outPath := filepath.Join(dec.opt.DecryptOut, dec.names.decryptPath(rel))
+	return r.WriteTo(filepath.Dir(outPath))
+}
+
+// decryptAgeFile decrypt the file that is encrypted to the recipients, the age file has no file name inside,
+// so the output path is the relative path of the file
+func (dec *Decrypt) decryptAgeFile(r io.Reader, name string, rel string) error {
+	outPath := filepath.Join(dec.opt.DecryptOut, dec.names.decryptPath(rel))
+	if err := os.MkdirAll(filepath.Dir(outPath), fs.ModePerm); err != nil {
+		return err
+	}
+	err := writeFile(outPath, dec.opt.Logger, func(w io.Writer) error {
+		return decryptAge(w, r, dec.identities)
 	})
+	if err != nil {
+		return fmt.Errorf("%w => %s", err, name)
+	}
+	dec.opt.Logger.Info("save decryption file success => %s", outPath)
+	return nil
 }
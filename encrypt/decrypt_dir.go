@@ -0,0 +1,95 @@
+package encrypt
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/no-src/gofs/age"
+	"github.com/no-src/gofs/keyring"
+)
+
+var errEmptyEncryptFile = errors.New("there is no file in the encryption file")
+
+// DecryptDir the file system of the encryption files that provides the plain content of the files,
+// the secret is specified by the caller every time, so every user can decrypt the files with their own secret
+type DecryptDir struct {
+	fs         http.FileSystem
+	kr         *keyring.KeyRing
+	identities []*age.X25519Identity
+	legacy     bool
+}
+
+// NewDecryptDir create a DecryptDir of the encryption files, the keyfile and the identity file are optional,
+// the files of the legacy format v1 are rejected unless the legacy is true
+func NewDecryptDir(fs http.FileSystem, keyFile string, identityFile string, legacy bool) (d *DecryptDir, err error) {
+	d = &DecryptDir{
+		fs:     fs,
+		legacy: legacy,
+	}
+	if len(keyFile) > 0 {
+		if d.kr, err = keyring.Load(keyFile); err != nil {
+			return nil, err
+		}
+	}
+	if len(identityFile) > 0 {
+		if d.identities, err = age.LoadIdentities(identityFile); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// WithSecret returns the file system that shows the decrypted file names by the secret,
+// the file names are not decrypted if the secret is empty
+func (d *DecryptDir) WithSecret(secret []byte) (http.FileSystem, error) {
+	if len(secret) == 0 {
+		return d.fs, nil
+	}
+	if d.kr == nil {
+		if err := checkAESKey(secret); err != nil {
+			return nil, err
+		}
+	}
+	names, err := newNameCiphers(secret, d.kr)
+	if err != nil {
+		return nil, err
+	}
+	return &nameDir{fs: d.fs, names: names}, nil
+}
+
+// DecryptFile decrypt the encryption file by the secret or the identities and write the plain content to the writer
+func (d *DecryptDir) DecryptFile(w io.Writer, f http.File, secret []byte) error {
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	ra := newReaderAt(f)
+	isAge, err := isAgeReaderAt(ra)
+	if err != nil {
+		return err
+	}
+	if isAge {
+		return decryptAge(w, io.NewSectionReader(ra, 0, stat.Size()), d.identities)
+	}
+	if len(secret) == 0 {
+		return errSecretRequired
+	}
+	zr, err := zip.NewReader(ra, stat.Size())
+	if err != nil {
+		return err
+	}
+	for _, file := range zr.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		return decryptContent(w, rc, secret, d.kr, d.legacy)
+	}
+	return errEmptyEncryptFile
+}
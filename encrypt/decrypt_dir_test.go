@@ -0,0 +1,166 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/no-src/gofs/age"
+	"github.com/no-src/gofs/conf"
+	"github.com/no-src/gofs/logger"
+)
+
+func TestDecrypt_FileSystem(t *testing.T) {
+	logger := logger.NewTestLogger()
+	defer logger.Close()
+
+	dir := t.TempDir()
+	destDir := filepath.Join(dir, "dest")
+	outDir := filepath.Join(dir, "decrypt_out")
+	identityFile := filepath.Join(dir, "identity.txt")
+	identity, err := age.GenerateIdentityFile(identityFile)
+	if err != nil {
+		t.Errorf("generate identity file error => %v", err)
+		return
+	}
+
+	large := make([]byte, defaultChunkSize*2+10)
+	rand.Read(large)
+	files := map[string][]byte{
+		filepath.Join("workspace", "a.txt"):          []byte("a"),
+		filepath.Join("workspace", "dir", "b.txt"):   large,
+		filepath.Join("workspace", "dir", "age.txt"): []byte("age"),
+		"ignored.txt": []byte("ignored"),
+	}
+	for file, data := range files {
+		path := filepath.Join(destDir, file)
+		if err = os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Errorf("create directory error => %v", err)
+			return
+		}
+		writeEncryptFile(t, path, data, func(f *os.File) (io.WriteCloser, error) {
+			if filepath.Base(file) == "age.txt" {
+				return age.Encrypt(f, identity.Recipient())
+			}
+			return newAEADEncryptWriter(f, filepath.Base(file), []byte(secret), nil)
+		}, time.Now())
+	}
+
+	// the remote file may not implement the io.ReaderAt, it should be read by seeking
+	dec, err := NewDecryptFS(NewOption(conf.Config{
+		Decrypt:         true,
+		DecryptSecret:   secret,
+		DecryptOut:      outDir,
+		DecryptIdentity: identityFile,
+	}, logger), seekOnlyDir{http.Dir(destDir)}, "workspace")
+	if err == nil {
+		err = dec.Decrypt()
+	}
+	if err != nil {
+		t.Errorf("decrypt error => %v", err)
+		return
+	}
+	for file, data := range files {
+		rel, _ := filepath.Rel("workspace", file)
+		actual, err := os.ReadFile(filepath.Join(outDir, rel))
+		if file == "ignored.txt" {
+			if !os.IsNotExist(err) {
+				t.Errorf("expect to decrypt the files of the root only, but get %s => %v", file, err)
+			}
+			continue
+		}
+		if err != nil || !bytes.Equal(actual, data) {
+			t.Errorf("expect to get the decrypted file %s with %d bytes, but get %d bytes => %v", file, len(data), len(actual), err)
+		}
+	}
+}
+
+func TestDecryptDir(t *testing.T) {
+	dir := t.TempDir()
+	identityFile := filepath.Join(dir, "identity.txt")
+	identity, err := age.GenerateIdentityFile(identityFile)
+	if err != nil {
+		t.Errorf("generate identity file error => %v", err)
+		return
+	}
+	nc, err := newNameCipher([]byte(secret))
+	if err != nil {
+		t.Errorf("init name cipher error => %v", err)
+		return
+	}
+	encName, err := nc.encrypt("secret.txt")
+	if err != nil {
+		t.Errorf("encrypt name error => %v", err)
+		return
+	}
+	writeEncryptFile(t, filepath.Join(dir, encName), []byte("secret"), func(f *os.File) (io.WriteCloser, error) {
+		return newAEADEncryptWriter(f, encName, []byte(secret), nil)
+	}, time.Now())
+	writeEncryptFile(t, filepath.Join(dir, "age.txt"), []byte("age"), func(f *os.File) (io.WriteCloser, error) {
+		return age.Encrypt(f, identity.Recipient())
+	}, time.Now())
+
+	d, err := NewDecryptDir(seekOnlyDir{http.Dir(dir)}, "", identityFile, false)
+	if err != nil {
+		t.Errorf("init decrypt dir error => %v", err)
+		return
+	}
+
+	testCases := []struct {
+		name   string
+		secret string
+		expect string
+		err    error
+	}{
+		{"/secret.txt", secret, "secret", nil},
+		{"/age.txt", secret, "age", nil},
+		{"/age.txt", "", "age", nil},
+		{"/" + encName, "", "", errSecretRequired},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fsys, err := d.WithSecret([]byte(tc.secret))
+			if err != nil {
+				t.Errorf("get the file system with the secret error => %v", err)
+				return
+			}
+			f, err := fsys.Open(tc.name)
+			if err != nil {
+				t.Errorf("open file error => %v", err)
+				return
+			}
+			defer f.Close()
+			var buf bytes.Buffer
+			err = d.DecryptFile(&buf, f, []byte(tc.secret))
+			if !errors.Is(err, tc.err) {
+				t.Errorf("expect to get error %v, but get %v", tc.err, err)
+			}
+			if buf.String() != tc.expect {
+				t.Errorf("expect to get the plain content %s, but get %s", tc.expect, buf.String())
+			}
+		})
+	}
+
+	if _, err = d.WithSecret([]byte("invalid")); err == nil {
+		t.Errorf("expect to get the error of the invalid secret, but get nil")
+	}
+}
+
+// seekOnlyDir the file system that hides the io.ReaderAt of the files
+type seekOnlyDir struct {
+	fs http.FileSystem
+}
+
+func (d seekOnlyDir) Open(name string) (http.File, error) {
+	f, err := d.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return struct{ http.File }{f}, nil
+}
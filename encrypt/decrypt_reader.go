@@ -9,24 +9,29 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/no-src/gofs/keyring"
 	"github.com/no-src/gofs/logger"
 )
 
 type decryptReader struct {
-	zrc    *zip.ReadCloser
+	zr     *zip.Reader
 	secret []byte
+	kr     *keyring.KeyRing
+	names  nameCiphers
+	legacy bool
 	logger *logger.Logger
 }
 
 func (r *decryptReader) WriteTo(path string) (err error) {
-	for _, file := range r.zrc.File {
+	for _, file := range r.zr.File {
+		name := r.names.decrypt(file.Name)
 		// check zip slip
-		isValid := fs.ValidPath(file.Name)
+		isValid := fs.ValidPath(name)
 		if !isValid {
-			return fmt.Errorf("%w => %s", errIllegalPath, file.Name)
+			return fmt.Errorf("%w => %s", errIllegalPath, name)
 		}
 
-		outPath := filepath.Join(path, file.Name)
+		outPath := filepath.Join(path, name)
 
 		// path is directory
 		if file.FileInfo().IsDir() {
@@ -39,7 +44,7 @@ func (r *decryptReader) WriteTo(path string) (err error) {
 
 		// path is a file
 		var f fs.File
-		f, err = r.zrc.Open(file.Name)
+		f, err = r.zr.Open(file.Name)
 		if err != nil {
 			return err
 		}
@@ -49,43 +54,107 @@ func (r *decryptReader) WriteTo(path string) (err error) {
 			return err
 		}
 
-		var out *os.File
-		out, err = os.Create(outPath)
+		err = writeFile(outPath, r.logger, func(w io.Writer) error {
+			return decryptContent(w, f, r.secret, r.kr, r.legacy)
+		})
+		f.Close()
 		if err != nil {
-			return err
+			return fmt.Errorf("%w => %s", err, name)
 		}
+		r.logger.Info("save decryption file success => %s", outPath)
+	}
+	return err
+}
 
-		var dw io.Writer
-		dw, err = newDecryptWriter(out, r.secret, aesIV)
+// writeFile write the plain content to the output path by the decrypt function,
+// the output file is removed if the decryption fails
+func writeFile(outPath string, logger *logger.Logger, decrypt func(w io.Writer) error) (err error) {
+	out, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
 		if err != nil {
-			out.Close()
-			return err
+			logger.ErrorIf(os.Remove(outPath), "remove the invalid decryption file error => %s", outPath)
 		}
+	}()
+	return decrypt(out)
+}
 
-		br := bufio.NewReader(f)
-		_, err = br.WriteTo(dw)
-		if err != nil {
-			out.Close()
-			return err
+// decryptContent decrypt the content of the encryption file entry and write the plain content to the writer,
+// the format version is detected by the header, the files without the header are rejected unless the legacy format v1
+// is accepted explicitly, because the format v1 has no authentication and a tampered header downgrades the file to it
+func decryptContent(w io.Writer, r io.Reader, secret []byte, kr *keyring.KeyRing, legacy bool) error {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(formatMagic))
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	// the files without the format header are encrypted by the legacy format v1
+	if !hasFormatMagic(magic) {
+		if !legacy {
+			return errLegacyFormat
 		}
-		err = out.Close()
+		var dw io.Writer
+		dw, err = newDecryptWriter(w, secret, aesIV)
 		if err != nil {
 			return err
 		}
-		r.logger.Info("save decryption file success => %s", outPath)
+		_, err = br.WriteTo(dw)
+		return err
 	}
-	return err
+
+	h, err := readFormatHeader(br)
+	if err != nil {
+		return err
+	}
+	key, err := decryptKey(h, secret, kr)
+	if err != nil {
+		return err
+	}
+	dw, err := newAEADDecryptWriter(w, h, key)
+	if err != nil {
+		return err
+	}
+	if _, err = br.WriteTo(dw); err != nil {
+		return err
+	}
+	return dw.Close()
+}
+
+// decryptKey returns the key to decrypt the file, the key is derived from the secret by the key of the keyfile that
+// is recorded in the header, or the secret is used as the key directly if there is no key id in the header
+func decryptKey(h *formatHeader, secret []byte, kr *keyring.KeyRing) ([]byte, error) {
+	if !h.hasKeyID() {
+		return secret, nil
+	}
+	if kr == nil {
+		return nil, fmt.Errorf("%w => %x", errKeyFileRequired, h.keyID)
+	}
+	k, err := kr.KeyByIDBytes(h.keyID)
+	if err != nil {
+		return nil, err
+	}
+	return kr.Derive(k, secret)
 }
 
-// newDecryptReader create a decryption reader
-func newDecryptReader(path string, secret []byte, logger *logger.Logger) (*decryptReader, error) {
-	zrc, err := zip.OpenReader(path)
+// newDecryptReader create a decryption reader of the encryption file with the specified size,
+// the encryption file is read by the random access, so it is unnecessary to download the remote file first
+func newDecryptReader(ra io.ReaderAt, size int64, secret []byte, kr *keyring.KeyRing, names nameCiphers, legacy bool, logger *logger.Logger) (*decryptReader, error) {
+	zr, err := zip.NewReader(ra, size)
 	if err != nil {
 		return nil, err
 	}
 	return &decryptReader{
-		zrc:    zrc,
+		zr:     zr,
 		secret: secret,
+		kr:     kr,
+		names:  names,
+		legacy: legacy,
 		logger: logger,
 	}, nil
 }
@@ -19,6 +19,7 @@ func (w *decryptWriter) Write(p []byte) (nn int, err error) {
 	return w.w.Write(dst)
 }
 
+// newDecryptWriter create a decryption writer of the legacy format v1
 func newDecryptWriter(w io.Writer, key []byte, iv []byte) (io.Writer, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
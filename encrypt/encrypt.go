@@ -5,7 +5,12 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
+	"github.com/no-src/gofs/age"
+	"github.com/no-src/gofs/keyring"
 	"github.com/no-src/gofs/logger"
 	"github.com/no-src/nsgo/fsutil"
 )
@@ -14,6 +19,11 @@ import (
 type Encrypt struct {
 	opt        Option
 	parentPath string
+	key        []byte
+	keyID      []byte
+	names      *nameCipher
+	encryptRel []string
+	recipients []*age.X25519Recipient
 	logger     *logger.Logger
 }
 
@@ -32,21 +42,116 @@ func NewEncrypt(opt Option, parentPath string) (*Encrypt, error) {
 		if !isSub {
 			return nil, fmt.Errorf("%w, source=%s encrypt=%s", errNotSubDir, parentPath, opt.EncryptPath)
 		}
-		if err = checkAESKey(opt.EncryptSecret); err != nil {
+		if opt.hasRecipients() {
+			if opt.EncryptName {
+				return nil, errNameWithRecipients
+			}
+			if enc.recipients, err = loadRecipients(opt); err != nil {
+				return nil, err
+			}
+			return enc, nil
+		}
+		enc.key, enc.keyID, err = encryptKey(opt)
+		if err != nil {
 			return nil, err
 		}
+		if opt.EncryptName {
+			if err = enc.initNameCipher(); err != nil {
+				return nil, err
+			}
+		}
 	}
 	return enc, nil
 }
 
-// NewWriter create an encryption writer
+func (e *Encrypt) initNameCipher() (err error) {
+	e.names, err = newNameCipher(e.key)
+	if err != nil {
+		return err
+	}
+	parentAbs, err := filepath.Abs(e.parentPath)
+	if err != nil {
+		return err
+	}
+	encryptAbs, err := filepath.Abs(e.opt.EncryptPath)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(parentAbs, encryptAbs)
+	if err != nil {
+		return err
+	}
+	if rel != "." {
+		e.encryptRel = strings.Split(rel, string(filepath.Separator))
+	}
+	return nil
+}
+
+// EncryptName encrypt the names of the path components under the encrypt path if the name encryption is enabled,
+// the path is relative to the source path, and the encrypt path itself keeps the original name
+func (e *Encrypt) EncryptName(rel string) (string, error) {
+	// the source path itself keeps the original name
+	if e.names == nil || rel == "." {
+		return rel, nil
+	}
+	names := strings.Split(rel, string(filepath.Separator))
+	if len(names) <= len(e.encryptRel) || !slices.Equal(names[:len(e.encryptRel)], e.encryptRel) {
+		return rel, nil
+	}
+	for i := len(e.encryptRel); i < len(names); i++ {
+		encName, err := e.names.encrypt(names[i])
+		if err != nil {
+			return "", err
+		}
+		names[i] = encName
+	}
+	return filepath.Join(names...), nil
+}
+
+// NewWriter create an encryption writer, the content is encrypted to the recipients in the age format
+// if the recipients are specified, otherwise it is encrypted by the secret
 func (e *Encrypt) NewWriter(w io.Writer, source string, name string) (io.WriteCloser, error) {
 	if e.NeedEncrypt(source) {
-		return newEncryptWriter(w, name, e.opt.EncryptSecret, aesIV)
+		if len(e.recipients) > 0 {
+			return age.Encrypt(w, e.recipients...)
+		}
+		if e.names != nil {
+			var err error
+			if name, err = e.names.encrypt(name); err != nil {
+				return nil, err
+			}
+		}
+		return newAEADEncryptWriter(w, name, e.key, e.keyID)
 	}
 	return newBufferWriter(w), nil
 }
 
+// encryptKey returns the key and the key id to encrypt the files, the key is derived from the encrypt secret by the
+// active key of the keyfile if the keyfile is specified, otherwise the encrypt secret is used as the key directly
+func encryptKey(opt Option) (key []byte, keyID []byte, err error) {
+	if len(opt.KeyFile) == 0 {
+		return opt.EncryptSecret, nil, checkAESKey(opt.EncryptSecret)
+	}
+	kr, err := keyring.LoadOrCreate(opt.KeyFile, opt.KDF)
+	if err != nil {
+		return nil, nil, err
+	}
+	k, err := kr.ActiveKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	return deriveKey(kr, k, opt.EncryptSecret)
+}
+
+// deriveKey derive the key from the secret by the key of the keyfile
+func deriveKey(kr *keyring.KeyRing, k *keyring.Key, secret []byte) (key []byte, keyID []byte, err error) {
+	if keyID, err = k.IDBytes(); err != nil {
+		return nil, nil, err
+	}
+	key, err = kr.Derive(k, secret)
+	return key, keyID, err
+}
+
 // NeedEncrypt encryption is enabled and path is matched
 func (e *Encrypt) NeedEncrypt(path string) bool {
 	if e.opt.Encrypt {
@@ -58,52 +163,34 @@ func (e *Encrypt) NeedEncrypt(path string) bool {
 	return false
 }
 
-// CreateEncryptTemp create an encryption temporary file if enable encrypt and the path is matched
-func (e *Encrypt) CreateEncryptTemp(path string) (tempPath string, removeTemp func() error, err error) {
-	removeTemp = func() error {
-		return nil
-	}
-	if !e.NeedEncrypt(path) {
-		return path, removeTemp, nil
-	}
-	sourceFile, err := os.Open(path)
-	if err != nil {
-		return tempPath, removeTemp, err
-	}
-	defer func() {
-		e.logger.ErrorIf(sourceFile.Close(), "[encrypt temp] close the source file error")
-	}()
-	sourceStat, err := sourceFile.Stat()
+// NewReader open the source file as a stream, the content is encrypted on the fly if the path is matched,
+// so there is no need to write an encrypted copy of the file to a temporary file before upload
+func (e *Encrypt) NewReader(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
 	if err != nil {
-		return tempPath, removeTemp, err
+		return nil, err
 	}
-
-	fileName := sourceStat.Name()
-	reader := bufio.NewReader(sourceFile)
-
-	tempFile, err := os.CreateTemp("", fileName)
-	if err != nil {
-		return tempPath, removeTemp, err
+	if !e.NeedEncrypt(path) {
+		return f, nil
 	}
-
-	defer func() {
-		e.logger.ErrorIf(tempFile.Close(), "[encrypt temp] close the temporary file error")
+	pr, pw := io.Pipe()
+	go func() {
+		defer func() {
+			e.logger.ErrorIf(f.Close(), "[encrypt reader] close the source file error")
+		}()
+		pw.CloseWithError(e.encryptTo(pw, f, path))
 	}()
+	return pr, nil
+}
 
-	removeTemp = func() error {
-		return e.logger.ErrorIf(os.Remove(tempFile.Name()), "[encrypt temp] remove the temporary file error")
-	}
-
-	w, err := e.NewWriter(tempFile, path, fileName)
+// encryptTo encrypt the content of the source file and write it to the writer
+func (e *Encrypt) encryptTo(w io.Writer, f *os.File, path string) error {
+	ew, err := e.NewWriter(w, path, filepath.Base(path))
 	if err != nil {
-		return tempPath, removeTemp, err
+		return err
 	}
-	defer func() {
-		e.logger.ErrorIf(w.Close(), "[encrypt temp] close the encrypt writer error")
-	}()
-	_, err = reader.WriteTo(w)
-	if err != nil {
-		return tempPath, removeTemp, err
+	if _, err = bufio.NewReader(f).WriteTo(ew); err != nil {
+		return err
 	}
-	return tempFile.Name(), removeTemp, nil
+	return ew.Close()
 }
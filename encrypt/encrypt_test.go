@@ -48,6 +48,111 @@ func TestEncrypt(t *testing.T) {
 	}
 }
 
+func TestEncrypt_NewReader(t *testing.T) {
+	logger := logger.NewTestLogger()
+	defer logger.Close()
+
+	dir := t.TempDir()
+	sourceDir := filepath.Join(dir, "source")
+	encDir := filepath.Join(sourceDir, "encrypt")
+	destDir := filepath.Join(dir, "dest")
+	outDir := filepath.Join(dir, "decrypt_out")
+	if err := os.MkdirAll(encDir, os.ModePerm); err != nil {
+		t.Errorf("create directory error => %v", err)
+		return
+	}
+	// more than one chunk of the encryption format
+	plain := bytes.Repeat([]byte("gofs"), 50000)
+
+	enc, err := NewEncrypt(NewOption(conf.Config{
+		Encrypt:       true,
+		EncryptPath:   encDir,
+		EncryptSecret: secret,
+	}, logger), sourceDir)
+	if err != nil {
+		t.Errorf("init encrypt component error => %v", err)
+		return
+	}
+
+	testCases := []struct {
+		name    string
+		path    string
+		encrypt bool
+	}{
+		{"plain", filepath.Join(sourceDir, "plain.txt"), false},
+		{"encrypt", filepath.Join(encDir, "encrypt.txt"), true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err = os.WriteFile(tc.path, plain, 0600); err != nil {
+				t.Errorf("write source file error => %v", err)
+				return
+			}
+			r, err := enc.NewReader(tc.path)
+			if err != nil {
+				t.Errorf("create encrypt reader error => %v", err)
+				return
+			}
+			data, err := io.ReadAll(r)
+			r.Close()
+			if err != nil {
+				t.Errorf("read the encrypt reader error => %v", err)
+				return
+			}
+			if tc.encrypt == bytes.Equal(plain, data) {
+				t.Errorf("expect encrypt the stream is %v, but not", tc.encrypt)
+			}
+		})
+	}
+
+	// the encrypted stream can be decrypted
+	r, err := enc.NewReader(testCases[1].path)
+	if err != nil {
+		t.Errorf("create encrypt reader error => %v", err)
+		return
+	}
+	defer r.Close()
+	if err = os.MkdirAll(destDir, os.ModePerm); err != nil {
+		t.Errorf("create directory error => %v", err)
+		return
+	}
+	data, err := io.ReadAll(r)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(destDir, "encrypt.txt"), data, 0600)
+	}
+	if err != nil {
+		t.Errorf("write the encryption file error => %v", err)
+		return
+	}
+	dec, err := NewDecrypt(NewOption(conf.Config{
+		Decrypt:       true,
+		DecryptPath:   destDir,
+		DecryptSecret: secret,
+		DecryptOut:    outDir,
+	}, logger))
+	if err == nil {
+		err = dec.Decrypt()
+	}
+	if err != nil {
+		t.Errorf("decrypt error => %v", err)
+		return
+	}
+	actual, err := os.ReadFile(filepath.Join(outDir, "encrypt.txt"))
+	if err != nil || !bytes.Equal(plain, actual) {
+		t.Errorf("expect to get the plain content after decryption => %v", err)
+	}
+
+	// close the reader before reading to end
+	r, err = enc.NewReader(testCases[1].path)
+	if err != nil {
+		t.Errorf("create encrypt reader error => %v", err)
+		return
+	}
+	if err = r.Close(); err != nil {
+		t.Errorf("close the encrypt reader error => %v", err)
+	}
+}
+
 func TestEncrypt_Disabled(t *testing.T) {
 	logger := logger.NewTestLogger()
 	defer logger.Close()
@@ -192,12 +297,13 @@ func testEncrypt(encryptOpt Option, decryptOpt Option, sourcePath string, origin
 	}
 	w.Close()
 
-	// encrypt temp
-	_, removeFunc, err := enc.CreateEncryptTemp(originPath)
+	// encrypt stream
+	r, err := enc.NewReader(originPath)
 	if err != nil {
 		return err
 	}
-	err = removeFunc()
+	_, err = io.Copy(io.Discard, r)
+	r.Close()
 	if err != nil {
 		return err
 	}
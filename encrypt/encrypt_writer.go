@@ -29,6 +29,7 @@ func (w *encryptWriter) Close() error {
 	return w.zw.Close()
 }
 
+// newEncryptWriter create an encryption writer of the legacy format v1, it is only kept to verify the decryption of the old files
 func newEncryptWriter(w io.Writer, name string, key []byte, iv []byte) (io.WriteCloser, error) {
 	zw := zip.NewWriter(w)
 	ew, err := zw.CreateHeader(&zip.FileHeader{
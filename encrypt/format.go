@@ -0,0 +1,150 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/no-src/gofs/keyring"
+)
+
+// the layout of the encryption format v2 header:
+// magic(7) | version(1) | cipher(1) | key id(8) | chunk size(4, big endian) | salt(32)
+// the key id is all zero if the secret is used as the key directly without the keyfile
+const (
+	formatMagic     = "GOFSENC"
+	formatVersion2  = 2
+	formatSaltSize  = 32
+	formatKeyIDSize = keyring.KeyIDSize
+	formatHeaderLen = len(formatMagic) + 1 + 1 + formatKeyIDSize + 4 + formatSaltSize
+
+	cipherAES256GCM = 1
+
+	defaultChunkSize = 64 * 1024
+	maxChunkSize     = 16 * 1024 * 1024
+
+	keyInfoV2 = "gofs encryption v2"
+	keySizeV2 = 32
+
+	// nonceLastChunk the last byte of the nonce that marks the final chunk
+	nonceLastChunk = 1
+)
+
+var (
+	errUnsupportedVersion = errors.New("unsupported encryption format version")
+	errUnsupportedCipher  = errors.New("unsupported encryption cipher")
+	errInvalidHeader      = errors.New("invalid encryption header")
+	errAuthFailed         = errors.New("message authentication failed, the file is tampered or the secret is wrong")
+	errTruncated          = errors.New("the encryption file is truncated")
+	errLegacyFormat       = errors.New("the encryption file has no header of the format v2, it is tampered or encrypted by the legacy format v1 without authentication, see the -decrypt_legacy flag")
+)
+
+// formatHeader the header of the encryption format v2
+type formatHeader struct {
+	version   byte
+	cipher    byte
+	keyID     []byte
+	chunkSize uint32
+	salt      []byte
+}
+
+// newFormatHeader create a format v2 header with a random salt
+func newFormatHeader(keyID []byte) (*formatHeader, error) {
+	if len(keyID) == 0 {
+		keyID = make([]byte, formatKeyIDSize)
+	}
+	if len(keyID) != formatKeyIDSize {
+		return nil, fmt.Errorf("%w => key id size %d", errInvalidHeader, len(keyID))
+	}
+	salt := make([]byte, formatSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return &formatHeader{
+		version:   formatVersion2,
+		cipher:    cipherAES256GCM,
+		keyID:     keyID,
+		chunkSize: defaultChunkSize,
+		salt:      salt,
+	}, nil
+}
+
+// hasFormatMagic report whether the data starts with the magic of the versioned encryption format
+func hasFormatMagic(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(formatMagic))
+}
+
+// readFormatHeader read and validate the format header from the reader
+func readFormatHeader(r io.Reader) (*formatHeader, error) {
+	data := make([]byte, formatHeaderLen)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("%w => %w", errInvalidHeader, err)
+	}
+	if !hasFormatMagic(data) {
+		return nil, errInvalidHeader
+	}
+	offset := len(formatMagic)
+	h := &formatHeader{
+		version:   data[offset],
+		cipher:    data[offset+1],
+		keyID:     data[offset+2 : offset+2+formatKeyIDSize],
+		chunkSize: binary.BigEndian.Uint32(data[offset+2+formatKeyIDSize:]),
+		salt:      data[offset+6+formatKeyIDSize:],
+	}
+	if h.version != formatVersion2 {
+		return nil, fmt.Errorf("%w => %d", errUnsupportedVersion, h.version)
+	}
+	if h.cipher != cipherAES256GCM {
+		return nil, fmt.Errorf("%w => %d", errUnsupportedCipher, h.cipher)
+	}
+	if h.chunkSize == 0 || h.chunkSize > maxChunkSize {
+		return nil, fmt.Errorf("%w => chunk size %d", errInvalidHeader, h.chunkSize)
+	}
+	return h, nil
+}
+
+// Bytes returns the binary form of the header, it is also used as the additional data of every chunk
+func (h *formatHeader) Bytes() []byte {
+	data := make([]byte, 0, formatHeaderLen)
+	data = append(data, formatMagic...)
+	data = append(data, h.version, h.cipher)
+	data = append(data, h.keyID...)
+	data = binary.BigEndian.AppendUint32(data, h.chunkSize)
+	return append(data, h.salt...)
+}
+
+// hasKeyID report whether the file is encrypted by a key of the keyfile
+func (h *formatHeader) hasKeyID() bool {
+	return slices.ContainsFunc(h.keyID, func(b byte) bool { return b != 0 })
+}
+
+// newAEAD derive the per-file key from the key and the salt, then create the AEAD cipher
+func (h *formatHeader) newAEAD(key []byte) (cipher.AEAD, error) {
+	fileKey, err := hkdf.Key(sha256.New, key, h.salt, keyInfoV2, keySizeV2)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(fileKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce build the nonce of the chunk, it is composed of the chunk counter and the last chunk flag
+func chunkNonce(size int, counter uint64, last bool) []byte {
+	nonce := make([]byte, size)
+	binary.BigEndian.PutUint64(nonce[size-9:size-1], counter)
+	if last {
+		nonce[size-1] = nonceLastChunk
+	}
+	return nonce
+}
@@ -0,0 +1,285 @@
+package encrypt
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/no-src/gofs/conf"
+	"github.com/no-src/gofs/logger"
+)
+
+func TestAEAD_RoundTrip(t *testing.T) {
+	testCases := []struct {
+		name string
+		size int
+	}{
+		{"empty", 0},
+		{"one byte", 1},
+		{"less than one chunk", defaultChunkSize - 1},
+		{"one chunk", defaultChunkSize},
+		{"more than one chunk", defaultChunkSize + 1},
+		{"multiple chunks", defaultChunkSize*3 + 5},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			plain := randomBytes(t, tc.size)
+			content := aeadEncryptContent(t, plain, []byte(secret))
+			actual, err := aeadDecryptContent(content, []byte(secret))
+			if err != nil {
+				t.Errorf("decrypt the content error => %v", err)
+				return
+			}
+			if !bytes.Equal(plain, actual) {
+				t.Errorf("the decrypted content is not equal to the origin content")
+			}
+		})
+	}
+}
+
+func TestAEAD_RandomSalt(t *testing.T) {
+	plain := []byte("hello gofs")
+	c1 := aeadEncryptContent(t, plain, []byte(secret))
+	c2 := aeadEncryptContent(t, plain, []byte(secret))
+	if bytes.Equal(c1, c2) {
+		t.Errorf("expect to get the different encryption content with the random salt")
+	}
+}
+
+func TestAEAD_Tampered(t *testing.T) {
+	plain := randomBytes(t, defaultChunkSize*2+100)
+	content := aeadEncryptContent(t, plain, []byte(secret))
+	chunkLen := defaultChunkSize + 16
+
+	testCases := []struct {
+		name   string
+		secret string
+		modify func(c []byte) []byte
+		expect error
+	}{
+		{"wrong secret", "encrypt_secure_encrypt_secure_02", func(c []byte) []byte { return c }, errAuthFailed},
+		{"tamper magic", secret, flipByte(0), errInvalidHeader},
+		{"tamper version", secret, flipByte(len(formatMagic)), errUnsupportedVersion},
+		{"tamper cipher", secret, flipByte(len(formatMagic) + 1), errUnsupportedCipher},
+		{"tamper key id", secret, flipByte(len(formatMagic) + 2), errAuthFailed},
+		{"tamper chunk size", secret, flipByte(len(formatMagic) + 2 + formatKeyIDSize + 3), errAuthFailed},
+		{"tamper salt", secret, flipByte(formatHeaderLen - 1), errAuthFailed},
+		{"tamper first chunk", secret, flipByte(formatHeaderLen + 10), errAuthFailed},
+		{"tamper last chunk", secret, flipByte(len(content) - 1), errAuthFailed},
+		{"truncate header", secret, truncate(formatHeaderLen - 1), errInvalidHeader},
+		{"truncate all chunks", secret, truncate(formatHeaderLen), errTruncated},
+		{"truncate last chunk", secret, truncate(len(content) - 1), errAuthFailed},
+		{"drop last chunk", secret, truncate(formatHeaderLen + chunkLen*2), errAuthFailed},
+		{"swap chunks", secret, swapChunks(formatHeaderLen, chunkLen), errAuthFailed},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := tc.modify(bytes.Clone(content))
+			_, err := aeadDecryptContent(data, []byte(tc.secret))
+			if !errors.Is(err, tc.expect) {
+				t.Errorf("expect to get error %v, but get %v", tc.expect, err)
+			}
+		})
+	}
+}
+
+func TestDecrypt_LegacyFormat(t *testing.T) {
+	logger := logger.NewTestLogger()
+	defer logger.Close()
+
+	legacyPath := "./testdata/legacy/encrypt"
+	legacyOut := "./testdata/legacy/decrypt_out"
+	name := "legacy.txt"
+	plain := randomBytes(t, defaultChunkSize+10)
+
+	if err := os.MkdirAll(legacyPath, os.ModePerm); err != nil {
+		t.Errorf("create the legacy directory error => %v", err)
+		return
+	}
+	f, err := os.Create(filepath.Join(legacyPath, name+".data"))
+	if err != nil {
+		t.Errorf("create the legacy file error => %v", err)
+		return
+	}
+	w, err := newEncryptWriter(f, name, []byte(secret), aesIV)
+	if err == nil {
+		_, err = w.Write(plain)
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	f.Close()
+	if err != nil {
+		t.Errorf("write the legacy file error => %v", err)
+		return
+	}
+
+	c := conf.Config{
+		Decrypt:       true,
+		DecryptPath:   legacyPath,
+		DecryptSecret: secret,
+		DecryptOut:    legacyOut,
+	}
+	dec, err := NewDecrypt(NewOption(c, logger))
+	if err == nil {
+		err = dec.Decrypt()
+	}
+	if !errors.Is(err, errLegacyFormat) {
+		t.Errorf("expect to reject the legacy file by default with error %v, but get %v", errLegacyFormat, err)
+		return
+	}
+
+	c.DecryptLegacy = true
+	dec, err = NewDecrypt(NewOption(c, logger))
+	if err == nil {
+		err = dec.Decrypt()
+	}
+	if err != nil {
+		t.Errorf("decrypt the legacy file error => %v", err)
+		return
+	}
+	actual, err := os.ReadFile(filepath.Join(legacyOut, name))
+	if err != nil {
+		t.Errorf("read the decrypted legacy file error => %v", err)
+		return
+	}
+	if !bytes.Equal(plain, actual) {
+		t.Errorf("the decrypted legacy content is not equal to the origin content")
+	}
+}
+
+func TestDecrypt_TamperedFile(t *testing.T) {
+	logger := logger.NewTestLogger()
+	defer logger.Close()
+
+	tamperedPath := "./testdata/tampered/encrypt"
+	tamperedOut := "./testdata/tampered/decrypt_out"
+	name := "tampered.txt"
+
+	if err := os.MkdirAll(tamperedPath, os.ModePerm); err != nil {
+		t.Errorf("create the tampered directory error => %v", err)
+		return
+	}
+	var buf bytes.Buffer
+	w, err := newAEADEncryptWriter(&buf, name, []byte(secret), nil)
+	if err == nil {
+		_, err = w.Write(randomBytes(t, defaultChunkSize*2))
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		t.Errorf("encrypt the file error => %v", err)
+		return
+	}
+	// the zip entry is stored without compression, so flip a byte in the ciphertext directly
+	data := buf.Bytes()
+	data[bytes.Index(data, []byte(formatMagic))+formatHeaderLen+1] ^= 0xff
+	if err = os.WriteFile(filepath.Join(tamperedPath, name+".data"), data, 0600); err != nil {
+		t.Errorf("write the tampered file error => %v", err)
+		return
+	}
+
+	dec, err := NewDecrypt(NewOption(conf.Config{
+		Decrypt:       true,
+		DecryptPath:   tamperedPath,
+		DecryptSecret: secret,
+		DecryptOut:    tamperedOut,
+	}, logger))
+	if err != nil {
+		t.Errorf("init decrypt component error => %v", err)
+		return
+	}
+	err = dec.Decrypt()
+	if !errors.Is(err, errAuthFailed) {
+		t.Errorf("expect to get error %v, but get %v", errAuthFailed, err)
+	}
+	if _, err = os.Stat(filepath.Join(tamperedOut, name)); !os.IsNotExist(err) {
+		t.Errorf("expect the tampered output file to be removed, but get %v", err)
+	}
+}
+
+func randomBytes(t *testing.T, size int) []byte {
+	data := make([]byte, size)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatalf("generate random bytes error => %v", err)
+	}
+	return data
+}
+
+// aeadEncryptContent encrypt the data and returns the content of the zip entry
+func aeadEncryptContent(t *testing.T, data []byte, secret []byte) []byte {
+	var buf bytes.Buffer
+	w, err := newAEADEncryptWriter(&buf, "test.txt", secret, nil)
+	if err != nil {
+		t.Fatalf("create the encrypt writer error => %v", err)
+	}
+	if _, err = w.Write(data); err != nil {
+		t.Fatalf("write the encrypt writer error => %v", err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatalf("close the encrypt writer error => %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("open the zip reader error => %v", err)
+	}
+	f, err := zr.File[0].Open()
+	if err != nil {
+		t.Fatalf("open the zip entry error => %v", err)
+	}
+	defer f.Close()
+	content, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("read the zip entry error => %v", err)
+	}
+	return content
+}
+
+// aeadDecryptContent decrypt the content of the zip entry, write it in small pieces to cover the chunk buffering
+func aeadDecryptContent(content []byte, secret []byte) ([]byte, error) {
+	r := bytes.NewReader(content)
+	h, err := readFormatHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	dw, err := newAEADDecryptWriter(&out, h, secret)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = io.CopyBuffer(dw, struct{ io.Reader }{r}, make([]byte, 1000)); err != nil {
+		return nil, err
+	}
+	if err = dw.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func flipByte(i int) func(c []byte) []byte {
+	return func(c []byte) []byte {
+		c[i] ^= 0xff
+		return c
+	}
+}
+
+func truncate(n int) func(c []byte) []byte {
+	return func(c []byte) []byte {
+		return c[:n]
+	}
+}
+
+func swapChunks(offset int, chunkLen int) func(c []byte) []byte {
+	return func(c []byte) []byte {
+		first := bytes.Clone(c[offset : offset+chunkLen])
+		copy(c[offset:], c[offset+chunkLen:offset+chunkLen*2])
+		copy(c[offset+chunkLen:], first)
+		return c
+	}
+}
@@ -0,0 +1,118 @@
+package encrypt
+
+import (
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/no-src/gofs/keyring"
+)
+
+const (
+	nameKeyInfo = "gofs name encryption"
+	nameKeySize = 64
+	// maxNameLen the max length of the file name that most file systems support
+	maxNameLen = 255
+)
+
+var (
+	nameEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	errNameTooLong = errors.New("the encrypted file name is too long")
+)
+
+// nameCipher encrypt the file name deterministically by AES-SIV, and encode it with the lower case base32,
+// so the encrypted name is safe for the case-insensitive file systems
+type nameCipher struct {
+	siv *siv
+}
+
+// newNameCipher create a name cipher with the name key derived from the encryption key
+func newNameCipher(key []byte) (*nameCipher, error) {
+	nameKey, err := hkdf.Key(sha256.New, key, nil, nameKeyInfo, nameKeySize)
+	if err != nil {
+		return nil, err
+	}
+	s, err := newSIV(nameKey)
+	if err != nil {
+		return nil, err
+	}
+	return &nameCipher{siv: s}, nil
+}
+
+func (c *nameCipher) encrypt(name string) (string, error) {
+	encName := strings.ToLower(nameEncoding.EncodeToString(c.siv.Seal([]byte(name))))
+	if len(encName) > maxNameLen {
+		return "", fmt.Errorf("%w => %s", errNameTooLong, name)
+	}
+	return encName, nil
+}
+
+func (c *nameCipher) decrypt(encName string) (name string, ok bool) {
+	data, err := nameEncoding.DecodeString(strings.ToUpper(encName))
+	if err != nil {
+		return "", false
+	}
+	plain, err := c.siv.Open(data)
+	if err != nil {
+		return "", false
+	}
+	name = string(plain)
+	// the name must be a single path component, avoid the path traversal
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", false
+	}
+	return name, true
+}
+
+// nameCiphers the candidate name ciphers to decrypt the file names, because the names may be encrypted by
+// the different keys of the keyfile or the secret itself
+type nameCiphers []*nameCipher
+
+// newNameCiphers create the candidate name ciphers by the secret and all the keys of the keyfile
+func newNameCiphers(secret []byte, kr *keyring.KeyRing) (ciphers nameCiphers, err error) {
+	keys := [][]byte{secret}
+	if kr != nil {
+		for _, k := range kr.Keys {
+			key, err := kr.Derive(k, secret)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range keys {
+		c, err := newNameCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		ciphers = append(ciphers, c)
+	}
+	return ciphers, nil
+}
+
+// decrypt returns the original name, or returns the name itself if it is not encrypted by any of the ciphers
+func (cs nameCiphers) decrypt(encName string) string {
+	for _, c := range cs {
+		if name, ok := c.decrypt(encName); ok {
+			return name
+		}
+	}
+	return encName
+}
+
+// decryptPath decrypt every component of the relative path
+func (cs nameCiphers) decryptPath(rel string) string {
+	if len(cs) == 0 {
+		return rel
+	}
+	names := strings.Split(rel, string(filepath.Separator))
+	for i, name := range names {
+		names[i] = cs.decrypt(name)
+	}
+	return filepath.Join(names...)
+}
@@ -0,0 +1,112 @@
+package encrypt
+
+import (
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/no-src/gofs/keyring"
+)
+
+// nameDir the file system that shows the decrypted file names and directory names,
+// and accepts both the decrypted names and the encrypted names to open a file
+type nameDir struct {
+	fs    http.FileSystem
+	names nameCiphers
+}
+
+// NewNameDir wrap the file system to show the decrypted file names, the names are decrypted by the secret
+// and all the keys of the keyfile if it is specified
+func NewNameDir(fs http.FileSystem, secret []byte, keyFile string) (http.FileSystem, error) {
+	var kr *keyring.KeyRing
+	if len(keyFile) > 0 {
+		var err error
+		if kr, err = keyring.Load(keyFile); err != nil {
+			return nil, err
+		}
+	}
+	names, err := newNameCiphers(secret, kr)
+	if err != nil {
+		return nil, err
+	}
+	return &nameDir{
+		fs:    fs,
+		names: names,
+	}, nil
+}
+
+func (d *nameDir) Open(name string) (http.File, error) {
+	f, err := d.fs.Open(d.realPath(name))
+	if err != nil {
+		return nil, err
+	}
+	return &nameFile{File: f, names: d.names}, nil
+}
+
+// realPath find the real path of the name, every component of the name may be the decrypted name
+func (d *nameDir) realPath(name string) string {
+	real := "/"
+	for _, p := range strings.Split(name, "/") {
+		if len(p) == 0 {
+			continue
+		}
+		candidate := path.Join(real, p)
+		if !d.exist(candidate) {
+			for _, c := range d.names {
+				if encName, err := c.encrypt(p); err == nil && d.exist(path.Join(real, encName)) {
+					candidate = path.Join(real, encName)
+					break
+				}
+			}
+		}
+		real = candidate
+	}
+	return real
+}
+
+func (d *nameDir) exist(name string) bool {
+	f, err := d.fs.Open(name)
+	if err != nil {
+		return false
+	}
+	f.Close()
+	return true
+}
+
+type nameFile struct {
+	http.File
+	names nameCiphers
+}
+
+func (f *nameFile) Readdir(count int) ([]fs.FileInfo, error) {
+	infos, err := f.File.Readdir(count)
+	for i, info := range infos {
+		infos[i] = f.decryptInfo(info)
+	}
+	return infos, err
+}
+
+func (f *nameFile) Stat() (fs.FileInfo, error) {
+	info, err := f.File.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return f.decryptInfo(info), nil
+}
+
+func (f *nameFile) decryptInfo(info fs.FileInfo) fs.FileInfo {
+	if name := f.names.decrypt(info.Name()); name != info.Name() {
+		return &nameFileInfo{FileInfo: info, name: name}
+	}
+	return info
+}
+
+type nameFileInfo struct {
+	fs.FileInfo
+	name string
+}
+
+func (fi *nameFileInfo) Name() string {
+	return fi.name
+}
@@ -0,0 +1,302 @@
+package encrypt
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/no-src/gofs/conf"
+	"github.com/no-src/gofs/logger"
+)
+
+func TestNameCipher(t *testing.T) {
+	c, err := newNameCipher([]byte(secret))
+	if err != nil {
+		t.Errorf("create name cipher error => %v", err)
+		return
+	}
+	other, err := newNameCipher([]byte("another_secret_another_secret_01"))
+	if err != nil {
+		t.Errorf("create name cipher error => %v", err)
+		return
+	}
+
+	testCases := []string{"a", "hello.txt", "文件.txt", ".hidden", strings.Repeat("x", 100)}
+	for _, name := range testCases {
+		t.Run(name, func(t *testing.T) {
+			encName, err := c.encrypt(name)
+			if err != nil {
+				t.Errorf("encrypt name error => %v", err)
+				return
+			}
+			if encName == name || encName != strings.ToLower(encName) {
+				t.Errorf("expect to get a lower case encrypted name, but get %s", encName)
+			}
+			again, err := c.encrypt(name)
+			if err != nil || again != encName {
+				t.Errorf("expect to get the same encrypted name %s, but get %s => %v", encName, again, err)
+			}
+			actual, ok := c.decrypt(encName)
+			if !ok || actual != name {
+				t.Errorf("expect to decrypt the name %s, but get %s", name, actual)
+			}
+			if _, ok = other.decrypt(encName); ok {
+				t.Errorf("expect to fail to decrypt the name with another secret")
+			}
+			if _, ok = c.decrypt(strings.ToUpper(encName)); !ok {
+				t.Errorf("expect to decrypt the upper case name on the case-insensitive file system")
+			}
+		})
+	}
+}
+
+func TestNameCipher_ReturnError(t *testing.T) {
+	c, err := newNameCipher([]byte(secret))
+	if err != nil {
+		t.Errorf("create name cipher error => %v", err)
+		return
+	}
+	if _, err = c.encrypt(strings.Repeat("x", 200)); !errors.Is(err, errNameTooLong) {
+		t.Errorf("expect to get error %v, but get %v", errNameTooLong, err)
+	}
+	for _, name := range []string{"", "plain.txt", "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"} {
+		if _, ok := c.decrypt(name); ok {
+			t.Errorf("expect to fail to decrypt the name %s", name)
+		}
+	}
+	// the illegal names are never decrypted even if they are authenticated
+	for _, name := range []string{".", "..", "a/b", `a\b`} {
+		encName := strings.ToLower(nameEncoding.EncodeToString(c.siv.Seal([]byte(name))))
+		if _, ok := c.decrypt(encName); ok {
+			t.Errorf("expect to fail to decrypt the illegal name %s", name)
+		}
+	}
+}
+
+func TestEncrypt_EncryptName(t *testing.T) {
+	logger := logger.NewTestLogger()
+	defer logger.Close()
+
+	dir := t.TempDir()
+	sourceDir := filepath.Join(dir, "source")
+	destDir := filepath.Join(dir, "dest")
+	outDir := filepath.Join(dir, "decrypt_out")
+	plain := []byte("hello gofs")
+
+	enc, err := NewEncrypt(NewOption(conf.Config{
+		Encrypt:       true,
+		EncryptPath:   filepath.Join(sourceDir, "secret"),
+		EncryptSecret: secret,
+		EncryptName:   true,
+	}, logger), sourceDir)
+	if err != nil {
+		t.Errorf("init encrypt component error => %v", err)
+		return
+	}
+
+	testCases := []struct {
+		rel     string
+		encrypt bool
+	}{
+		{"plain.txt", false},
+		{filepath.Join("other", "plain.txt"), false},
+		{"secret", false},
+		{filepath.Join("secret", "a.txt"), true},
+		{filepath.Join("secret", "dir", "b.txt"), true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.rel, func(t *testing.T) {
+			actual, err := enc.EncryptName(tc.rel)
+			if err != nil {
+				t.Errorf("encrypt name error => %v", err)
+				return
+			}
+			if tc.encrypt == (actual == tc.rel) {
+				t.Errorf("expect encrypt name is %v, but get %s => %s", tc.encrypt, tc.rel, actual)
+			}
+			if tc.encrypt && !strings.HasPrefix(actual, "secret"+string(filepath.Separator)) {
+				t.Errorf("expect to keep the name of the encrypt path, but get %s", actual)
+			}
+		})
+	}
+
+	// write an encryption file to the dest with the encrypted names
+	rel, err := enc.EncryptName(filepath.Join("secret", "dir", "b.txt"))
+	if err != nil {
+		t.Errorf("encrypt name error => %v", err)
+		return
+	}
+	destFile := filepath.Join(destDir, rel)
+	if err = os.MkdirAll(filepath.Dir(destFile), os.ModePerm); err != nil {
+		t.Errorf("create the dest directory error => %v", err)
+		return
+	}
+	source := filepath.Join(sourceDir, "secret", "dir", "b.txt")
+	writeEncryptFile(t, destFile, plain, func(f *os.File) (io.WriteCloser, error) {
+		return enc.NewWriter(f, source, filepath.Base(source))
+	}, time.Now())
+
+	// the plain name is invisible in the encryption file
+	data, err := os.ReadFile(destFile)
+	if err != nil {
+		t.Errorf("read the encryption file error => %v", err)
+		return
+	}
+	if strings.Contains(string(data), "b.txt") {
+		t.Errorf("expect the plain name is invisible in the encryption file")
+	}
+
+	dec, err := NewDecrypt(NewOption(conf.Config{
+		Decrypt:       true,
+		DecryptPath:   destDir,
+		DecryptSecret: secret,
+		DecryptOut:    outDir,
+	}, logger))
+	if err == nil {
+		err = dec.Decrypt()
+	}
+	if err != nil {
+		t.Errorf("decrypt error => %v", err)
+		return
+	}
+	actual, err := os.ReadFile(filepath.Join(outDir, "secret", "dir", "b.txt"))
+	if err != nil {
+		t.Errorf("read the decrypted file error => %v", err)
+		return
+	}
+	if string(actual) != string(plain) {
+		t.Errorf("expect to get the content %s, but get %s", plain, actual)
+	}
+
+	// show the decrypted names in the file system
+	nd, err := NewNameDir(http.Dir(destDir), []byte(secret), "")
+	if err != nil {
+		t.Errorf("create name dir error => %v", err)
+		return
+	}
+	f, err := nd.Open("/secret")
+	if err != nil {
+		t.Errorf("open the encrypt path error => %v", err)
+		return
+	}
+	infos, err := f.Readdir(-1)
+	f.Close()
+	if err != nil || len(infos) != 1 || infos[0].Name() != "dir" {
+		t.Errorf("expect to list the decrypted directory name, but get %v => %v", infos, err)
+	}
+	for _, name := range []string{"/secret/dir/b.txt", "/" + filepath.ToSlash(rel)} {
+		f, err = nd.Open(name)
+		if err != nil {
+			t.Errorf("open the file by name %s error => %v", name, err)
+			continue
+		}
+		stat, err := f.Stat()
+		f.Close()
+		if err != nil || stat.Name() != "b.txt" {
+			t.Errorf("expect to get the decrypted file name, but get %v => %v", stat, err)
+		}
+	}
+	if _, err = nd.Open("/secret/dir/not_found.txt"); !os.IsNotExist(err) {
+		t.Errorf("expect to get the not exist error, but get %v", err)
+	}
+}
+
+func TestReEncrypt_EncryptName(t *testing.T) {
+	logger := logger.NewTestLogger()
+	defer logger.Close()
+
+	dir := t.TempDir()
+	encDir := filepath.Join(dir, "encrypt")
+	outDir := filepath.Join(dir, "decrypt_out")
+	newSecret := "new_secret_new_secret_new_secret"
+
+	enc, err := NewEncrypt(NewOption(conf.Config{
+		Encrypt:       true,
+		EncryptPath:   encDir,
+		EncryptSecret: secret,
+		EncryptName:   true,
+	}, logger), dir)
+	if err != nil {
+		t.Errorf("init encrypt component error => %v", err)
+		return
+	}
+	files := []string{filepath.Join("a", "b.txt"), filepath.Join("a", "c", "d.txt"), "e.txt"}
+	for _, file := range files {
+		rel, err := enc.EncryptName(filepath.Join("encrypt", file))
+		if err != nil {
+			t.Errorf("encrypt name error => %v", err)
+			return
+		}
+		path := filepath.Join(dir, rel)
+		if err = os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Errorf("create directory error => %v", err)
+			return
+		}
+		writeEncryptFile(t, path, []byte(file), func(f *os.File) (io.WriteCloser, error) {
+			return enc.NewWriter(f, filepath.Join(encDir, file), filepath.Base(file))
+		}, time.Now())
+	}
+	before := listNames(t, encDir)
+
+	re, err := NewReEncrypt(NewOption(conf.Config{
+		ReEncrypt:     true,
+		DecryptPath:   encDir,
+		DecryptSecret: secret,
+		EncryptSecret: newSecret,
+	}, logger))
+	if err == nil {
+		err = re.ReEncrypt()
+	}
+	if err != nil {
+		t.Errorf("reencrypt error => %v", err)
+		return
+	}
+	after := listNames(t, encDir)
+	if len(before) != len(after) {
+		t.Errorf("expect to get %d names, but get %d", len(before), len(after))
+	}
+	for _, name := range after {
+		if slices.Contains(before, name) {
+			t.Errorf("expect to rename the encrypted name by the new secret => %s", name)
+		}
+	}
+
+	dec, err := NewDecrypt(NewOption(conf.Config{
+		Decrypt:       true,
+		DecryptPath:   encDir,
+		DecryptSecret: newSecret,
+		DecryptOut:    outDir,
+	}, logger))
+	if err == nil {
+		err = dec.Decrypt()
+	}
+	if err != nil {
+		t.Errorf("decrypt error => %v", err)
+		return
+	}
+	for _, file := range files {
+		actual, err := os.ReadFile(filepath.Join(outDir, file))
+		if err != nil || string(actual) != file {
+			t.Errorf("expect to get the decrypted file %s, but get %s => %v", file, actual, err)
+		}
+	}
+}
+
+func listNames(t *testing.T, dir string) (names []string) {
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if path != dir {
+			names = append(names, d.Name())
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("list names error => %v", err)
+	}
+	return names
+}
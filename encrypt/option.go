@@ -10,29 +10,53 @@ type Option struct {
 	Encrypt       bool
 	EncryptPath   string
 	EncryptSecret []byte
+	EncryptName   bool
+
+	EncryptRecipients     string
+	EncryptRecipientsFile string
 
 	Decrypt       bool
 	DecryptPath   string
 	DecryptSecret []byte
 	DecryptOut    string
 
+	DecryptIdentity string
+	DecryptLegacy   bool
+
+	ReEncrypt bool
+	RotateKey bool
+	KeyFile   string
+	KDF       string
+
 	Logger *logger.Logger
 }
 
 // NewOption create an encryption option
 func NewOption(config conf.Config, logger *logger.Logger) Option {
-	if !config.Encrypt && !config.Decrypt {
+	if !config.Encrypt && !config.Decrypt && !config.ReEncrypt {
 		return EmptyOption()
 	}
 	return Option{
 		Encrypt:       config.Encrypt,
 		EncryptPath:   config.EncryptPath,
 		EncryptSecret: []byte(config.EncryptSecret),
-		Decrypt:       config.Decrypt,
-		DecryptPath:   config.DecryptPath,
-		DecryptSecret: []byte(config.DecryptSecret),
-		DecryptOut:    config.DecryptOut,
-		Logger:        logger,
+		EncryptName:   config.EncryptName,
+
+		EncryptRecipients:     config.EncryptRecipients,
+		EncryptRecipientsFile: config.EncryptRecipientsFile,
+
+		Decrypt:         config.Decrypt,
+		DecryptPath:     config.DecryptPath,
+		DecryptSecret:   []byte(config.DecryptSecret),
+		DecryptOut:      config.DecryptOut,
+		DecryptIdentity: config.DecryptIdentity,
+		DecryptLegacy:   config.DecryptLegacy,
+
+		ReEncrypt: config.ReEncrypt,
+		RotateKey: config.RotateKey,
+		KeyFile:   config.KeyFile,
+		KDF:       config.KDF,
+		Logger:    logger,
 	}
 }
 
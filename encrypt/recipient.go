@@ -0,0 +1,70 @@
+package encrypt
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"github.com/no-src/gofs/age"
+)
+
+var (
+	errNameWithRecipients = errors.New("the name encryption requires the encrypt_secret, it is unsupported with the recipients")
+	errIdentityRequired   = errors.New("the file is encrypted to the recipients, see the -decrypt_identity flag")
+	errSecretRequired     = errors.New("the file is encrypted by the secret, see the -decrypt_secret flag")
+)
+
+// hasRecipients report whether the files are encrypted to the recipients instead of the secret
+func (opt Option) hasRecipients() bool {
+	return len(opt.EncryptRecipients) > 0 || len(opt.EncryptRecipientsFile) > 0
+}
+
+// loadRecipients load all the recipients from the encrypt_recipients and the encrypt_recipients_file
+func loadRecipients(opt Option) (recipients []*age.X25519Recipient, err error) {
+	if len(opt.EncryptRecipients) > 0 {
+		if recipients, err = age.ParseRecipients(opt.EncryptRecipients); err != nil {
+			return nil, err
+		}
+	}
+	if len(opt.EncryptRecipientsFile) > 0 {
+		var fileRecipients []*age.X25519Recipient
+		if fileRecipients, err = age.LoadRecipients(opt.EncryptRecipientsFile); err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, fileRecipients...)
+	}
+	return recipients, nil
+}
+
+// isAgeFile report whether the file is encrypted to the recipients in the age format
+func isAgeFile(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	return isAgeReaderAt(f)
+}
+
+// isAgeReaderAt report whether the content of the reader is encrypted to the recipients in the age format
+func isAgeReaderAt(r io.ReaderAt) (bool, error) {
+	intro := make([]byte, len(age.Intro))
+	n, err := r.ReadAt(intro, 0)
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	return age.IsAge(intro[:n]), nil
+}
+
+// decryptAge decrypt the age content by the identities and write the plain content to the writer
+func decryptAge(w io.Writer, r io.Reader, identities []*age.X25519Identity) error {
+	if len(identities) == 0 {
+		return errIdentityRequired
+	}
+	ar, err := age.Decrypt(r, identities...)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, ar)
+	return err
+}
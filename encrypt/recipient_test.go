@@ -0,0 +1,155 @@
+package encrypt
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/no-src/gofs/age"
+	"github.com/no-src/gofs/conf"
+	"github.com/no-src/gofs/logger"
+)
+
+func TestEncrypt_Recipients(t *testing.T) {
+	logger := logger.NewTestLogger()
+	defer logger.Close()
+
+	dir := t.TempDir()
+	sourceDir := filepath.Join(dir, "source")
+	destDir := filepath.Join(dir, "dest")
+	outDir := filepath.Join(dir, "decrypt_out")
+	aliceFile := filepath.Join(dir, "alice.txt")
+	bobFile := filepath.Join(dir, "bob.txt")
+	recipientsFile := filepath.Join(dir, "recipients.txt")
+
+	alice, err := age.GenerateIdentityFile(aliceFile)
+	if err != nil {
+		t.Errorf("generate identity file error => %v", err)
+		return
+	}
+	bob, err := age.GenerateIdentityFile(bobFile)
+	if err != nil {
+		t.Errorf("generate identity file error => %v", err)
+		return
+	}
+	if err = os.WriteFile(recipientsFile, []byte(bob.Recipient().String()+"\n"), 0600); err != nil {
+		t.Errorf("write recipients file error => %v", err)
+		return
+	}
+
+	enc, err := NewEncrypt(NewOption(conf.Config{
+		Encrypt:               true,
+		EncryptPath:           sourceDir,
+		EncryptRecipients:     alice.Recipient().String(),
+		EncryptRecipientsFile: recipientsFile,
+	}, logger), sourceDir)
+	if err != nil {
+		t.Errorf("init encrypt component error => %v", err)
+		return
+	}
+	files := []string{"a.txt", filepath.Join("dir", "b.txt")}
+	for _, file := range files {
+		path := filepath.Join(destDir, file)
+		if err = os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Errorf("create directory error => %v", err)
+			return
+		}
+		writeEncryptFile(t, path, []byte(file), func(f *os.File) (io.WriteCloser, error) {
+			return enc.NewWriter(f, filepath.Join(sourceDir, file), filepath.Base(file))
+		}, time.Now())
+		if isAge, err := isAgeFile(path); err != nil || !isAge {
+			t.Errorf("expect to write an age file => %v", err)
+		}
+	}
+
+	// every identity of the recipients can decrypt the files without the secret
+	for _, identityFile := range []string{aliceFile, bobFile} {
+		if err = os.RemoveAll(outDir); err != nil {
+			t.Errorf("remove the output directory error => %v", err)
+			return
+		}
+		dec, err := NewDecrypt(NewOption(conf.Config{
+			Decrypt:         true,
+			DecryptPath:     destDir,
+			DecryptOut:      outDir,
+			DecryptIdentity: identityFile,
+		}, logger))
+		if err == nil {
+			err = dec.Decrypt()
+		}
+		if err != nil {
+			t.Errorf("decrypt error => %v", err)
+			return
+		}
+		for _, file := range files {
+			actual, err := os.ReadFile(filepath.Join(outDir, file))
+			if err != nil || string(actual) != file {
+				t.Errorf("expect to get the decrypted file %s, but get %s => %v", file, actual, err)
+			}
+		}
+	}
+}
+
+func TestEncrypt_Recipients_ReturnError(t *testing.T) {
+	logger := logger.NewTestLogger()
+	defer logger.Close()
+
+	dir := t.TempDir()
+	identityFile := filepath.Join(dir, "identity.txt")
+	identity, err := age.GenerateIdentityFile(identityFile)
+	if err != nil {
+		t.Errorf("generate identity file error => %v", err)
+		return
+	}
+
+	_, err = NewEncrypt(NewOption(conf.Config{
+		Encrypt:           true,
+		EncryptPath:       dir,
+		EncryptRecipients: identity.Recipient().String(),
+		EncryptName:       true,
+	}, logger), dir)
+	if !errors.Is(err, errNameWithRecipients) {
+		t.Errorf("expect to get error %v, but get %v", errNameWithRecipients, err)
+	}
+
+	// an age file requires the identity, and an encryption file of the secret requires the secret
+	ageFile := filepath.Join(dir, "age", "a.txt")
+	secretFile := filepath.Join(dir, "secret", "b.txt")
+	for _, path := range []string{ageFile, secretFile} {
+		if err = os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Errorf("create directory error => %v", err)
+			return
+		}
+	}
+	writeEncryptFile(t, ageFile, []byte("age"), func(f *os.File) (io.WriteCloser, error) {
+		return age.Encrypt(f, identity.Recipient())
+	}, time.Now())
+	writeEncryptFile(t, secretFile, []byte("secret"), func(f *os.File) (io.WriteCloser, error) {
+		return newAEADEncryptWriter(f, "b.txt", []byte(secret), nil)
+	}, time.Now())
+
+	testCases := []struct {
+		name   string
+		config conf.Config
+		expect error
+	}{
+		{"identity required", conf.Config{DecryptPath: filepath.Dir(ageFile), DecryptSecret: secret}, errIdentityRequired},
+		{"secret required", conf.Config{DecryptPath: filepath.Dir(secretFile), DecryptIdentity: identityFile}, errSecretRequired},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.config.Decrypt = true
+			tc.config.DecryptOut = filepath.Join(t.TempDir(), "decrypt_out")
+			dec, err := NewDecrypt(NewOption(tc.config, logger))
+			if err == nil {
+				err = dec.Decrypt()
+			}
+			if !errors.Is(err, tc.expect) {
+				t.Errorf("expect to get error %v, but get %v", tc.expect, err)
+			}
+		})
+	}
+}
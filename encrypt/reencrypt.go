@@ -0,0 +1,270 @@
+package encrypt
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/no-src/gofs/keyring"
+	"github.com/no-src/gofs/logger"
+)
+
+var errReEncryptEntries = errors.New("the encryption file must contain only one file")
+
+// ReEncrypt the re-encryption component, rewrite the encryption files from the old key to the new key
+type ReEncrypt struct {
+	opt    Option
+	kr     *keyring.KeyRing
+	key    []byte
+	keyID  []byte
+	logger *logger.Logger
+
+	// the old names may be encrypted by the old secret or the new secret if the re-encryption is interrupted
+	oldNames nameCiphers
+	newName  *nameCipher
+}
+
+// NewReEncrypt create a re-encryption component, the decrypt secret is the old secret and the encrypt secret is the
+// new secret. If the keyfile is specified, the files are rewritten by the active key, and a new active key is appended
+// to the keyfile before rewriting the files if the key rotation is enabled, the old keys are kept to decrypt the old files
+func NewReEncrypt(opt Option) (*ReEncrypt, error) {
+	re := &ReEncrypt{
+		opt:    opt,
+		logger: opt.Logger,
+	}
+	if len(opt.KeyFile) == 0 {
+		if err := checkAESKey(opt.EncryptSecret); err != nil {
+			return nil, err
+		}
+		re.key = opt.EncryptSecret
+		return re, re.initNameCipher()
+	}
+
+	_, err := os.Stat(opt.KeyFile)
+	exist := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	// the new keyfile contains the new active key already, so rotate the existing keyfile only
+	re.kr, err = keyring.LoadOrCreate(opt.KeyFile, opt.KDF)
+	if err != nil {
+		return nil, err
+	}
+	k, err := re.kr.ActiveKey()
+	if exist && opt.RotateKey && err == nil {
+		k, err = re.kr.Rotate(opt.KDF)
+	}
+	if err != nil {
+		return nil, err
+	}
+	re.logger.Info("the files will be encrypted by the active key => %s", k.ID)
+	re.key, re.keyID, err = deriveKey(re.kr, k, opt.EncryptSecret)
+	if err != nil {
+		return nil, err
+	}
+	return re, re.initNameCipher()
+}
+
+func (re *ReEncrypt) initNameCipher() (err error) {
+	if re.newName, err = newNameCipher(re.key); err != nil {
+		return err
+	}
+	for _, secret := range [][]byte{re.opt.DecryptSecret, re.opt.EncryptSecret} {
+		var names nameCiphers
+		if names, err = newNameCiphers(secret, re.kr); err != nil {
+			return err
+		}
+		re.oldNames = append(re.oldNames, names...)
+	}
+	return nil
+}
+
+// ReEncrypt rewrite all the encryption files in the decrypt path by the new key,
+// and rename the encrypted file names and directory names by the new key
+func (re *ReEncrypt) ReEncrypt() error {
+	var paths []string
+	err := filepath.WalkDir(re.opt.DecryptPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != re.opt.DecryptPath {
+			paths = append(paths, path)
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if err = re.reencryptFile(path); err != nil {
+			return fmt.Errorf("%w => %s", err, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// rename from the deepest path, the decrypt path itself keeps its name
+	for i := len(paths) - 1; i >= 0; i-- {
+		if err = re.rename(paths[i]); err != nil {
+			return fmt.Errorf("%w => %s", err, paths[i])
+		}
+	}
+	return nil
+}
+
+// rename rename the file by the new key if its name is encrypted by the old key
+func (re *ReEncrypt) rename(path string) error {
+	name := filepath.Base(path)
+	newName, err := re.reencryptName(name)
+	if err != nil || newName == name {
+		return err
+	}
+	if err = os.Rename(path, filepath.Join(filepath.Dir(path), newName)); err == nil {
+		re.logger.Info("[reencrypt] [rename] [success] => %s", path)
+	}
+	return err
+}
+
+// reencryptName encrypt the name by the new key if it is encrypted by the old key, otherwise return itself
+func (re *ReEncrypt) reencryptName(name string) (string, error) {
+	if _, ok := re.newName.decrypt(name); ok {
+		return name, nil
+	}
+	plain := re.oldNames.decrypt(name)
+	if plain == name {
+		return name, nil
+	}
+	return re.newName.encrypt(plain)
+}
+
+func (re *ReEncrypt) reencryptFile(path string) (err error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	// the private identities are not used to re-encrypt, encrypt the source files to the new recipients instead
+	if isAge, err := isAgeFile(path); err != nil || isAge {
+		if isAge {
+			re.logger.Info("[reencrypt] [ignored] the file is encrypted to the recipients => %s", path)
+		}
+		return err
+	}
+	zrc, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if zrc != nil {
+			re.logger.ErrorIf(zrc.Close(), "[reencrypt] close the encryption file error => %s", path)
+		}
+	}()
+	if len(zrc.File) != 1 {
+		return errReEncryptEntries
+	}
+	file := zrc.File[0]
+
+	if done, err := re.isReEncrypted(file); err != nil || done {
+		if done {
+			re.logger.Info("[reencrypt] [ignored] the file is encrypted by the new key already => %s", path)
+		}
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".gofs-reencrypt-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			re.logger.ErrorIf(os.Remove(tmp.Name()), "[reencrypt] remove the temporary file error")
+		}
+	}()
+
+	if err = re.rewrite(tmp, file); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	err = zrc.Close()
+	zrc = nil
+	if err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	// keep the modification time, avoid to sync the unmodified source files again
+	if err = os.Chtimes(path, stat.ModTime(), stat.ModTime()); err != nil {
+		return err
+	}
+	re.logger.Info("[reencrypt] [success] => %s", path)
+	return nil
+}
+
+// rewrite decrypt the file by the old secret and encrypt it by the new key, the new secret is also tried to decrypt
+// the file if the old secret is failed, so that the interrupted re-encryption can be run again
+func (re *ReEncrypt) rewrite(tmp *os.File, file *zip.File) (err error) {
+	name, err := re.reencryptName(file.Name)
+	if err != nil {
+		return err
+	}
+	secrets := [][]byte{re.opt.DecryptSecret, re.opt.EncryptSecret}
+	for i, secret := range secrets {
+		if err = tmp.Truncate(0); err != nil {
+			return err
+		}
+		if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		var w io.WriteCloser
+		w, err = newAEADEncryptWriter(tmp, name, re.key, re.keyID)
+		if err != nil {
+			return err
+		}
+		var r io.ReadCloser
+		r, err = file.Open()
+		if err != nil {
+			return err
+		}
+		err = decryptContent(w, r, secret, re.kr, re.opt.DecryptLegacy)
+		r.Close()
+		if err == nil {
+			return w.Close()
+		}
+		if !errors.Is(err, errAuthFailed) || i == len(secrets)-1 {
+			return err
+		}
+	}
+	return err
+}
+
+// isReEncrypted report whether the file is encrypted by the new key of the keyfile already
+func (re *ReEncrypt) isReEncrypted(file *zip.File) (bool, error) {
+	if len(re.keyID) == 0 {
+		return false, nil
+	}
+	r, err := file.Open()
+	if err != nil {
+		return false, err
+	}
+	defer r.Close()
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(formatMagic))
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	if !hasFormatMagic(magic) {
+		return false, nil
+	}
+	h, err := readFormatHeader(br)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(h.keyID, re.keyID), nil
+}
@@ -0,0 +1,184 @@
+package encrypt
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/no-src/gofs/conf"
+	"github.com/no-src/gofs/keyring"
+	"github.com/no-src/gofs/logger"
+)
+
+func TestEncrypt_KeyFile(t *testing.T) {
+	logger := logger.NewTestLogger()
+	defer logger.Close()
+
+	keyFile := filepath.Join(t.TempDir(), "gofs.key")
+	passphrase := "a passphrase of any length"
+
+	encryptOpt := NewOption(conf.Config{
+		Encrypt:       true,
+		EncryptPath:   encryptPath,
+		EncryptSecret: passphrase,
+		KeyFile:       keyFile,
+		KDF:           keyring.Scrypt,
+	}, logger)
+
+	decryptOpt := NewOption(conf.Config{
+		Decrypt:       true,
+		DecryptPath:   decryptPath,
+		DecryptSecret: passphrase,
+		DecryptOut:    decryptOut,
+		KeyFile:       keyFile,
+	}, logger)
+
+	err := testEncrypt(encryptOpt, decryptOpt, sourcePath, originPath, encryptFilePath)
+	if err != nil {
+		t.Errorf("test encrypt and decrypt with keyfile error err=%v", err)
+	}
+
+	// the keyfile is required to decrypt the files that are encrypted by the keyfile
+	decryptOpt.KeyFile = ""
+	decryptOpt.DecryptSecret = []byte(secret)
+	dec, err := NewDecrypt(decryptOpt)
+	if err != nil {
+		t.Errorf("init decrypt component error => %v", err)
+		return
+	}
+	if err = dec.Decrypt(); err == nil {
+		t.Errorf("expect to get an error when decrypt without the keyfile")
+	}
+}
+
+func TestReEncrypt(t *testing.T) {
+	logger := logger.NewTestLogger()
+	defer logger.Close()
+
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "gofs.key")
+	encDir := filepath.Join(dir, "encrypt")
+	outDir := filepath.Join(dir, "decrypt_out")
+	newSecret := "the new passphrase"
+	files := map[string][]byte{
+		"keyfile.txt": randomBytes(t, defaultChunkSize+1),
+		"legacy.txt":  randomBytes(t, 100),
+	}
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	if err := os.MkdirAll(encDir, os.ModePerm); err != nil {
+		t.Errorf("create the encrypt directory error => %v", err)
+		return
+	}
+
+	// encrypt a file by the keyfile and the old secret
+	enc, err := NewEncrypt(NewOption(conf.Config{
+		Encrypt:       true,
+		EncryptPath:   dir,
+		EncryptSecret: secret,
+		KeyFile:       keyFile,
+		KDF:           keyring.Scrypt,
+	}, logger), dir)
+	if err != nil {
+		t.Errorf("init encrypt component error => %v", err)
+		return
+	}
+	writeEncryptFile(t, filepath.Join(encDir, "keyfile.txt.data"), files["keyfile.txt"], func(f *os.File) (io.WriteCloser, error) {
+		return enc.NewWriter(f, dir, "keyfile.txt")
+	}, modTime)
+
+	// encrypt a file by the legacy format v1 and the old secret
+	writeEncryptFile(t, filepath.Join(encDir, "legacy.txt.data"), files["legacy.txt"], func(f *os.File) (io.WriteCloser, error) {
+		return newEncryptWriter(f, "legacy.txt", []byte(secret), aesIV)
+	}, modTime)
+
+	opt := NewOption(conf.Config{
+		ReEncrypt:     true,
+		DecryptPath:   encDir,
+		DecryptSecret: secret,
+		DecryptLegacy: true,
+		EncryptSecret: newSecret,
+		KeyFile:       keyFile,
+		KDF:           keyring.Scrypt,
+	}, logger)
+
+	// run twice to make sure the re-encryption can be run again, and the key is rotated only when it is enabled
+	for i := 0; i < 2; i++ {
+		opt.RotateKey = i == 0
+		re, err := NewReEncrypt(opt)
+		if err != nil {
+			t.Errorf("init reencrypt component error => %v", err)
+			return
+		}
+		if err = re.ReEncrypt(); err != nil {
+			t.Errorf("reencrypt error => %v", err)
+			return
+		}
+	}
+
+	kr, err := keyring.Load(keyFile)
+	if err != nil {
+		t.Errorf("load keyfile error => %v", err)
+		return
+	}
+	if len(kr.Keys) != 2 {
+		t.Errorf("expect to get 2 keys in the keyfile, but get %d", len(kr.Keys))
+	}
+
+	dec, err := NewDecrypt(NewOption(conf.Config{
+		Decrypt:       true,
+		DecryptPath:   encDir,
+		DecryptSecret: newSecret,
+		DecryptOut:    outDir,
+		KeyFile:       keyFile,
+	}, logger))
+	if err == nil {
+		err = dec.Decrypt()
+	}
+	if err != nil {
+		t.Errorf("decrypt by the new secret error => %v", err)
+		return
+	}
+	for name, plain := range files {
+		actual, err := os.ReadFile(filepath.Join(outDir, name))
+		if err != nil {
+			t.Errorf("read the decrypted file error => %v", err)
+			continue
+		}
+		if !bytes.Equal(plain, actual) {
+			t.Errorf("the decrypted content is not equal to the origin content => %s", name)
+		}
+		stat, err := os.Stat(filepath.Join(encDir, name+".data"))
+		if err != nil {
+			t.Errorf("get the encryption file stat error => %v", err)
+			continue
+		}
+		if !stat.ModTime().Equal(modTime) {
+			t.Errorf("expect to keep the modification time %v, but get %v => %s", modTime, stat.ModTime(), name)
+		}
+	}
+}
+
+func writeEncryptFile(t *testing.T, path string, data []byte, newWriter func(f *os.File) (io.WriteCloser, error), modTime time.Time) {
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create the encryption file error => %v", err)
+	}
+	w, err := newWriter(f)
+	if err == nil {
+		_, err = w.Write(data)
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	f.Close()
+	if err == nil {
+		err = os.Chtimes(path, modTime, modTime)
+	}
+	if err != nil {
+		t.Fatalf("write the encryption file error => %v", err)
+	}
+}
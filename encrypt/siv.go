@@ -0,0 +1,138 @@
+package encrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+)
+
+var errSIVAuthFailed = errors.New("siv authentication failed")
+
+// siv the deterministic authenticated encryption AES-SIV, see RFC 5297
+type siv struct {
+	mac cipher.Block
+	ctr cipher.Block
+}
+
+// newSIV create an AES-SIV cipher, the key is split into the MAC key and the CTR key,
+// so the key size must be either 32, 48, or 64 bytes
+func newSIV(key []byte) (*siv, error) {
+	if len(key) != 32 && len(key) != 48 && len(key) != 64 {
+		return nil, fmt.Errorf("invalid siv key size => %d, must be either 32, 48, or 64 bytes", len(key))
+	}
+	mac, err := aes.NewCipher(key[:len(key)/2])
+	if err != nil {
+		return nil, err
+	}
+	ctr, err := aes.NewCipher(key[len(key)/2:])
+	if err != nil {
+		return nil, err
+	}
+	return &siv{mac: mac, ctr: ctr}, nil
+}
+
+// Seal encrypt and authenticate the plaintext with the associated data, returns the synthetic iv and the ciphertext
+func (s *siv) Seal(plaintext []byte, ad ...[]byte) []byte {
+	v := s.s2v(plaintext, ad...)
+	out := make([]byte, aes.BlockSize+len(plaintext))
+	copy(out, v)
+	s.xorCTR(out[aes.BlockSize:], plaintext, v)
+	return out
+}
+
+// Open decrypt and verify the ciphertext that is sealed by Seal
+func (s *siv) Open(ciphertext []byte, ad ...[]byte) ([]byte, error) {
+	if len(ciphertext) < aes.BlockSize {
+		return nil, errSIVAuthFailed
+	}
+	v := ciphertext[:aes.BlockSize]
+	plaintext := make([]byte, len(ciphertext)-aes.BlockSize)
+	s.xorCTR(plaintext, ciphertext[aes.BlockSize:], v)
+	if subtle.ConstantTimeCompare(v, s.s2v(plaintext, ad...)) != 1 {
+		return nil, errSIVAuthFailed
+	}
+	return plaintext, nil
+}
+
+func (s *siv) xorCTR(dst, src, v []byte) {
+	q := make([]byte, aes.BlockSize)
+	copy(q, v)
+	// clear the 31st and 63rd bits from the right, see RFC 5297 section 2.5
+	q[8] &= 0x7f
+	q[12] &= 0x7f
+	cipher.NewCTR(s.ctr, q).XORKeyStream(dst, src)
+}
+
+// s2v the string to vector function, see RFC 5297 section 2.4
+func (s *siv) s2v(plaintext []byte, ad ...[]byte) []byte {
+	d := s.cmac(make([]byte, aes.BlockSize))
+	for _, a := range ad {
+		d = dbl(d)
+		subtle.XORBytes(d, d, s.cmac(a))
+	}
+	var t []byte
+	if len(plaintext) >= aes.BlockSize {
+		t = make([]byte, len(plaintext))
+		copy(t, plaintext)
+		subtle.XORBytes(t[len(t)-aes.BlockSize:], t[len(t)-aes.BlockSize:], d)
+	} else {
+		t = pad(plaintext)
+		subtle.XORBytes(t, t, dbl(d))
+	}
+	return s.cmac(t)
+}
+
+// cmac the AES-CMAC function, see RFC 4493
+func (s *siv) cmac(m []byte) []byte {
+	k1 := make([]byte, aes.BlockSize)
+	s.mac.Encrypt(k1, k1)
+	k1 = dbl(k1)
+	k2 := dbl(k1)
+
+	n := (len(m) + aes.BlockSize - 1) / aes.BlockSize
+	complete := n > 0 && len(m)%aes.BlockSize == 0
+	if n == 0 {
+		n = 1
+	}
+	var last []byte
+	if complete {
+		last = make([]byte, aes.BlockSize)
+		subtle.XORBytes(last, m[(n-1)*aes.BlockSize:], k1)
+	} else {
+		last = pad(m[(n-1)*aes.BlockSize:])
+		subtle.XORBytes(last, last, k2)
+	}
+
+	x := make([]byte, aes.BlockSize)
+	for i := 0; i < n-1; i++ {
+		subtle.XORBytes(x, x, m[i*aes.BlockSize:(i+1)*aes.BlockSize])
+		s.mac.Encrypt(x, x)
+	}
+	subtle.XORBytes(x, x, last)
+	s.mac.Encrypt(x, x)
+	return x
+}
+
+// dbl the multiplication by x in GF(2^128)
+func dbl(b []byte) []byte {
+	out := make([]byte, len(b))
+	var carry byte
+	for i := len(b) - 1; i >= 0; i-- {
+		out[i] = b[i]<<1 | carry
+		carry = b[i] >> 7
+	}
+	if carry != 0 {
+		out[len(out)-1] ^= 0x87
+	}
+	return out
+}
+
+// pad append a single 1 bit and the minimum number of 0 bits to make a full block
+func pad(b []byte) []byte {
+	out := make([]byte, aes.BlockSize)
+	copy(out, b)
+	out[len(b)] = 0x80
+	return out
+}
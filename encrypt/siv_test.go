@@ -0,0 +1,88 @@
+package encrypt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestSIV(t *testing.T) {
+	// the test vectors from RFC 5297 appendix A
+	testCases := []struct {
+		name       string
+		key        string
+		ad         []string
+		plaintext  string
+		ciphertext string
+	}{
+		{
+			name:       "deterministic authenticated encryption",
+			key:        "fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff",
+			ad:         []string{"101112131415161718191a1b1c1d1e1f2021222324252627"},
+			plaintext:  "112233445566778899aabbccddee",
+			ciphertext: "85632d07c6e8f37f950acd320a2ecc9340c02b9690c4dc04daef7f6afe5c",
+		},
+		{
+			name: "nonce-based authenticated encryption",
+			key:  "7f7e7d7c7b7a79787776757473727170404142434445464748494a4b4c4d4e4f",
+			ad: []string{
+				"00112233445566778899aabbccddeeffdeaddadadeaddadaffeeddccbbaa99887766554433221100",
+				"102030405060708090a0",
+				"09f911029d74e35bd84156c5635688c0",
+			},
+			plaintext:  "7468697320697320736f6d6520706c61696e7465787420746f20656e6372797074207573696e67205349562d414553",
+			ciphertext: "7bdb6e3b432667eb06f4d14bff2fbd0fcb900f2fddbe404326601965c889bf17dba77ceb094fa663b7a3f748ba8af829ea64ad544a272e9c485b62a3fd5c0d",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := newSIV(mustDecodeHex(t, tc.key))
+			if err != nil {
+				t.Errorf("create siv error => %v", err)
+				return
+			}
+			var ad [][]byte
+			for _, a := range tc.ad {
+				ad = append(ad, mustDecodeHex(t, a))
+			}
+			plaintext := mustDecodeHex(t, tc.plaintext)
+			expect := mustDecodeHex(t, tc.ciphertext)
+
+			actual := s.Seal(plaintext, ad...)
+			if !bytes.Equal(expect, actual) {
+				t.Errorf("expect to get ciphertext %x, but get %x", expect, actual)
+				return
+			}
+			opened, err := s.Open(actual, ad...)
+			if err != nil {
+				t.Errorf("open the ciphertext error => %v", err)
+				return
+			}
+			if !bytes.Equal(plaintext, opened) {
+				t.Errorf("expect to get plaintext %x, but get %x", plaintext, opened)
+			}
+
+			actual[len(actual)-1] ^= 1
+			if _, err = s.Open(actual, ad...); !errors.Is(err, errSIVAuthFailed) {
+				t.Errorf("expect to get error %v, but get %v", errSIVAuthFailed, err)
+			}
+		})
+	}
+}
+
+func TestNewSIV_ReturnError(t *testing.T) {
+	for _, size := range []int{0, 16, 33, 128} {
+		if _, err := newSIV(make([]byte, size)); err == nil {
+			t.Errorf("expect to get an error with the key size %d", size)
+		}
+	}
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("decode hex error => %v", err)
+	}
+	return data
+}
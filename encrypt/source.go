@@ -0,0 +1,90 @@
+package encrypt
+
+import (
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// localDir returns the file system of the local path and the root name of the path in the file system,
+// the root name is the file itself if the path is a file
+func localDir(p string) (http.FileSystem, string, error) {
+	stat, err := os.Stat(p)
+	if err != nil {
+		return nil, "", err
+	}
+	if stat.IsDir() {
+		return http.Dir(p), "/", nil
+	}
+	return http.Dir(filepath.Dir(p)), "/" + filepath.Base(p), nil
+}
+
+// walkDir walk the file tree of the file system rooted at name and call the fn for every file in lexical order
+func walkDir(fsys http.FileSystem, name string, fn func(name string, info fs.FileInfo) error) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if !stat.IsDir() {
+		f.Close()
+		return fn(name, stat)
+	}
+	infos, err := f.Readdir(-1)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name() < infos[j].Name()
+	})
+	for _, info := range infos {
+		child := path.Join(name, info.Name())
+		if info.IsDir() {
+			err = walkDir(fsys, child, fn)
+		} else {
+			err = fn(child, info)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newReaderAt returns the io.ReaderAt of the file, the remote files are read by the random access directly
+// without downloading them to the local disk
+func newReaderAt(f http.File) io.ReaderAt {
+	if ra, ok := f.(io.ReaderAt); ok {
+		return ra
+	}
+	return &seekReaderAt{rs: f}
+}
+
+// seekReaderAt an implementation of io.ReaderAt that is based on the io.ReadSeeker
+type seekReaderAt struct {
+	mu sync.Mutex
+	rs io.ReadSeeker
+}
+
+func (r *seekReaderAt) ReadAt(p []byte, off int64) (n int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err = r.rs.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err = io.ReadFull(r.rs, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
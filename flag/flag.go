@@ -3,11 +3,14 @@ package flag
 import (
 	"flag"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/no-src/gofs/auth"
 	"github.com/no-src/gofs/conf"
 	"github.com/no-src/gofs/core"
 	"github.com/no-src/gofs/daemon"
+	"github.com/no-src/gofs/keyring"
 	"github.com/no-src/gofs/logger"
 	"github.com/no-src/gofs/server"
 	"github.com/no-src/nsgo/hashutil"
@@ -30,6 +33,14 @@ func ParseFlags(args []string) (config conf.Config) {
 	cl.BoolVar(&config.PrintVersion, "v", false, "print the version info")
 	cl.BoolVar(&config.PrintAbout, "about", false, "print the about info")
 	cl.StringVar(&config.Conf, "conf", "", "the path of config file")
+	cl.BoolVar(&config.CheckConf, "check_conf", false, "validate the config and print all the problems")
+	cl.BoolVar(&config.PrintConf, "print_conf", false, "print the effective config with the secrets masked")
+	cl.StringVar(&config.ConfFormat, "conf_format", conf.YamlFormat.Name(), "the format of the printed config, current support yaml and json")
+	cl.StringVar(&config.InitConf, "init_conf", "", fmt.Sprintf("write an annotated starter config file of the specified mode to the path of -conf, default is %s, current supported modes: %s", conf.DefaultTemplatePath, strings.Join(conf.TemplateModes(), ", ")))
+	cl.StringVar(&config.GenIdentity, "gen_identity", "", "generate a new identity file of the public-key encryption to the specified path, and print the recipient of it")
+	cl.BoolVar(&config.HashPassword, "hash_password", false, "read a password from the stdin and print the password hash that can be used in the -users and -users_file")
+	cl.StringVar(&config.TOTPEnroll, "totp_enroll", "", "generate a TOTP secret and some recovery codes of the two-factor authentication for the specified username, and print the fields that are appended to the line of the user in the -users_file")
+	cl.StringVar(&config.AuditVerify, "audit_verify", "", "verify the HMAC chain of the audit log files that match the specified pattern in order with the -audit_hmac_key flag, and print the count of the verified records")
 
 	// file sync
 	cl.VFSVar(&config.Source, "source", core.NewEmptyVFS(), "the source path by monitor")
@@ -71,6 +82,8 @@ func ParseFlags(args []string) (config conf.Config) {
 	cl.Float64Var(&config.LogSampleRate, "log_sample_rate", 1, "set the sample rate for the sample logger, and the value ranges from 0 to 1")
 	cl.StringVar(&config.LogFormat, "log_format", logger.DefaultFormatter, "set the log output format, current support text and json")
 	cl.BoolVar(&config.LogSplitDate, "log_split_date", false, "split log file by date")
+	cl.BoolVar(&config.EnableAuditLog, "audit_log", false, "enable the audit log that records the access to the file server, the push server and the grpc server as JSON lines")
+	cl.StringVar(&config.AuditHMACKey, "audit_hmac_key", "", "chain the audit records by the HMAC-SHA256 hashes with the key to detect the tampering, see the -audit_verify flag")
 
 	// daemon
 	cl.BoolVar(&config.IsDaemon, "daemon", false, "enable daemon to create and monitor a subprocess to work, you can use [go build -ldflags=\"-H windowsgui\"] to build on Windows")
@@ -87,8 +100,29 @@ func ParseFlags(args []string) (config conf.Config) {
 	cl.BoolVar(&config.EnableManage, "manage", false, "enable the manage api route")
 	cl.BoolVar(&config.ManagePrivate, "manage_private", true, "allow to access manage api route by private address and loopback address only")
 	cl.BoolVar(&config.EnablePushServer, "push_server", false, "whether to enable the push server")
+	cl.BoolVar(&config.PushUserRoot, "push_user_root", false, "confine every login user of the push server to its own root directory like <source>/<username>, and the query api, the source route and the monitor stream are scoped to the user root too")
+	cl.SizeVar(&config.PushUserQuota, "push_user_quota", "0", "the max total size of the files in every user root of the push server, zero means unlimited, need to enable the -push_user_root flag first")
+	cl.IntVar(&config.PushUserQuotaFiles, "push_user_quota_files", 0, "the max count of the files in every user root of the push server, zero means unlimited, need to enable the -push_user_root flag first")
+	cl.SizeVar(&config.PushQuota, "push_quota", "0", "the max total size of the files in the whole storage of the push server, zero means unlimited")
+	cl.IntVar(&config.PushQuotaFiles, "push_quota_files", 0, "the max count of the files in the whole storage of the push server, zero means unlimited")
+	cl.SizeVar(&config.PushMinFreeSpace, "push_min_free_space", "0", "the push server rejects the uploads if the free space of the disk would drop below the reserved size, zero means no reserve")
 	cl.BoolVar(&config.EnableReport, "report", false, "enable the report api route and start to collect the report data, need to enable -manage flag first")
+	cl.BoolVar(&config.EnableServerDecrypt, "server_decrypt", false, "enable the decrypt route to serve the decrypted content of the dest directory to the signed in users that provide the secret for the session")
 	cl.StringVar(&config.SessionConnection, "session_connection", "memory:", "the session connection string, an example for redis session: redis://127.0.0.1:6379?password=redis_password&db=10&max_idle=10&secret=redis_secret")
+	cl.DurationVar(&config.SessionIdleTimeout, "session_idle_timeout", server.DefaultSessionIdleTimeout, "the web session expires if it is inactive for the timeout, zero means never")
+	cl.DurationVar(&config.SessionAbsoluteTimeout, "session_absolute_timeout", server.DefaultSessionAbsoluteTimeout, "the web session expires after the timeout since the user signs in, zero means never")
+
+	// ip access
+	cl.StringVar(&config.TrustedProxies, "trusted_proxies", "", "the ips or CIDRs of the trusted reverse proxies separated by comma, the client ip of the file server is resolved from the X-Forwarded-For or X-Real-IP header only if the request comes from them")
+	cl.StringVar(&config.ServerAllowIP, "server_allow_ip", "", "the ips or CIDRs separated by comma that are allowed to access the file server, every ip is allowed if it is empty")
+	cl.StringVar(&config.ServerDenyIP, "server_deny_ip", "", "the ips or CIDRs separated by comma that are denied to access the file server, it takes precedence over the -server_allow_ip flag")
+	cl.StringVar(&config.PushAllowIP, "push_allow_ip", "", "the ips or CIDRs separated by comma that are allowed to access the push route, every ip is allowed if it is empty")
+	cl.StringVar(&config.PushDenyIP, "push_deny_ip", "", "the ips or CIDRs separated by comma that are denied to access the push route, it takes precedence over the -push_allow_ip flag")
+	cl.StringVar(&config.GRPCAllowIP, "grpc_allow_ip", "", "the ips or CIDRs separated by comma that are allowed to access the grpc api of the remote disk server, every ip is allowed if it is empty")
+	cl.StringVar(&config.GRPCDenyIP, "grpc_deny_ip", "", "the ips or CIDRs separated by comma that are denied to access the grpc api of the remote disk server, it takes precedence over the -grpc_allow_ip flag")
+	cl.IntVar(&config.IPMaxConns, "ip_max_conns", 0, "the max concurrent requests and streams per client ip of the file server and the grpc api, zero means unlimited")
+	cl.Float64Var(&config.IPMaxRate, "ip_max_rate", 0, "the max requests per second per client ip of the file server and the grpc api, zero means unlimited")
+	cl.IntVar(&config.IPRateBurst, "ip_rate_burst", 0, "the max burst requests per client ip that exceed the -ip_max_rate flag, default is the -ip_max_rate flag rounded up")
 
 	// http protocol
 	cl.BoolVar(&config.EnableHTTP3, "http3", false, "enable the HTTP3 protocol, pay attention to what you enable the TLS first")
@@ -98,14 +132,34 @@ func ParseFlags(args []string) (config conf.Config) {
 	cl.StringVar(&config.TLSCertFile, "tls_cert_file", "gofs.pem", "cert file for tls connections")
 	cl.StringVar(&config.TLSKeyFile, "tls_key_file", "gofs.key", "key file for tls connections")
 	cl.BoolVar(&config.TLSInsecureSkipVerify, "tls_insecure_skip_verify", true, "controls whether a client skip verifies the server's certificate chain and host name")
+	cl.StringVar(&config.TLSClientCAFile, "tls_client_ca_file", "", "the CA bundle file to verify the client certificates, the server requires the client certificate for the mutual TLS if it is specified, and the client certificate that matches a username by the common name or the subject alternative names signs in as the user")
+	cl.StringVar(&config.TLSClientCertFile, "tls_client_cert_file", "", "the client certificate file that the client presents to the server for the mutual TLS")
+	cl.StringVar(&config.TLSClientKeyFile, "tls_client_key_file", "", "the private key file of the client certificate for the mutual TLS")
+	cl.BoolVar(&config.TLSAuto, "tls_auto", false, "generate a local CA and a server certificate signed by it if the -tls_cert_file and -tls_key_file are not found, and re-issue the server certificate before it expires, the local CA is stored in the gofs_ca.pem and gofs_ca.key in the same directory as the -tls_cert_file")
+	cl.StringVar(&config.TLSPin, "tls_pin", "", "the SHA-256 fingerprint of the server certificate or its CA certificate that the client trusts, the client verifies the server certificate by it instead of the -tls_insecure_skip_verify and -tls_cert_file flags")
+	cl.StringVar(&config.TLSKnownHostsFile, "tls_known_hosts_file", "", "the client trusts the server certificate on first use and records its fingerprint into the file, then rejects the server certificate that does not match the recorded fingerprint, it is ignored if the -tls_pin flag is specified")
 
 	// login user
 	cl.StringVar(&config.Users, "users", "", "the server accounts, the server allows anonymous access if there is no effective account, format like this, user1|password1|rwx,user2|password2|rwx")
+	cl.StringVar(&config.UsersFile, "users_file", "", "the htpasswd compatible file of the server accounts, one account per line, format like this, user1:password_hash:rwx, the file is watched and reloaded when changed")
+	cl.StringVar(&config.HashAlgorithm, "hash_algorithm", auth.DefaultHashAlgorithm, "the password hash algorithm of the -hash_password and the random users, supported bcrypt and argon2id")
+	cl.StringVar(&config.ACL, "acl", "", "the path-scoped permissions of the server accounts, the subject is a username, a group like @group1 or * for all the accounts, the rule with the longest path prefix takes effect, and '-' denies the access, format like this, user1|/source/docs|rw,@group1|/dest|r,*|/source/public|r")
+	cl.StringVar(&config.ACLGroups, "acl_groups", "", "the groups of the server accounts that are used in the -acl, format like this, group1|user1|user2,group2|user3")
+	cl.StringVar(&config.TOTPRequiredPerm, "totp_required_perm", "", "require the two-factor authentication of the web login for the users that have any of the permissions, like wx, the users that are enrolled always require it")
+	cl.StringVar(&config.APIKeysFile, "api_keys_file", "", "the file that persists the api keys of the server accounts created by the manage api, the api keys are only kept in memory if it is empty")
 	cl.IntVar(&config.RandomUserCount, "rand_user_count", 0, "the number of random server accounts, if it is greater than zero, random generate some accounts for -users")
 	cl.IntVar(&config.RandomUserNameLen, "rand_user_len", 6, "the length of the random user's username")
 	cl.IntVar(&config.RandomPasswordLen, "rand_pwd_len", 10, "the length of the random user's password")
 	cl.StringVar(&config.RandomDefaultPerm, "rand_perm", "r", "the default permission of every random user, like 'rwx'")
 	cl.StringVar(&config.TokenSecret, "token_secret", "", "a secret string for token")
+	cl.StringVar(&config.TokenAlgorithm, "token_algorithm", "HS256", "the signing algorithm of the grpc api token, supported HS256 and EdDSA, the HS256 signs the token with the -token_secret and the EdDSA signs the token with the -token_key_file")
+	cl.StringVar(&config.TokenKeyFile, "token_key_file", "", "the Ed25519 private key file in PKCS #8 PEM format that is used to sign the token with the EdDSA algorithm")
+	cl.DurationVar(&config.TokenExpires, "token_expires", time.Minute*30, "the lifetime of the grpc api token, the client refreshes the token automatically before it expires")
+	cl.StringVar(&config.TokenRevocation, "token_revocation", "memory:", "the cache connection string of the revoked token list, share it between the servers to revoke the token everywhere, an example for redis: redis://127.0.0.1:6379?password=redis_password&db=10&max_idle=10")
+	cl.IntVar(&config.LoginMaxFailures, "login_max_failures", 5, "the consecutive failed login attempts of a username that trigger a lockout, the lockout state is stored in the -session_connection, zero means unlimited")
+	cl.IntVar(&config.LoginMaxIPFailures, "login_max_ip_failures", 20, "the consecutive failed login attempts of an ip that trigger a lockout, zero means unlimited")
+	cl.DurationVar(&config.LoginLockout, "login_lockout", auth.DefaultLoginLockout, "the lockout duration of the first lockout, it is doubled for every next lockout until the -login_max_lockout")
+	cl.DurationVar(&config.LoginMaxLockout, "login_max_lockout", auth.DefaultLoginMaxLockout, "the max lockout duration of the exponential backoff")
 
 	// checksum
 	cl.BoolVar(&config.Checksum, "checksum", false, "calculate and print the checksum for source file")
@@ -114,12 +168,25 @@ func ParseFlags(args []string) (config conf.Config) {
 	cl.BoolVar(&config.Encrypt, "encrypt", false, "enable the encrypt path")
 	cl.StringVar(&config.EncryptPath, "encrypt_path", "", "the files in the encrypt path will be encrypted before sync to destination")
 	cl.StringVar(&config.EncryptSecret, "encrypt_secret", "", "a secret string for encryption")
+	cl.BoolVar(&config.EncryptName, "encrypt_name", false, "encrypt the file names and directory names in the encrypt path, and show the decrypted names in the dest route of the file server if the decrypt_secret is specified")
+
+	// public-key encryption
+	cl.StringVar(&config.EncryptRecipients, "encrypt_recipients", "", "encrypt the files in the encrypt path to the X25519 recipients instead of the encrypt_secret, multiple recipients are separated by commas, like age1xxx,age1yyy")
+	cl.StringVar(&config.EncryptRecipientsFile, "encrypt_recipients_file", "", "the file that contains the X25519 recipients to encrypt the files, one recipient per line")
+	cl.StringVar(&config.DecryptIdentity, "decrypt_identity", "", "the identity file that contains the X25519 private keys to decrypt the files encrypted to the recipients")
 
 	// decrypt
 	cl.BoolVar(&config.Decrypt, "decrypt", false, "decrypt the files from decrypt path to decrypt output path")
 	cl.StringVar(&config.DecryptPath, "decrypt_path", "", "a directory or file to decrypt")
 	cl.StringVar(&config.DecryptSecret, "decrypt_secret", "", "a secret string for decryption")
 	cl.StringVar(&config.DecryptOut, "decrypt_out", "", "the decrypt files output directory path")
+	cl.BoolVar(&config.DecryptLegacy, "decrypt_legacy", false, "accept the encryption files of the legacy format v1 that has no authentication, the files without the header of the format v2 are rejected by default")
+
+	// key derivation
+	cl.BoolVar(&config.ReEncrypt, "reencrypt", false, "rewrite the encryption files in the decrypt path from the old key to a new key, the decrypt_secret is the old secret and the encrypt_secret is the new secret")
+	cl.BoolVar(&config.RotateKey, "rotate_key", false, "append a new active key to the keyfile before the re-encryption, the files are rewritten by the current active key if it is not specified")
+	cl.StringVar(&config.KeyFile, "keyfile", "", "the keyfile that stores the key derivation parameters, the encrypt_secret, decrypt_secret and token_secret are used as passphrases of any length if it is specified")
+	cl.StringVar(&config.KDF, "kdf", keyring.DefaultKDF, "the key derivation function to create the new key in the keyfile, supported argon2id and scrypt")
 
 	// task
 	cl.StringVar(&config.TaskConf, "task_conf", "", "the task conf address")
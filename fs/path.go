@@ -0,0 +1,40 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	errUnsafePath    = errors.New("the path is outside of the root directory")
+	errUnsafeSymlink = errors.New("the symlink points to the outside of the root directory")
+)
+
+// SafeJoin join the root and the relative path, return an error if the path is outside of the root,
+// the leading separators of the path are ignored
+func SafeJoin(root string, path string) (string, error) {
+	path = strings.TrimLeft(filepath.FromSlash(path), `/\`)
+	if len(path) == 0 {
+		return filepath.Clean(root), nil
+	}
+	if !filepath.IsLocal(path) {
+		return "", fmt.Errorf("%w => %s", errUnsafePath, path)
+	}
+	return filepath.Join(root, path), nil
+}
+
+// CheckSymlink check the symlink that is created at the path points to the inside of the root,
+// the path is an absolute path in the root and the linkTo is the target of the symlink
+func CheckSymlink(root string, path string, linkTo string) error {
+	target := filepath.FromSlash(linkTo)
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(path), target)
+	}
+	rel, err := filepath.Rel(root, target)
+	if err != nil || !filepath.IsLocal(rel) && rel != "." {
+		return fmt.Errorf("%w => %s -> %s", errUnsafeSymlink, path, linkTo)
+	}
+	return nil
+}
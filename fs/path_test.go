@@ -0,0 +1,82 @@
+package fs
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestSafeJoin(t *testing.T) {
+	root := filepath.Join("data", "source")
+	testCases := []struct {
+		name   string
+		path   string
+		expect string
+	}{
+		{"empty", "", root},
+		{"current", ".", root},
+		{"file", "a.txt", filepath.Join(root, "a.txt")},
+		{"absolute", "/docs/a.txt", filepath.Join(root, "docs", "a.txt")},
+		{"clean", "docs/../a.txt", filepath.Join(root, "a.txt")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := SafeJoin(root, tc.path)
+			if err != nil {
+				t.Errorf("SafeJoin error => %v", err)
+				return
+			}
+			if actual != tc.expect {
+				t.Errorf("SafeJoin expect to get %s, but get %s", tc.expect, actual)
+			}
+		})
+	}
+}
+
+func TestSafeJoin_ReturnError(t *testing.T) {
+	testCases := []struct {
+		name string
+		path string
+	}{
+		{"parent", ".."},
+		{"parent file", "../a.txt"},
+		{"absolute parent", "/../../etc/passwd"},
+		{"nested parent", "docs/../../a.txt"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := SafeJoin("source", tc.path)
+			if !errors.Is(err, errUnsafePath) {
+				t.Errorf("SafeJoin expect to get error %v, but get %v", errUnsafePath, err)
+			}
+		})
+	}
+}
+
+func TestCheckSymlink(t *testing.T) {
+	root, _ := filepath.Abs("source")
+	testCases := []struct {
+		name   string
+		path   string
+		linkTo string
+		expect error
+	}{
+		{"sibling", filepath.Join(root, "docs", "link"), "a.txt", nil},
+		{"parent in root", filepath.Join(root, "docs", "link"), "../a.txt", nil},
+		{"root", filepath.Join(root, "docs", "link"), "..", nil},
+		{"absolute in root", filepath.Join(root, "link"), filepath.Join(root, "docs"), nil},
+		{"outside", filepath.Join(root, "docs", "link"), "../../a.txt", errUnsafeSymlink},
+		{"absolute outside", filepath.Join(root, "link"), filepath.Dir(root), errUnsafeSymlink},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckSymlink(root, tc.path, tc.linkTo)
+			if !errors.Is(err, tc.expect) {
+				t.Errorf("CheckSymlink expect to get error %v, but get %v", tc.expect, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package fs
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// UploadDirName the name of the directory under the storage root that stores the unfinished uploads of the web UI
+const UploadDirName = ".gofs_upload"
+
+// IsUploadPath the path is the directory of the unfinished uploads or in it
+func IsUploadPath(path string) bool {
+	for _, name := range strings.Split(filepath.ToSlash(path), "/") {
+		if name == UploadDirName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package fs
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestIsUploadPath(t *testing.T) {
+	testCases := []struct {
+		name   string
+		path   string
+		expect bool
+	}{
+		{"upload dir", UploadDirName, true},
+		{"upload file", filepath.Join("source", UploadDirName, "abc"), true},
+		{"slash path", "/source/" + UploadDirName + "/abc", true},
+		{"normal file", filepath.Join("source", "hello.txt"), false},
+		{"similar name", filepath.Join("source", UploadDirName+".txt"), false},
+		{"empty", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := IsUploadPath(tc.path); actual != tc.expect {
+				t.Errorf("IsUploadPath expect to get %v, but get %v => %s", tc.expect, actual, tc.path)
+			}
+		})
+	}
+}
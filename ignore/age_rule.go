@@ -0,0 +1,59 @@
+package ignore
+
+import (
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const day = time.Hour * 24
+
+type ageRule struct {
+	expr string
+	op   compareOperator
+	age  time.Duration
+	now  func() time.Time
+}
+
+func newAgeRule(expr string) (AttrRule, error) {
+	op, value, err := parseCompareExpr(expr, ageSwitch)
+	if err != nil {
+		return nil, err
+	}
+	age, err := parseAge(value)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s rule failed, expression=%s, %w", ageSwitch, expr, err)
+	}
+	return &ageRule{
+		expr: expr,
+		op:   op,
+		age:  age,
+		now:  time.Now,
+	}, nil
+}
+
+// parseAge parse the age value, support the day unit "d" and all the units of the time.ParseDuration, like "7d", "12h" or "30m"
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		v, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(v * float64(day)), nil
+	}
+	return time.ParseDuration(s)
+}
+
+func (r *ageRule) MatchFileInfo(fi fs.FileInfo) bool {
+	return r.op.compare(int64(r.now().Sub(fi.ModTime())), int64(r.age))
+}
+
+func (r *ageRule) SwitchName() string {
+	return ageSwitch
+}
+
+func (r *ageRule) Expression() string {
+	return r.expr
+}
@@ -0,0 +1,76 @@
+package ignore
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// AttrRule the match rule provider that checks the attributes of the file
+type AttrRule interface {
+	// MatchFileInfo reports whether the file info is matched of this rule
+	MatchFileInfo(fi fs.FileInfo) bool
+	// SwitchName return the rule switch name
+	SwitchName() string
+	// Expression return the rule expression
+	Expression() string
+}
+
+// compareOperator the operator to compare the attribute value of the file with the expected value
+type compareOperator string
+
+const (
+	greaterEqual compareOperator = ">="
+	lessEqual    compareOperator = "<="
+	greater      compareOperator = ">"
+	less         compareOperator = "<"
+)
+
+// compareOperators all the supported compare operators, the longer operators must be in front of the shorter ones
+var compareOperators = []compareOperator{greaterEqual, lessEqual, greater, less}
+
+func newAttrRule(expr string, switchName string) (AttrRule, error) {
+	switch switchName {
+	case sizeSwitch:
+		return newSizeRule(expr)
+	case ageSwitch:
+		return newAgeRule(expr)
+	case typeSwitch:
+		return newTypeRule(expr)
+	}
+	return nil, fmt.Errorf("unsupported attribute rule switch => %s", switchName)
+}
+
+// isAttrSwitch reports whether the switch name is an attribute rule switch
+func isAttrSwitch(switchName string) bool {
+	return switchName == sizeSwitch || switchName == ageSwitch || switchName == typeSwitch
+}
+
+// parseCompareExpr parse the compare expression like "> 2GiB" to the operator and the value
+func parseCompareExpr(expr string, switchName string) (op compareOperator, value string, err error) {
+	expr = strings.TrimSpace(expr)
+	for _, op = range compareOperators {
+		if strings.HasPrefix(expr, string(op)) {
+			value = strings.TrimSpace(strings.TrimPrefix(expr, string(op)))
+			if len(value) == 0 {
+				break
+			}
+			return op, value, nil
+		}
+	}
+	return op, value, fmt.Errorf("parse %s rule failed, expression=%s, the expression must be an operator(>, >=, <, <=) followed by a value", switchName, expr)
+}
+
+func (op compareOperator) compare(x, y int64) bool {
+	switch op {
+	case greaterEqual:
+		return x >= y
+	case lessEqual:
+		return x <= y
+	case greater:
+		return x > y
+	case less:
+		return x < y
+	}
+	return false
+}
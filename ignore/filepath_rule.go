@@ -6,16 +6,18 @@ import (
 )
 
 type filePathRule struct {
-	expr string
+	expr       string
+	switchName string
 }
 
-func newFilePathRule(expr string) (Rule, error) {
+func newFilePathRule(expr string, switchName string) (Rule, error) {
 	// check expression
 	if _, err := filepath.Match(expr, ""); err != nil {
-		return nil, fmt.Errorf("parse %s rule failed, expression=%s, %w", filePathSwitch, expr, err)
+		return nil, fmt.Errorf("parse %s rule failed, expression=%s, %w", switchName, expr, err)
 	}
 	return &filePathRule{
-		expr: expr,
+		expr:       expr,
+		switchName: switchName,
 	}, nil
 }
 
@@ -25,7 +27,7 @@ func (r *filePathRule) Match(s string) bool {
 }
 
 func (r *filePathRule) SwitchName() string {
-	return filePathSwitch
+	return r.switchName
 }
 
 func (r *filePathRule) Expression() string {
@@ -1,6 +1,7 @@
 package ignore
 
 import (
+	"io/fs"
 	"os"
 	"strings"
 
@@ -9,22 +10,27 @@ import (
 
 // Ignore support to check the string matches the ignore rule or not
 type Ignore interface {
+	// Match reports whether the string s matches the ignore rules
 	Match(s string) bool
+	// MatchFileInfo reports whether the file matches the attribute rules or does not match any include rule,
+	// the attribute rules and the include rules only work for the non-directory files, and they are skipped if the file info is nil,
+	// like the removed or renamed paths, so the removal of a directory that contains the included files is still synchronized
+	MatchFileInfo(s string, fi fs.FileInfo) bool
 }
 
 type ignore struct {
-	rules []Rule
+	rules        []Rule
+	includeRules []Rule
+	attrRules    []AttrRule
 }
 
 // New get a default Ignore instance
 func New(ignoreFile string, logger *logger.Logger) (Ignore, error) {
-	rules, err := parseIgnoreFile(ignoreFile, logger)
+	conf, err := os.ReadFile(ignoreFile)
 	if err != nil {
 		return nil, err
 	}
-	return &ignore{
-		rules: rules,
-	}, nil
+	return parse(conf, logger)
 }
 
 func (ig *ignore) Match(s string) bool {
@@ -36,34 +42,60 @@ func (ig *ignore) Match(s string) bool {
 	return false
 }
 
-func parseIgnoreFile(ignoreFile string, logger *logger.Logger) ([]Rule, error) {
-	conf, err := os.ReadFile(ignoreFile)
-	if err != nil {
-		return nil, err
+func (ig *ignore) MatchFileInfo(s string, fi fs.FileInfo) bool {
+	if fi == nil || fi.IsDir() {
+		return false
 	}
-	return parse(conf, logger)
+	for _, rule := range ig.attrRules {
+		if rule.MatchFileInfo(fi) {
+			return true
+		}
+	}
+	if len(ig.includeRules) == 0 {
+		return false
+	}
+	for _, rule := range ig.includeRules {
+		if rule.Match(s) {
+			return false
+		}
+	}
+	return true
 }
 
-func parse(data []byte, logger *logger.Logger) (rs []Rule, err error) {
+func parse(data []byte, logger *logger.Logger) (*ignore, error) {
+	ig := &ignore{}
 	conf := string(data)
 	lines := strings.Split(conf, "\n")
 	switchName := filePathSwitch
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
-		if len(line) > 0 && line[0] != '#' {
-			if line == filePathSwitch {
-				switchName = filePathSwitch
-			} else if line == regexpSwitch {
-				switchName = regexpSwitch
-			} else {
-				r, err := newRule(line, switchName)
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		switch line {
+		case filePathSwitch, regexpSwitch, includeFilePathSwitch, includeRegexpSwitch, sizeSwitch, ageSwitch, typeSwitch:
+			switchName = line
+		default:
+			if isAttrSwitch(switchName) {
+				r, err := newAttrRule(line, switchName)
 				if err != nil {
 					return nil, err
 				}
 				logger.Debug("register %s rule, expression=%s", r.SwitchName(), r.Expression())
-				rs = append(rs, r)
+				ig.attrRules = append(ig.attrRules, r)
+				continue
+			}
+			r, err := newRule(line, switchName)
+			if err != nil {
+				return nil, err
+			}
+			logger.Debug("register %s rule, expression=%s", r.SwitchName(), r.Expression())
+			if isIncludeSwitch(switchName) {
+				ig.includeRules = append(ig.includeRules, r)
+			} else {
+				ig.rules = append(ig.rules, r)
 			}
 		}
 	}
-	return rs, nil
+	return ig, nil
 }
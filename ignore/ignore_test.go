@@ -1,14 +1,17 @@
 package ignore
 
 import (
+	"io/fs"
 	"testing"
+	"time"
 
 	"github.com/no-src/gofs/logger"
 	"github.com/no-src/nsgo/osutil"
 )
 
 const (
-	testIgnoreFile = "./testdata/demo.ignore"
+	testIgnoreFile     = "./testdata/demo.ignore"
+	testAttrIgnoreFile = "./testdata/attr.ignore"
 )
 
 func TestMatch(t *testing.T) {
@@ -126,6 +129,7 @@ func TestMatchPath_WithIgnoreDeletedPath_True(t *testing.T) {
 	}{
 		{"/hello.txt.1643351810.deleted", true},
 		{"/hello.txt", false},
+		{"/source/.gofs_upload/abc", true},
 	}
 
 	for _, tc := range testCases {
@@ -152,6 +156,7 @@ func TestMatchPath_WithIgnoreDeletedPath_False(t *testing.T) {
 		{"/hello.txt.1643351810.deleted", false},
 		{"/hello.txt", false},
 		{"/source/bin/", true},
+		{"/source/.gofs_upload/abc", true},
 	}
 
 	for _, tc := range testCases {
@@ -219,3 +224,148 @@ func testMatchPath(t *testing.T, pi PathIgnore, expect bool, path string) {
 		t.Errorf("[%s] => expect: %v, but actual: %v", path, expect, actual)
 	}
 }
+
+func TestMatchFileInfo(t *testing.T) {
+	logger := logger.NewTestLogger()
+	defer logger.Close()
+
+	pi, err := NewPathIgnore(testAttrIgnoreFile, true, logger)
+	if err != nil {
+		t.Errorf("init default ignore component error => %v", err)
+		return
+	}
+
+	now := time.Now()
+	testCases := []struct {
+		name   string
+		path   string
+		fi     fs.FileInfo
+		expect bool
+	}{
+		{"include file", "/workspace/hello.txt", newTestFileInfo(1024, now, 0), false},
+		{"include regexp file", "/workspace/doc/README.md", newTestFileInfo(1024, now, 0), false},
+		{"not include file", "/workspace/hello.log", newTestFileInfo(1024, now, 0), true},
+		{"not include sub file", "/workspace/sub/hello.txt", newTestFileInfo(1024, now, 0), true},
+		{"directory is always included", "/workspace/sub", newTestFileInfo(0, now.Add(-day*30), fs.ModeDir), false},
+		{"nil file info", "/workspace/hello.log", nil, false},
+		{"include file with nil file info", "/workspace/hello.txt", nil, false},
+		{"directory with nil file info", "/workspace/sub", nil, false},
+		{"path rule", "/workspace/ignore.txt", newTestFileInfo(1024, now, 0), true},
+		{"path rule with nil file info", "/workspace/ignore.txt", nil, true},
+		{"deleted file", "/workspace/hello.txt.1643351810.deleted", newTestFileInfo(1024, now, 0), true},
+		{"equal to max size", "/workspace/hello.txt", newTestFileInfo(2<<30, now, 0), false},
+		{"larger than max size", "/workspace/hello.txt", newTestFileInfo(2<<30+1, now, 0), true},
+		{"modified in 7 days", "/workspace/hello.txt", newTestFileInfo(1024, now.Add(-day*6), 0), false},
+		{"modified before 7 days", "/workspace/hello.txt", newTestFileInfo(1024, now.Add(-day*8), 0), true},
+		{"socket", "/workspace/hello.txt", newTestFileInfo(0, now, fs.ModeSocket), true},
+		{"fifo", "/workspace/hello.txt", newTestFileInfo(0, now, fs.ModeNamedPipe), true},
+		{"block device", "/workspace/hello.txt", newTestFileInfo(0, now, fs.ModeDevice), true},
+		{"char device", "/workspace/hello.txt", newTestFileInfo(0, now, fs.ModeDevice|fs.ModeCharDevice), true},
+		{"symlink", "/workspace/hello.txt", newTestFileInfo(0, now, fs.ModeSymlink), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := pi.MatchFileInfo(tc.path, tc.fi, "test suit", "test")
+			if actual != tc.expect {
+				t.Errorf("[%s] => expect: %v, but actual: %v", tc.path, tc.expect, actual)
+			}
+		})
+	}
+}
+
+func TestMatchFileInfo_WithNoConfig(t *testing.T) {
+	logger := logger.NewTestLogger()
+	defer logger.Close()
+
+	pi, err := NewPathIgnore("", true, logger)
+	if err != nil {
+		t.Errorf("init default ignore component error => %v", err)
+		return
+	}
+	if pi.MatchFileInfo("/workspace/hello.txt", newTestFileInfo(2<<40, time.Now(), fs.ModeSocket), "test suit", "test") {
+		t.Errorf("expect to match nothing without config, but actual matched")
+	}
+}
+
+func TestParse_AttrRule_ReturnError(t *testing.T) {
+	logger := logger.NewTestLogger()
+	defer logger.Close()
+
+	testCases := []struct {
+		expr string
+	}{
+		{sizeSwitch + "\n2GiB"},
+		{sizeSwitch + "\n>"},
+		{sizeSwitch + "\n> 2XiB"},
+		{sizeSwitch + "\n= 2GiB"},
+		{ageSwitch + "\n7d"},
+		{ageSwitch + "\n> 7x"},
+		{ageSwitch + "\n> d"},
+		{typeSwitch + "\nunknown"},
+		{includeFilePathSwitch + "\n*[]"},
+		{includeRegexpSwitch + "\n/error**"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			_, err := parse([]byte(tc.expr), logger)
+			if err == nil {
+				t.Errorf("parse the rule text should be return error => [%s] error => %v", tc.expr, err)
+				return
+			}
+		})
+	}
+}
+
+func TestPathIgnore_Reload(t *testing.T) {
+	logger := logger.NewTestLogger()
+	defer logger.Close()
+
+	pi, err := NewPathIgnore("", false, logger)
+	if err != nil {
+		t.Errorf("init default ignore component error => %v", err)
+		return
+	}
+	testMatchPath(t, pi, false, "/source/bin/")
+	testMatchPath(t, pi, false, "/hello.txt.1643351810.deleted")
+
+	if err = pi.Reload(testIgnoreFile, true); err != nil {
+		t.Errorf("reload ignore component error => %v", err)
+		return
+	}
+	testMatchPath(t, pi, true, "/source/bin/")
+	testMatchPath(t, pi, true, "/hello.txt.1643351810.deleted")
+
+	if err = pi.Reload("./testdata/notfound.ignore", false); err == nil {
+		t.Errorf("reload ignore component with a not found file should return error")
+	}
+	testMatchPath(t, pi, true, "/source/bin/")
+	testMatchPath(t, pi, true, "/hello.txt.1643351810.deleted")
+
+	if err = pi.Reload("", false); err != nil {
+		t.Errorf("reload ignore component error => %v", err)
+		return
+	}
+	testMatchPath(t, pi, false, "/source/bin/")
+}
+
+type testFileInfo struct {
+	size    int64
+	modTime time.Time
+	mode    fs.FileMode
+}
+
+func newTestFileInfo(size int64, modTime time.Time, mode fs.FileMode) fs.FileInfo {
+	return &testFileInfo{
+		size:    size,
+		modTime: modTime,
+		mode:    mode,
+	}
+}
+
+func (fi *testFileInfo) Name() string       { return "test" }
+func (fi *testFileInfo) Size() int64        { return fi.size }
+func (fi *testFileInfo) Mode() fs.FileMode  { return fi.mode }
+func (fi *testFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *testFileInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi *testFileInfo) Sys() any           { return nil }
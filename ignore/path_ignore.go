@@ -1,18 +1,28 @@
 package ignore
 
 import (
-	"github.com/no-src/gofs/fs"
+	"io/fs"
+	"sync"
+
+	nsfs "github.com/no-src/gofs/fs"
 	"github.com/no-src/gofs/logger"
 	"github.com/no-src/nsgo/stringutil"
 )
 
 // PathIgnore check the ignore rules of the specified file path
 type PathIgnore interface {
-	// MatchPath the current string matches the rule or not, if enable the matchIgnoreDeletedPath, check the deleted file rule is matched or not first
+	// MatchPath the current string matches the rule or not, the unfinished uploads of the web UI are always ignored,
+	// if enable the matchIgnoreDeletedPath, check the deleted file rule is matched or not first
 	MatchPath(path, caller, desc string) bool
+	// MatchFileInfo the same as MatchPath, then check the attribute rules and the include rules with the file info,
+	// if the file info is nil, like the removed or renamed paths, only check the path rules like MatchPath
+	MatchFileInfo(path string, fi fs.FileInfo, caller, desc string) bool
+	// Reload re-parse the ignore config file and replace the current rules, keep the current rules if parse failed
+	Reload(ignoreConf string, ignoreDeletedPath bool) error
 }
 
 type pathIgnore struct {
+	mu                sync.RWMutex
 	ig                Ignore
 	ignoreDeletedPath bool
 	logger            *logger.Logger
@@ -25,29 +35,50 @@ func NewPathIgnore(ignoreConf string, ignoreDeletedPath bool, logger *logger.Log
 	pi := &pathIgnore{
 		logger: logger,
 	}
+	if err := pi.Reload(ignoreConf, ignoreDeletedPath); err != nil {
+		return nil, err
+	}
+	return pi, nil
+}
+
+func (pi *pathIgnore) Reload(ignoreConf string, ignoreDeletedPath bool) error {
+	var ig Ignore
 	if !stringutil.IsEmpty(ignoreConf) {
-		ig, err := New(ignoreConf, logger)
+		var err error
+		ig, err = New(ignoreConf, pi.logger)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		pi.ig = ig
 	}
+	pi.mu.Lock()
+	defer pi.mu.Unlock()
+	pi.ig = ig
 	pi.ignoreDeletedPath = ignoreDeletedPath
-	return pi, nil
+	return nil
+}
+
+// rules return the current ignore rules and whether ignore the deleted path
+func (pi *pathIgnore) rules() (Ignore, bool) {
+	pi.mu.RLock()
+	defer pi.mu.RUnlock()
+	return pi.ig, pi.ignoreDeletedPath
 }
 
 // match the current string matches the rule or not
 func (pi *pathIgnore) match(s string) bool {
-	if pi.ig != nil {
-		return pi.ig.Match(s)
-	}
-	return false
+	ig, _ := pi.rules()
+	return ig != nil && ig.Match(s)
 }
 
 func (pi *pathIgnore) MatchPath(path, caller, desc string) bool {
+	_, ignoreDeletedPath := pi.rules()
+	if nsfs.IsUploadPath(path) {
+		pi.logger.Debug("[ignored] [%s] an unfinished upload path is matched [%s] => [%s]", caller, desc, path)
+		return true
+	}
 	var matched bool
-	if pi.ignoreDeletedPath {
-		matched = fs.IsDeleted(path)
+	if ignoreDeletedPath {
+		matched = nsfs.IsDeleted(path)
 		if matched {
 			pi.logger.Debug("[ignored] [%s] a deleted path is matched [%s] => [%s]", caller, desc, path)
 			return true
@@ -59,3 +90,15 @@ func (pi *pathIgnore) MatchPath(path, caller, desc string) bool {
 	}
 	return matched
 }
+
+func (pi *pathIgnore) MatchFileInfo(path string, fi fs.FileInfo, caller, desc string) bool {
+	if pi.MatchPath(path, caller, desc) {
+		return true
+	}
+	ig, _ := pi.rules()
+	matched := ig != nil && ig.MatchFileInfo(path, fi)
+	if matched {
+		pi.logger.Debug("[ignored] [%s] an attribute rule or include rule is matched [%s] => [%s]", caller, desc, path)
+	}
+	return matched
+}
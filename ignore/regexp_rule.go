@@ -6,18 +6,20 @@ import (
 )
 
 type regexpRule struct {
-	expr string
-	reg  *regexp.Regexp
+	expr       string
+	reg        *regexp.Regexp
+	switchName string
 }
 
-func newRegexpRule(expr string) (Rule, error) {
+func newRegexpRule(expr string, switchName string) (Rule, error) {
 	reg, err := regexp.Compile(expr)
 	if err != nil {
-		return nil, fmt.Errorf("parse %s rule failed, expression=%s, %w", regexpSwitch, expr, err)
+		return nil, fmt.Errorf("parse %s rule failed, expression=%s, %w", switchName, expr, err)
 	}
 	return &regexpRule{
-		expr: expr,
-		reg:  reg,
+		expr:       expr,
+		reg:        reg,
+		switchName: switchName,
 	}, nil
 }
 
@@ -26,7 +28,7 @@ func (r *regexpRule) Match(s string) bool {
 }
 
 func (r *regexpRule) SwitchName() string {
-	return regexpSwitch
+	return r.switchName
 }
 
 func (r *regexpRule) Expression() string {
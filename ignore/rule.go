@@ -13,14 +13,24 @@ type Rule interface {
 }
 
 const (
-	filePathSwitch = "[filepath]"
-	regexpSwitch   = "[regexp]"
+	filePathSwitch        = "[filepath]"
+	regexpSwitch          = "[regexp]"
+	includeFilePathSwitch = "[include_filepath]"
+	includeRegexpSwitch   = "[include_regexp]"
+	sizeSwitch            = "[size]"
+	ageSwitch             = "[age]"
+	typeSwitch            = "[type]"
 )
 
 func newRule(expr string, switchName string) (Rule, error) {
 	switchName = strings.TrimSpace(switchName)
-	if switchName == regexpSwitch {
-		return newRegexpRule(expr)
+	if switchName == regexpSwitch || switchName == includeRegexpSwitch {
+		return newRegexpRule(expr, switchName)
 	}
-	return newFilePathRule(expr)
+	return newFilePathRule(expr, switchName)
+}
+
+// isIncludeSwitch reports whether the switch name is an include rule switch
+func isIncludeSwitch(switchName string) bool {
+	return switchName == includeFilePathSwitch || switchName == includeRegexpSwitch
 }
@@ -0,0 +1,42 @@
+package ignore
+
+import (
+	"fmt"
+	"io/fs"
+
+	"github.com/no-src/gofs/core"
+)
+
+type sizeRule struct {
+	expr string
+	op   compareOperator
+	size int64
+}
+
+func newSizeRule(expr string) (AttrRule, error) {
+	op, value, err := parseCompareExpr(expr, sizeSwitch)
+	if err != nil {
+		return nil, err
+	}
+	var size core.Size
+	if err = size.Set(value); err != nil {
+		return nil, fmt.Errorf("parse %s rule failed, expression=%s, %w", sizeSwitch, expr, err)
+	}
+	return &sizeRule{
+		expr: expr,
+		op:   op,
+		size: size.Bytes(),
+	}, nil
+}
+
+func (r *sizeRule) MatchFileInfo(fi fs.FileInfo) bool {
+	return r.op.compare(fi.Size(), r.size)
+}
+
+func (r *sizeRule) SwitchName() string {
+	return sizeSwitch
+}
+
+func (r *sizeRule) Expression() string {
+	return r.expr
+}
@@ -0,0 +1,46 @@
+package ignore
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// fileTypes the supported file types of the type rule and the file mode bits of them
+var fileTypes = map[string]fs.FileMode{
+	"symlink":   fs.ModeSymlink,
+	"socket":    fs.ModeSocket,
+	"fifo":      fs.ModeNamedPipe,
+	"pipe":      fs.ModeNamedPipe,
+	"device":    fs.ModeDevice,
+	"char":      fs.ModeCharDevice,
+	"irregular": fs.ModeIrregular,
+}
+
+type typeRule struct {
+	expr string
+	mode fs.FileMode
+}
+
+func newTypeRule(expr string) (AttrRule, error) {
+	mode, ok := fileTypes[strings.ToLower(strings.TrimSpace(expr))]
+	if !ok {
+		return nil, fmt.Errorf("parse %s rule failed, expression=%s, unsupported file type, current supported types: symlink, socket, fifo, pipe, device, char, irregular", typeSwitch, expr)
+	}
+	return &typeRule{
+		expr: expr,
+		mode: mode,
+	}, nil
+}
+
+func (r *typeRule) MatchFileInfo(fi fs.FileInfo) bool {
+	return fi.Mode()&r.mode != 0
+}
+
+func (r *typeRule) SwitchName() string {
+	return typeSwitch
+}
+
+func (r *typeRule) Expression() string {
+	return r.expr
+}
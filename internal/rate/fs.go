@@ -7,9 +7,9 @@ import (
 )
 
 type dir struct {
-	fs             http.FileSystem
-	bytesPerSecond int64
-	logger         *logger.Logger
+	fs     http.FileSystem
+	limit  *Limit
+	logger *logger.Logger
 }
 
 // NewHTTPDir create a limit http.FileSystem that wrap the real http.Dir.
@@ -22,10 +22,23 @@ func NewDir(fs http.FileSystem, bytesPerSecond int64, logger *logger.Logger) htt
 	if bytesPerSecond <= 0 {
 		return fs
 	}
+	return NewLimitDir(fs, NewLimit(bytesPerSecond), logger)
+}
+
+// NewLimitHTTPDir create a limit http.FileSystem that wrap the real http.Dir, the limit can be updated at runtime.
+func NewLimitHTTPDir(path string, limit *Limit, logger *logger.Logger) http.FileSystem {
+	return NewLimitDir(http.Dir(path), limit, logger)
+}
+
+// NewLimitDir create a limit http.FileSystem that wrap the real http.FileSystem, the limit can be updated at runtime.
+func NewLimitDir(fs http.FileSystem, limit *Limit, logger *logger.Logger) http.FileSystem {
+	if limit == nil {
+		return fs
+	}
 	return &dir{
-		fs:             fs,
-		bytesPerSecond: bytesPerSecond,
-		logger:         logger,
+		fs:     fs,
+		limit:  limit,
+		logger: logger,
 	}
 }
 
@@ -34,5 +47,5 @@ func (d *dir) Open(name string) (http.File, error) {
 	if err != nil {
 		return f, err
 	}
-	return NewFile(f, d.bytesPerSecond, d.logger), err
+	return NewFile(f, d.limit.Bytes(), d.logger), err
 }
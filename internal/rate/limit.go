@@ -0,0 +1,28 @@
+package rate
+
+import "sync/atomic"
+
+// Limit a transmission rate limit that supports to update at runtime, the zero or negative value means no limit
+type Limit struct {
+	bytesPerSecond atomic.Int64
+}
+
+// NewLimit create an instance of the Limit with the specified bytes per second
+func NewLimit(bytesPerSecond int64) *Limit {
+	l := &Limit{}
+	l.Set(bytesPerSecond)
+	return l
+}
+
+// Bytes return the current bytes per second, return zero if the Limit is nil
+func (l *Limit) Bytes() int64 {
+	if l == nil {
+		return 0
+	}
+	return l.bytesPerSecond.Load()
+}
+
+// Set update the bytes per second
+func (l *Limit) Set(bytesPerSecond int64) {
+	l.bytesPerSecond.Store(bytesPerSecond)
+}
@@ -0,0 +1,65 @@
+package rate
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/no-src/gofs/logger"
+)
+
+func TestLimit(t *testing.T) {
+	testCases := []struct {
+		name  string
+		init  int64
+		set   int64
+		bytes int64
+	}{
+		{"disable to enable", 0, KB, KB},
+		{"enable to disable", KB, 0, 0},
+		{"update", KB, M, M},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := NewLimit(tc.init)
+			if l.Bytes() != tc.init {
+				t.Errorf("expect to get init limit %d, actual get %d", tc.init, l.Bytes())
+			}
+			l.Set(tc.set)
+			if l.Bytes() != tc.bytes {
+				t.Errorf("expect to get limit %d, actual get %d", tc.bytes, l.Bytes())
+			}
+		})
+	}
+}
+
+func TestLimit_Nil(t *testing.T) {
+	var l *Limit
+	if l.Bytes() != 0 {
+		t.Errorf("expect to get zero from a nil limit, actual get %d", l.Bytes())
+	}
+}
+
+func TestNewLimitDir(t *testing.T) {
+	logger := logger.NewTestLogger()
+	defer logger.Close()
+
+	l := NewLimit(0)
+	d := NewLimitHTTPDir("./", l, logger)
+	if _, ok := d.(*dir); !ok {
+		t.Errorf("expect to get *dir type with a limit, actual get %T", d)
+	}
+	l.Set(KB)
+	f, err := d.Open("limit_test.go")
+	if err != nil {
+		t.Errorf("open file error, %v", err)
+		return
+	}
+	defer f.Close()
+	if _, ok := f.(*file); !ok {
+		t.Errorf("expect to get *file type, actual get %T", f)
+	}
+
+	if _, ok := NewLimitHTTPDir("./", nil, logger).(http.Dir); !ok {
+		t.Errorf("expect to get http.Dir type without a limit")
+	}
+}
@@ -0,0 +1,45 @@
+package secret
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	envPrefix  = "env:"
+	filePrefix = "file:"
+)
+
+var errEnvNotFound = errors.New("the environment variable of the secret reference is not found")
+
+// IsRef report whether the value is a secret reference, like env:NAME or file:/path
+func IsRef(value string) bool {
+	return strings.HasPrefix(value, envPrefix) || strings.HasPrefix(value, filePrefix)
+}
+
+// Resolve return the secret that the reference points to, the trailing line breaks of the secret file are removed,
+// return the value itself if it is not a secret reference
+func Resolve(value string) (string, error) {
+	if name, ok := strings.CutPrefix(value, envPrefix); ok {
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("%w => %s", errEnvNotFound, name)
+		}
+		return v, nil
+	}
+	if path, ok := strings.CutPrefix(value, filePrefix); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	return value, nil
+}
+
+// EnvRef return the secret reference to the specified environment variable
+func EnvRef(name string) string {
+	return envPrefix + name
+}
@@ -0,0 +1,76 @@
+package secret
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	t.Setenv("GOFS_TEST_SECRET", "env_secret")
+	secretFile := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(secretFile, []byte("file_secret\r\n"), 0600); err != nil {
+		t.Errorf("write the secret file error => %v", err)
+		return
+	}
+
+	testCases := []struct {
+		name   string
+		value  string
+		expect string
+	}{
+		{"plain text", "plain_secret", "plain_secret"},
+		{"empty", "", ""},
+		{"env reference", EnvRef("GOFS_TEST_SECRET"), "env_secret"},
+		{"file reference", "file:" + secretFile, "file_secret"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := Resolve(tc.value)
+			if err != nil {
+				t.Errorf("Resolve: unexpected error => %v", err)
+				return
+			}
+			if actual != tc.expect {
+				t.Errorf("Resolve: expect to get %s, but actual get %s", tc.expect, actual)
+			}
+		})
+	}
+}
+
+func TestResolve_ReturnError(t *testing.T) {
+	testCases := []struct {
+		name  string
+		value string
+	}{
+		{"env not found", EnvRef("GOFS_TEST_SECRET_NOT_FOUND")},
+		{"file not found", "file:" + filepath.Join(t.TempDir(), "not_found.txt")},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Resolve(tc.value); err == nil {
+				t.Errorf("Resolve: expect to get an error but get nil")
+			}
+		})
+	}
+}
+
+func TestIsRef(t *testing.T) {
+	testCases := []struct {
+		value  string
+		expect bool
+	}{
+		{"env:NAME", true},
+		{"file:/path", true},
+		{"plain", false},
+		{"", false},
+		{"ENV:NAME", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			if actual := IsRef(tc.value); actual != tc.expect {
+				t.Errorf("IsRef: expect to get %v, but actual get %v", tc.expect, actual)
+			}
+		})
+	}
+}
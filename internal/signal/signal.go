@@ -24,11 +24,21 @@ type StopSignal func()
 
 // Notify receive signal and try to shut down
 func Notify(shutdown func() error, logger *logger.Logger) (NotifySignal, StopSignal) {
+	return NotifyWithReload(shutdown, nil, logger)
+}
+
+// NotifyWithReload receive signal and try to shut down, try to reload if receive the SIGHUP signal and the reload is not nil
+func NotifyWithReload(shutdown func() error, reload func() error, logger *logger.Logger) (NotifySignal, StopSignal) {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGABRT, syscall.SIGTERM)
 	go func() {
 		for {
 			s := <-c
+			if s == syscall.SIGHUP && reload != nil {
+				logger.Info("received a signal [%s], try to reload", s.String())
+				logger.ErrorIf(reload(), "reload error")
+				continue
+			}
 			switch s {
 			case syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGABRT, syscall.SIGTERM:
 				logger.Debug("received a signal [%s], waiting to exit", s.String())
@@ -89,3 +89,43 @@ func TestNotify_IgnoreSignal(t *testing.T) {
 		})
 	}
 }
+
+func TestNotifyWithReload(t *testing.T) {
+	logger := logger.NewTestLogger()
+	defer logger.Close()
+
+	reloaded := make(chan struct{}, 1)
+	shutdown := make(chan struct{}, 1)
+	ns, ss := NotifyWithReload(func() error {
+		shutdown <- struct{}{}
+		return nil
+	}, func() error {
+		reloaded <- struct{}{}
+		return errors.New("reload error mock")
+	}, logger)
+	defer ss()
+
+	if err := ns(syscall.SIGHUP, time.Second); err != nil {
+		t.Errorf("send SIGHUP signal error => %v", err)
+		return
+	}
+	select {
+	case <-reloaded:
+	case <-shutdown:
+		t.Errorf("expect to reload when receive the SIGHUP signal, actual shutdown")
+		return
+	case <-time.After(time.Second):
+		t.Errorf("expect to reload when receive the SIGHUP signal, actual timeout")
+		return
+	}
+
+	if err := ns(syscall.SIGTERM, time.Second); err != nil {
+		t.Errorf("send SIGTERM signal error => %v", err)
+		return
+	}
+	select {
+	case <-shutdown:
+	case <-time.After(time.Second):
+		t.Errorf("expect to shutdown when receive the SIGTERM signal, actual timeout")
+	}
+}
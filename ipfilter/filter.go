@@ -0,0 +1,114 @@
+package ipfilter
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+var errInvalidRule = errors.New("the ip rule must be an ip or a CIDR")
+
+// Filter an ip filter with the allow list and the deny list, the deny list takes precedence over the allow list
+type Filter struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+// NewFilter create an instance of the Filter, the allow and deny lists are the ips or the CIDRs separated by comma,
+// like 127.0.0.1,10.0.0.0/8, every ip is allowed if the allow list is empty
+func NewFilter(allow string, deny string) (*Filter, error) {
+	allowRules, err := ParseRules(allow)
+	if err != nil {
+		return nil, err
+	}
+	denyRules, err := ParseRules(deny)
+	if err != nil {
+		return nil, err
+	}
+	return &Filter{
+		allow: allowRules,
+		deny:  denyRules,
+	}, nil
+}
+
+// Enabled report whether the filter has any rule, the nil filter is disabled
+func (f *Filter) Enabled() bool {
+	return f != nil && (len(f.allow) > 0 || len(f.deny) > 0)
+}
+
+// Allow report whether the ip is allowed, the invalid ip is denied if the filter is enabled
+func (f *Filter) Allow(ip string) bool {
+	if !f.Enabled() {
+		return true
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	if contains(f.deny, addr) {
+		return false
+	}
+	return len(f.allow) == 0 || contains(f.allow, addr)
+}
+
+// ParseRules parse the ips or the CIDRs separated by comma, the empty items are ignored
+func ParseRules(rules string) (prefixes []netip.Prefix, err error) {
+	for _, rule := range strings.Split(rules, ",") {
+		rule = strings.TrimSpace(rule)
+		if len(rule) == 0 {
+			continue
+		}
+		prefix, err := parseRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes, nil
+}
+
+// Match report whether the ip matches any of the ips or the CIDRs, the invalid rules are ignored
+func Match(rules []string, ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, rule := range rules {
+		if prefix, err := parseRule(strings.TrimSpace(rule)); err == nil && prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRule parse an ip or a CIDR to a prefix, an ip is the prefix that only contains itself
+func parseRule(rule string) (netip.Prefix, error) {
+	if strings.Contains(rule, "/") {
+		prefix, err := netip.ParsePrefix(rule)
+		if err != nil {
+			return prefix, fmt.Errorf("%w => %s", errInvalidRule, rule)
+		}
+		if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(rule)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%w => %s", errInvalidRule, rule)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+func contains(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
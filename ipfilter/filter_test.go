@@ -0,0 +1,90 @@
+package ipfilter
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFilter_Allow(t *testing.T) {
+	testCases := []struct {
+		name   string
+		allow  string
+		deny   string
+		ip     string
+		expect bool
+	}{
+		{"no rule", "", "", "1.2.3.4", true},
+		{"no rule with invalid ip", "", "", "invalid", true},
+		{"allow the exact ip", "127.0.0.1", "", "127.0.0.1", true},
+		{"not in the allow list", "127.0.0.1", "", "127.0.0.2", false},
+		{"allow the CIDR", "10.0.0.0/8, 192.168.1.0/24", "", "10.1.2.3", true},
+		{"not in the allow CIDR", "10.0.0.0/8,192.168.1.0/24", "", "192.168.2.1", false},
+		{"deny the exact ip", "", "1.2.3.4", "1.2.3.4", false},
+		{"not in the deny list", "", "1.2.3.4", "1.2.3.5", true},
+		{"the deny list takes precedence", "10.0.0.0/8", "10.0.0.0/16", "10.0.1.1", false},
+		{"allowed by the CIDR but not denied", "10.0.0.0/8", "10.0.0.0/16", "10.1.0.1", true},
+		{"ipv6 CIDR", "fd00::/8", "", "fd12::1", true},
+		{"ipv4-mapped ipv6 address", "127.0.0.0/8", "", "::ffff:127.0.0.1", true},
+		{"ipv4-mapped ipv6 rule", "::ffff:10.0.0.0/104", "", "10.1.1.1", true},
+		{"invalid ip with rules", "127.0.0.1", "", "invalid", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := NewFilter(tc.allow, tc.deny)
+			if err != nil {
+				t.Errorf("NewFilter error => %v", err)
+				return
+			}
+			if actual := f.Allow(tc.ip); actual != tc.expect {
+				t.Errorf("expect to allow the ip %s [%v], but actual get %v", tc.ip, tc.expect, actual)
+			}
+		})
+	}
+
+	var f *Filter
+	if f.Enabled() || !f.Allow("1.2.3.4") {
+		t.Errorf("expect the nil filter allows every ip")
+	}
+}
+
+func TestNewFilter_ReturnError(t *testing.T) {
+	testCases := []struct {
+		name  string
+		allow string
+		deny  string
+	}{
+		{"invalid allow ip", "127.0.0.256", ""},
+		{"invalid deny CIDR", "", "10.0.0.0/33"},
+		{"hostname", "localhost", ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewFilter(tc.allow, tc.deny); !errors.Is(err, errInvalidRule) {
+				t.Errorf("expect to get error %v, but actual get %v", errInvalidRule, err)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	testCases := []struct {
+		name   string
+		rules  []string
+		ip     string
+		expect bool
+	}{
+		{"exact ip", []string{"127.0.0.1"}, "127.0.0.1", true},
+		{"CIDR", []string{"192.168.0.0/16"}, "192.168.10.1", true},
+		{"no matched rule", []string{"127.0.0.1", "192.168.0.0/16"}, "10.0.0.1", false},
+		{"ignore the invalid rule", []string{"invalid", " 10.0.0.0/8 "}, "10.0.0.1", true},
+		{"invalid ip", []string{"10.0.0.0/8"}, "invalid", false},
+		{"no rule", nil, "10.0.0.1", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := Match(tc.rules, tc.ip); actual != tc.expect {
+				t.Errorf("expect to match the ip %s [%v], but actual get %v", tc.ip, tc.expect, actual)
+			}
+		})
+	}
+}
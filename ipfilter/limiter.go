@@ -0,0 +1,108 @@
+package ipfilter
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// limiterSweepInterval the interval to remove the idle clients from the Limiter
+const limiterSweepInterval = time.Minute
+
+var (
+	errTooManyConns   = errors.New("too many concurrent connections from the ip")
+	errTooManyRequest = errors.New("too many requests from the ip")
+)
+
+// Limiter limit the concurrent connections and the request rate per ip
+type Limiter struct {
+	maxConns  int
+	limit     rate.Limit
+	burst     int
+	mu        sync.Mutex
+	clients   map[string]*client
+	lastSweep time.Time
+}
+
+type client struct {
+	conns   int
+	limiter *rate.Limiter
+}
+
+// NewLimiter create an instance of the Limiter, the maxConns limits the concurrent connections per ip, the maxRate limits
+// the requests per second per ip and allows the burst requests at most, zero means unlimited, return nil if both are unlimited,
+// the burst is the maxRate rounded up by default
+func NewLimiter(maxConns int, maxRate float64, burst int) *Limiter {
+	if maxConns <= 0 && maxRate <= 0 {
+		return nil
+	}
+	l := &Limiter{
+		maxConns:  maxConns,
+		limit:     rate.Inf,
+		clients:   make(map[string]*client),
+		lastSweep: time.Now(),
+	}
+	if maxRate > 0 {
+		l.limit = rate.Limit(maxRate)
+		l.burst = burst
+		if l.burst <= 0 {
+			l.burst = int(math.Ceil(maxRate))
+		}
+	}
+	return l
+}
+
+// Enabled report whether the limiter has any limit, the nil limiter is disabled
+func (l *Limiter) Enabled() bool {
+	return l != nil
+}
+
+// Acquire acquire a connection of the ip, return an error if the ip exceeds the limits,
+// the release function must be called when the connection is closed
+func (l *Limiter) Acquire(ip string) (release func(), err error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+	c := l.clients[ip]
+	if c == nil {
+		c = &client{
+			limiter: rate.NewLimiter(l.limit, l.burst),
+		}
+		l.clients[ip] = c
+	}
+	if l.maxConns > 0 && c.conns >= l.maxConns {
+		return nil, errTooManyConns
+	}
+	if !c.limiter.AllowN(now, 1) {
+		return nil, errTooManyRequest
+	}
+	c.conns++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			c.conns--
+			l.mu.Unlock()
+		})
+	}, nil
+}
+
+// sweep remove the clients that have no connection and have recovered all the tokens to release the memory
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < limiterSweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for ip, c := range l.clients {
+		if c.conns == 0 && (l.limit == rate.Inf || c.limiter.TokensAt(now) >= float64(l.burst)) {
+			delete(l.clients, ip)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package ipfilter
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimiter_MaxConns(t *testing.T) {
+	l := NewLimiter(2, 0, 0)
+	var releases []func()
+	testCases := []struct {
+		name   string
+		ip     string
+		expect error
+	}{
+		{"first connection", "127.0.0.1", nil},
+		{"second connection", "127.0.0.1", nil},
+		{"third connection exceeds the limit", "127.0.0.1", errTooManyConns},
+		{"the other ip is not limited", "127.0.0.2", nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			release, err := l.Acquire(tc.ip)
+			if !errors.Is(err, tc.expect) {
+				t.Errorf("expect to get error %v, but actual get %v", tc.expect, err)
+			}
+			if err == nil {
+				releases = append(releases, release)
+			}
+		})
+	}
+
+	// release twice is the same as release once
+	releases[0]()
+	releases[0]()
+	if _, err := l.Acquire("127.0.0.1"); err != nil {
+		t.Errorf("expect to acquire the connection after release, but actual get %v", err)
+	}
+	if _, err := l.Acquire("127.0.0.1"); !errors.Is(err, errTooManyConns) {
+		t.Errorf("expect to get error %v, but actual get %v", errTooManyConns, err)
+	}
+}
+
+func TestLimiter_MaxRate(t *testing.T) {
+	l := NewLimiter(0, 1, 2)
+	for i := 0; i < 2; i++ {
+		release, err := l.Acquire("127.0.0.1")
+		if err != nil {
+			t.Errorf("expect the burst requests are allowed, but actual get %v", err)
+			return
+		}
+		release()
+	}
+	if _, err := l.Acquire("127.0.0.1"); !errors.Is(err, errTooManyRequest) {
+		t.Errorf("expect to get error %v, but actual get %v", errTooManyRequest, err)
+	}
+	release, err := l.Acquire("127.0.0.2")
+	if err != nil {
+		t.Errorf("expect the other ip is not limited, but actual get %v", err)
+		return
+	}
+	release()
+
+	// the idle clients are removed after they recover all the tokens
+	l.lastSweep = time.Now().Add(-limiterSweepInterval)
+	l.sweep(time.Now().Add(3 * time.Second))
+	if len(l.clients) != 0 {
+		t.Errorf("expect the idle clients are removed, but actual get %d clients", len(l.clients))
+	}
+}
+
+func TestLimiter_Disabled(t *testing.T) {
+	l := NewLimiter(0, 0, 0)
+	if l.Enabled() {
+		t.Errorf("expect the limiter is disabled without any limit")
+	}
+	release, err := l.Acquire("127.0.0.1")
+	if err != nil {
+		t.Errorf("expect the disabled limiter allows every ip, but actual get %v", err)
+		return
+	}
+	release()
+}
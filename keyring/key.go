@@ -0,0 +1,140 @@
+package keyring
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// Argon2id the Argon2id key derivation function
+	Argon2id = "argon2id"
+	// Scrypt the scrypt key derivation function
+	Scrypt = "scrypt"
+
+	// DefaultKDF the default key derivation function to create the new key
+	DefaultKDF = Argon2id
+
+	// KeyIDSize the size of the key id in bytes
+	KeyIDSize = 8
+	// KeySize the size of the derived key in bytes
+	KeySize = 32
+
+	saltSize    = 32
+	minSaltSize = 16
+
+	argon2Time    = 3
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+	// maxMemory the max memory in KiB that the key derivation can use
+	maxMemory = 4 * 1024 * 1024
+
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+var (
+	errUnsupportedKDF = errors.New("unsupported key derivation function")
+	errInvalidKey     = errors.New("invalid key in the keyfile")
+	errEmptyPassword  = errors.New("the passphrase can't be empty")
+)
+
+// Key the key derivation parameters of a passphrase, the passphrase itself is never stored
+type Key struct {
+	ID        string    `json:"id"`
+	KDF       string    `json:"kdf"`
+	Salt      []byte    `json:"salt"`
+	Time      uint32    `json:"time,omitempty"`
+	Memory    uint32    `json:"memory,omitempty"`
+	Threads   uint8     `json:"threads,omitempty"`
+	N         int       `json:"n,omitempty"`
+	R         int       `json:"r,omitempty"`
+	P         int       `json:"p,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewKey create a new key with a random id and salt by the specified key derivation function
+func NewKey(kdf string) (*Key, error) {
+	if err := CheckKDF(kdf); err != nil {
+		return nil, err
+	}
+	id := make([]byte, KeyIDSize)
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	k := &Key{
+		ID:        hex.EncodeToString(id),
+		KDF:       kdf,
+		Salt:      salt,
+		CreatedAt: time.Now(),
+	}
+	if kdf == Argon2id {
+		k.Time, k.Memory, k.Threads = argon2Time, argon2Memory, argon2Threads
+	} else {
+		k.N, k.R, k.P = scryptN, scryptR, scryptP
+	}
+	return k, nil
+}
+
+// CheckKDF check the key derivation function is supported
+func CheckKDF(kdf string) error {
+	if kdf == Argon2id || kdf == Scrypt {
+		return nil
+	}
+	return fmt.Errorf("%w => %s", errUnsupportedKDF, kdf)
+}
+
+// IDBytes returns the binary form of the key id
+func (k *Key) IDBytes() ([]byte, error) {
+	id, err := hex.DecodeString(k.ID)
+	if err != nil || len(id) != KeyIDSize {
+		return nil, fmt.Errorf("%w => invalid id %s", errInvalidKey, k.ID)
+	}
+	return id, nil
+}
+
+// Derive derive the key from the passphrase by the parameters of the key
+func (k *Key) Derive(passphrase []byte) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, errEmptyPassword
+	}
+	if err := k.check(); err != nil {
+		return nil, err
+	}
+	if k.KDF == Argon2id {
+		return argon2.IDKey(passphrase, k.Salt, k.Time, k.Memory, k.Threads, KeySize), nil
+	}
+	return scrypt.Key(passphrase, k.Salt, k.N, k.R, k.P, KeySize)
+}
+
+func (k *Key) check() error {
+	if _, err := k.IDBytes(); err != nil {
+		return err
+	}
+	if len(k.Salt) < minSaltSize {
+		return fmt.Errorf("%w => the salt of key %s is too short", errInvalidKey, k.ID)
+	}
+	switch k.KDF {
+	case Argon2id:
+		if k.Time == 0 || k.Threads == 0 || k.Memory == 0 || k.Memory > maxMemory {
+			return fmt.Errorf("%w => invalid argon2id parameters of key %s", errInvalidKey, k.ID)
+		}
+	case Scrypt:
+		if k.R <= 0 || k.P <= 0 || k.N <= 1 || k.N&(k.N-1) != 0 || uint64(k.N)*uint64(k.R) > maxMemory*1024/128 {
+			return fmt.Errorf("%w => invalid scrypt parameters of key %s", errInvalidKey, k.ID)
+		}
+	default:
+		return fmt.Errorf("%w => %s", errUnsupportedKDF, k.KDF)
+	}
+	return nil
+}
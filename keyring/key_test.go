@@ -0,0 +1,107 @@
+package keyring
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestNewKey(t *testing.T) {
+	testCases := []struct {
+		name   string
+		kdf    string
+		expect error
+	}{
+		{"argon2id", Argon2id, nil},
+		{"scrypt", Scrypt, nil},
+		{"unsupported", "pbkdf2", errUnsupportedKDF},
+		{"empty", "", errUnsupportedKDF},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			k, err := NewKey(tc.kdf)
+			if !errors.Is(err, tc.expect) {
+				t.Errorf("expect to get error %v, but get %v", tc.expect, err)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if id, err := k.IDBytes(); err != nil || len(id) != KeyIDSize {
+				t.Errorf("expect to get a valid key id, but get %s => %v", k.ID, err)
+			}
+			if err = k.check(); err != nil {
+				t.Errorf("expect to get a valid key, but get error %v", err)
+			}
+		})
+	}
+}
+
+func TestKey_Derive(t *testing.T) {
+	for _, kdf := range []string{Argon2id, Scrypt} {
+		t.Run(kdf, func(t *testing.T) {
+			k, err := NewKey(kdf)
+			if err != nil {
+				t.Errorf("create key error => %v", err)
+				return
+			}
+			k1, err := k.Derive([]byte("correct horse battery staple"))
+			if err != nil {
+				t.Errorf("derive key error => %v", err)
+				return
+			}
+			k2, err := k.Derive([]byte("correct horse battery staple"))
+			if err != nil {
+				t.Errorf("derive key error => %v", err)
+				return
+			}
+			k3, err := k.Derive([]byte("another passphrase"))
+			if err != nil {
+				t.Errorf("derive key error => %v", err)
+				return
+			}
+			if len(k1) != KeySize {
+				t.Errorf("expect to get the key size %d, but get %d", KeySize, len(k1))
+			}
+			if !bytes.Equal(k1, k2) {
+				t.Errorf("expect to derive the same key from the same passphrase")
+			}
+			if bytes.Equal(k1, k3) {
+				t.Errorf("expect to derive the different keys from the different passphrases")
+			}
+		})
+	}
+}
+
+func TestKey_Derive_ReturnError(t *testing.T) {
+	newKey := func(modify func(k *Key)) *Key {
+		k, err := NewKey(Argon2id)
+		if err != nil {
+			t.Fatalf("create key error => %v", err)
+		}
+		modify(k)
+		return k
+	}
+	testCases := []struct {
+		name       string
+		key        *Key
+		passphrase string
+		expect     error
+	}{
+		{"empty passphrase", newKey(func(k *Key) {}), "", errEmptyPassword},
+		{"invalid id", newKey(func(k *Key) { k.ID = "xyz" }), "passphrase", errInvalidKey},
+		{"short salt", newKey(func(k *Key) { k.Salt = k.Salt[:8] }), "passphrase", errInvalidKey},
+		{"zero time", newKey(func(k *Key) { k.Time = 0 }), "passphrase", errInvalidKey},
+		{"too much memory", newKey(func(k *Key) { k.Memory = maxMemory + 1 }), "passphrase", errInvalidKey},
+		{"invalid scrypt n", newKey(func(k *Key) { k.KDF, k.N, k.R, k.P = Scrypt, 1000, 8, 1 }), "passphrase", errInvalidKey},
+		{"unsupported kdf", newKey(func(k *Key) { k.KDF = "pbkdf2" }), "passphrase", errUnsupportedKDF},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.key.Derive([]byte(tc.passphrase))
+			if !errors.Is(err, tc.expect) {
+				t.Errorf("expect to get error %v, but get %v", tc.expect, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package keyring
+
+import (
+	"bytes"
+	"crypto/hkdf"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/no-src/nsgo/jsonutil"
+)
+
+const tokenSecretInfo = "gofs token secret"
+
+var (
+	errKeyNotFound       = errors.New("the key is not found in the keyfile")
+	errActiveKeyNotFound = errors.New("the active key is not found in the keyfile")
+)
+
+// KeyRing the keyfile that stores the key derivation parameters of multiple keys, the active key is used to encrypt
+// the new files, and the other keys are kept to decrypt the files that are encrypted by them
+type KeyRing struct {
+	Active string `json:"active"`
+	Keys   []*Key `json:"keys"`
+
+	path    string
+	mu      sync.Mutex
+	derived map[string][]byte
+}
+
+// Load load the keyfile from the path
+func Load(path string) (*KeyRing, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	kr := &KeyRing{}
+	if err = jsonutil.Unmarshal(data, kr); err != nil {
+		return nil, fmt.Errorf("parse the keyfile error => %s, %w", path, err)
+	}
+	if _, err = kr.ActiveKey(); err != nil {
+		return nil, err
+	}
+	for _, k := range kr.Keys {
+		if err = k.check(); err != nil {
+			return nil, err
+		}
+	}
+	kr.path = path
+	kr.derived = make(map[string][]byte)
+	return kr, nil
+}
+
+// LoadOrCreate load the keyfile from the path, create a new keyfile with an active key if it does not exist
+func LoadOrCreate(path string, kdf string) (*KeyRing, error) {
+	kr, err := Load(path)
+	if !os.IsNotExist(err) {
+		return kr, err
+	}
+	k, err := NewKey(kdf)
+	if err != nil {
+		return nil, err
+	}
+	kr = &KeyRing{
+		Active:  k.ID,
+		Keys:    []*Key{k},
+		path:    path,
+		derived: make(map[string][]byte),
+	}
+	data, err := kr.marshal()
+	if err != nil {
+		return nil, err
+	}
+	// create the keyfile exclusively, load the keyfile if it is created by another job in the meantime
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		return Load(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return kr, nil
+}
+
+// ActiveKey returns the active key that is used to encrypt the new files
+func (kr *KeyRing) ActiveKey() (*Key, error) {
+	k, err := kr.Key(kr.Active)
+	if err != nil {
+		return nil, fmt.Errorf("%w => %s", errActiveKeyNotFound, kr.Active)
+	}
+	return k, nil
+}
+
+// Key returns the key of the specified id
+func (kr *KeyRing) Key(id string) (*Key, error) {
+	for _, k := range kr.Keys {
+		if k.ID == id {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("%w => %s", errKeyNotFound, id)
+}
+
+// KeyByIDBytes returns the key of the specified binary id
+func (kr *KeyRing) KeyByIDBytes(id []byte) (*Key, error) {
+	for _, k := range kr.Keys {
+		if kid, err := k.IDBytes(); err == nil && bytes.Equal(kid, id) {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("%w => %x", errKeyNotFound, id)
+}
+
+// Derive derive the key from the passphrase and cache the result
+func (kr *KeyRing) Derive(k *Key, passphrase []byte) ([]byte, error) {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	cacheKey := k.ID + "/" + string(passphrase)
+	if key, ok := kr.derived[cacheKey]; ok {
+		return key, nil
+	}
+	key, err := k.Derive(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	kr.derived[cacheKey] = key
+	return key, nil
+}
+
+// Rotate create a new key by the specified key derivation function, mark it as the active key and save the keyfile,
+// the old keys are kept to decrypt the old files
+func (kr *KeyRing) Rotate(kdf string) (*Key, error) {
+	k, err := NewKey(kdf)
+	if err != nil {
+		return nil, err
+	}
+	kr.Keys = append(kr.Keys, k)
+	kr.Active = k.ID
+	return k, kr.save()
+}
+
+func (kr *KeyRing) save() error {
+	data, err := kr.marshal()
+	if err != nil {
+		return err
+	}
+	// write to a temporary file first, avoid to break the keyfile
+	tmp := kr.path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, kr.path)
+}
+
+func (kr *KeyRing) marshal() ([]byte, error) {
+	return jsonutil.MarshalIndent(kr)
+}
+
+// TokenSecret derive the token secret from the passphrase by the first key of the keyfile, the first key is never
+// changed by the key rotation, so the issued tokens are still valid after the rotation.
+// The keyfile is created if it does not exist
+func TokenSecret(path string, kdf string, passphrase string) (string, error) {
+	kr, err := LoadOrCreate(path, kdf)
+	if err != nil {
+		return "", err
+	}
+	key, err := kr.Derive(kr.Keys[0], []byte(passphrase))
+	if err != nil {
+		return "", err
+	}
+	secret, err := hkdf.Key(sha256.New, key, nil, tokenSecretInfo, KeySize)
+	return string(secret), err
+}
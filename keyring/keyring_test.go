@@ -0,0 +1,137 @@
+package keyring
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadOrCreate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gofs.key")
+	kr, err := LoadOrCreate(path, Scrypt)
+	if err != nil {
+		t.Errorf("create keyfile error => %v", err)
+		return
+	}
+	active, err := kr.ActiveKey()
+	if err != nil {
+		t.Errorf("get active key error => %v", err)
+		return
+	}
+	if active.KDF != Scrypt {
+		t.Errorf("expect to get the kdf %s, but get %s", Scrypt, active.KDF)
+	}
+
+	// load the exist keyfile
+	loaded, err := LoadOrCreate(path, Argon2id)
+	if err != nil {
+		t.Errorf("load keyfile error => %v", err)
+		return
+	}
+	if loaded.Active != kr.Active || len(loaded.Keys) != 1 {
+		t.Errorf("expect to load the exist keyfile, but get active key %s with %d keys", loaded.Active, len(loaded.Keys))
+	}
+
+	// rotate to a new key
+	k, err := loaded.Rotate(Argon2id)
+	if err != nil {
+		t.Errorf("rotate key error => %v", err)
+		return
+	}
+	rotated, err := Load(path)
+	if err != nil {
+		t.Errorf("load keyfile error => %v", err)
+		return
+	}
+	if rotated.Active != k.ID || len(rotated.Keys) != 2 {
+		t.Errorf("expect to get the active key %s with 2 keys, but get %s with %d keys", k.ID, rotated.Active, len(rotated.Keys))
+	}
+	if _, err = rotated.Key(active.ID); err != nil {
+		t.Errorf("expect to keep the old key, but get error %v", err)
+	}
+	id, err := k.IDBytes()
+	if err != nil {
+		t.Errorf("get key id error => %v", err)
+		return
+	}
+	if found, err := rotated.KeyByIDBytes(id); err != nil || found.ID != k.ID {
+		t.Errorf("expect to find the key by id %s, but get error %v", k.ID, err)
+	}
+	if _, err = rotated.KeyByIDBytes(make([]byte, KeyIDSize)); !errors.Is(err, errKeyNotFound) {
+		t.Errorf("expect to get error %v, but get %v", errKeyNotFound, err)
+	}
+}
+
+func TestLoad_ReturnError(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("write keyfile error => %v", err)
+		}
+		return path
+	}
+	testCases := []struct {
+		name   string
+		path   string
+		expect error
+	}{
+		{"not exist", filepath.Join(dir, "not_exist.key"), os.ErrNotExist},
+		{"no active key", write("no_active.key", `{"active":"0011223344556677","keys":[]}`), errActiveKeyNotFound},
+		{"invalid key", write("invalid_key.key", `{"active":"0011223344556677","keys":[{"id":"0011223344556677","kdf":"argon2id"}]}`), errInvalidKey},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Load(tc.path)
+			if !errors.Is(err, tc.expect) {
+				t.Errorf("expect to get error %v, but get %v", tc.expect, err)
+			}
+		})
+	}
+
+	if _, err := Load(write("invalid_json.key", "{")); err == nil {
+		t.Errorf("expect to get an error with the invalid json keyfile")
+	}
+}
+
+func TestTokenSecret(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gofs.key")
+	s1, err := TokenSecret(path, Scrypt, "token passphrase")
+	if err != nil {
+		t.Errorf("derive token secret error => %v", err)
+		return
+	}
+	s2, err := TokenSecret(path, Scrypt, "token passphrase")
+	if err != nil {
+		t.Errorf("derive token secret error => %v", err)
+		return
+	}
+	if len(s1) != KeySize {
+		t.Errorf("expect to get the token secret size %d, but get %d", KeySize, len(s1))
+	}
+	if s1 != s2 {
+		t.Errorf("expect to derive the same token secret from the same keyfile and passphrase")
+	}
+
+	// the rotation does not change the token secret
+	kr, err := Load(path)
+	if err == nil {
+		_, err = kr.Rotate(Scrypt)
+	}
+	if err != nil {
+		t.Errorf("rotate key error => %v", err)
+		return
+	}
+	s3, err := TokenSecret(path, Scrypt, "token passphrase")
+	if err != nil {
+		t.Errorf("derive token secret error => %v", err)
+		return
+	}
+	if s1 != s3 {
+		t.Errorf("expect to derive the same token secret after the key rotation")
+	}
+	if _, err = TokenSecret(path, Scrypt, ""); !errors.Is(err, errEmptyPassword) {
+		t.Errorf("expect to get error %v, but get %v", errEmptyPassword, err)
+	}
+}
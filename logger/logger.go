@@ -26,11 +26,11 @@ type Logger struct {
 	Sample log.Logger
 }
 
-// NewLogger create an instance of Logger
+// NewLogger create an instance of Logger, the loggers can be replaced by Reload at runtime
 func NewLogger(logger, sample log.Logger) *Logger {
 	return &Logger{
-		Logger: logger,
-		Sample: sample,
+		Logger: newReloadableLogger(logger),
+		Sample: newReloadableLogger(sample),
 	}
 }
 
@@ -0,0 +1,104 @@
+package logger
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/no-src/log"
+	"github.com/no-src/log/formatter"
+)
+
+var errNotReloadable = errors.New("the logger is not reloadable")
+
+// reloadableLogger a log.Logger that supports to replace the real logger at runtime
+type reloadableLogger struct {
+	v atomic.Value
+}
+
+type loggerHolder struct {
+	log.Logger
+}
+
+func newReloadableLogger(logger log.Logger) *reloadableLogger {
+	if rl, ok := logger.(*reloadableLogger); ok {
+		return rl
+	}
+	rl := &reloadableLogger{}
+	rl.store(logger)
+	return rl
+}
+
+func (rl *reloadableLogger) load() log.Logger {
+	return rl.v.Load().(loggerHolder).Logger
+}
+
+func (rl *reloadableLogger) store(logger log.Logger) {
+	rl.v.Store(loggerHolder{logger})
+}
+
+// swap replace the real logger and return the old one
+func (rl *reloadableLogger) swap(logger log.Logger) log.Logger {
+	return rl.v.Swap(loggerHolder{logger}).(loggerHolder).Logger
+}
+
+func (rl *reloadableLogger) Write(p []byte) (n int, err error) {
+	return rl.load().Write(p)
+}
+
+func (rl *reloadableLogger) Log(format string, args ...any) {
+	rl.load().Log(format, args...)
+}
+
+func (rl *reloadableLogger) Close() error {
+	return rl.load().Close()
+}
+
+func (rl *reloadableLogger) WithFormatter(f formatter.Formatter) log.Logger {
+	rl.load().WithFormatter(f)
+	return rl
+}
+
+func (rl *reloadableLogger) WithTimeFormat(f string) log.Logger {
+	rl.load().WithTimeFormat(f)
+	return rl
+}
+
+func (rl *reloadableLogger) Debug(format string, args ...any) {
+	rl.load().Debug(format, args...)
+}
+
+func (rl *reloadableLogger) Info(format string, args ...any) {
+	rl.load().Info(format, args...)
+}
+
+func (rl *reloadableLogger) Warn(format string, args ...any) {
+	rl.load().Warn(format, args...)
+}
+
+func (rl *reloadableLogger) Error(err error, format string, args ...any) {
+	rl.load().Error(err, format, args...)
+}
+
+func (rl *reloadableLogger) ErrorIf(err error, format string, args ...any) error {
+	return rl.load().ErrorIf(err, format, args...)
+}
+
+// Reload replace the current loggers with the specified logger, and close the old loggers, it is used to change the log level or rotate the log files at runtime.
+// The specified logger should not be used anymore after reloading.
+func (l *Logger) Reload(nl *Logger) error {
+	current, ok := l.Logger.(*reloadableLogger)
+	currentSample, sampleOk := l.Sample.(*reloadableLogger)
+	if !ok || !sampleOk || nl == nil {
+		return errNotReloadable
+	}
+	newLogger, newSample := unwrap(nl.Logger), unwrap(nl.Sample)
+	currentSample.swap(newSample)
+	return current.swap(newLogger).Close()
+}
+
+func unwrap(logger log.Logger) log.Logger {
+	if rl, ok := logger.(*reloadableLogger); ok {
+		return rl.load()
+	}
+	return logger
+}
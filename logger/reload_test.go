@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/no-src/log"
+)
+
+func TestLogger_Reload(t *testing.T) {
+	oldLogger := &closeCounterLogger{Logger: log.NewEmptyLogger()}
+	l := NewLogger(oldLogger, oldLogger)
+	newLogger := &closeCounterLogger{Logger: log.NewEmptyLogger()}
+	if err := l.Reload(NewLogger(newLogger, newLogger)); err != nil {
+		t.Errorf("reload logger error => %v", err)
+		return
+	}
+	if oldLogger.closed != 1 {
+		t.Errorf("expect to close the old logger once, actual close %d times", oldLogger.closed)
+	}
+	if got := unwrap(l.Logger); got != newLogger {
+		t.Errorf("expect to get the new logger after reloading, actual get %v", got)
+	}
+	if got := unwrap(l.Sample); got != newLogger {
+		t.Errorf("expect to get the new sample logger after reloading, actual get %v", got)
+	}
+	testLogger("reload", l)
+	if err := l.Close(); err != nil {
+		t.Errorf("close logger error => %v", err)
+	}
+	if newLogger.closed != 1 {
+		t.Errorf("expect to close the new logger once, actual close %d times", newLogger.closed)
+	}
+}
+
+func TestLogger_Reload_ReturnError(t *testing.T) {
+	testCases := []struct {
+		name   string
+		logger *Logger
+		nl     *Logger
+	}{
+		{"nil new logger", NewEmptyLogger(), nil},
+		{"not reloadable logger", &Logger{Logger: log.NewEmptyLogger(), Sample: log.NewEmptyLogger()}, NewEmptyLogger()},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.logger.Reload(tc.nl); !errors.Is(err, errNotReloadable) {
+				t.Errorf("expect to get error %v, actual get %v", errNotReloadable, err)
+			}
+		})
+	}
+}
+
+type closeCounterLogger struct {
+	log.Logger
+	closed int
+}
+
+func (l *closeCounterLogger) Close() error {
+	l.closed++
+	return l.Logger.Close()
+}
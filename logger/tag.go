@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"github.com/no-src/log"
+	"github.com/no-src/log/formatter"
+)
+
+// tagLogger a log.Logger that adds a tag to the beginning of every log, the real logger is not closed by it
+type tagLogger struct {
+	log.Logger
+
+	tag string
+}
+
+func newTagLogger(logger log.Logger, tag string) log.Logger {
+	return &tagLogger{
+		Logger: logger,
+		tag:    "[" + tag + "] ",
+	}
+}
+
+func (l *tagLogger) Log(format string, args ...any) {
+	l.Logger.Log(l.tag+format, args...)
+}
+
+func (l *tagLogger) Close() error {
+	return nil
+}
+
+func (l *tagLogger) WithFormatter(f formatter.Formatter) log.Logger {
+	l.Logger.WithFormatter(f)
+	return l
+}
+
+func (l *tagLogger) WithTimeFormat(f string) log.Logger {
+	l.Logger.WithTimeFormat(f)
+	return l
+}
+
+func (l *tagLogger) Debug(format string, args ...any) {
+	l.Logger.Debug(l.tag+format, args...)
+}
+
+func (l *tagLogger) Info(format string, args ...any) {
+	l.Logger.Info(l.tag+format, args...)
+}
+
+func (l *tagLogger) Warn(format string, args ...any) {
+	l.Logger.Warn(l.tag+format, args...)
+}
+
+func (l *tagLogger) Error(err error, format string, args ...any) {
+	l.Logger.Error(err, l.tag+format, args...)
+}
+
+func (l *tagLogger) ErrorIf(err error, format string, args ...any) error {
+	return l.Logger.ErrorIf(err, l.tag+format, args...)
+}
+
+// WithTag return a logger that adds the tag to the beginning of every log and shares the current loggers,
+// closing the returned logger does not close the current loggers
+func (l *Logger) WithTag(tag string) *Logger {
+	return &Logger{
+		Logger: newTagLogger(l.Logger, tag),
+		Sample: newTagLogger(l.Sample, tag),
+	}
+}
@@ -0,0 +1,41 @@
+package logger
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/no-src/log"
+)
+
+func TestLogger_WithTag(t *testing.T) {
+	rl := &recordLogger{Logger: log.NewEmptyLogger()}
+	l := NewLogger(rl, rl)
+	tl := l.WithTag("job1")
+	testLogger("tag", tl)
+	tl.Info("hello %s", "gofs")
+	expect := "[job1] hello gofs"
+	if actual := rl.last; actual != expect {
+		t.Errorf("expect to get log [%s], actual get [%s]", expect, actual)
+	}
+	if err := tl.Close(); err != nil {
+		t.Errorf("close tag logger error => %v", err)
+	}
+	if rl.closed {
+		t.Errorf("expect not to close the real logger when closing the tag logger")
+	}
+}
+
+type recordLogger struct {
+	log.Logger
+	last   string
+	closed bool
+}
+
+func (l *recordLogger) Info(format string, args ...any) {
+	l.last = fmt.Sprintf(format, args...)
+}
+
+func (l *recordLogger) Close() error {
+	l.closed = true
+	return nil
+}
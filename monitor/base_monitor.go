@@ -27,10 +27,12 @@ type baseMonitor struct {
 	writeNotify     chan struct{}
 	mu              sync.Mutex
 	syncSpec        string
+	cron            *cron.Cron
 	cronChan        chan struct{}
 	shutdown        chan struct{}
 	syncOnce        bool
 	el              eventlog.EventLog
+	syncDelayMu     sync.RWMutex
 	enableSyncDelay bool
 	syncDelayEvents int
 	syncDelayTime   time.Duration
@@ -259,10 +261,18 @@ func (m *baseMonitor) startCron(f func() error) error {
 		return err
 	}
 	m.logger.Info("cron task starting, spec=[%s] id=[%d]", m.syncSpec, id)
+	m.cron = c
 	c.Start()
 	return nil
 }
 
+// stopCron stop the cron task if it is started
+func (m *baseMonitor) stopCron() {
+	if m.cron != nil {
+		m.cron.Stop()
+	}
+}
+
 func (m *baseMonitor) SyncCron(spec string) error {
 	spec = strings.TrimSpace(spec)
 	if len(spec) == 0 {
@@ -288,17 +298,33 @@ func (m *baseMonitor) Shutdown() (err error) {
 	return err
 }
 
+func (m *baseMonitor) SetSyncDelay(enable bool, events int, delay time.Duration) {
+	m.syncDelayMu.Lock()
+	defer m.syncDelayMu.Unlock()
+	m.enableSyncDelay = enable
+	m.syncDelayEvents = events
+	m.syncDelayTime = delay
+}
+
+// syncDelay return a snapshot of the current sync delay settings
+func (m *baseMonitor) syncDelay() (enable bool, events int, delay time.Duration) {
+	m.syncDelayMu.RLock()
+	defer m.syncDelayMu.RUnlock()
+	return m.enableSyncDelay, m.syncDelayEvents, m.syncDelayTime
+}
+
 func (m *baseMonitor) waitSyncDelay(eventLenFunc func() int) {
 	for {
-		if m.enableSyncDelay && !m.syncing {
+		enableSyncDelay, syncDelayEvents, syncDelayTime := m.syncDelay()
+		if enableSyncDelay && !m.syncing {
 			currentEvents := eventLenFunc()
 			if currentEvents > 0 {
-				if currentEvents < m.syncDelayEvents && time.Now().Before(m.lastSyncTime.Add(m.syncDelayTime)) {
-					m.logger.Sample.Debug("[sync delay] [waiting] sync delay time => %s, sync delay events => %d, last sync time => %s, current event count => %d ", m.syncDelayTime, m.syncDelayEvents, m.lastSyncTime, currentEvents)
+				if currentEvents < syncDelayEvents && time.Now().Before(m.lastSyncTime.Add(syncDelayTime)) {
+					m.logger.Sample.Debug("[sync delay] [waiting] sync delay time => %s, sync delay events => %d, last sync time => %s, current event count => %d ", syncDelayTime, syncDelayEvents, m.lastSyncTime, currentEvents)
 					<-time.After(time.Second)
 					continue
 				}
-				m.logger.Debug("[sync delay] [starting] sync delay time => %s, sync delay events => %d, last sync time => %s, current event count => %d ", m.syncDelayTime, m.syncDelayEvents, m.lastSyncTime, currentEvents)
+				m.logger.Debug("[sync delay] [starting] sync delay time => %s, sync delay events => %d, last sync time => %s, current event count => %d ", syncDelayTime, syncDelayEvents, m.lastSyncTime, currentEvents)
 				m.syncing = true
 			}
 		}
@@ -308,11 +334,12 @@ func (m *baseMonitor) waitSyncDelay(eventLenFunc func() int) {
 
 func (m *baseMonitor) resetSyncDelay() {
 	m.lastSyncTime = time.Now()
-	if m.enableSyncDelay {
+	enableSyncDelay, syncDelayEvents, syncDelayTime := m.syncDelay()
+	if enableSyncDelay {
 		syncing := m.syncing
 		m.syncing = false
 		if syncing {
-			m.logger.Debug("[sync delay] [reset] sync delay time => %s, sync delay events => %d, last sync time => %s ", m.syncDelayTime, m.syncDelayEvents, m.lastSyncTime)
+			m.logger.Debug("[sync delay] [reset] sync delay time => %s, sync delay events => %d, last sync time => %s ", syncDelayTime, syncDelayEvents, m.lastSyncTime)
 		}
 	} else {
 		m.syncing = true
@@ -56,5 +56,6 @@ func (m *driverPullClientMonitor) sync() (err error) {
 }
 
 func (m *driverPullClientMonitor) Close() error {
+	m.stopCron()
 	return nil
 }
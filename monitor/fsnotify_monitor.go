@@ -158,7 +158,7 @@ func (m *fsNotifyMonitor) startProcessEvents() error {
 			continue
 		}
 		event := element.Value.(fsnotify.Event)
-		if m.pi.MatchPath(event.Name, "monitor", event.Op.String()) {
+		if m.pi.MatchFileInfo(event.Name, m.lstat(event.Name), "monitor", event.Op.String()) {
 			// if the rule is matched, then ignore the event except create a directory, because of the subdirectory maybe not match the ignore rule.
 			// so we should monitor the current directory here, otherwise we will lose some data.
 			// for example, we define an ignore rule "/home/logs/*" and create a directory "/home/logs" to trigger Create event, then create a file "/home/logs/2022/info.log".
@@ -186,6 +186,15 @@ func (m *fsNotifyMonitor) startProcessEvents() error {
 	}
 }
 
+// lstat returns the file info of the path, returns nil if the path does not exist or getting the file info fails
+func (m *fsNotifyMonitor) lstat(path string) fs.FileInfo {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return nil
+	}
+	return fi
+}
+
 func (m *fsNotifyMonitor) write(event fsnotify.Event) {
 	// ignore is not exist error
 	if err := m.syncer.Create(event.Name); err != nil && !os.IsNotExist(err) {
@@ -273,6 +282,7 @@ func (m *fsNotifyMonitor) monitorDirIfCreate(event fsnotify.Event) {
 }
 
 func (m *fsNotifyMonitor) Close() error {
+	m.stopCron()
 	if m.watcher != nil {
 		return m.watcher.Close()
 	}
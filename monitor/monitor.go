@@ -2,6 +2,7 @@ package monitor
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/no-src/gofs/core"
 	"github.com/no-src/gofs/result"
@@ -18,6 +19,8 @@ type Monitor interface {
 	SyncCron(spec string) error
 	// Shutdown exit the Start
 	Shutdown() error
+	// SetSyncDelay update the sync delay settings at runtime
+	SetSyncDelay(enable bool, events int, delay time.Duration)
 }
 
 type runFn func(content string, ext string) result.Result
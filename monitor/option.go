@@ -19,11 +19,15 @@ type Option struct {
 	SyncOnce            bool
 	EnableTLS           bool
 	TLSCertFile         string
+	TLSClientCertFile   string
+	TLSClientKeyFile    string
+	TLSPin              string
+	TLSKnownHostsFile   string
 	EnableSyncDelay     bool
 	SyncDelayEvents     int
 	SyncDelayTime       time.Duration
 	SyncWorkers         int
-	Users               []*auth.User
+	Users               *auth.UserStore
 	EventWriter         io.Writer
 	Syncer              sync.Sync
 	Retry               retry.Retry
@@ -36,11 +40,15 @@ type Option struct {
 }
 
 // NewMonitorOption create an instance of the Option, store all the monitor component options
-func NewMonitorOption(config conf.Config, syncer sync.Sync, retry retry.Retry, users []*auth.User, eventWriter io.Writer, pi ignore.PathIgnore, reporter report.Reporter, logger *logger.Logger) Option {
+func NewMonitorOption(config conf.Config, syncer sync.Sync, retry retry.Retry, users *auth.UserStore, eventWriter io.Writer, pi ignore.PathIgnore, reporter report.Reporter, logger *logger.Logger) Option {
 	opt := Option{
 		SyncOnce:            config.SyncOnce,
 		EnableTLS:           config.EnableTLS,
 		TLSCertFile:         config.TLSCertFile,
+		TLSClientCertFile:   config.TLSClientCertFile,
+		TLSClientKeyFile:    config.TLSClientKeyFile,
+		TLSPin:              config.TLSPin,
+		TLSKnownHostsFile:   config.TLSKnownHostsFile,
 		EnableSyncDelay:     config.EnableSyncDelay,
 		SyncDelayEvents:     config.SyncDelayEvents,
 		SyncDelayTime:       config.SyncDelayTime.Duration(),
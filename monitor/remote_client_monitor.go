@@ -3,6 +3,7 @@ package monitor
 import (
 	"errors"
 	"fmt"
+	"io/fs"
 	"net/url"
 	"os"
 	"sync/atomic"
@@ -16,6 +17,7 @@ import (
 	"github.com/no-src/gofs/eventlog"
 	"github.com/no-src/gofs/ignore"
 	"github.com/no-src/gofs/internal/clist"
+	"github.com/no-src/gofs/tlsutil"
 	"github.com/no-src/gofs/wait"
 	"github.com/no-src/nsgo/fsutil"
 	"github.com/no-src/nsgo/stringutil"
@@ -38,7 +40,10 @@ func NewRemoteClientMonitor(opt Option) (Monitor, error) {
 	port := source.Port()
 	enableTLS := opt.EnableTLS
 	certFile := opt.TLSCertFile
-	users := opt.Users
+	clientCertFile := opt.TLSClientCertFile
+	clientKeyFile := opt.TLSClientKeyFile
+	pin := tlsutil.Pin{Fingerprint: opt.TLSPin, KnownHostsFile: opt.TLSKnownHostsFile, Host: host}
+	users := opt.Users.Users()
 	pi := opt.PathIgnore
 
 	if syncer == nil {
@@ -51,7 +56,7 @@ func NewRemoteClientMonitor(opt Option) (Monitor, error) {
 		user = users[0]
 	}
 	m := &remoteClientMonitor{
-		client:      apiclient.New(host, port, enableTLS, certFile, user),
+		client:      apiclient.New(host, port, enableTLS, certFile, clientCertFile, clientKeyFile, pin, user),
 		messages:    clist.New(),
 		baseMonitor: newBaseMonitor(opt),
 		pi:          pi,
@@ -192,7 +197,7 @@ func (m *remoteClientMonitor) startProcessMessage() {
 		}
 		msg := element.Value.(*monitor.MonitorMessage)
 		m.logger.Info("client read request => %s", msg.String())
-		if m.pi.MatchPath(msg.FileInfo.Path, "remote client monitor", action.Action(msg.Action).String()) {
+		if m.pi.MatchFileInfo(msg.FileInfo.Path, m.fileInfo(msg), "remote client monitor", action.Action(msg.Action).String()) {
 			// ignore match
 		} else {
 			m.execSync(msg)
@@ -201,6 +206,23 @@ func (m *remoteClientMonitor) startProcessMessage() {
 	}
 }
 
+// fileInfo returns the file info of the file change message to check the attribute rules,
+// returns nil if the file is removed or renamed, because the file info of them is meaningless
+func (m *remoteClientMonitor) fileInfo(msg *monitor.MonitorMessage) fs.FileInfo {
+	act := action.Action(msg.Action)
+	if act == action.RemoveAction || act == action.RenameAction || msg.FileInfo == nil {
+		return nil
+	}
+	fi := msg.FileInfo
+	return contract.FileInfo{
+		Path:   fi.Path,
+		IsDir:  contract.FsDirValue(fi.IsDir),
+		Size:   fi.Size,
+		MTime:  fi.MTime,
+		LinkTo: fi.LinkTo,
+	}.Stat()
+}
+
 // execSync execute the file change message to sync
 func (m *remoteClientMonitor) execSync(msg *monitor.MonitorMessage) (err error) {
 	fi := msg.FileInfo
@@ -248,6 +270,7 @@ func (m *remoteClientMonitor) execSync(msg *monitor.MonitorMessage) (err error)
 // Close mark the monitor is closed, then close the connection
 func (m *remoteClientMonitor) Close() error {
 	m.closed.Store(true)
+	m.stopCron()
 	if m.client != nil {
 		return m.client.Stop()
 	}
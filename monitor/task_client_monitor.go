@@ -15,6 +15,7 @@ import (
 	"github.com/no-src/gofs/logger"
 	"github.com/no-src/gofs/result"
 	"github.com/no-src/gofs/retry"
+	"github.com/no-src/gofs/tlsutil"
 	"github.com/no-src/gofs/wait"
 	"github.com/no-src/nsgo/randutil"
 )
@@ -40,7 +41,10 @@ func NewTaskClientMonitor(opt Option, run runFn) (Monitor, error) {
 	port := source.Port()
 	enableTLS := opt.EnableTLS
 	certFile := opt.TLSCertFile
-	users := opt.Users
+	clientCertFile := opt.TLSClientCertFile
+	clientKeyFile := opt.TLSClientKeyFile
+	pin := tlsutil.Pin{Fingerprint: opt.TLSPin, KnownHostsFile: opt.TLSKnownHostsFile, Host: host}
+	users := opt.Users.Users()
 	labels := opt.TaskClientLabels
 	retry := opt.Retry
 	maxWorker := opt.TaskClientMaxWorker
@@ -53,7 +57,7 @@ func NewTaskClientMonitor(opt Option, run runFn) (Monitor, error) {
 	m := &taskClientMonitor{
 		shutdown: make(chan struct{}, 1),
 		retry:    retry,
-		client:   apiclient.New(host, port, enableTLS, certFile, user),
+		client:   apiclient.New(host, port, enableTLS, certFile, clientCertFile, clientKeyFile, pin, user),
 		runFn:    run,
 		clientId: randutil.RandomString(10),
 		labels:   labels,
@@ -162,6 +166,10 @@ func (m *taskClientMonitor) Shutdown() (err error) {
 	return err
 }
 
+func (m *taskClientMonitor) SetSyncDelay(enable bool, events int, delay time.Duration) {
+	// the task client runs the sync tasks with their own config, so nothing to do
+}
+
 func (m *taskClientMonitor) run(t *task.TaskInfo) {
 	m.logger.Info("running gofs task [%s]", t.Name)
 	r := m.runFn(t.Content, t.Ext)
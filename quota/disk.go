@@ -0,0 +1,33 @@
+package quota
+
+import (
+	"errors"
+	"fmt"
+)
+
+var errFreeSpaceNotEnough = errors.New("the free space of the disk is not enough")
+
+// FreeSpace return the free space of the disk that the path is located in that is available to the current user,
+// return errors.ErrUnsupported if the current platform is unsupported
+func FreeSpace(path string) (int64, error) {
+	return freeSpace(path)
+}
+
+// CheckFreeSpace check the free space of the disk does not drop below the reserve after the size bytes are written,
+// the check is skipped if the current platform is unsupported
+func CheckFreeSpace(path string, reserve int64, size int64) error {
+	if reserve <= 0 || size <= 0 {
+		return nil
+	}
+	free, err := FreeSpace(path)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if free-size < reserve {
+		return fmt.Errorf("%w => free=%d reserve=%d size=%d", errFreeSpaceNotEnough, free, reserve, size)
+	}
+	return nil
+}
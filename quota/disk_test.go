@@ -0,0 +1,38 @@
+package quota
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestCheckFreeSpace(t *testing.T) {
+	free, err := FreeSpace(t.TempDir())
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skip("the free space is unsupported on the current platform")
+	}
+	if err != nil || free <= 0 {
+		t.Fatalf("FreeSpace expect to get the free space, but get %d, error => %v", free, err)
+	}
+
+	testCases := []struct {
+		name    string
+		reserve int64
+		size    int64
+		expect  error
+	}{
+		{"disabled", 0, math.MaxInt64, nil},
+		{"empty file", math.MaxInt64, 0, nil},
+		{"enough", 1, 1, nil},
+		{"reserve not enough", math.MaxInt64, 1, errFreeSpaceNotEnough},
+		{"size not enough", 1, free, errFreeSpaceNotEnough},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := CheckFreeSpace(t.TempDir(), tc.reserve, tc.size); !errors.Is(err, tc.expect) {
+				t.Errorf("CheckFreeSpace expect to get error %v, but get %v", tc.expect, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+//go:build windows
+
+package quota
+
+import "golang.org/x/sys/windows"
+
+func freeSpace(path string) (int64, error) {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var free, total, totalFree uint64
+	if err = windows.GetDiskFreeSpaceEx(p, &free, &total, &totalFree); err != nil {
+		return 0, err
+	}
+	return int64(free), nil
+}
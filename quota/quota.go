@@ -0,0 +1,151 @@
+package quota
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var (
+	errQuotaExceeded     = errors.New("the storage quota is exceeded")
+	errFileQuotaExceeded = errors.New("the file count quota is exceeded")
+)
+
+// Usage the storage usage of a root directory
+type Usage struct {
+	// Bytes the total size of the regular files
+	Bytes int64 `json:"bytes"`
+	// Files the count of the regular files
+	Files int64 `json:"files"`
+}
+
+// Sub return the difference between the usage and v
+func (u Usage) Sub(v Usage) Usage {
+	return Usage{Bytes: u.Bytes - v.Bytes, Files: u.Files - v.Files}
+}
+
+// Quota the storage quota of the root directories, the usage of every root directory is calculated at the first check
+// and tracked incrementally after that
+type Quota struct {
+	mu       sync.Mutex
+	maxBytes int64
+	maxFiles int64
+	usage    map[string]Usage
+}
+
+// New create an instance of the Quota, the limit is disabled if the max value is less than or equal to zero
+func New(maxBytes int64, maxFiles int64) *Quota {
+	return &Quota{
+		maxBytes: maxBytes,
+		maxFiles: maxFiles,
+		usage:    make(map[string]Usage),
+	}
+}
+
+// Enabled report whether the quota is enabled
+func (q *Quota) Enabled() bool {
+	return q != nil && (q.maxBytes > 0 || q.maxFiles > 0)
+}
+
+// Max return the max bytes and the max file count of the quota
+func (q *Quota) Max() Usage {
+	if q == nil {
+		return Usage{}
+	}
+	return Usage{Bytes: q.maxBytes, Files: q.maxFiles}
+}
+
+// Check check the usage of the root does not exceed the quota after the delta is added, the decrease of the usage
+// is always allowed. The increase of the delta is reserved atomically if it is allowed, and returned as the reserved usage,
+// the caller should add the difference between the actual change and the reserved usage by Add after writing
+func (q *Quota) Check(root string, delta Usage) (reserved Usage, err error) {
+	if !q.Enabled() {
+		return reserved, nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	usage, err := q.load(root)
+	if err != nil {
+		return reserved, err
+	}
+	if q.maxBytes > 0 && delta.Bytes > 0 && usage.Bytes+delta.Bytes > q.maxBytes {
+		return reserved, fmt.Errorf("%w => usage=%d max=%d size=%d", errQuotaExceeded, usage.Bytes, q.maxBytes, delta.Bytes)
+	}
+	if q.maxFiles > 0 && delta.Files > 0 && usage.Files+delta.Files > q.maxFiles {
+		return reserved, fmt.Errorf("%w => files=%d max=%d", errFileQuotaExceeded, usage.Files, q.maxFiles)
+	}
+	reserved = Usage{Bytes: max(delta.Bytes, 0), Files: max(delta.Files, 0)}
+	q.usage[root] = Usage{Bytes: usage.Bytes + reserved.Bytes, Files: usage.Files + reserved.Files}
+	return reserved, nil
+}
+
+// Add add the delta to the usage of the root, the delta is negative if some files are removed
+func (q *Quota) Add(root string, delta Usage) {
+	if !q.Enabled() {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if usage, ok := q.usage[root]; ok {
+		q.usage[root] = Usage{
+			Bytes: max(usage.Bytes+delta.Bytes, 0),
+			Files: max(usage.Files+delta.Files, 0),
+		}
+	}
+}
+
+// Usage return the current usage of the root
+func (q *Quota) Usage(root string) (Usage, error) {
+	if !q.Enabled() {
+		return Usage{}, nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.load(root)
+}
+
+func (q *Quota) load(root string) (Usage, error) {
+	if usage, ok := q.usage[root]; ok {
+		return usage, nil
+	}
+	usage, err := DirUsage(root)
+	if err != nil {
+		return usage, err
+	}
+	q.usage[root] = usage
+	return usage, nil
+}
+
+// DirUsage return the total size and the count of the regular files in the path, return zero if the path does not exist
+func DirUsage(path string) (usage Usage, err error) {
+	err = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			usage.Bytes += info.Size()
+			usage.Files++
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return Usage{}, nil
+	}
+	return usage, err
+}
+
+// FileSize return the size of the regular file and report whether the regular file exists
+func FileSize(path string) (int64, bool) {
+	stat, err := os.Lstat(path)
+	if err != nil || !stat.Mode().IsRegular() {
+		return 0, false
+	}
+	return stat.Size(), true
+}
@@ -0,0 +1,150 @@
+package quota
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path string, size int) {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		t.Fatalf("create the test directory error => %v", err)
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0600); err != nil {
+		t.Fatalf("create the test file error => %v", err)
+	}
+}
+
+func TestQuota_Check(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "a.txt"), 40)
+	writeTestFile(t, filepath.Join(root, "docs", "b.txt"), 50)
+
+	testCases := []struct {
+		name     string
+		delta    Usage
+		reserved Usage
+		expect   error
+	}{
+		{"new file", Usage{Bytes: 10, Files: 1}, Usage{Bytes: 10, Files: 1}, nil},
+		{"new file exceeded", Usage{Bytes: 11, Files: 1}, Usage{}, errQuotaExceeded},
+		{"overwrite file", Usage{Bytes: 10}, Usage{Bytes: 10}, nil},
+		{"overwrite file exceeded", Usage{Bytes: 11}, Usage{}, errQuotaExceeded},
+		{"shrink file", Usage{Bytes: -50}, Usage{}, nil},
+		{"file count exceeded", Usage{Files: 2}, Usage{}, errFileQuotaExceeded},
+		{"remove files", Usage{Bytes: -90, Files: -2}, Usage{}, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q := New(100, 3)
+			reserved, err := q.Check(root, tc.delta)
+			if !errors.Is(err, tc.expect) {
+				t.Errorf("Check expect to get error %v, but get %v", tc.expect, err)
+			}
+			if reserved != tc.reserved {
+				t.Errorf("Check expect to reserve %v, but get %v", tc.reserved, reserved)
+			}
+			if usage, _ := q.Usage(root); usage != (Usage{Bytes: 90 + tc.reserved.Bytes, Files: 2 + tc.reserved.Files}) {
+				t.Errorf("Usage expect to contain the reserved usage %v, but get %v", tc.reserved, usage)
+			}
+		})
+	}
+}
+
+func TestQuota_Check_Reserve(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "a.txt"), 40)
+	q := New(100, 0)
+
+	reserved, err := q.Check(root, Usage{Bytes: 60, Files: 1})
+	if err != nil {
+		t.Fatalf("Check error => %v", err)
+	}
+	// the concurrent write can't exceed the quota with the reserved usage
+	if _, err = q.Check(root, Usage{Bytes: 1, Files: 1}); !errors.Is(err, errQuotaExceeded) {
+		t.Errorf("Check expect to get error %v, but get %v", errQuotaExceeded, err)
+	}
+
+	// release the reservation after the write is failed
+	q.Add(root, Usage{}.Sub(reserved))
+	if _, err = q.Check(root, Usage{Bytes: 60, Files: 1}); err != nil {
+		t.Errorf("Check expect to allow the write after the reservation is released, but get %v", err)
+	}
+}
+
+func TestQuota_Add(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "a.txt"), 40)
+	q := New(100, 0)
+
+	// the usage is not tracked before the first check
+	q.Add(root, Usage{Bytes: 100, Files: 1})
+	if usage, err := q.Usage(root); err != nil || usage != (Usage{Bytes: 40, Files: 1}) {
+		t.Errorf("Usage expect to get 40 bytes and 1 file, but get %v, error => %v", usage, err)
+	}
+
+	q.Add(root, Usage{Bytes: 60, Files: 1})
+	if _, err := q.Check(root, Usage{Bytes: 1}); !errors.Is(err, errQuotaExceeded) {
+		t.Errorf("Check expect to get error %v, but get %v", errQuotaExceeded, err)
+	}
+
+	q.Add(root, Usage{Bytes: -200, Files: -5})
+	if usage, _ := q.Usage(root); usage != (Usage{}) {
+		t.Errorf("Usage expect to get zero, but get %v", usage)
+	}
+	if max := q.Max(); max != (Usage{Bytes: 100}) {
+		t.Errorf("Max expect to get 100 bytes, but get %v", max)
+	}
+}
+
+func TestQuota_Disabled(t *testing.T) {
+	var nilQuota *Quota
+	for _, q := range []*Quota{nilQuota, New(0, 0), New(-1, -1)} {
+		if q.Enabled() {
+			t.Errorf("Enabled expect to get false")
+		}
+		if reserved, err := q.Check("not_exist", Usage{Bytes: 1 << 40, Files: 1 << 20}); err != nil || reserved != (Usage{}) {
+			t.Errorf("Check expect to allow all the changes if the quota is disabled, but get %v", err)
+		}
+		q.Add("not_exist", Usage{Bytes: 1})
+	}
+}
+
+func TestDirUsage(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "a.txt"), 10)
+	writeTestFile(t, filepath.Join(root, "docs", "b.txt"), 20)
+
+	testCases := []struct {
+		name   string
+		path   string
+		expect Usage
+	}{
+		{"directory", root, Usage{Bytes: 30, Files: 2}},
+		{"sub directory", filepath.Join(root, "docs"), Usage{Bytes: 20, Files: 1}},
+		{"file", filepath.Join(root, "a.txt"), Usage{Bytes: 10, Files: 1}},
+		{"not exist", filepath.Join(root, "not_exist"), Usage{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			usage, err := DirUsage(tc.path)
+			if err != nil {
+				t.Errorf("DirUsage error => %v", err)
+				return
+			}
+			if usage != tc.expect {
+				t.Errorf("DirUsage expect to get %v, but get %v", tc.expect, usage)
+			}
+		})
+	}
+
+	if size, exist := FileSize(filepath.Join(root, "docs")); size != 0 || exist {
+		t.Errorf("FileSize expect to get 0 with a directory, but get %d", size)
+	}
+	if size, exist := FileSize(filepath.Join(root, "a.txt")); size != 10 || !exist {
+		t.Errorf("FileSize expect to get 10, but get %d", size)
+	}
+}
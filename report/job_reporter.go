@@ -0,0 +1,22 @@
+package report
+
+import "github.com/no-src/gofs/eventlog"
+
+// jobReporter a Reporter that records the file change events to the specified sync job
+type jobReporter struct {
+	Reporter
+
+	name string
+}
+
+// NewJobReporter create an instance of the Reporter that shares the specified Reporter and records the file change events to the specified sync job
+func NewJobReporter(r Reporter, name string) Reporter {
+	return &jobReporter{
+		Reporter: r,
+		name:     name,
+	}
+}
+
+func (r *jobReporter) PutEvent(event eventlog.Event) {
+	r.Reporter.PutJobEvent(r.name, event)
+}
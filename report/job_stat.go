@@ -0,0 +1,37 @@
+package report
+
+import (
+	"github.com/no-src/nsgo/timeutil"
+)
+
+// JobStatus the status of a sync job
+type JobStatus string
+
+const (
+	// JobRunning the sync job is running
+	JobRunning JobStatus = "running"
+	// JobStopped the sync job is stopped
+	JobStopped JobStatus = "stopped"
+	// JobFailed the sync job is exited with an error
+	JobFailed JobStatus = "failed"
+)
+
+// JobStat the status info of a sync job
+type JobStat struct {
+	// Name the unique name of the job
+	Name string `json:"name"`
+	// Source the source of the job
+	Source string `json:"source"`
+	// Dest the dest of the job
+	Dest string `json:"dest"`
+	// Status the current status of the job
+	Status JobStatus `json:"status"`
+	// StartTime the last start time of the job
+	StartTime timeutil.Time `json:"start_time"`
+	// StopTime the last stop time of the job
+	StopTime timeutil.Time `json:"stop_time"`
+	// Error the error info if the job is failed
+	Error string `json:"error"`
+	// EventStat the statistical data of file change events of the job
+	EventStat EventStat `json:"event_stat"`
+}
@@ -40,4 +40,8 @@ type Report struct {
 	EventStat EventStat `json:"event_stat"`
 	// ApiStat returns the statistical data of api access info
 	ApiStat ApiStat `json:"api_stat"`
+	// Jobs returns the status info of the sync jobs
+	Jobs map[string]JobStat `json:"jobs"`
+	// Storage returns the storage usage of the push server
+	Storage map[string]StorageStat `json:"storage"`
 }
@@ -26,6 +26,12 @@ type Reporter interface {
 	PutEvent(event eventlog.Event)
 	// PutApiStat put an access log of api
 	PutApiStat(ip string)
+	// PutJob put or update the status info of a sync job
+	PutJob(job JobStat)
+	// PutJobEvent put a file change event of a sync job
+	PutJobEvent(name string, event eventlog.Event)
+	// PutStorage put or update the storage usage of the push server
+	PutStorage(stat StorageStat)
 	// Enable enable or disable the Reporter
 	Enable(enabled bool)
 }
@@ -52,6 +58,8 @@ func NewReporter() Reporter {
 		ApiStat: ApiStat{
 			VisitorStat: make(map[string]uint64),
 		},
+		Jobs:    make(map[string]JobStat),
+		Storage: make(map[string]StorageStat),
 	}
 	report.Events, _ = toplist.New(100)
 	report.Hostname, _ = os.Hostname()
@@ -65,7 +73,20 @@ func (r *reporter) GetReport() Report {
 	defer r.mu.Unlock()
 	r.report.CurrentTime = timeutil.Now()
 	r.report.UpTime = core.Duration(r.report.CurrentTime.Sub(r.report.StartTime))
-	return r.report
+	report := r.report
+	report.Jobs = make(map[string]JobStat, len(r.report.Jobs))
+	for name, job := range r.report.Jobs {
+		job.EventStat = make(EventStat, len(r.report.Jobs[name].EventStat))
+		for op, count := range r.report.Jobs[name].EventStat {
+			job.EventStat[op] = count
+		}
+		report.Jobs[name] = job
+	}
+	report.Storage = make(map[string]StorageStat, len(r.report.Storage))
+	for name, stat := range r.report.Storage {
+		report.Storage[name] = stat
+	}
+	return report
 }
 
 func (r *reporter) PutConnection(addr string, user *auth.SessionUser) {
@@ -144,6 +165,45 @@ func (r *reporter) putApiStat(ip string) {
 	r.report.ApiStat.VisitorStat[ip]++
 }
 
+func (r *reporter) PutJob(job JobStat) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.enabled {
+		return
+	}
+	job.EventStat = r.report.Jobs[job.Name].EventStat
+	if job.EventStat == nil {
+		job.EventStat = make(EventStat)
+	}
+	r.report.Jobs[job.Name] = job
+}
+
+func (r *reporter) PutJobEvent(name string, event eventlog.Event) {
+	go r.putJobEvent(name, event)
+}
+
+func (r *reporter) putJobEvent(name string, event eventlog.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.enabled {
+		return
+	}
+	r.report.Events.Add(event)
+	r.report.EventStat[event.Op]++
+	if job, ok := r.report.Jobs[name]; ok {
+		job.EventStat[event.Op]++
+	}
+}
+
+func (r *reporter) PutStorage(stat StorageStat) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.enabled {
+		return
+	}
+	r.report.Storage[stat.Name] = stat
+}
+
 func (r *reporter) Enable(enabled bool) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -148,3 +148,75 @@ func testGetReporterWithEnable(t *testing.T, reporter Reporter, addrOnline, addr
 		t.Errorf("[enabled] test PutApiStat error, expect to get %d access count, actual:%d", expectAccessCount, actualAccessCount)
 	}
 }
+
+func TestReporter_PutJob(t *testing.T) {
+	testCases := []struct {
+		name        string
+		enabled     bool
+		expectJob   bool
+		expectEvent uint64
+	}{
+		{"enabled", true, true, 1},
+		{"disabled", false, false, 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reporter := NewReporter()
+			reporter.Enable(tc.enabled)
+			jobName := "job1"
+			reporter.PutJob(JobStat{Name: jobName, Status: JobRunning})
+			NewJobReporter(reporter, jobName).PutEvent(eventlog.NewEvent("./reporter_test.go", "WRITE"))
+			NewJobReporter(reporter, "not_found").PutEvent(eventlog.NewEvent("./reporter_test.go", "WRITE"))
+			time.Sleep(time.Millisecond * 100)
+			reporter.PutJob(JobStat{Name: jobName, Status: JobStopped})
+
+			r := reporter.GetReport()
+			job, ok := r.Jobs[jobName]
+			if ok != tc.expectJob {
+				t.Errorf("expect to get the job => %v, actual => %v", tc.expectJob, ok)
+				return
+			}
+			if !ok {
+				return
+			}
+			if job.Status != JobStopped {
+				t.Errorf("expect to get the job status %s, actual get %s", JobStopped, job.Status)
+			}
+			if actual := job.EventStat["WRITE"]; actual != tc.expectEvent {
+				t.Errorf("expect to get %d job event, actual get %d", tc.expectEvent, actual)
+			}
+			if actual := r.EventStat["WRITE"]; actual != 2 {
+				t.Errorf("expect to get 2 events, actual get %d", actual)
+			}
+		})
+	}
+}
+
+func TestReporter_PutStorage(t *testing.T) {
+	testCases := []struct {
+		name    string
+		enabled bool
+		expect  int
+	}{
+		{"enabled", true, 2},
+		{"disabled", false, 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reporter := NewReporter()
+			reporter.Enable(tc.enabled)
+			reporter.PutStorage(StorageStat{Name: StorageAll, UsedBytes: 10, UsedFiles: 1})
+			reporter.PutStorage(StorageStat{Name: "alice", UsedBytes: 10, UsedFiles: 1})
+			reporter.PutStorage(StorageStat{Name: "alice", UsedBytes: 20, UsedFiles: 2, MaxBytes: 100})
+
+			r := reporter.GetReport()
+			if len(r.Storage) != tc.expect {
+				t.Errorf("expect to get %d storage stats, actual get %d", tc.expect, len(r.Storage))
+				return
+			}
+			if tc.enabled && r.Storage["alice"].UsedBytes != 20 {
+				t.Errorf("expect to get the latest storage stat, actual get %v", r.Storage["alice"])
+			}
+		})
+	}
+}
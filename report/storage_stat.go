@@ -0,0 +1,22 @@
+package report
+
+// StorageAll the name of the storage stat of the whole storage of the push server
+const StorageAll = "*"
+
+// StorageStat the storage usage of the push server
+type StorageStat struct {
+	// Name the username of the user root, or StorageAll for the whole storage
+	Name string `json:"name"`
+	// Path the root directory of the storage
+	Path string `json:"path"`
+	// UsedBytes the total size of the files in the storage
+	UsedBytes int64 `json:"used_bytes"`
+	// UsedFiles the count of the files in the storage
+	UsedFiles int64 `json:"used_files"`
+	// MaxBytes the max total size of the files, zero means unlimited
+	MaxBytes int64 `json:"max_bytes"`
+	// MaxFiles the max count of the files, zero means unlimited
+	MaxFiles int64 `json:"max_files"`
+	// FreeBytes the free space of the disk, it is only reported for the whole storage
+	FreeBytes int64 `json:"free_bytes"`
+}
@@ -2,6 +2,7 @@ package retry
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/no-src/gofs/logger"
@@ -9,6 +10,7 @@ import (
 )
 
 type defaultRetry struct {
+	mu     sync.RWMutex
 	count  int
 	wait   time.Duration
 	async  bool
@@ -44,24 +46,25 @@ func (r *defaultRetry) DoWithContext(ctx context.Context, f func() error, desc s
 		}
 	}()
 
-	if f == nil || f() == nil || r.count <= 0 {
+	count, waitTime, async := r.rule()
+	if f == nil || f() == nil || count <= 0 {
 		wd.Done()
 		return wd
 	}
-	r.logger.Warn("execute failed, wait to retry [%s] %d times, execute once per %s", desc, r.count, r.wait)
-	if r.async {
-		go r.retry(ctx, wd, f, desc)
+	r.logger.Warn("execute failed, wait to retry [%s] %d times, execute once per %s", desc, count, waitTime)
+	if async {
+		go r.retry(ctx, wd, f, desc, count, waitTime)
 	} else {
-		r.retry(ctx, wd, f, desc)
+		r.retry(ctx, wd, f, desc, count, waitTime)
 	}
 	return wd
 }
 
-func (r *defaultRetry) retry(ctx context.Context, wd wait.Done, f func() error, desc string) {
+func (r *defaultRetry) retry(ctx context.Context, wd wait.Done, f func() error, desc string, count int, waitTime time.Duration) {
 	defer func() {
 		wd.Done()
 	}()
-	for i := 0; i < r.count; i++ {
+	for i := 0; i < count; i++ {
 		select {
 		case <-ctx.Done():
 			r.logger.Debug("retry [%d] [%s] done => %s", i+1, desc, ctx.Err())
@@ -76,20 +79,37 @@ func (r *defaultRetry) retry(ctx context.Context, wd wait.Done, f func() error,
 			}
 			break
 		} else {
-			r.logger.Debug("retry [%d] after %s [%s]", i+1, r.wait.String(), desc)
-			if i == r.count-1 {
-				r.logger.Error(err, "retry [%d] times, and aborted [%s]", r.count, desc)
+			r.logger.Debug("retry [%d] after %s [%s]", i+1, waitTime.String(), desc)
+			if i == count-1 {
+				r.logger.Error(err, "retry [%d] times, and aborted [%s]", count, desc)
 			} else {
-				time.Sleep(r.wait)
+				time.Sleep(waitTime)
 			}
 		}
 	}
 }
 
 func (r *defaultRetry) Count() int {
-	return r.count
+	count, _, _ := r.rule()
+	return count
 }
 
 func (r *defaultRetry) WaitTime() time.Duration {
-	return r.wait
+	_, waitTime, _ := r.rule()
+	return waitTime
+}
+
+func (r *defaultRetry) Reset(count int, waitTime time.Duration, async bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.count = count
+	r.wait = waitTime
+	r.async = async
+}
+
+// rule return a snapshot of the current retry rule
+func (r *defaultRetry) rule() (count int, waitTime time.Duration, async bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.count, r.wait, r.async
 }
@@ -17,4 +17,6 @@ type Retry interface {
 	Count() int
 	// WaitTime the wait time after every retry to fail
 	WaitTime() time.Duration
+	// Reset update the retry rule at runtime, it takes effect on the next execution
+	Reset(count int, waitTime time.Duration, async bool)
 }
@@ -121,6 +121,27 @@ func TestDefaultRetry_Panic(t *testing.T) {
 	}
 }
 
+func TestDefaultRetry_Reset(t *testing.T) {
+	tw := testWork{}
+	r := New(testRetryCount, testWaitTime, true, logger.NewTestLogger())
+	expectCount := testRetryCount + 2
+	expectWaitTime := testWaitTime * 2
+	r.Reset(expectCount, expectWaitTime, false)
+	if r.Count() != expectCount {
+		t.Errorf("test retry reset error, retry count expect:%d, actual:%d", expectCount, r.Count())
+	}
+	if r.WaitTime() != expectWaitTime {
+		t.Errorf("test retry reset error, retry wait time expect:%s, actual:%s", expectWaitTime.String(), r.WaitTime().String())
+	}
+	err := r.Do(tw.doFail, "do work failed after reset").Wait()
+	if err != nil {
+		t.Errorf("test retry reset error, wait return error => %s", err)
+	}
+	if tw.c != expectCount+1 {
+		t.Errorf("test retry reset error, execute count expect:%d, actual:%d", expectCount+1, tw.c)
+	}
+}
+
 type testWork struct {
 	c int
 }
@@ -131,6 +152,7 @@ func (tw *testWork) doSuccess() error {
 }
 
 func (tw *testWork) doFail() error {
+	tw.c++
 	return errors.New("work error")
 }
 
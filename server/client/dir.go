@@ -0,0 +1,270 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/no-src/gofs/auth"
+	"github.com/no-src/gofs/contract"
+	"github.com/no-src/gofs/logger"
+	"github.com/no-src/gofs/server"
+	"github.com/no-src/nsgo/httputil"
+	"github.com/no-src/nsgo/jsonutil"
+)
+
+var (
+	errQueryAPI         = errors.New("call the query api error")
+	errUnexpectedStatus = errors.New("unexpected response status")
+	errInvalidWhence    = errors.New("invalid whence")
+	errNegativeOffset   = errors.New("negative offset")
+)
+
+// Dir a read-only implementation of http.FileSystem for the source of the remote file server,
+// the directories are listed by the query api and the files are read by the range requests
+type Dir struct {
+	serverAddr string
+	httpClient httputil.HttpClient
+	user       *auth.User
+	logger     *logger.Logger
+
+	mu      sync.RWMutex
+	cookies []*http.Cookie
+}
+
+// NewDir returns a http.FileSystem instance for the source of the remote file server,
+// the user is used to sign in the file server automatically if it is not nil
+func NewDir(serverAddr string, httpClient httputil.HttpClient, user *auth.User, logger *logger.Logger) http.FileSystem {
+	return &Dir{
+		serverAddr: strings.TrimRight(serverAddr, "/"),
+		httpClient: httpClient,
+		user:       user,
+		logger:     logger,
+	}
+}
+
+// Open opens the named file for reading
+func (d *Dir) Open(name string) (http.File, error) {
+	name = path.Clean("/" + name)
+	info := contract.FileInfo{Path: "/", IsDir: contract.FsIsDir}
+	if name != "/" {
+		files, err := d.readDir(path.Dir(name))
+		if err != nil {
+			return nil, err
+		}
+		found := false
+		for _, file := range files {
+			if file.Path == path.Base(name) {
+				info, found = file, true
+				break
+			}
+		}
+		if !found {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+	}
+	return &remoteFile{dir: d, name: name, info: info.Stat()}, nil
+}
+
+// readDir list the files of the directory by the query api
+func (d *Dir) readDir(name string) (files []contract.FileInfo, err error) {
+	values := url.Values{}
+	values.Add(contract.FsPath, strings.Trim(server.SourceRoutePrefix, "/")+name)
+	resp, err := d.get(fmt.Sprintf("%s%s?%s", d.serverAddr, server.QueryRoute, values.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var apiResult server.ApiResult
+	if err = jsonutil.Unmarshal(data, &apiResult); err != nil {
+		return nil, err
+	}
+	if apiResult.Code == contract.NotFound {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	} else if apiResult.Code != contract.Success {
+		return nil, fmt.Errorf("%w => %s", errQueryAPI, apiResult.Message)
+	}
+	if apiResult.Data == nil {
+		return nil, nil
+	}
+	dataBytes, err := jsonutil.Marshal(apiResult.Data)
+	if err != nil {
+		return nil, err
+	}
+	err = jsonutil.Unmarshal(dataBytes, &files)
+	return files, err
+}
+
+// get send a get request with the cookies and sign in the file server automatically if it is unauthorized
+func (d *Dir) get(rawURL string, header http.Header) (*http.Response, error) {
+	d.mu.RLock()
+	cookies := d.cookies
+	d.mu.RUnlock()
+	resp, err := d.httpClient.HttpGetWithCookie(rawURL, header, cookies...)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && d.user != nil {
+		resp.Body.Close()
+		parseUrl, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		if cookies, err = SignIn(d.httpClient, parseUrl.Scheme, parseUrl.Host, d.user.UserName(), d.user.Password(), d.logger); err != nil {
+			return nil, err
+		}
+		d.mu.Lock()
+		d.cookies = cookies
+		d.mu.Unlock()
+		resp, err = d.httpClient.HttpGetWithCookie(rawURL, header, cookies...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, os.ErrNotExist
+	}
+	return resp, nil
+}
+
+// remoteFile the file of the remote file server, the content is read by the range requests
+type remoteFile struct {
+	dir    *Dir
+	name   string
+	info   fs.FileInfo
+	offset int64
+	body   io.ReadCloser
+}
+
+func (f *remoteFile) Close() error {
+	if f.body != nil {
+		err := f.body.Close()
+		f.body = nil
+		return err
+	}
+	return nil
+}
+
+func (f *remoteFile) Read(p []byte) (n int, err error) {
+	if f.info.IsDir() {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: errors.New("is a directory")}
+	}
+	if f.offset >= f.info.Size() {
+		return 0, io.EOF
+	}
+	if f.body == nil {
+		if f.body, err = f.open(f.offset, -1); err != nil {
+			return 0, err
+		}
+	}
+	n, err = f.body.Read(p)
+	f.offset += int64(n)
+	return n, err
+}
+
+// ReadAt read the content at the offset by a range request, it is used to read the zip file without downloading it
+func (f *remoteFile) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, errNegativeOffset
+	}
+	if off >= f.info.Size() {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	end := min(off+int64(len(p)), f.info.Size()) - 1
+	body, err := f.open(off, end)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+	n, err = io.ReadFull(body, p[:end-off+1])
+	if err == nil && n < len(p) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (f *remoteFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.info.Size()
+	default:
+		return 0, errInvalidWhence
+	}
+	if offset < 0 {
+		return 0, errNegativeOffset
+	}
+	if offset != f.offset {
+		f.Close()
+		f.offset = offset
+	}
+	return offset, nil
+}
+
+func (f *remoteFile) Readdir(count int) ([]fs.FileInfo, error) {
+	files, err := f.dir.readDir(f.name)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]fs.FileInfo, 0, len(files))
+	for _, file := range files {
+		infos = append(infos, file.Stat())
+	}
+	if count > 0 && len(infos) > count {
+		infos = infos[:count]
+	}
+	return infos, nil
+}
+
+func (f *remoteFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+// open request the content of the file from start to end, the end is ignored if it is negative
+func (f *remoteFile) open(start, end int64) (io.ReadCloser, error) {
+	header := http.Header{}
+	if end >= 0 {
+		header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+	} else if start > 0 {
+		header.Set("Range", fmt.Sprintf("bytes=%d-", start))
+	}
+	resp, err := f.dir.get(f.dir.serverAddr+server.SourceRoutePrefix+escapePath(f.name), header)
+	if err != nil {
+		return nil, err
+	}
+	expect := http.StatusOK
+	if len(header.Get("Range")) > 0 {
+		expect = http.StatusPartialContent
+	}
+	if resp.StatusCode != expect {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w => %s %s", errUnexpectedStatus, resp.Status, f.name)
+	}
+	return resp.Body, nil
+}
+
+// escapePath escape every component of the path to build the url
+func escapePath(name string) string {
+	parts := strings.Split(strings.TrimPrefix(name, "/"), "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
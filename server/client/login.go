@@ -14,22 +14,68 @@ import (
 // ErrSignIn the current user sign in failed
 var ErrSignIn = errors.New("file server sign in failed")
 
-// SignIn sign in the file server
+// SignIn sign in the file server, get the session cookie and the CSRF token from the login page first,
+// the returned cookies carry the CSRF token that is required by the state-changing requests, see CSRFToken
 func SignIn(httpClient httputil.HttpClient, scheme, host, userName, password string, logger *logger.Logger) ([]*http.Cookie, error) {
+	indexUrl := fmt.Sprintf("%s://%s%s", scheme, host, server.LoginIndexFullRoute)
+	indexResp, err := httpClient.HttpGetWithCookie(indexUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	indexResp.Body.Close()
+	cookies := indexResp.Cookies()
+
 	loginUrl := fmt.Sprintf("%s://%s%s", scheme, host, server.LoginSignInFullRoute)
 	form := url.Values{}
 	form.Set(server.ParamUserName, userName)
 	form.Set(server.ParamPassword, password)
+	form.Set(server.ParamCSRFToken, CSRFToken(cookies))
 	logger.Debug("try to auto login file server %s=%s", server.ParamUserName, userName)
-	loginResp, err := httpClient.HttpPostWithoutRedirect(loginUrl, form)
+	loginResp, err := httpClient.HttpPostWithCookie(loginUrl, form, cookies...)
 	if err != nil {
 		return nil, err
 	}
-	if loginResp.StatusCode == http.StatusFound {
-		cookies := loginResp.Cookies()
-		if len(cookies) > 0 {
-			return cookies, nil
+	loginResp.Body.Close()
+
+	// the redirect is followed by the http client, find the response of the sign in api
+	signInResp := loginResp
+	for signInResp.Request != nil && signInResp.Request.Response != nil {
+		signInResp = signInResp.Request.Response
+	}
+	if signInResp.StatusCode == http.StatusFound {
+		// the failed sign in is redirected to the login page
+		location, err := signInResp.Location()
+		if err == nil && location.Path != server.LoginIndexFullRoute {
+			return mergeCookies(cookies, signInResp.Cookies()), nil
 		}
 	}
 	return nil, ErrSignIn
 }
+
+// CSRFToken return the CSRF token of the cookies that are returned by the SignIn
+func CSRFToken(cookies []*http.Cookie) string {
+	for _, cookie := range cookies {
+		if cookie != nil && cookie.Name == server.CSRFCookieName {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+// mergeCookies replace the cookies with the new cookies of the same name
+func mergeCookies(cookies []*http.Cookie, newCookies []*http.Cookie) []*http.Cookie {
+	merged := make([]*http.Cookie, 0, len(cookies)+len(newCookies))
+	for _, cookie := range cookies {
+		replaced := false
+		for _, newCookie := range newCookies {
+			if newCookie.Name == cookie.Name {
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, cookie)
+		}
+	}
+	return append(merged, newCookies...)
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// csrfTokenSize the byte size of the CSRF token
+const csrfTokenSize = 32
+
+// CSRFToken return the CSRF token of the session, generate a new token if the session has no token,
+// and set the token to the CSRF cookie that can be read by the clients if the cookie of the request is different
+func CSRFToken(c *gin.Context) (string, error) {
+	session := sessions.Default(c)
+	token, _ := session.Get(SessionCSRFToken).(string)
+	if len(token) == 0 {
+		b := make([]byte, csrfTokenSize)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		token = base64.RawURLEncoding.EncodeToString(b)
+		session.Set(SessionCSRFToken, token)
+		if err := session.Save(); err != nil {
+			return "", err
+		}
+	}
+	if cookie, err := c.Cookie(CSRFCookieName); err != nil || cookie != token {
+		http.SetCookie(c.Writer, &http.Cookie{
+			Name:     CSRFCookieName,
+			Value:    token,
+			Path:     "/",
+			Secure:   c.Request.TLS != nil,
+			SameSite: http.SameSiteStrictMode,
+		})
+	}
+	return token, nil
+}
+
+// VerifyCSRFToken report whether the request carries the CSRF token of the session by the X-CSRF-Token header or the csrf_token form field
+func VerifyCSRFToken(c *gin.Context) bool {
+	token, _ := sessions.Default(c).Get(SessionCSRFToken).(string)
+	actual := c.GetHeader(HeaderCSRFToken)
+	if len(actual) == 0 {
+		actual = c.PostForm(ParamCSRFToken)
+	}
+	return len(token) > 0 && subtle.ConstantTimeCompare([]byte(token), []byte(actual)) == 1
+}
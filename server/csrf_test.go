@@ -0,0 +1,87 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCSRFToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store, err := NewSessionStore("memory:")
+	if err != nil {
+		t.Errorf("create session store error => %v", err)
+		return
+	}
+	engine := gin.New()
+	engine.Use(sessions.Sessions(SessionName, store))
+	engine.GET("/token", func(c *gin.Context) {
+		token, err := CSRFToken(c)
+		if err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.String(http.StatusOK, token)
+	})
+	engine.POST("/verify", func(c *gin.Context) {
+		c.String(http.StatusOK, "%v", VerifyCSRFToken(c))
+	})
+
+	resp := httptest.NewRecorder()
+	engine.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/token", nil))
+	token := resp.Body.String()
+	cookies := resp.Result().Cookies()
+	if resp.Code != http.StatusOK || len(token) == 0 {
+		t.Errorf("get the csrf token error => %d %s", resp.Code, token)
+		return
+	}
+	cookieToken := ""
+	for _, cookie := range cookies {
+		if cookie.Name == CSRFCookieName {
+			cookieToken = cookie.Value
+		}
+	}
+	if cookieToken != token {
+		t.Errorf("expect to set the csrf token %s to the cookie, but actual get %s", token, cookieToken)
+	}
+
+	testCases := []struct {
+		name        string
+		withSession bool
+		header      string
+		form        string
+		expect      string
+	}{
+		{"token in the header", true, token, "", "true"},
+		{"token in the form", true, "", token, "true"},
+		{"without token", true, "", "", "false"},
+		{"incorrect token", true, "", token + "x", "false"},
+		{"without session", false, token, "", "false"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Set(ParamCSRFToken, tc.form)
+			req := httptest.NewRequest(http.MethodPost, "/verify", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if len(tc.header) > 0 {
+				req.Header.Set(HeaderCSRFToken, tc.header)
+			}
+			if tc.withSession {
+				for _, cookie := range cookies {
+					req.AddCookie(cookie)
+				}
+			}
+			resp := httptest.NewRecorder()
+			engine.ServeHTTP(resp, req)
+			if actual := resp.Body.String(); actual != tc.expect {
+				t.Errorf("expect to verify the csrf token [%s], but actual get %s", tc.expect, actual)
+			}
+		})
+	}
+}
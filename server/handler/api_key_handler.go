@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/no-src/gofs/auth"
+	"github.com/no-src/gofs/contract"
+	"github.com/no-src/gofs/logger"
+	"github.com/no-src/gofs/server"
+)
+
+var errAPIKeyUserNotFound = errors.New("the user of the api key is not found")
+
+type apiKeyHandler struct {
+	logger *logger.Logger
+	users  *auth.UserStore
+	keys   *auth.APIKeyStore
+}
+
+// NewAPIKeyListHandlerFunc returns a gin.HandlerFunc that providers an api to list the api keys, filter by the user if specified
+func NewAPIKeyListHandlerFunc(logger *logger.Logger, keys *auth.APIKeyStore) gin.HandlerFunc {
+	return (&apiKeyHandler{
+		logger: logger,
+		keys:   keys,
+	}).List
+}
+
+// NewAPIKeyCreateHandlerFunc returns a gin.HandlerFunc that providers an api to create an api key for the specified user,
+// the api key is only returned once
+func NewAPIKeyCreateHandlerFunc(logger *logger.Logger, users *auth.UserStore, keys *auth.APIKeyStore) gin.HandlerFunc {
+	return (&apiKeyHandler{
+		logger: logger,
+		users:  users,
+		keys:   keys,
+	}).Create
+}
+
+// NewAPIKeyRevokeHandlerFunc returns a gin.HandlerFunc that providers an api to revoke the specified api key
+func NewAPIKeyRevokeHandlerFunc(logger *logger.Logger, keys *auth.APIKeyStore) gin.HandlerFunc {
+	return (&apiKeyHandler{
+		logger: logger,
+		keys:   keys,
+	}).Revoke
+}
+
+func (h *apiKeyHandler) List(c *gin.Context) {
+	c.JSON(http.StatusOK, server.NewApiResult(contract.Success, contract.SuccessDesc, h.keys.List(c.Query(server.ParamUser))))
+}
+
+func (h *apiKeyHandler) Create(c *gin.Context) {
+	userName := c.Query(server.ParamUser)
+	name := c.Query(server.ParamName)
+	key, info, err := h.create(userName, name, c.Query(server.ParamPerm), c.Query(server.ParamExpires))
+	if err != nil {
+		h.logger.Error(err, "create the api key error => [%s] [%s], remote=%s", userName, name, c.Request.RemoteAddr)
+		c.JSON(http.StatusOK, server.NewErrorApiResult(contract.Fail, err.Error()))
+		return
+	}
+	h.logger.Info("create the api key success => [%s] [%s] [%s], remote=%s", userName, name, info.Id, c.Request.RemoteAddr)
+	c.JSON(http.StatusOK, server.NewApiResult(contract.Success, contract.SuccessDesc, gin.H{"key": key, "info": info}))
+}
+
+func (h *apiKeyHandler) create(userName, name, perm, expires string) (key string, info auth.APIKey, err error) {
+	var user *auth.User
+	for _, u := range h.users.Users() {
+		if u.UserName() == userName {
+			user = u
+			break
+		}
+	}
+	if user == nil {
+		return key, info, fmt.Errorf("%w => %s", errAPIKeyUserNotFound, userName)
+	}
+	var d time.Duration
+	if len(expires) > 0 {
+		if d, err = time.ParseDuration(expires); err != nil {
+			return key, info, err
+		}
+	}
+	return h.keys.Create(user, name, auth.ToPermWithDefault(perm, string(user.Perm())), d)
+}
+
+func (h *apiKeyHandler) Revoke(c *gin.Context) {
+	id := c.Query(server.ParamId)
+	if err := h.keys.Revoke(id); err != nil {
+		h.logger.Error(err, "revoke the api key error => [%s], remote=%s", id, c.Request.RemoteAddr)
+		c.JSON(http.StatusOK, server.NewErrorApiResult(contract.Fail, err.Error()))
+		return
+	}
+	h.logger.Info("revoke the api key success => [%s], remote=%s", id, c.Request.RemoteAddr)
+	c.JSON(http.StatusOK, server.NewApiResult(contract.Success, contract.SuccessDesc, nil))
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/no-src/gofs/auth"
+	"github.com/no-src/gofs/encrypt"
+	"github.com/no-src/gofs/logger"
+	"github.com/no-src/gofs/server"
+)
+
+type decryptHandler struct {
+	logger *logger.Logger
+	users  *auth.UserStore
+	dir    *encrypt.DecryptDir
+}
+
+// NewDecryptHandlerFunc returns a gin.HandlerFunc that serves the decrypted content of the dest by the secret of the session,
+// the acl rules of the dest route are applied to the decrypt route
+func NewDecryptHandlerFunc(logger *logger.Logger, users *auth.UserStore, dir *encrypt.DecryptDir) gin.HandlerFunc {
+	return (&decryptHandler{
+		logger: logger,
+		users:  users,
+		dir:    dir,
+	}).Handle
+}
+
+func (h *decryptHandler) Handle(c *gin.Context) {
+	session := sessions.Default(c)
+	secret, ok := session.Get(server.SessionDecryptSecret).(string)
+	if !ok {
+		h.secretPage(c, http.StatusOK, "")
+		return
+	}
+	fsys, err := h.dir.WithSecret([]byte(secret))
+	if err != nil {
+		h.secretPage(c, http.StatusBadRequest, "invalid decrypt secret")
+		return
+	}
+	if user := loginUser(c); user != nil {
+		fsys = auth.NewACLDir(fsys, h.users.ACL(), user.UserName, strings.TrimSuffix(server.DestRoutePrefix, "/"))
+	}
+
+	name := path.Clean("/" + c.Param("path"))
+	f, err := fsys.Open(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			c.String(http.StatusNotFound, "404 page not found")
+		} else if os.IsPermission(err) {
+			c.String(http.StatusForbidden, "403 Forbidden")
+		} else {
+			h.logger.Error(err, "decrypt handler => open file error, name=%s", name)
+			c.String(http.StatusInternalServerError, "open file error")
+		}
+		return
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		h.logger.Error(err, "decrypt handler => get file stat error, name=%s", name)
+		c.String(http.StatusInternalServerError, "get file stat error")
+		return
+	}
+
+	// list the directory with the decrypted names
+	if stat.IsDir() {
+		http.StripPrefix(strings.TrimSuffix(server.DecryptRoutePrefix, "/"), http.FileServer(fsys)).ServeHTTP(c.Writer, c.Request)
+		return
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(stat.Name()))
+	if len(contentType) == 0 {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Status(http.StatusOK)
+	if err = h.dir.DecryptFile(c.Writer, f, []byte(secret)); err != nil {
+		h.logger.Error(err, "decrypt handler => decrypt file error, name=%s remote=%s", name, c.Request.RemoteAddr)
+		// the response is broken if the plain content is written partly, abort the connection only
+		if !c.Writer.Written() {
+			h.secretPage(c, http.StatusForbidden, "decrypt file error, please check the decrypt secret")
+		}
+		c.Abort()
+	}
+}
+
+func (h *decryptHandler) secretPage(c *gin.Context, code int, errMsg string) {
+	c.HTML(code, "decrypt.html", struct {
+		Action    string
+		ReturnUrl string
+		Error     string
+		CSRFToken string
+	}{
+		server.DecryptSecretRoute,
+		c.Request.URL.Path,
+		errMsg,
+		csrfToken(c, h.logger),
+	})
+}
+
+type decryptSecretHandler struct {
+	logger *logger.Logger
+}
+
+// NewDecryptSecretHandlerFunc returns a gin.HandlerFunc that saves the decrypt secret to the session of the current user
+func NewDecryptSecretHandlerFunc(logger *logger.Logger) gin.HandlerFunc {
+	return (&decryptSecretHandler{
+		logger: logger,
+	}).Handle
+}
+
+func (h *decryptSecretHandler) Handle(c *gin.Context) {
+	// never redirect to the other routes
+	returnUrl := path.Clean("/" + c.PostForm(server.ParamReturnUrl))
+	if !strings.HasPrefix(returnUrl+"/", server.DecryptRoutePrefix) {
+		returnUrl = server.DecryptRoutePrefix
+	}
+	session := sessions.Default(c)
+	if session == nil {
+		h.logger.Error(errors.New("session is nil"), "decrypt secret handler => get session error, remote=%s", c.Request.RemoteAddr)
+		c.String(http.StatusInternalServerError, "get session error")
+		return
+	}
+	session.Set(server.SessionDecryptSecret, c.PostForm(server.ParamSecret))
+	if err := session.Save(); err != nil {
+		h.logger.Error(err, "save session error, remote=%s", c.Request.RemoteAddr)
+		c.String(http.StatusInternalServerError, "save session error")
+		return
+	}
+	c.Redirect(http.StatusFound, returnUrl)
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/no-src/gofs/logger"
@@ -9,22 +10,49 @@ import (
 )
 
 type defaultHandler struct {
-	logger *logger.Logger
+	logger           *logger.Logger
+	enableBrowse     bool
+	enableFileManage bool
+	chunkSize        int64
 }
 
-// NewDefaultHandlerFunc returns a gin.HandlerFunc that shows the default home page
-func NewDefaultHandlerFunc(logger *logger.Logger) gin.HandlerFunc {
+// NewDefaultHandlerFunc returns a gin.HandlerFunc that shows the default home page, the home page browses the source if the enableBrowse is true,
+// and manages the files of the source by the file management api if the enableFileManage is true and the login user has the write permission
+func NewDefaultHandlerFunc(logger *logger.Logger, enableBrowse bool, enableFileManage bool, chunkSize int64) gin.HandlerFunc {
 	return (&defaultHandler{
-		logger: logger,
+		logger:           logger,
+		enableBrowse:     enableBrowse,
+		enableFileManage: enableFileManage,
+		chunkSize:        chunkSize,
 	}).Handle
 }
 
 func (h *defaultHandler) Handle(c *gin.Context) {
+	writable := h.enableFileManage
+	if user := loginUser(c); user != nil {
+		writable = writable && user.Perm.W()
+	}
 	c.HTML(http.StatusOK, "index.html", struct {
-		Source string
-		Dest   string
+		Source    string
+		Dest      string
+		SignOut   string
+		CSRFToken string
+		Browse    bool
+		Writable  bool
+		ChunkSize int64
+		Query     string
+		File      string
+		Zip       string
 	}{
 		server.SourceRoutePrefix,
 		server.DestRoutePrefix,
+		server.LoginSignOutFullRoute,
+		csrfToken(c, h.logger),
+		h.enableBrowse,
+		writable,
+		h.chunkSize,
+		server.QueryRoute,
+		server.FileRoutePrefix,
+		strings.TrimSuffix(server.ZipRoutePrefix, "/") + server.SourceRoutePrefix,
 	})
 }
@@ -9,7 +9,9 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/no-src/gofs/auth"
 	"github.com/no-src/gofs/contract"
+	nsfs "github.com/no-src/gofs/fs"
 	"github.com/no-src/gofs/logger"
 	"github.com/no-src/gofs/server"
 	"github.com/no-src/nsgo/fsutil"
@@ -18,20 +20,25 @@ import (
 
 type fileApiHandler struct {
 	logger          *logger.Logger
+	users           *auth.UserStore
 	root            http.Dir
 	chunkSize       int64
 	checkpointCount int
 	hash            hashutil.Hash
+	userRoot        bool
 }
 
-// NewFileApiHandlerFunc returns a gin.HandlerFunc that queries the file info
-func NewFileApiHandlerFunc(logger *logger.Logger, root http.Dir, chunkSize int64, checkpointCount int, hash hashutil.Hash) gin.HandlerFunc {
+// NewFileApiHandlerFunc returns a gin.HandlerFunc that queries the file info,
+// the query is scoped to the root directory of the login user like <root>/<username> if the userRoot is true
+func NewFileApiHandlerFunc(logger *logger.Logger, users *auth.UserStore, root http.Dir, chunkSize int64, checkpointCount int, hash hashutil.Hash, userRoot bool) gin.HandlerFunc {
 	return (&fileApiHandler{
 		logger:          logger,
+		users:           users,
 		root:            root,
 		chunkSize:       chunkSize,
 		checkpointCount: checkpointCount,
 		hash:            hash,
+		userRoot:        userRoot,
 	}).Handle
 }
 
@@ -64,7 +71,29 @@ func (h *fileApiHandler) Handle(c *gin.Context) {
 
 	path = strings.TrimLeft(path, sourcePrefix)
 
-	f, err := h.root.Open(path)
+	// only the files that the login user can read are visible
+	var acl *auth.ACL
+	var userName string
+	root := h.root
+	if user := loginUser(c); user != nil {
+		acl, userName = h.users.ACL(), user.UserName
+		if h.userRoot {
+			dir, err := auth.UserRoot(string(h.root), userName)
+			if err != nil {
+				h.logger.Error(err, "file server get the user root error")
+				c.JSON(http.StatusOK, server.NewErrorApiResult(contract.NoPermission, contract.NoPermissionDesc))
+				return
+			}
+			root = http.Dir(dir)
+		}
+	}
+	routePath := filepath.ToSlash(filepath.Join(server.SourceRoutePrefix, path))
+	if !acl.Visible(userName, routePath) || nsfs.IsUploadPath(path) {
+		c.JSON(http.StatusOK, server.NewErrorApiResult(contract.NoPermission, contract.NoPermissionDesc))
+		return
+	}
+
+	f, err := root.Open(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			h.logger.Error(err, contract.NotFoundDesc)
@@ -85,18 +114,22 @@ func (h *fileApiHandler) Handle(c *gin.Context) {
 	}
 
 	if stat.IsDir() {
-		dirFileList, err := h.readDir(f, needHash, needCheckpoint, path)
+		dirFileList, err := h.readDir(root, f, needHash, needCheckpoint, path)
 		if err != nil {
 			c.JSON(http.StatusOK, server.NewErrorApiResult(-505, "read dir error"))
 			return
 		}
-		fileList = append(fileList, dirFileList...)
+		for _, file := range dirFileList {
+			if acl.Visible(userName, routePath+"/"+file.Path) && !nsfs.IsUploadPath(file.Path) {
+				fileList = append(fileList, file)
+			}
+		}
 	}
 
 	c.JSON(http.StatusOK, server.NewApiResult(contract.Success, contract.SuccessDesc, fileList))
 }
 
-func (h *fileApiHandler) readDir(f http.File, needHash bool, needCheckpoint bool, path string) (fileList []contract.FileInfo, err error) {
+func (h *fileApiHandler) readDir(root http.Dir, f http.File, needHash bool, needCheckpoint bool, path string) (fileList []contract.FileInfo, err error) {
 	const (
 		maxCalcSizeSingle int64 = 1024 * 1024 * 1024 * 15  // 15G
 		maxCalcSizeSum    int64 = 1024 * 1024 * 1024 * 500 // 500G
@@ -119,7 +152,7 @@ func (h *fileApiHandler) readDir(f http.File, needHash bool, needCheckpoint bool
 		hash := ""
 		var hvs hashutil.HashValues
 		if !file.IsDir() && (needHash || needCheckpoint) && calcSizeSum < maxCalcSizeSum && file.Size() < maxCalcSizeSingle {
-			if cf, err := h.root.Open(filepath.ToSlash(filepath.Join(path, file.Name()))); err == nil {
+			if cf, err := root.Open(filepath.ToSlash(filepath.Join(path, file.Name()))); err == nil {
 				if needCheckpoint {
 					hvs, _ = h.hash.CheckpointsHashFromFile(cf.(*os.File), h.chunkSize, h.checkpointCount)
 				}
@@ -144,15 +177,15 @@ func (h *fileApiHandler) readDir(f http.File, needHash bool, needCheckpoint bool
 			ATime:      aTime.Unix(),
 			CTime:      cTime.Unix(),
 			MTime:      mTime.Unix(),
-			LinkTo:     h.readlink(file),
+			LinkTo:     h.readlink(root, file),
 		})
 	}
 	return fileList, nil
 }
 
-func (h *fileApiHandler) readlink(file fs.FileInfo) string {
+func (h *fileApiHandler) readlink(root http.Dir, file fs.FileInfo) string {
 	if fsutil.IsSymlinkMode(file.Mode()) {
-		path := filepath.Join(string(h.root), file.Name())
+		path := filepath.Join(string(root), file.Name())
 		realPath, err := fsutil.Readlink(path)
 		if err == nil {
 			return realPath
@@ -0,0 +1,346 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/no-src/gofs/action"
+	"github.com/no-src/gofs/auth"
+	"github.com/no-src/gofs/contract"
+	"github.com/no-src/gofs/contract/push"
+	"github.com/no-src/gofs/core"
+	nsfs "github.com/no-src/gofs/fs"
+	"github.com/no-src/gofs/logger"
+	"github.com/no-src/gofs/quota"
+	"github.com/no-src/gofs/report"
+	"github.com/no-src/gofs/server"
+	"github.com/no-src/nsgo/hashutil"
+)
+
+// uploadExpires the lifetime of the unfinished upload that can be resumed
+const uploadExpires = 24 * time.Hour
+
+var (
+	errInvalidOffset = errors.New("the offset of the chunk is greater than the uploaded size")
+	errChunkTooLarge = errors.New("the end of the chunk is greater than the size of the file")
+)
+
+type fileManageHandler struct {
+	*pushHandler
+}
+
+// uploadResult the result of the upload api, the upload is finished if the offset is equal to the size of the file
+type uploadResult struct {
+	Offset int64 `json:"offset"`
+}
+
+// NewFileManageHandlerFunc returns a gin.HandlerFunc that manages the files of the source by the web UI, like creating a directory,
+// renaming, deleting and uploading by chunks, it shares the permission, user root, quota and logically delete semantics of the push api,
+// so the changes flow through the sync pipeline like the pushed files. The unfinished uploads are stored in the .gofs_upload directory of the root
+// until they are finished, they are counted in the quotas and never synced
+func NewFileManageHandlerFunc(logger *logger.Logger, users *auth.UserStore, reporter report.Reporter, source core.VFS, enableLogicallyDelete bool, hash hashutil.Hash,
+	userRoot bool, userQuota *quota.Quota, storageQuota *quota.Quota, minFreeSpace int64) gin.HandlerFunc {
+	return (&fileManageHandler{
+		pushHandler: newPushHandler(logger, users, reporter, source, enableLogicallyDelete, hash, userRoot, userQuota, storageQuota, minFreeSpace),
+	}).Handle
+}
+
+func (h *fileManageHandler) Handle(c *gin.Context) {
+	defer func() {
+		e := recover()
+		if e != nil {
+			h.response(c, server.NewServerErrorResult())
+		}
+	}()
+
+	op := c.Param(server.ParamOp)
+	if c.Request.Method == http.MethodGet {
+		if op == server.FileOpUpload {
+			h.uploadStatus(c)
+		} else {
+			c.JSON(http.StatusOK, server.NewErrorApiResult(contract.NotFound, contract.NotFoundDesc))
+		}
+		return
+	}
+	switch op {
+	case server.FileOpMkdir:
+		h.mkdir(c)
+	case server.FileOpRename:
+		h.renameFile(c)
+	case server.FileOpDelete:
+		h.delete(c)
+	case server.FileOpUpload:
+		h.upload(c)
+	default:
+		h.response(c, server.NewErrorApiResult(contract.NotFound, contract.NotFoundDesc))
+	}
+}
+
+// resolve check the write permission of the login user to the path, and return the storage root and the absolute path of the file,
+// the storage root itself can't be changed
+func (h *fileManageHandler) resolve(c *gin.Context, act action.Action, path string) (root string, absPath string, ok bool) {
+	c.Set(server.AuditOp, act.String())
+	c.Set(server.AuditPath, path)
+	user := loginUser(c)
+	fi := contract.FileInfo{Path: path, IsDir: contract.ParseFsDirValue(act == action.CreateAction)}
+	if user != nil && !h.allow(user.UserName, push.PushData{Action: act, FileInfo: fi}) {
+		h.logger.Warn("file manage handler => no permission to write the path, username=%s path=%s remote=%s", user.UserName, path, c.Request.RemoteAddr)
+		h.response(c, server.NewErrorApiResult(contract.NoPermission, contract.NoPermissionDesc))
+		return root, absPath, false
+	}
+	root, absPath, err := h.buildAbsPath(user, path)
+	if err == nil && filepath.Clean(absPath) == filepath.Clean(root) {
+		err = errors.New("the root directory can't be changed")
+	}
+	if err != nil {
+		h.logger.Warn("file manage handler => %v, remote=%s", err, c.Request.RemoteAddr)
+		h.response(c, server.NewErrorApiResult(contract.NoPermission, contract.NoPermissionDesc))
+		return root, absPath, false
+	}
+	return root, absPath, true
+}
+
+func (h *fileManageHandler) mkdir(c *gin.Context) {
+	path := c.PostForm(server.ParamPath)
+	_, absPath, ok := h.resolve(c, action.CreateAction, path)
+	if !ok {
+		return
+	}
+	if _, err := os.Lstat(absPath); err == nil {
+		h.response(c, server.NewErrorApiResult(contract.AlreadyExists, fmt.Sprintf("%s => [%s]", contract.AlreadyExistsDesc, path)))
+		return
+	}
+	now := time.Now().Unix()
+	if err := h.create(absPath, contract.FileInfo{Path: path, IsDir: contract.FsIsDir, ATime: now, MTime: now}); err != nil {
+		h.logger.Error(err, "create the directory error => %s", path)
+		h.response(c, server.NewErrorApiResult(-501, "create the directory error"))
+		return
+	}
+	h.response(c, server.NewApiResult(contract.Success, contract.SuccessDesc, nil))
+}
+
+func (h *fileManageHandler) renameFile(c *gin.Context) {
+	path := c.PostForm(server.ParamPath)
+	to := c.PostForm(server.ParamTo)
+	_, absPath, ok := h.resolve(c, action.RenameAction, path)
+	if !ok {
+		return
+	}
+	_, absTo, ok := h.resolve(c, action.WriteAction, to)
+	if !ok {
+		return
+	}
+	c.Set(server.AuditOp, action.RenameAction.String())
+	c.Set(server.AuditPath, path)
+	if _, err := os.Lstat(absPath); os.IsNotExist(err) {
+		h.response(c, server.NewErrorApiResult(contract.NotFound, contract.NotFoundDesc))
+		return
+	}
+	if _, err := os.Lstat(absTo); err == nil {
+		h.response(c, server.NewErrorApiResult(contract.AlreadyExists, fmt.Sprintf("%s => [%s]", contract.AlreadyExistsDesc, to)))
+		return
+	}
+	err := os.MkdirAll(filepath.Dir(absTo), fs.ModePerm)
+	if err == nil {
+		err = os.Rename(absPath, absTo)
+	}
+	if err != nil {
+		h.logger.Error(err, "rename the file error => %s -> %s", path, to)
+		h.response(c, server.NewErrorApiResult(-502, "rename the file error"))
+		return
+	}
+	h.logger.Info("rename the file success [%s] -> [%s]", absPath, absTo)
+	h.response(c, server.NewApiResult(contract.Success, contract.SuccessDesc, nil))
+}
+
+func (h *fileManageHandler) delete(c *gin.Context) {
+	path := c.PostForm(server.ParamPath)
+	root, absPath, ok := h.resolve(c, action.RemoveAction, path)
+	if !ok {
+		return
+	}
+	if _, err := os.Lstat(absPath); os.IsNotExist(err) {
+		h.response(c, server.NewErrorApiResult(contract.NotFound, contract.NotFoundDesc))
+		return
+	}
+	if err := h.remove(root, absPath); err != nil {
+		h.logger.Error(err, "delete the file error => %s", path)
+		h.response(c, server.NewErrorApiResult(-503, "delete the file error"))
+		return
+	}
+	h.updateUsage(root, loginUser(c))
+	h.response(c, server.NewApiResult(contract.Success, contract.SuccessDesc, nil))
+}
+
+// uploadStatus return the uploaded offset of the file, the upload can be resumed from the offset
+func (h *fileManageHandler) uploadStatus(c *gin.Context) {
+	path := c.Query(server.ParamPath)
+	size, _ := strconv.ParseInt(c.Query(server.ParamSize), 10, 64)
+	mtime, _ := strconv.ParseInt(c.Query(server.ParamMTime), 10, 64)
+	root, _, ok := h.resolve(c, action.WriteAction, path)
+	if !ok {
+		return
+	}
+	// querying the upload status changes nothing
+	c.Set(server.AuditOp, "")
+	offset, _ := quota.FileSize(h.uploadPath(root, c, path, size, mtime))
+	h.response(c, server.NewApiResult(contract.Success, contract.SuccessDesc, uploadResult{Offset: offset}))
+}
+
+// upload write the chunk to the unfinished upload at the offset, and move the upload to the path after the last chunk is written,
+// the unfinished upload is stored under the root and counted in the usage, so the quotas are checked with every chunk before writing
+func (h *fileManageHandler) upload(c *gin.Context) {
+	path := c.PostForm(server.ParamPath)
+	offset, errOffset := strconv.ParseInt(c.PostForm(server.ParamOffset), 10, 64)
+	size, errSize := strconv.ParseInt(c.PostForm(server.ParamSize), 10, 64)
+	mtime, _ := strconv.ParseInt(c.PostForm(server.ParamMTime), 10, 64)
+	root, absPath, ok := h.resolve(c, action.WriteAction, path)
+	if !ok {
+		return
+	}
+	h.cleanExpiredUploads(root)
+	if errOffset != nil || errSize != nil || offset < 0 || size < 0 {
+		h.response(c, server.NewErrorApiResult(-504, "invalid offset or size"))
+		return
+	}
+	if stat, err := os.Stat(absPath); err == nil && stat.IsDir() {
+		h.response(c, server.NewErrorApiResult(contract.AlreadyExists, fmt.Sprintf("%s => [%s]", contract.AlreadyExistsDesc, path)))
+		return
+	}
+	fh, err := c.FormFile(push.ParamUpFile)
+	if err != nil {
+		h.logger.Error(err, "read the upload chunk error => %s", path)
+		h.response(c, server.NewErrorApiResult(-506, "write the upload chunk error"))
+		return
+	}
+	if offset+fh.Size > size {
+		h.response(c, server.NewErrorApiResult(-508, fmt.Sprintf("%s => [%s]", errChunkTooLarge.Error(), path)))
+		return
+	}
+	tmpPath := h.uploadPath(root, c, path, size, mtime)
+	staged, exist := quota.FileSize(tmpPath)
+	if offset > staged {
+		h.response(c, server.NewApiResult(-505, errInvalidOffset.Error(), uploadResult{Offset: staged}))
+		return
+	}
+
+	delta := quota.Usage{Bytes: offset + fh.Size - staged}
+	if !exist {
+		delta.Files = 1
+	}
+	reserved, code, err := h.reserveQuota(root, delta)
+	if err != nil {
+		h.logger.Warn("file manage handler => reject to upload the file [%s], %v", absPath, err)
+		h.response(c, server.NewErrorApiResult(code, fmt.Sprintf("%s => [%s]", code.String(), path)))
+		return
+	}
+	defer h.updateUsage(root, loginUser(c))
+	uploaded, err := h.writeChunk(fh, tmpPath, offset)
+	written, writtenExist := quota.FileSize(tmpPath)
+	actual := quota.Usage{Bytes: written - staged}
+	if !exist && writtenExist {
+		actual.Files = 1
+	}
+	h.commitUsage(root, reserved, actual)
+	if err != nil {
+		h.logger.Error(err, "write the upload chunk error => %s", path)
+		h.response(c, server.NewErrorApiResult(-506, "write the upload chunk error"))
+		return
+	}
+	if uploaded < size {
+		h.response(c, server.NewApiResult(contract.Success, contract.SuccessDesc, uploadResult{Offset: uploaded}))
+		return
+	}
+
+	oldSize, oldExist := quota.FileSize(absPath)
+	if err = h.finishUpload(tmpPath, absPath, mtime); err != nil {
+		h.logger.Error(err, "save the upload file error => %s", path)
+		h.response(c, server.NewErrorApiResult(-507, "save the upload file error"))
+		return
+	}
+	// the finished upload replaces the old file
+	if oldExist {
+		h.addUsage(root, quota.Usage{Bytes: -oldSize, Files: -1})
+	}
+	h.logger.Info("upload the file success [%s]", absPath)
+	h.response(c, server.NewApiResult(contract.Success, contract.SuccessDesc, uploadResult{Offset: uploaded}))
+}
+
+// writeChunk write the uploaded chunk to the file at the offset, return the size of the file after writing,
+// no more than the size of the chunk is written
+func (h *fileManageHandler) writeChunk(fh *multipart.FileHeader, tmpPath string, offset int64) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(tmpPath), 0700); err != nil {
+		return 0, err
+	}
+	src, err := fh.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+	out, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer out.Close()
+	if err = out.Truncate(offset); err != nil {
+		return 0, err
+	}
+	if _, err = out.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(out, io.LimitReader(src, fh.Size))
+	return offset + n, err
+}
+
+// finishUpload move the finished upload to the path
+func (h *fileManageHandler) finishUpload(tmpPath string, absPath string, mtime int64) error {
+	if err := os.MkdirAll(filepath.Dir(absPath), fs.ModePerm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, absPath); err != nil {
+		return err
+	}
+	if mtime > 0 {
+		return h.chtimes(absPath, contract.FileInfo{ATime: time.Now().Unix(), MTime: mtime})
+	}
+	return nil
+}
+
+// uploadPath return the path of the unfinished upload under the root, the same file of the same user is resumed from the same path
+func (h *fileManageHandler) uploadPath(root string, c *gin.Context, path string, size int64, mtime int64) string {
+	var userName string
+	if user := loginUser(c); user != nil {
+		userName = user.UserName
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%d\x00%d", userName, filepath.Clean(path), size, mtime)))
+	return filepath.Join(root, nsfs.UploadDirName, hex.EncodeToString(sum[:]))
+}
+
+// cleanExpiredUploads remove the unfinished uploads under the root that are not written for a long time
+func (h *fileManageHandler) cleanExpiredUploads(root string) {
+	dir := filepath.Join(root, nsfs.UploadDirName)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil && !info.IsDir() && time.Since(info.ModTime()) > uploadExpires {
+			err = os.Remove(filepath.Join(dir, entry.Name()))
+			if err == nil {
+				h.addUsage(root, quota.Usage{Bytes: -info.Size(), Files: -1})
+			}
+			h.logger.ErrorIf(err, "remove the expired upload error")
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/no-src/gofs/auth"
+	"github.com/no-src/gofs/contract"
+	"github.com/no-src/gofs/contract/push"
+	"github.com/no-src/gofs/core"
+	nsfs "github.com/no-src/gofs/fs"
+	"github.com/no-src/gofs/logger"
+	"github.com/no-src/gofs/quota"
+	"github.com/no-src/gofs/report"
+	"github.com/no-src/gofs/server"
+	"github.com/no-src/nsgo/hashutil"
+)
+
+type testUploadResult struct {
+	Code contract.Code `json:"code"`
+	Data uploadResult  `json:"data"`
+}
+
+func TestFileManageHandler(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(filepath.Dir(root), "outside.txt"), []byte("outside"), 0600); err != nil {
+		t.Fatalf("create the test file error => %v", err)
+	}
+	handler := newTestFileManageHandler(t, root, nil)
+
+	testCases := []struct {
+		name     string
+		op       string
+		path     string
+		to       string
+		expect   contract.Code
+		exist    []string
+		notExist []string
+	}{
+		{"mkdir", server.FileOpMkdir, "docs/2024", "", contract.Success, []string{"docs/2024"}, nil},
+		{"mkdir exist", server.FileOpMkdir, "docs", "", contract.AlreadyExists, nil, nil},
+		{"mkdir root", server.FileOpMkdir, "/", "", contract.NoPermission, nil, nil},
+		{"mkdir outside", server.FileOpMkdir, "../docs", "", contract.NoPermission, nil, []string{"../docs"}},
+		{"mkdir upload dir", server.FileOpMkdir, nsfs.UploadDirName + "/docs", "", contract.NoPermission, nil, []string{nsfs.UploadDirName}},
+		{"rename", server.FileOpRename, "docs/2024", "docs/2025", contract.Success, []string{"docs/2025"}, []string{"docs/2024"}},
+		{"rename not exist", server.FileOpRename, "docs/2024", "docs/2026", contract.NotFound, nil, []string{"docs/2026"}},
+		{"rename to exist", server.FileOpRename, "docs/2025", "docs", contract.AlreadyExists, []string{"docs/2025"}, nil},
+		{"rename root", server.FileOpRename, "", "docs2", contract.NoPermission, nil, []string{"docs2"}},
+		{"rename to root", server.FileOpRename, "docs", "/", contract.NoPermission, []string{"docs"}, nil},
+		{"rename to outside", server.FileOpRename, "docs", "../docs", contract.NoPermission, []string{"docs"}, []string{"../docs"}},
+		{"rename from outside", server.FileOpRename, "../outside.txt", "outside.txt", contract.NoPermission, []string{"../outside.txt"}, []string{"outside.txt"}},
+		{"delete", server.FileOpDelete, "docs/2025", "", contract.Success, []string{"docs"}, []string{"docs/2025"}},
+		{"delete not exist", server.FileOpDelete, "docs/2025", "", contract.NotFound, nil, nil},
+		{"delete root", server.FileOpDelete, "/", "", contract.NoPermission, []string{"docs"}, nil},
+		{"delete outside", server.FileOpDelete, "../outside.txt", "", contract.NoPermission, []string{"../outside.txt"}, nil},
+		{"delete outside with the dot", server.FileOpDelete, "docs/../../outside.txt", "", contract.NoPermission, []string{"../outside.txt"}, nil},
+		{"unknown op", "copy", "docs", "", contract.NotFound, []string{"docs"}, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Set(server.ParamPath, tc.path)
+			form.Set(server.ParamTo, tc.to)
+			req := httptest.NewRequest(http.MethodPost, server.FileRoutePrefix+tc.op, strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			var result server.ApiResult
+			serveFileManage(t, handler, req, &result)
+			if result.Code != tc.expect {
+				t.Errorf("expect to get code %d, but get %d => %s", tc.expect, result.Code, result.Message)
+			}
+			for _, path := range tc.exist {
+				if _, err := os.Stat(filepath.Join(root, path)); err != nil {
+					t.Errorf("expect the path [%s] is exist, but get error => %v", path, err)
+				}
+			}
+			for _, path := range tc.notExist {
+				if _, err := os.Stat(filepath.Join(root, path)); !os.IsNotExist(err) {
+					t.Errorf("expect the path [%s] is not exist, but get error => %v", path, err)
+				}
+			}
+		})
+	}
+}
+
+func TestFileManageHandler_Upload(t *testing.T) {
+	root := t.TempDir()
+	handler := newTestFileManageHandler(t, root, quota.New(20, 0))
+
+	testCases := []struct {
+		name   string
+		path   string
+		size   int64
+		offset int64
+		chunk  string
+		expect contract.Code
+		// the offset of the upload result, the -1 means ignore it
+		expectOffset int64
+	}{
+		{"first chunk", "hello.txt", 10, 0, "hello", contract.Success, 5},
+		{"resume", "hello.txt", 10, -1, "", contract.Success, 5},
+		{"offset is ahead", "hello.txt", 10, 7, "rld", -505, 5},
+		{"chunk is oversize", "hello.txt", 10, 5, "world!", -508, -1},
+		{"chunk is oversize at the start", "hello.txt", 4, 0, "hello", -508, -1},
+		{"negative offset", "hello.txt", 10, -5, "hello", -504, -1},
+		{"last chunk", "hello.txt", 10, 5, "world", contract.Success, 10},
+		{"overwrite", "hello.txt", 5, 0, "HELLO", contract.Success, 5},
+		{"over quota", "big.txt", 16, 0, "0123456789abcdef", contract.QuotaExceeded, -1},
+		{"in quota", "big.txt", 15, 0, "0123456789abcde", contract.Success, 15},
+		{"unfinished upload over quota", "more.txt", 2, 0, "m", contract.QuotaExceeded, -1},
+		{"upload dir", nsfs.UploadDirName + "/hello.txt", 5, 0, "hello", contract.NoPermission, -1},
+		{"outside", "../hello.txt", 5, 0, "hello", contract.NoPermission, -1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var req *http.Request
+			if tc.offset < 0 && len(tc.chunk) == 0 {
+				query := url.Values{}
+				query.Set(server.ParamPath, tc.path)
+				query.Set(server.ParamSize, strconv.FormatInt(tc.size, 10))
+				req = httptest.NewRequest(http.MethodGet, server.FileRoutePrefix+server.FileOpUpload+"?"+query.Encode(), nil)
+			} else {
+				req = newUploadRequest(t, tc.path, tc.size, tc.offset, tc.chunk)
+			}
+			var result testUploadResult
+			serveFileManage(t, handler, req, &result)
+			if result.Code != tc.expect {
+				t.Errorf("expect to get code %d, but get %d", tc.expect, result.Code)
+			}
+			if tc.expectOffset >= 0 && result.Data.Offset != tc.expectOffset {
+				t.Errorf("expect to get offset %d, but get %d", tc.expectOffset, result.Data.Offset)
+			}
+		})
+	}
+
+	expectFiles := map[string]string{"hello.txt": "HELLO", "big.txt": "0123456789abcde"}
+	for name, content := range expectFiles {
+		data, err := os.ReadFile(filepath.Join(root, name))
+		if err != nil || string(data) != content {
+			t.Errorf("expect the content of [%s] is %s, but get %s => %v", name, content, data, err)
+		}
+	}
+	entries, err := os.ReadDir(filepath.Join(root, nsfs.UploadDirName))
+	if err != nil || len(entries) != 0 {
+		t.Errorf("expect the finished and rejected uploads are removed from the upload directory, but get %d entries => %v", len(entries), err)
+	}
+	if _, err = os.Stat(filepath.Join(filepath.Dir(root), "hello.txt")); !os.IsNotExist(err) {
+		t.Errorf("expect the file outside of the root is not uploaded => %v", err)
+	}
+}
+
+func TestFileManageHandler_CleanExpiredUploads(t *testing.T) {
+	root := t.TempDir()
+	handler := newTestFileManageHandler(t, root, nil)
+	expired := filepath.Join(root, nsfs.UploadDirName, "expired")
+	if err := os.MkdirAll(filepath.Dir(expired), 0700); err != nil {
+		t.Fatalf("create the upload directory error => %v", err)
+	}
+	if err := os.WriteFile(expired, []byte("expired"), 0600); err != nil {
+		t.Fatalf("create the expired upload error => %v", err)
+	}
+	mtime := time.Now().Add(-uploadExpires - time.Hour)
+	if err := os.Chtimes(expired, mtime, mtime); err != nil {
+		t.Fatalf("change the time of the expired upload error => %v", err)
+	}
+
+	// querying the upload status does not remove the expired uploads
+	query := url.Values{}
+	query.Set(server.ParamPath, "hello.txt")
+	query.Set(server.ParamSize, "10")
+	var result testUploadResult
+	serveFileManage(t, handler, httptest.NewRequest(http.MethodGet, server.FileRoutePrefix+server.FileOpUpload+"?"+query.Encode(), nil), &result)
+	if _, err := os.Stat(expired); err != nil {
+		t.Errorf("expect to keep the expired upload after querying the upload status => %v", err)
+	}
+
+	serveFileManage(t, handler, newUploadRequest(t, "hello.txt", 10, 0, "hello"), &result)
+	if result.Code != contract.Success {
+		t.Errorf("expect to get code %d, but get %d", contract.Success, result.Code)
+	}
+	if _, err := os.Stat(expired); !os.IsNotExist(err) {
+		t.Errorf("expect to remove the expired upload after uploading a chunk => %v", err)
+	}
+}
+
+func newTestFileManageHandler(t *testing.T, root string, storageQuota *quota.Quota) gin.HandlerFunc {
+	gin.SetMode(gin.TestMode)
+	hash, err := hashutil.NewHash(hashutil.DefaultHash)
+	if err != nil {
+		t.Fatalf("create the hash error => %v", err)
+	}
+	l := logger.NewTestLogger()
+	t.Cleanup(func() { l.Close() })
+	return NewFileManageHandlerFunc(l, auth.NewUserStore(nil), report.NewReporter(), core.NewDiskVFS(root), false, hash,
+		false, nil, storageQuota, 0)
+}
+
+func serveFileManage(t *testing.T, handler gin.HandlerFunc, req *http.Request, result any) {
+	engine := gin.New()
+	engine.Any(server.FileFullRoute, func(c *gin.Context) {
+		c.Set(server.SessionUser, &auth.SessionUser{UserId: 1, UserName: "alice", Perm: auth.ToPerm("rw")})
+		handler(c)
+	})
+	resp := httptest.NewRecorder()
+	engine.ServeHTTP(resp, req)
+	if err := json.Unmarshal(resp.Body.Bytes(), result); err != nil {
+		t.Fatalf("parse the response error => %v, %s", err, resp.Body.String())
+	}
+}
+
+func newUploadRequest(t *testing.T, path string, size int64, offset int64, chunk string) *http.Request {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	fields := map[string]string{
+		server.ParamPath:   path,
+		server.ParamSize:   strconv.FormatInt(size, 10),
+		server.ParamOffset: strconv.FormatInt(offset, 10),
+	}
+	for k, v := range fields {
+		if err := w.WriteField(k, v); err != nil {
+			t.Fatalf("write the form field error => %v", err)
+		}
+	}
+	fw, err := w.CreateFormFile(push.ParamUpFile, filepath.Base(path))
+	if err == nil {
+		_, err = fw.Write([]byte(chunk))
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		t.Fatalf("write the upload chunk error => %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, server.FileRoutePrefix+server.FileOpUpload, body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
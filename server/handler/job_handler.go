@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/no-src/gofs/contract"
+	"github.com/no-src/gofs/logger"
+	"github.com/no-src/gofs/server"
+)
+
+type jobHandler struct {
+	logger *logger.Logger
+	action func(name string) error
+	desc   string
+}
+
+// NewJobStartHandlerFunc returns a gin.HandlerFunc that providers an api to start the specified sync job
+func NewJobStartHandlerFunc(logger *logger.Logger, jobs server.JobManager) gin.HandlerFunc {
+	return (&jobHandler{
+		logger: logger,
+		action: jobs.Start,
+		desc:   "start",
+	}).Handle
+}
+
+// NewJobStopHandlerFunc returns a gin.HandlerFunc that providers an api to stop the specified sync job
+func NewJobStopHandlerFunc(logger *logger.Logger, jobs server.JobManager) gin.HandlerFunc {
+	return (&jobHandler{
+		logger: logger,
+		action: jobs.Stop,
+		desc:   "stop",
+	}).Handle
+}
+
+func (h *jobHandler) Handle(c *gin.Context) {
+	name := c.Query(server.ParamJob)
+	if err := h.action(name); err != nil {
+		h.logger.Error(err, "%s the job error => [%s], remote=%s", h.desc, name, c.Request.RemoteAddr)
+		c.JSON(http.StatusOK, server.NewErrorApiResult(contract.Fail, err.Error()))
+		return
+	}
+	h.logger.Info("%s the job success => [%s], remote=%s", h.desc, name, c.Request.RemoteAddr)
+	c.JSON(http.StatusOK, server.NewApiResult(contract.Success, contract.SuccessDesc, nil))
+}
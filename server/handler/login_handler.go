@@ -5,21 +5,28 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
+	"github.com/no-src/gofs/audit"
 	"github.com/no-src/gofs/auth"
+	"github.com/no-src/gofs/contract"
 	"github.com/no-src/gofs/logger"
 	"github.com/no-src/gofs/server"
 )
 
+// twoFactorTimeout the time limit of the two-factor authentication after the password verification
+const twoFactorTimeout = 5 * time.Minute
+
 type loginHandler struct {
-	users  []*auth.User
+	users  *auth.UserStore
 	logger *logger.Logger
 }
 
 // NewLoginHandlerFunc returns a gin.HandlerFunc that providers a login api
-func NewLoginHandlerFunc(users []*auth.User, logger *logger.Logger) gin.HandlerFunc {
+func NewLoginHandlerFunc(users *auth.UserStore, logger *logger.Logger) gin.HandlerFunc {
 	return (&loginHandler{
 		users:  users,
 		logger: logger,
@@ -36,22 +43,14 @@ func (h *loginHandler) Handle(c *gin.Context) {
 
 	userName := c.PostForm(server.ParamUserName)
 	password := c.PostForm(server.ParamPassword)
-	returnUrl := c.PostForm(server.ParamReturnUrl)
-	if len(returnUrl) == 0 {
-		returnUrl = "/"
-	} else {
-		_, parseErr := url.Parse(returnUrl)
-		if parseErr != nil {
-			returnUrl = "/"
-		}
-	}
+	returnUrl := getReturnUrl(c)
+	c.Set(server.AuditUser, userName)
 
-	var loginUser *auth.SessionUser
-	for _, user := range h.users {
-		if user.UserName() == userName && user.Password() == password {
-			loginUser = auth.MapperToSessionUser(user)
-		}
+	user, err := h.users.Login(userName, password, c.ClientIP())
+	if loginLocked(c, h.logger, userName, err) {
+		return
 	}
+	loginUser := auth.MapperToSessionUser(user)
 	if loginUser != nil {
 		session := sessions.Default(c)
 		if session == nil {
@@ -59,17 +58,200 @@ func (h *loginHandler) Handle(c *gin.Context) {
 			c.String(http.StatusInternalServerError, "get session error")
 			return
 		}
-		session.Set(server.SessionUser, loginUser)
-		err := session.Save()
+		if h.users.RequireTwoFactor(user) {
+			h.requireTwoFactor(c, session, user, loginUser, returnUrl)
+			return
+		}
+		server.SignIn(session, loginUser, time.Now())
+		err = session.Save()
 		if err != nil {
 			h.logger.Error(err, "save session error, remote=%s", c.Request.RemoteAddr)
 			c.String(http.StatusInternalServerError, "save session error")
 			return
 		}
 		h.logger.Info("login success, userid=%d username=%s remote=%s", loginUser.UserId, loginUser.UserName, c.Request.RemoteAddr)
+		c.Set(server.AuditCode, contract.Success)
 		c.Redirect(http.StatusFound, returnUrl)
 	} else {
 		h.logger.Info("login failed, username=%s remote=%s", userName, c.Request.RemoteAddr)
+		c.Set(server.AuditCode, contract.Unauthorized)
 		c.Redirect(http.StatusFound, server.LoginIndexFullRoute)
 	}
 }
+
+// requireTwoFactor keep the user that has passed the password verification in the session and wait for the two-factor authentication
+func (h *loginHandler) requireTwoFactor(c *gin.Context, session sessions.Session, user *auth.User, loginUser *auth.SessionUser, returnUrl string) {
+	if !user.TwoFactorEnabled() {
+		h.logger.Warn("login failed, the user requires the two-factor authentication but is not enrolled, username=%s remote=%s", loginUser.UserName, c.Request.RemoteAddr)
+		c.Set(server.AuditCode, contract.NoPermission)
+		c.Redirect(http.StatusFound, server.LoginIndexFullRoute)
+		return
+	}
+	session.Delete(server.SessionUser)
+	session.Set(server.SessionTwoFactorUser, loginUser)
+	session.Set(server.SessionTwoFactorExpires, time.Now().Add(twoFactorTimeout).Unix())
+	session.Set(server.SessionTwoFactorReturnUrl, returnUrl)
+	if err := session.Save(); err != nil {
+		h.logger.Error(err, "save session error, remote=%s", c.Request.RemoteAddr)
+		c.String(http.StatusInternalServerError, "save session error")
+		return
+	}
+	h.logger.Info("login requires the two-factor authentication, userid=%d username=%s remote=%s", loginUser.UserId, loginUser.UserName, c.Request.RemoteAddr)
+	c.Set(server.AuditOp, audit.OpTwoFactorRequired)
+	c.Set(server.AuditCode, contract.Success)
+	c.Redirect(http.StatusFound, server.LoginIndexFullRoute)
+}
+
+type twoFactorHandler struct {
+	users  *auth.UserStore
+	logger *logger.Logger
+}
+
+// NewTwoFactorHandlerFunc returns a gin.HandlerFunc that providers the two-factor authentication api of the login,
+// the used recovery code is removed from the users file by the users
+func NewTwoFactorHandlerFunc(users *auth.UserStore, logger *logger.Logger) gin.HandlerFunc {
+	return (&twoFactorHandler{
+		users:  users,
+		logger: logger,
+	}).Handle
+}
+
+func (h *twoFactorHandler) Handle(c *gin.Context) {
+	session := sessions.Default(c)
+	pending, ok := twoFactorUser(session)
+	if !ok {
+		clearTwoFactor(session)
+		h.logger.ErrorIf(session.Save(), "save session error, remote=%s", c.Request.RemoteAddr)
+		c.Set(server.AuditCode, contract.Unauthorized)
+		c.Redirect(http.StatusFound, server.LoginIndexFullRoute)
+		return
+	}
+
+	c.Set(server.AuditUser, pending.UserName)
+	user, recoveryCode, err := h.users.VerifyTwoFactor(pending.UserName, c.PostForm(server.ParamCode), c.ClientIP(), time.Now())
+	if loginLocked(c, h.logger, pending.UserName, err) {
+		return
+	}
+	if err != nil {
+		h.logger.Info("two-factor authentication failed, username=%s remote=%s, %v", pending.UserName, c.Request.RemoteAddr, err)
+		c.Set(server.AuditCode, contract.Unauthorized)
+		c.Redirect(http.StatusFound, server.LoginIndexFullRoute)
+		return
+	}
+	if len(recoveryCode) > 0 {
+		h.logger.Warn("two-factor authentication by the recovery code, username=%s remote=%s", pending.UserName, c.Request.RemoteAddr)
+		c.Set(server.AuditOp, audit.OpRecoveryCode)
+	}
+
+	returnUrl, _ := session.Get(server.SessionTwoFactorReturnUrl).(string)
+	if len(returnUrl) == 0 {
+		returnUrl = "/"
+	}
+	loginUser := auth.MapperToSessionUser(user)
+	clearTwoFactor(session)
+	server.SignIn(session, loginUser, time.Now())
+	if err = session.Save(); err != nil {
+		h.logger.Error(err, "save session error, remote=%s", c.Request.RemoteAddr)
+		c.String(http.StatusInternalServerError, "save session error")
+		return
+	}
+	h.logger.Info("login success, userid=%d username=%s remote=%s", loginUser.UserId, loginUser.UserName, c.Request.RemoteAddr)
+	c.Set(server.AuditCode, contract.Success)
+	c.Redirect(http.StatusFound, returnUrl)
+}
+
+type loginIndexHandler struct {
+	logger *logger.Logger
+}
+
+// NewLoginIndexHandlerFunc returns a gin.HandlerFunc that providers the login page, the page shows the two-factor authentication form
+// if the user of the session has passed the password verification
+func NewLoginIndexHandlerFunc(logger *logger.Logger) gin.HandlerFunc {
+	return (&loginIndexHandler{
+		logger: logger,
+	}).Handle
+}
+
+func (h *loginIndexHandler) Handle(c *gin.Context) {
+	_, twoFactor := twoFactorUser(sessions.Default(c))
+	c.HTML(http.StatusOK, "login.html", gin.H{
+		"TwoFactor": twoFactor,
+		"CSRFToken": csrfToken(c, h.logger),
+	})
+}
+
+type signOutHandler struct {
+	logger *logger.Logger
+}
+
+// NewSignOutHandlerFunc returns a gin.HandlerFunc that providers a sign out api, it removes the user and all the other state of the session
+func NewSignOutHandlerFunc(logger *logger.Logger) gin.HandlerFunc {
+	return (&signOutHandler{
+		logger: logger,
+	}).Handle
+}
+
+func (h *signOutHandler) Handle(c *gin.Context) {
+	session := sessions.Default(c)
+	userName := ""
+	if user, ok := session.Get(server.SessionUser).(auth.SessionUser); ok {
+		userName = user.UserName
+	}
+	c.Set(server.AuditUser, userName)
+	server.SignOut(session)
+	if err := session.Save(); err != nil {
+		h.logger.Error(err, "save session error, remote=%s", c.Request.RemoteAddr)
+		c.String(http.StatusInternalServerError, "save session error")
+		return
+	}
+	h.logger.Info("sign out success, username=%s remote=%s", userName, c.Request.RemoteAddr)
+	c.Set(server.AuditCode, contract.Success)
+	c.Redirect(http.StatusFound, server.LoginIndexFullRoute)
+}
+
+// twoFactorUser return the user of the session that waits for the two-factor authentication before it expires
+func twoFactorUser(session sessions.Session) (user auth.SessionUser, ok bool) {
+	if session == nil {
+		return user, false
+	}
+	user, ok = session.Get(server.SessionTwoFactorUser).(auth.SessionUser)
+	expires, _ := session.Get(server.SessionTwoFactorExpires).(int64)
+	return user, ok && time.Now().Unix() < expires
+}
+
+func clearTwoFactor(session sessions.Session) {
+	session.Delete(server.SessionTwoFactorUser)
+	session.Delete(server.SessionTwoFactorExpires)
+	session.Delete(server.SessionTwoFactorReturnUrl)
+}
+
+// loginLocked response the 429 status if the login is locked out by the login guard
+func loginLocked(c *gin.Context, logger *logger.Logger, userName string, err error) bool {
+	var lockedErr *auth.LockedError
+	if !errors.As(err, &lockedErr) {
+		return false
+	}
+	logger.Warn("login locked, username=%s remote=%s, %v", userName, c.Request.RemoteAddr, err)
+	c.Set(server.AuditCode, contract.LoginLocked)
+	c.Header("Retry-After", strconv.Itoa(lockedErr.RetryAfterSeconds()))
+	c.String(http.StatusTooManyRequests, err.Error())
+	return true
+}
+
+// csrfToken return the CSRF token of the session for the forms, return an empty token if failed, then the form is rejected
+func csrfToken(c *gin.Context, logger *logger.Logger) string {
+	token, err := server.CSRFToken(c)
+	logger.ErrorIf(err, "get the csrf token error, remote=%s", c.Request.RemoteAddr)
+	return token
+}
+
+func getReturnUrl(c *gin.Context) string {
+	returnUrl := c.PostForm(server.ParamReturnUrl)
+	if len(returnUrl) == 0 {
+		return "/"
+	}
+	if _, err := url.Parse(returnUrl); err != nil {
+		return "/"
+	}
+	return returnUrl
+}
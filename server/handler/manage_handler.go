@@ -26,20 +26,7 @@ func NewManageHandlerFunc(logger *logger.Logger, conf conf.Config) gin.HandlerFu
 
 func (h *manageHandler) Handle(c *gin.Context) {
 	format := strings.ToLower(c.Query(server.ParamFormat))
-	config := h.conf
-	mask := "******"
-	if len(config.Users) > 0 {
-		config.Users = mask
-	}
-	if len(config.SessionConnection) > 0 {
-		config.SessionConnection = mask
-	}
-	if len(config.EncryptSecret) > 0 {
-		config.EncryptSecret = mask
-	}
-	if len(config.DecryptSecret) > 0 {
-		config.DecryptSecret = mask
-	}
+	config := h.conf.Mask()
 	result := server.NewApiResult(contract.Success, contract.SuccessDesc, config)
 	if format == conf.YamlFormat.Name() {
 		c.YAML(http.StatusOK, result)
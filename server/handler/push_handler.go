@@ -13,39 +13,65 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/no-src/gofs/action"
+	"github.com/no-src/gofs/auth"
 	"github.com/no-src/gofs/contract"
 	"github.com/no-src/gofs/contract/push"
 	"github.com/no-src/gofs/core"
 	nsfs "github.com/no-src/gofs/fs"
 	"github.com/no-src/gofs/logger"
+	"github.com/no-src/gofs/quota"
+	"github.com/no-src/gofs/report"
 	"github.com/no-src/gofs/server"
 	"github.com/no-src/nsgo/fsutil"
 	"github.com/no-src/nsgo/hashutil"
 	"github.com/no-src/nsgo/jsonutil"
 )
 
+var errUploadPath = errors.New("the directory of the unfinished uploads can't be changed")
+
 type pushHandler struct {
 	logger                *logger.Logger
+	users                 *auth.UserStore
 	storagePath           string
 	enableLogicallyDelete bool
 	hash                  hashutil.Hash
+	userRoot              bool
+	userQuota             *quota.Quota
+	storageQuota          *quota.Quota
+	minFreeSpace          int64
+	reporter              report.Reporter
+}
+
+// NewPushHandlerFunc returns a gin.HandlerFunc that to manage the files,
+// every login user is confined to its own root directory like <source>/<username> if the userRoot is true,
+// the userQuota limits every user root and the storageQuota limits the whole storage,
+// and the uploads are rejected if the free space of the disk would drop below the minFreeSpace
+func NewPushHandlerFunc(logger *logger.Logger, users *auth.UserStore, reporter report.Reporter, source core.VFS, enableLogicallyDelete bool, hash hashutil.Hash,
+	userRoot bool, userQuota *quota.Quota, storageQuota *quota.Quota, minFreeSpace int64) gin.HandlerFunc {
+	return newPushHandler(logger, users, reporter, source, enableLogicallyDelete, hash, userRoot, userQuota, storageQuota, minFreeSpace).Handle
 }
 
-// NewPushHandlerFunc returns a gin.HandlerFunc that to manage the files
-func NewPushHandlerFunc(logger *logger.Logger, source core.VFS, enableLogicallyDelete bool, hash hashutil.Hash) gin.HandlerFunc {
-	return (&pushHandler{
+func newPushHandler(logger *logger.Logger, users *auth.UserStore, reporter report.Reporter, source core.VFS, enableLogicallyDelete bool, hash hashutil.Hash,
+	userRoot bool, userQuota *quota.Quota, storageQuota *quota.Quota, minFreeSpace int64) *pushHandler {
+	return &pushHandler{
 		logger:                logger,
+		users:                 users,
 		storagePath:           source.Path().Base(),
 		enableLogicallyDelete: enableLogicallyDelete,
 		hash:                  hash,
-	}).Handle
+		userRoot:              userRoot,
+		userQuota:             userQuota,
+		storageQuota:          storageQuota,
+		minFreeSpace:          minFreeSpace,
+		reporter:              reporter,
+	}
 }
 
 func (h *pushHandler) Handle(c *gin.Context) {
 	defer func() {
 		e := recover()
 		if e != nil {
-			c.JSON(http.StatusOK, server.NewServerErrorResult())
+			h.response(c, server.NewServerErrorResult())
 		}
 	}()
 
@@ -54,7 +80,7 @@ func (h *pushHandler) Handle(c *gin.Context) {
 	err := jsonutil.Unmarshal([]byte(pushDataStr), &pushData)
 	if err != nil {
 		msg := "unmarshal push data error"
-		c.JSON(http.StatusOK, server.NewErrorApiResult(-501, msg))
+		h.response(c, server.NewErrorApiResult(-501, msg))
 		h.logger.Error(err, "%s => %s", msg, pushDataStr)
 		return
 	}
@@ -62,42 +88,103 @@ func (h *pushHandler) Handle(c *gin.Context) {
 	h.logger.Debug("receive action %s => %s", pushData.Action.String(), pushDataStr)
 
 	if pushData.Action.Valid() == action.UnknownAction {
-		c.JSON(http.StatusOK, server.NewErrorApiResult(-502, fmt.Sprintf("unknown action => %d", pushData.Action.Int())))
+		h.response(c, server.NewErrorApiResult(-502, fmt.Sprintf("unknown action => %d", pushData.Action.Int())))
 		return
 	}
 	fi := pushData.FileInfo
+	c.Set(server.AuditOp, pushData.Action.String())
+	c.Set(server.AuditPath, fi.Path)
+	user := loginUser(c)
+	if user != nil && !h.allow(user.UserName, pushData) {
+		h.logger.Warn("push handler => no permission to write the path, username=%s path=%s remote=%s", user.UserName, fi.Path, c.Request.RemoteAddr)
+		h.response(c, server.NewErrorApiResult(contract.NoPermission, contract.NoPermissionDesc))
+		return
+	}
+	root, path, err := h.buildAbsPath(user, fi.Path)
+	if err != nil {
+		h.logger.Warn("push handler => %v, remote=%s", err, c.Request.RemoteAddr)
+		h.response(c, server.NewErrorApiResult(contract.NoPermission, contract.NoPermissionDesc))
+		return
+	}
 	switch pushData.Action {
 	case action.CreateAction:
-		err = h.create(fi)
+		_, exist := quota.FileSize(path)
+		var reserved quotaReservation
+		if !exist && !fi.IsDir.Bool() {
+			var code contract.Code
+			if reserved, code, err = h.reserveQuota(root, quota.Usage{Files: 1}); err != nil {
+				h.logger.Warn("push handler => reject to create the file [%s], %v", path, err)
+				h.response(c, server.NewErrorApiResult(code, fmt.Sprintf("%s => [%s]", code.String(), fi.Path)))
+				return
+			}
+		}
+		err = h.create(path, fi)
+		var created quota.Usage
+		if _, newExist := quota.FileSize(path); !exist && newExist {
+			created.Files = 1
+		}
+		h.commitUsage(root, reserved, created)
 	case action.SymlinkAction:
-		err = h.symlink(fi)
+		err = h.symlink(root, path, fi)
 	case action.RemoveAction:
-		err = h.remove(fi)
+		err = h.remove(root, path)
 	case action.RenameAction:
-		err = h.rename(fi)
+		err = h.rename(root, path)
 	case action.ChmodAction:
-		err = h.chmod(fi)
+		err = h.chmod(path)
 	case action.WriteAction:
-		r, _ := h.write(pushData, c)
-		c.JSON(http.StatusOK, r)
+		r, _ := h.write(root, path, pushData, c)
+		h.response(c, r)
 		return
 	default:
 		err = fmt.Errorf("unsupported action => [%d:%s]", pushData.Action.Int(), pushData.Action.String())
 	}
 	if err != nil {
 		h.logger.Error(err, "process action error %s => %s", pushData.Action.String(), fi.Path)
-		c.JSON(http.StatusOK, server.NewErrorApiResult(-503, fmt.Sprintf("process action error => %s", err.Error())))
+		h.response(c, server.NewErrorApiResult(-503, fmt.Sprintf("process action error => %s", err.Error())))
 	} else {
-		c.JSON(http.StatusOK, server.NewApiResult(contract.Success, contract.SuccessDesc, nil))
+		h.updateUsage(root, user)
+		h.response(c, server.NewApiResult(contract.Success, contract.SuccessDesc, nil))
+	}
+}
+
+// response write the result of the push api, the result code is recorded by the audit log
+func (h *pushHandler) response(c *gin.Context, r server.ApiResult) {
+	c.Set(server.AuditCode, r.Code)
+	c.JSON(http.StatusOK, r)
+}
+
+// allow report whether the user has the write permission to the path of the push data,
+// the parent directories of the writable paths are allowed to create
+func (h *pushHandler) allow(userName string, pushData push.PushData) bool {
+	acl := h.users.ACL()
+	routePath := filepath.ToSlash(filepath.Join(server.SourceRoutePrefix, pushData.FileInfo.Path))
+	if pushData.Action == action.CreateAction && pushData.FileInfo.IsDir.Bool() {
+		return acl.Reachable(userName, routePath, auth.WritePerm)
 	}
+	return acl.Check(userName, routePath, auth.WritePerm)
 }
 
-func (h *pushHandler) buildAbsPath(path string) string {
-	return filepath.Join(h.storagePath, path)
+// buildAbsPath return the storage root of the login user and the absolute path of the file in the root,
+// return an error if the path is outside of the root or in the directory of the unfinished uploads
+func (h *pushHandler) buildAbsPath(user *auth.SessionUser, path string) (root string, absPath string, err error) {
+	root = h.storagePath
+	if h.userRoot && user != nil {
+		if root, err = auth.UserRoot(h.storagePath, user.UserName); err != nil {
+			return root, absPath, err
+		}
+	}
+	absPath, err = nsfs.SafeJoin(root, path)
+	if err != nil {
+		return root, absPath, err
+	}
+	if rel, _ := filepath.Rel(root, absPath); nsfs.IsUploadPath(rel) {
+		err = fmt.Errorf("%w => %s", errUploadPath, path)
+	}
+	return root, absPath, err
 }
 
-func (h *pushHandler) create(fi contract.FileInfo) error {
-	path := h.buildAbsPath(fi.Path)
+func (h *pushHandler) create(path string, fi contract.FileInfo) error {
 	exist, err := fsutil.FileExist(path)
 	if err != nil {
 		return err
@@ -135,9 +222,13 @@ func (h *pushHandler) create(fi contract.FileInfo) error {
 	return nil
 }
 
-func (h *pushHandler) symlink(fi contract.FileInfo) error {
-	path := h.buildAbsPath(fi.Path)
-	err := os.RemoveAll(path)
+func (h *pushHandler) symlink(root string, path string, fi contract.FileInfo) error {
+	if h.userRoot {
+		if err := nsfs.CheckSymlink(root, path, fi.LinkTo); err != nil {
+			return err
+		}
+	}
+	err := h.removeAll(root, path)
 	if err != nil {
 		return err
 	}
@@ -148,12 +239,11 @@ func (h *pushHandler) symlink(fi contract.FileInfo) error {
 	return nil
 }
 
-func (h *pushHandler) remove(fi contract.FileInfo) (err error) {
-	path := h.buildAbsPath(fi.Path)
+func (h *pushHandler) remove(root string, path string) (err error) {
 	if h.enableLogicallyDelete {
 		err = nsfs.LogicallyDelete(path)
 	} else {
-		err = os.RemoveAll(path)
+		err = h.removeAll(root, path)
 	}
 	if err == nil {
 		h.logger.Info("remove file success [%s]", path)
@@ -161,29 +251,107 @@ func (h *pushHandler) remove(fi contract.FileInfo) (err error) {
 	return err
 }
 
-func (h *pushHandler) rename(fi contract.FileInfo) (err error) {
-	path := h.buildAbsPath(fi.Path)
-	err = os.RemoveAll(path)
+func (h *pushHandler) rename(root string, path string) (err error) {
+	err = h.removeAll(root, path)
 	if err == nil {
 		h.logger.Info("remove file success [%s]", path)
 	}
 	return err
 }
 
-func (h *pushHandler) chmod(fi contract.FileInfo) (err error) {
-	path := h.buildAbsPath(fi.Path)
+// removeAll remove the path and release the usage of the quotas
+func (h *pushHandler) removeAll(root string, path string) error {
+	var usage quota.Usage
+	if h.userQuota.Enabled() || h.storageQuota.Enabled() {
+		usage, _ = quota.DirUsage(path)
+	}
+	err := os.RemoveAll(path)
+	if err == nil {
+		h.addUsage(root, quota.Usage{Bytes: -usage.Bytes, Files: -usage.Files})
+	}
+	return err
+}
+
+// quotaReservation the usage that is reserved in the quota of the user root and the whole storage before writing
+type quotaReservation struct {
+	user    quota.Usage
+	storage quota.Usage
+}
+
+// reserveQuota check the delta of the usage does not exceed the quota of the user root and the whole storage,
+// and the free space of the disk does not drop below the minFreeSpace. The increase of the delta is reserved
+// in the quotas, so the concurrent writes can't exceed the quotas together, commit it by commitUsage after writing
+func (h *pushHandler) reserveQuota(root string, delta quota.Usage) (r quotaReservation, code contract.Code, err error) {
+	if root != h.storagePath {
+		if r.user, err = h.userQuota.Check(root, delta); err != nil {
+			return quotaReservation{}, contract.QuotaExceeded, err
+		}
+	}
+	if r.storage, err = h.storageQuota.Check(h.storagePath, delta); err != nil {
+		h.commitUsage(root, r, quota.Usage{})
+		return quotaReservation{}, contract.QuotaExceeded, err
+	}
+	if err = quota.CheckFreeSpace(h.storagePath, h.minFreeSpace, delta.Bytes); err != nil {
+		h.commitUsage(root, r, quota.Usage{})
+		return quotaReservation{}, contract.NoSpace, err
+	}
+	return r, contract.Success, nil
+}
+
+// commitUsage replace the reserved usage with the actual change of the usage, the reservation is released
+// if nothing is changed, for example, the write is failed
+func (h *pushHandler) commitUsage(root string, r quotaReservation, actual quota.Usage) {
+	if root != h.storagePath {
+		h.userQuota.Add(root, actual.Sub(r.user))
+	}
+	h.storageQuota.Add(h.storagePath, actual.Sub(r.storage))
+}
+
+// addUsage add the delta to the usage of the user root and the whole storage
+func (h *pushHandler) addUsage(root string, delta quota.Usage) {
+	if root != h.storagePath {
+		h.userQuota.Add(root, delta)
+	}
+	h.storageQuota.Add(h.storagePath, delta)
+}
+
+// updateUsage report the current usage of the user root and the whole storage
+func (h *pushHandler) updateUsage(root string, user *auth.SessionUser) {
+	if root != h.storagePath && user != nil && h.userQuota.Enabled() {
+		usage, _ := h.userQuota.Usage(root)
+		h.putStorage(user.UserName, root, usage, h.userQuota.Max(), 0)
+	}
+	if h.storageQuota.Enabled() || h.minFreeSpace > 0 {
+		usage, _ := h.storageQuota.Usage(h.storagePath)
+		free, _ := quota.FreeSpace(h.storagePath)
+		h.putStorage(report.StorageAll, h.storagePath, usage, h.storageQuota.Max(), free)
+	}
+}
+
+func (h *pushHandler) putStorage(name string, root string, usage quota.Usage, max quota.Usage, free int64) {
+	h.reporter.PutStorage(report.StorageStat{
+		Name:      name,
+		Path:      root,
+		UsedBytes: usage.Bytes,
+		UsedFiles: usage.Files,
+		MaxBytes:  max.Bytes,
+		MaxFiles:  max.Files,
+		FreeBytes: free,
+	})
+}
+
+func (h *pushHandler) chmod(path string) (err error) {
 	h.logger.Debug("chmod is unimplemented [%s]", path)
 	return nil
 }
 
-func (h *pushHandler) write(pushData push.PushData, c *gin.Context) (server.ApiResult, error) {
+func (h *pushHandler) write(root string, path string, pushData push.PushData, c *gin.Context) (server.ApiResult, error) {
 	fi := pushData.FileInfo
 	if fi.IsDir.Bool() {
 		err := errors.New("can't write a directory")
 		h.logger.Error(err, "write upload file error")
 		return server.NewErrorApiResult(-504, err.Error()), err
 	}
-	path := h.buildAbsPath(fi.Path)
 	fh, err := c.FormFile(push.ParamUpFile)
 	if err != nil {
 		msg := "get upload file error"
@@ -191,7 +359,32 @@ func (h *pushHandler) write(pushData push.PushData, c *gin.Context) (server.ApiR
 		return server.NewErrorApiResult(-505, msg), err
 	}
 
+	// check the quotas with the final size of the file before accepting a file or chunk, the size of the file info
+	// is zero if the file is encrypted by the client, so check the end of the chunk too
+	oldSize, exist := quota.FileSize(path)
+	delta := quota.Usage{Bytes: max(fi.Size, pushData.Chunk.Offset+fh.Size) - oldSize}
+	if !exist {
+		delta.Files = 1
+	}
+	var reserved quotaReservation
+	if pushData.PushAction >= push.WritePushAction {
+		var code contract.Code
+		if reserved, code, err = h.reserveQuota(root, delta); err != nil {
+			h.logger.Warn("push handler => reject to write the file [%s], %v", path, err)
+			return server.NewErrorApiResult(code, fmt.Sprintf("%s => [%s]", code.String(), fi.Path)), err
+		}
+	}
+
 	code, hv, err := h.Save(fh, path, pushData)
+	if pushData.PushAction >= push.WritePushAction {
+		newSize, newExist := quota.FileSize(path)
+		delta = quota.Usage{Bytes: newSize - oldSize}
+		if !exist && newExist {
+			delta.Files = 1
+		}
+		h.commitUsage(root, reserved, delta)
+		h.updateUsage(root, loginUser(c))
+	}
 	if err != nil {
 		h.logger.Error(err, fmt.Sprintf("save upload file error => [%s]", path))
 		return server.NewErrorApiResult(-506, fmt.Sprintf("save upload file error => [%s]", fi.Path)), err
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/no-src/gofs/action"
+	"github.com/no-src/gofs/auth"
+	"github.com/no-src/gofs/contract"
+	"github.com/no-src/gofs/contract/push"
+	"github.com/no-src/gofs/core"
+	"github.com/no-src/gofs/logger"
+	"github.com/no-src/gofs/report"
+	"github.com/no-src/gofs/server"
+	"github.com/no-src/nsgo/hashutil"
+)
+
+func TestPushHandler_ACL(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	root := t.TempDir()
+	for _, name := range []string{"docs/private/keep.txt", "other/keep.txt", "docs/remove.txt"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatalf("create the test directory error => %v", err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0600); err != nil {
+			t.Fatalf("create the test file error => %v", err)
+		}
+	}
+	acl, err := auth.ParseACL("alice|/source/docs|w,alice|/source/docs/private|-,bob|/source|r", "")
+	if err != nil {
+		t.Fatalf("ParseACL error => %v", err)
+	}
+	users := auth.NewUserStore(nil)
+	users.SetACL(acl)
+	hash, err := hashutil.NewHash(hashutil.DefaultHash)
+	if err != nil {
+		t.Fatalf("create the hash error => %v", err)
+	}
+	l := logger.NewTestLogger()
+	defer l.Close()
+	handler := NewPushHandlerFunc(l, users, report.NewReporter(), core.NewDiskVFS(root), false, hash, false, nil, nil, 0)
+
+	testCases := []struct {
+		name     string
+		userName string
+		act      action.Action
+		path     string
+		isDir    bool
+		expect   contract.Code
+		exist    []string
+		notExist []string
+	}{
+		{"create the parent directory of the writable path", "alice", action.CreateAction, "/", true, contract.Success, nil, nil},
+		{"create file", "alice", action.CreateAction, "docs/a.txt", false, contract.Success, []string{"docs/a.txt"}, nil},
+		{"create directory", "alice", action.CreateAction, "docs/sub", true, contract.Success, []string{"docs/sub"}, nil},
+		{"create file in the denied directory", "alice", action.CreateAction, "docs/private/b.txt", false, contract.NoPermission, nil, []string{"docs/private/b.txt"}},
+		{"create the denied directory", "alice", action.CreateAction, "docs/private/sub", true, contract.NoPermission, nil, []string{"docs/private/sub"}},
+		{"create file without rule", "alice", action.CreateAction, "other/c.txt", false, contract.NoPermission, nil, []string{"other/c.txt"}},
+		{"create directory without rule", "alice", action.CreateAction, "new", true, contract.NoPermission, nil, []string{"new"}},
+		{"create file without write permission", "bob", action.CreateAction, "docs/d.txt", false, contract.NoPermission, nil, []string{"docs/d.txt"}},
+		{"remove the denied file", "alice", action.RemoveAction, "docs/private/keep.txt", false, contract.NoPermission, []string{"docs/private/keep.txt"}, nil},
+		{"remove file without rule", "alice", action.RemoveAction, "other/keep.txt", false, contract.NoPermission, []string{"other/keep.txt"}, nil},
+		{"remove the parent directory of the writable path", "alice", action.RemoveAction, "/", true, contract.NoPermission, []string{"docs"}, nil},
+		{"rename the denied file", "alice", action.RenameAction, "docs/private/keep.txt", false, contract.NoPermission, []string{"docs/private/keep.txt"}, nil},
+		{"chmod file without rule", "alice", action.ChmodAction, "other/keep.txt", false, contract.NoPermission, nil, nil},
+		{"symlink without rule", "alice", action.SymlinkAction, "other/link", false, contract.NoPermission, nil, []string{"other/link"}},
+		{"write file without rule", "alice", action.WriteAction, "other/keep.txt", false, contract.NoPermission, nil, nil},
+		{"remove file", "alice", action.RemoveAction, "docs/remove.txt", false, contract.Success, nil, []string{"docs/remove.txt"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pushData := push.PushData{
+				Action: tc.act,
+				FileInfo: contract.FileInfo{
+					Path:  tc.path,
+					IsDir: contract.ParseFsDirValue(tc.isDir),
+					MTime: time.Now().Unix(),
+				},
+			}
+			data, err := json.Marshal(pushData)
+			if err != nil {
+				t.Fatalf("marshal the push data error => %v", err)
+			}
+			form := url.Values{}
+			form.Set(push.ParamPushData, string(data))
+			req := httptest.NewRequest(http.MethodPost, server.PushFullRoute, strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			engine := gin.New()
+			engine.POST(server.PushFullRoute, func(c *gin.Context) {
+				c.Set(server.SessionUser, &auth.SessionUser{UserId: 1, UserName: tc.userName, Perm: auth.ToPerm("rw")})
+				handler(c)
+			})
+			resp := httptest.NewRecorder()
+			engine.ServeHTTP(resp, req)
+			var result server.ApiResult
+			if err = json.Unmarshal(resp.Body.Bytes(), &result); err != nil {
+				t.Fatalf("parse the response error => %v, %s", err, resp.Body.String())
+			}
+			if result.Code != tc.expect {
+				t.Errorf("expect to get code %d, but get %d => %s", tc.expect, result.Code, result.Message)
+			}
+			for _, path := range tc.exist {
+				if _, err := os.Stat(filepath.Join(root, path)); err != nil {
+					t.Errorf("expect the path [%s] is exist, but get error => %v", path, err)
+				}
+			}
+			for _, path := range tc.notExist {
+				if _, err := os.Lstat(filepath.Join(root, path)); !os.IsNotExist(err) {
+					t.Errorf("expect the path [%s] is not exist, but get error => %v", path, err)
+				}
+			}
+		})
+	}
+}
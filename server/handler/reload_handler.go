@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/no-src/gofs/contract"
+	"github.com/no-src/gofs/logger"
+	"github.com/no-src/gofs/server"
+)
+
+type reloadHandler struct {
+	logger *logger.Logger
+	reload server.ReloadFunc
+}
+
+// NewReloadHandlerFunc returns a gin.HandlerFunc that providers a reload api to re-read the config and apply the changes at runtime
+func NewReloadHandlerFunc(logger *logger.Logger, reload server.ReloadFunc) gin.HandlerFunc {
+	return (&reloadHandler{
+		logger: logger,
+		reload: reload,
+	}).Handle
+}
+
+func (h *reloadHandler) Handle(c *gin.Context) {
+	r, err := h.reload()
+	if err != nil {
+		h.logger.Error(err, "reload the config error, remote=%s", c.Request.RemoteAddr)
+		c.JSON(http.StatusOK, server.NewErrorApiResult(contract.Fail, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, server.NewApiResult(contract.Success, contract.SuccessDesc, r))
+}
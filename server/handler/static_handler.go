@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"io/fs"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/no-src/gofs/auth"
+	nsfs "github.com/no-src/gofs/fs"
+	"github.com/no-src/gofs/logger"
+	"github.com/no-src/gofs/server"
+)
+
+type staticHandler struct {
+	logger   *logger.Logger
+	users    *auth.UserStore
+	prefix   string
+	fs       http.FileSystem
+	userRoot bool
+}
+
+// NewStaticHandlerFunc returns a gin.HandlerFunc that serves the files of the file system under the route prefix,
+// only the files that the login user can read are visible if the acl is enabled,
+// and the login user can only access its own root directory like /<username> if the userRoot is true
+func NewStaticHandlerFunc(logger *logger.Logger, users *auth.UserStore, prefix string, fs http.FileSystem, userRoot bool) gin.HandlerFunc {
+	return (&staticHandler{
+		logger:   logger,
+		users:    users,
+		prefix:   strings.TrimSuffix(prefix, "/"),
+		fs:       fs,
+		userRoot: userRoot,
+	}).Handle
+}
+
+func (h *staticHandler) Handle(c *gin.Context) {
+	http.StripPrefix(h.prefix, http.FileServer(h.fileSystem(c))).ServeHTTP(c.Writer, c.Request)
+}
+
+// fileSystem return the file system that the login user can access, the unfinished uploads are always hidden
+func (h *staticHandler) fileSystem(c *gin.Context) http.FileSystem {
+	fs := h.fs
+	if user := loginUser(c); user != nil {
+		if h.userRoot {
+			fs = auth.NewUserRootDir(fs, user.UserName)
+		}
+		fs = auth.NewACLDir(fs, h.users.ACL(), user.UserName, h.prefix)
+	}
+	return uploadHiddenDir{fs}
+}
+
+// uploadHiddenDir an implementation of http.FileSystem that hides the directory of the unfinished uploads
+type uploadHiddenDir struct {
+	http.FileSystem
+}
+
+func (d uploadHiddenDir) Open(name string) (http.File, error) {
+	if nsfs.IsUploadPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	f, err := d.FileSystem.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return uploadHiddenFile{f}, nil
+}
+
+// uploadHiddenFile the file of the uploadHiddenDir, the directory of the unfinished uploads is removed from the entries
+type uploadHiddenFile struct {
+	http.File
+}
+
+func (f uploadHiddenFile) Readdir(count int) ([]fs.FileInfo, error) {
+	files, err := f.File.Readdir(count)
+	visible := files[:0]
+	for _, file := range files {
+		if !nsfs.IsUploadPath(file.Name()) {
+			visible = append(visible, file)
+		}
+	}
+	return visible, err
+}
+
+// loginUser return the login user that is set by the auth middleware, return nil if the anonymous access is allowed
+func loginUser(c *gin.Context) *auth.SessionUser {
+	if v, ok := c.Get(server.SessionUser); ok {
+		user, _ := v.(*auth.SessionUser)
+		return user
+	}
+	return nil
+}
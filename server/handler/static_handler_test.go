@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/no-src/gofs/auth"
+	nsfs "github.com/no-src/gofs/fs"
+	"github.com/no-src/gofs/logger"
+	"github.com/no-src/gofs/server"
+)
+
+func TestStaticHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	root := t.TempDir()
+	for _, name := range []string{"docs/a.txt", "docs/private/b.txt", "other/c.txt", "alice/d.txt", "bob/e.txt", nsfs.UploadDirName + "/f"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatalf("create the test directory error => %v", err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0600); err != nil {
+			t.Fatalf("create the test file error => %v", err)
+		}
+	}
+	acl, err := auth.ParseACL("alice|/source/docs|r,alice|/source/docs/private|-,bob|/source|r", "")
+	if err != nil {
+		t.Fatalf("ParseACL error => %v", err)
+	}
+	users := auth.NewUserStore(nil)
+	users.SetACL(acl)
+	l := logger.NewTestLogger()
+	defer l.Close()
+
+	testCases := []struct {
+		name       string
+		userName   string
+		userRoot   bool
+		path       string
+		expectCode int
+		contains   []string
+		excludes   []string
+	}{
+		{"readable file", "alice", false, "/docs/a.txt", http.StatusOK, []string{"docs/a.txt"}, nil},
+		{"denied file", "alice", false, "/docs/private/b.txt", http.StatusForbidden, nil, nil},
+		{"denied directory", "alice", false, "/docs/private/", http.StatusForbidden, nil, nil},
+		{"file without rule", "alice", false, "/other/c.txt", http.StatusForbidden, nil, nil},
+		{"root with acl", "alice", false, "/", http.StatusOK, []string{"docs/"}, []string{"other/", "alice/", nsfs.UploadDirName}},
+		{"directory with acl", "alice", false, "/docs/", http.StatusOK, []string{"a.txt"}, []string{"private/"}},
+		{"root", "bob", false, "/", http.StatusOK, []string{"docs/", "other/", "alice/", "bob/"}, []string{nsfs.UploadDirName}},
+		{"file", "bob", false, "/docs/private/b.txt", http.StatusOK, []string{"docs/private/b.txt"}, nil},
+		{"upload directory", "bob", false, "/" + nsfs.UploadDirName + "/f", http.StatusNotFound, nil, nil},
+		{"user root", "bob", true, "/e.txt", http.StatusOK, []string{"bob/e.txt"}, nil},
+		{"other user root", "bob", true, "/alice/d.txt", http.StatusNotFound, nil, nil},
+		{"user root with acl", "alice", true, "/d.txt", http.StatusForbidden, nil, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewStaticHandlerFunc(l, users, server.SourceRoutePrefix, http.Dir(root), tc.userRoot)
+			engine := gin.New()
+			engine.GET(server.SourceRoutePrefix+"*filepath", func(c *gin.Context) {
+				c.Set(server.SessionUser, &auth.SessionUser{UserId: 1, UserName: tc.userName, Perm: auth.ToPerm("r")})
+				handler(c)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.URL.Path = strings.TrimSuffix(server.SourceRoutePrefix, "/") + tc.path
+			resp := httptest.NewRecorder()
+			engine.ServeHTTP(resp, req)
+			if resp.Code != tc.expectCode {
+				t.Errorf("expect to get status code %d, but get %d => %s", tc.expectCode, resp.Code, resp.Body.String())
+				return
+			}
+			body := resp.Body.String()
+			for _, s := range tc.contains {
+				if !strings.Contains(body, s) {
+					t.Errorf("expect the response contains %s, but get %s", s, body)
+				}
+			}
+			for _, s := range tc.excludes {
+				if strings.Contains(body, s) {
+					t.Errorf("expect the response excludes %s, but get %s", s, body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/no-src/gofs/auth"
+	"github.com/no-src/gofs/contract"
+	"github.com/no-src/gofs/logger"
+	"github.com/no-src/gofs/server"
+)
+
+var errLoginGuardDisabled = errors.New("the login guard is disabled, see the -login_max_failures and -login_max_ip_failures flags")
+
+type unlockHandler struct {
+	logger *logger.Logger
+	users  *auth.UserStore
+}
+
+// NewUnlockHandlerFunc returns a gin.HandlerFunc that providers an api to unlock the username or the ip that is locked out by the login guard
+func NewUnlockHandlerFunc(logger *logger.Logger, users *auth.UserStore) gin.HandlerFunc {
+	return (&unlockHandler{
+		logger: logger,
+		users:  users,
+	}).Handle
+}
+
+func (h *unlockHandler) Handle(c *gin.Context) {
+	userName := c.Query(server.ParamUser)
+	ip := c.Query(server.ParamIP)
+	err := errLoginGuardDisabled
+	if guard := h.users.LoginGuard(); guard != nil {
+		err = guard.Unlock(userName, ip)
+	}
+	if err != nil {
+		h.logger.Error(err, "unlock the login error => [%s] [%s], remote=%s", userName, ip, c.Request.RemoteAddr)
+		c.JSON(http.StatusOK, server.NewErrorApiResult(contract.Fail, err.Error()))
+		return
+	}
+	h.logger.Warn("unlock the login success => [%s] [%s], remote=%s", userName, ip, c.Request.RemoteAddr)
+	c.JSON(http.StatusOK, server.NewApiResult(contract.Success, contract.SuccessDesc, nil))
+}
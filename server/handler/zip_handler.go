@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/no-src/gofs/auth"
+	"github.com/no-src/gofs/contract"
+	"github.com/no-src/gofs/logger"
+	"github.com/no-src/gofs/server"
+)
+
+type zipHandler struct {
+	staticHandler
+}
+
+// NewZipHandlerFunc returns a gin.HandlerFunc that downloads the directory of the file system under the route prefix as a zip file,
+// the zip file only contains the files that the login user can read like the NewStaticHandlerFunc,
+// and the symbolic links to the directories are skipped
+func NewZipHandlerFunc(logger *logger.Logger, users *auth.UserStore, prefix string, fs http.FileSystem, userRoot bool) gin.HandlerFunc {
+	return (&zipHandler{
+		staticHandler: staticHandler{
+			logger:   logger,
+			users:    users,
+			prefix:   strings.TrimSuffix(prefix, "/"),
+			fs:       fs,
+			userRoot: userRoot,
+		},
+	}).Handle
+}
+
+func (h *zipHandler) Handle(c *gin.Context) {
+	fs := h.fileSystem(c)
+	name := path.Clean("/" + c.Param("filepath"))
+	f, err := fs.Open(name)
+	if err != nil {
+		c.JSON(http.StatusNotFound, server.NewErrorApiResult(contract.NotFound, contract.NotFoundDesc))
+		return
+	}
+	stat, err := f.Stat()
+	f.Close()
+	if err != nil || !stat.IsDir() {
+		c.JSON(http.StatusNotFound, server.NewErrorApiResult(contract.NotFound, contract.NotFoundDesc))
+		return
+	}
+
+	base := path.Base(name)
+	if base == "/" {
+		base = path.Base(h.prefix)
+	}
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", url.PathEscape(base+".zip")))
+	c.Status(http.StatusOK)
+	zw := zip.NewWriter(c.Writer)
+	if err = h.addDir(zw, fs, name, base); err != nil {
+		// the response is already partially written, abort the broken zip file
+		h.logger.Error(err, "write the zip file error => %s", name)
+		return
+	}
+	h.logger.ErrorIf(zw.Close(), "close the zip file error => %s", name)
+}
+
+// addDir add the files of the directory to the zip file recursively
+func (h *zipHandler) addDir(zw *zip.Writer, fsys http.FileSystem, dir string, zipDir string) error {
+	d, err := fsys.Open(dir)
+	if err != nil {
+		return err
+	}
+	files, err := d.Readdir(-1)
+	d.Close()
+	if err != nil {
+		return err
+	}
+	if _, err = zw.Create(zipDir + "/"); err != nil {
+		return err
+	}
+	for _, file := range files {
+		name := path.Join(dir, file.Name())
+		zipName := path.Join(zipDir, file.Name())
+		f, err := fsys.Open(name)
+		if err != nil {
+			// the file is invisible to the user or is removed
+			continue
+		}
+		stat, err := f.Stat()
+		if err != nil || (stat.IsDir() && file.Mode()&fs.ModeSymlink != 0) {
+			f.Close()
+			continue
+		}
+		if stat.IsDir() {
+			f.Close()
+			err = h.addDir(zw, fsys, name, zipName)
+		} else {
+			err = addZipFile(zw, f, stat, zipName)
+			f.Close()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addZipFile(zw *zip.Writer, f io.Reader, stat fs.FileInfo, zipName string) error {
+	header, err := zip.FileInfoHeader(stat)
+	if err != nil {
+		return err
+	}
+	header.Name = zipName
+	header.Method = zip.Deflate
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
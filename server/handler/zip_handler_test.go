@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/no-src/gofs/auth"
+	nsfs "github.com/no-src/gofs/fs"
+	"github.com/no-src/gofs/logger"
+	"github.com/no-src/gofs/server"
+)
+
+func TestZipHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	root := t.TempDir()
+	for _, name := range []string{"docs/a.txt", "docs/private/b.txt", "other/c.txt", "d.txt", nsfs.UploadDirName + "/e"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatalf("create the test directory error => %v", err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0600); err != nil {
+			t.Fatalf("create the test file error => %v", err)
+		}
+	}
+	acl, err := auth.ParseACL("alice|/source/docs|r,alice|/source/docs/private|-,bob|/source|r", "")
+	if err != nil {
+		t.Fatalf("ParseACL error => %v", err)
+	}
+	users := auth.NewUserStore(nil)
+	users.SetACL(acl)
+	l := logger.NewTestLogger()
+	defer l.Close()
+	handler := NewZipHandlerFunc(l, users, server.SourceRoutePrefix, http.Dir(root), false)
+
+	testCases := []struct {
+		name       string
+		userName   string
+		path       string
+		expectCode int
+		expect     []string
+	}{
+		{"root with acl", "alice", "/", http.StatusOK, []string{"source/", "source/docs/", "source/docs/a.txt"}},
+		{"directory with acl", "alice", "/docs", http.StatusOK, []string{"docs/", "docs/a.txt"}},
+		{"denied directory", "alice", "/docs/private", http.StatusNotFound, nil},
+		{"root", "bob", "/", http.StatusOK, []string{"source/", "source/d.txt", "source/docs/", "source/docs/a.txt",
+			"source/docs/private/", "source/docs/private/b.txt", "source/other/", "source/other/c.txt"}},
+		{"upload directory", "bob", "/" + nsfs.UploadDirName, http.StatusNotFound, nil},
+		{"file", "bob", "/d.txt", http.StatusNotFound, nil},
+		{"not exist", "bob", "/not_exist", http.StatusNotFound, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			engine := gin.New()
+			engine.GET(server.ZipRoutePrefix+"source/*filepath", func(c *gin.Context) {
+				c.Set(server.SessionUser, &auth.SessionUser{UserId: 1, UserName: tc.userName, Perm: auth.ToPerm("r")})
+				handler(c)
+			})
+			resp := httptest.NewRecorder()
+			engine.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, server.ZipRoutePrefix+"source"+tc.path, nil))
+			if resp.Code != tc.expectCode {
+				t.Errorf("expect to get status code %d, but get %d", tc.expectCode, resp.Code)
+				return
+			}
+			if resp.Code != http.StatusOK {
+				return
+			}
+			zr, err := zip.NewReader(bytes.NewReader(resp.Body.Bytes()), int64(resp.Body.Len()))
+			if err != nil {
+				t.Errorf("read the zip file error => %v", err)
+				return
+			}
+			var actual []string
+			for _, f := range zr.File {
+				actual = append(actual, f.Name)
+			}
+			sort.Strings(actual)
+			if strings.Join(actual, ",") != strings.Join(tc.expect, ",") {
+				t.Errorf("expect to get the zip entries %v, but get %v", tc.expect, actual)
+			}
+		})
+	}
+}
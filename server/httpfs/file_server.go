@@ -1,12 +1,14 @@
 package httpfs
 
 import (
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"html/template"
 	"io"
 	"net/http"
 	"os"
+	"path"
 	"time"
 
 	"github.com/gin-contrib/gzip"
@@ -17,12 +19,16 @@ import (
 	"github.com/no-src/gofs/core"
 	"github.com/no-src/gofs/driver/minio"
 	"github.com/no-src/gofs/driver/sftp"
+	"github.com/no-src/gofs/encrypt"
 	"github.com/no-src/gofs/internal/rate"
+	"github.com/no-src/gofs/ipfilter"
 	"github.com/no-src/gofs/logger"
+	"github.com/no-src/gofs/quota"
 	"github.com/no-src/gofs/report"
 	"github.com/no-src/gofs/server"
 	"github.com/no-src/gofs/server/handler"
 	"github.com/no-src/gofs/server/middleware"
+	"github.com/no-src/gofs/tlsutil"
 	"github.com/no-src/nsgo/hashutil"
 	"github.com/quic-go/quic-go/http3"
 )
@@ -38,11 +44,16 @@ func StartFileServer(opt server.Option) error {
 
 	initCompress(engine, opt.EnableFileServerCompress)
 	initDefaultMiddleware(engine, logger, opt.Reporter)
+	engine.Use(middleware.NewAuditHandlerFunc(logger, opt.Audit))
+	if err := initIPAccess(engine, opt, logger); err != nil {
+		opt.Init.DoneWithError(err)
+		return err
+	}
 	if err := initHTMLTemplate(engine); err != nil {
 		opt.Init.DoneWithError(err)
 		return err
 	}
-	if err := initSession(engine, opt.SessionConnection); err != nil {
+	if err := initSession(engine, opt, logger); err != nil {
 		opt.Init.DoneWithError(err)
 		return err
 	}
@@ -65,9 +76,9 @@ func StartFileServer(opt server.Option) error {
 	var err error
 	if opt.EnableTLS {
 		if opt.EnableHTTP3 {
-			err = logger.ErrorIf(http3.ListenAndServeTLS(opt.FileServerAddr, opt.TLSCertFile, opt.TLSKeyFile, engine.Handler()), "running the http3 server error")
+			err = logger.ErrorIf(runTLS(opt, engine.Handler()), "running the http3 server error")
 		} else {
-			err = logger.ErrorIf(engine.RunTLS(opt.FileServerAddr, opt.TLSCertFile, opt.TLSKeyFile), "running the https server error")
+			err = logger.ErrorIf(runTLS(opt, engine.Handler()), "running the https server error")
 		}
 		c <- err
 		return err
@@ -81,6 +92,57 @@ func StartFileServer(opt server.Option) error {
 	return err
 }
 
+// runTLS run the https server or the http3 server, the client certificates are required if the client CA bundle is specified
+func runTLS(opt server.Option, handler http.Handler) error {
+	tlsConfig, err := tlsutil.ServerConfig(opt.TLSCertFile, opt.TLSKeyFile, opt.TLSClientCAFile)
+	if err != nil {
+		return err
+	}
+	if opt.EnableHTTP3 {
+		return listenAndServeHTTP3(opt.FileServerAddr, tlsConfig, handler)
+	}
+	srv := &http.Server{
+		Addr:      opt.FileServerAddr,
+		Handler:   handler,
+		TLSConfig: tlsConfig,
+	}
+	return srv.ListenAndServeTLS("", "")
+}
+
+// listenAndServeHTTP3 listen and serve the http3 server on the udp address and the https server on the tcp address like the http3.ListenAndServeTLS,
+// the https server advertises the http3 server by the Alt-Svc header
+func listenAndServeHTTP3(addr string, tlsConfig *tls.Config, handler http.Handler) error {
+	quicServer := &http3.Server{
+		Addr:      addr,
+		Handler:   handler,
+		TLSConfig: tlsConfig,
+	}
+	httpServer := &http.Server{
+		Addr:      addr,
+		TLSConfig: tlsConfig,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			quicServer.SetQUICHeaders(w.Header())
+			handler.ServeHTTP(w, r)
+		}),
+	}
+	hErr := make(chan error, 1)
+	qErr := make(chan error, 1)
+	go func() {
+		hErr <- httpServer.ListenAndServeTLS("", "")
+	}()
+	go func() {
+		qErr <- quicServer.ListenAndServe()
+	}()
+	select {
+	case err := <-hErr:
+		quicServer.Close()
+		return err
+	case err := <-qErr:
+		httpServer.Close()
+		return err
+	}
+}
+
 // initEnvGinMode change default mode is release
 func initEnvGinMode() {
 	mode := os.Getenv(gin.EnvGinMode)
@@ -90,12 +152,42 @@ func initEnvGinMode() {
 	gin.SetMode(mode)
 }
 
-func initSession(engine *gin.Engine, sessionConnection string) error {
-	store, err := server.NewSessionStore(sessionConnection)
+func initSession(engine *gin.Engine, opt server.Option, logger *logger.Logger) error {
+	store, err := server.NewSessionStore(opt.SessionConnection)
 	if err != nil {
 		return err
 	}
+	store.Options(server.SessionCookieOptions(opt.EnableTLS, opt.SessionAbsoluteTimeout.Duration()))
 	engine.Use(sessions.Sessions(server.SessionName, store))
+	engine.Use(middleware.NewSessionHandlerFunc(logger, opt.Users, opt.SessionIdleTimeout.Duration(), opt.SessionAbsoluteTimeout.Duration()))
+	engine.Use(middleware.NewCSRFHandlerFunc(logger, opt.Users))
+	return nil
+}
+
+// initIPAccess set the trusted proxies to resolve the client ip, and limit the client ips that access the file server
+func initIPAccess(engine *gin.Engine, opt server.Option, logger *logger.Logger) error {
+	proxies, err := ipfilter.ParseRules(opt.TrustedProxies)
+	if err != nil {
+		return err
+	}
+	var trustedProxies []string
+	for _, proxy := range proxies {
+		trustedProxies = append(trustedProxies, proxy.String())
+	}
+	// the X-Forwarded-For and X-Real-IP headers are ignored if there is no trusted proxy
+	if err = engine.SetTrustedProxies(trustedProxies); err != nil {
+		return err
+	}
+	filter, err := ipfilter.NewFilter(opt.ServerAllowIP, opt.ServerDenyIP)
+	if err != nil {
+		return err
+	}
+	if filter.Enabled() {
+		engine.Use(middleware.NewIPFilterHandlerFunc(logger, filter))
+	}
+	if limiter := ipfilter.NewLimiter(opt.IPMaxConns, opt.IPMaxRate, opt.IPRateBurst); limiter.Enabled() {
+		engine.Use(middleware.NewIPLimitHandlerFunc(logger, limiter))
+	}
 	return nil
 }
 
@@ -126,85 +218,169 @@ func initCompress(engine *gin.Engine, enableCompress bool) {
 func initRoute(engine *gin.Engine, opt server.Option, logger *logger.Logger) error {
 	enableFileApi := false
 	source := opt.Source
-	dest := opt.Dest
 	reporter := opt.Reporter
 
 	loginGroup := engine.Group(server.LoginGroupRoute)
-	loginGroup.GET(server.LoginIndexRoute, func(context *gin.Context) {
-		context.HTML(http.StatusOK, "login.html", nil)
-	})
+	loginGroup.GET(server.LoginIndexRoute, handler.NewLoginIndexHandlerFunc(logger))
 	loginGroup.POST(server.LoginSignInRoute, handler.NewLoginHandlerFunc(opt.Users, logger))
+	loginGroup.POST(server.LoginTwoFactorRoute, handler.NewTwoFactorHandlerFunc(opt.Users, logger))
+	loginGroup.POST(server.LoginSignOutRoute, handler.NewSignOutHandlerFunc(logger))
 
 	rootGroup := engine.Group(server.RootGroupRoute)
 	wGroup := engine.Group(server.WriteGroupRoute)
 	manageGroup := engine.Group(server.ManageGroupRoute)
 
-	initRouteAuth(opt, logger, rootGroup, wGroup, manageGroup)
+	keys, err := auth.NewAPIKeyStore(opt.APIKeysFile)
+	if err != nil {
+		return err
+	}
+
+	initRouteAuth(opt, logger, keys, rootGroup, wGroup, manageGroup)
 
-	rootGroup.GET(server.DefaultRoute, handler.NewDefaultHandlerFunc(logger))
+	enableSource := source.IsDisk() || source.Is(core.RemoteDisk)
+	rootGroup.GET(server.DefaultRoute, handler.NewDefaultHandlerFunc(logger, enableSource, enableSource && opt.EnablePushServer, opt.ChunkSize.Bytes()))
 
-	initManageRoute(opt, logger, manageGroup, reporter)
+	initManageRoute(opt, logger, manageGroup, reporter, keys)
 
 	hash, errHash := hashutil.NewHash(opt.ChecksumAlgorithm)
 	if errHash != nil {
 		return errHash
 	}
 
-	if source.IsDisk() || source.Is(core.RemoteDisk) {
-		rootGroup.StaticFS(server.SourceRoutePrefix, rate.NewHTTPDir(source.Path().Base(), opt.MaxTranRate.Bytes(), logger))
+	if enableSource {
+		sourceDir := rate.NewLimitHTTPDir(source.Path().Base(), opt.TranRate, logger)
+		staticFS(rootGroup, server.SourceRoutePrefix, handler.NewStaticHandlerFunc(logger, opt.Users, server.SourceRoutePrefix, sourceDir, opt.PushUserRoot))
+		rootGroup.GET(path.Join(server.ZipRoutePrefix, server.SourceRoutePrefix, "/*filepath"), handler.NewZipHandlerFunc(logger, opt.Users, server.SourceRoutePrefix, sourceDir, opt.PushUserRoot))
 		enableFileApi = true
 
 		if opt.EnablePushServer {
-			wGroup.POST(server.PushRoute, handler.NewPushHandlerFunc(logger, source, opt.EnableLogicallyDelete, hash))
+			if err = initPushRoute(opt, logger, wGroup, hash); err != nil {
+				return err
+			}
 		}
 	}
 
-	if dest.IsDisk() {
-		rootGroup.StaticFS(server.DestRoutePrefix, rate.NewHTTPDir(dest.Path().Base(), opt.MaxTranRate.Bytes(), logger))
-		enableFileApi = true
-	} else if dest.Is(core.SFTP) {
-		sftpDir, err := sftp.NewDir(dest.RemotePath().Base(), dest.Addr(), dest.SSHConfig(), opt.Retry, opt.MaxTranRate.Bytes(), logger)
+	destDir, err := newDestDir(opt, logger)
+	if err != nil {
+		return err
+	}
+	if destDir != nil {
+		nameDir, err := newNameDestDir(opt, destDir)
 		if err != nil {
 			return err
 		}
-		rootGroup.StaticFS(server.DestRoutePrefix, sftpDir)
+		staticFS(rootGroup, server.DestRoutePrefix, handler.NewStaticHandlerFunc(logger, opt.Users, server.DestRoutePrefix, nameDir, false))
+		rootGroup.GET(path.Join(server.ZipRoutePrefix, server.DestRoutePrefix, "/*filepath"), handler.NewZipHandlerFunc(logger, opt.Users, server.DestRoutePrefix, nameDir, false))
 		enableFileApi = true
-	} else if dest.Is(core.MinIO) {
-		if len(opt.Users) == 0 {
-			return errors.New("a user is required for MinIO server")
-		}
-		user := opt.Users[0]
-		minioDir, err := minio.NewDir(dest.RemotePath().Bucket(), dest.Addr(), dest.Secure(), user.UserName(), user.Password(), opt.Retry, opt.MaxTranRate.Bytes(), logger)
-		if err != nil {
+	}
+
+	if opt.EnableServerDecrypt {
+		if err = initDecryptRoute(opt, logger, rootGroup, destDir); err != nil {
 			return err
 		}
-		rootGroup.StaticFS(server.DestRoutePrefix, minioDir)
-		enableFileApi = true
 	}
 
 	if enableFileApi {
-		rootGroup.GET(server.QueryRoute, handler.NewFileApiHandlerFunc(logger, http.Dir(source.Path().Base()), opt.ChunkSize.Bytes(), opt.CheckpointCount, hash))
+		rootGroup.GET(server.QueryRoute, handler.NewFileApiHandlerFunc(logger, opt.Users, http.Dir(source.Path().Base()), opt.ChunkSize.Bytes(), opt.CheckpointCount, hash, opt.PushUserRoot))
 	}
 	return nil
 }
 
-func initRouteAuth(opt server.Option, logger *logger.Logger, rootGroup, wGroup, manageGroup *gin.RouterGroup) {
-	if len(opt.Users) > 0 {
-		rootGroup.Use(middleware.NewAuthHandlerFunc(logger, auth.ReadPerm))
-		wGroup.Use(middleware.NewAuthHandlerFunc(logger, auth.WritePerm))
-		manageGroup.Use(middleware.NewAuthHandlerFunc(logger, auth.ExecutePerm))
-	} else {
+// initPushRoute register the push api and the file management api of the web UI, they share the quotas of the source
+func initPushRoute(opt server.Option, logger *logger.Logger, wGroup *gin.RouterGroup, hash hashutil.Hash) error {
+	pushFilter, err := ipfilter.NewFilter(opt.PushAllowIP, opt.PushDenyIP)
+	if err != nil {
+		return err
+	}
+	userQuota := quota.New(opt.PushUserQuota.Bytes(), int64(opt.PushUserQuotaFiles))
+	storageQuota := quota.New(opt.PushQuota.Bytes(), int64(opt.PushQuotaFiles))
+	wGroup.POST(server.PushRoute, middleware.NewIPFilterHandlerFunc(logger, pushFilter), handler.NewPushHandlerFunc(logger, opt.Users, opt.Reporter, opt.Source, opt.EnableLogicallyDelete, hash, opt.PushUserRoot,
+		userQuota, storageQuota, opt.PushMinFreeSpace.Bytes()))
+
+	fileManageHandler := handler.NewFileManageHandlerFunc(logger, opt.Users, opt.Reporter, opt.Source, opt.EnableLogicallyDelete, hash, opt.PushUserRoot,
+		userQuota, storageQuota, opt.PushMinFreeSpace.Bytes())
+	wGroup.GET(server.FileRoute, fileManageHandler)
+	wGroup.POST(server.FileRoute, fileManageHandler)
+	return nil
+}
+
+// staticFS register the static file routes like the gin.RouterGroup.StaticFS
+func staticFS(group *gin.RouterGroup, prefix string, h gin.HandlerFunc) {
+	urlPattern := path.Join(prefix, "/*filepath")
+	group.GET(urlPattern, h)
+	group.HEAD(urlPattern, h)
+}
+
+// newDestDir create the file system of the dest, return nil if the dest is unsupported
+func newDestDir(opt server.Option, logger *logger.Logger) (destDir http.FileSystem, err error) {
+	dest := opt.Dest
+	if dest.IsDisk() {
+		destDir = rate.NewLimitHTTPDir(dest.Path().Base(), opt.TranRate, logger)
+	} else if dest.Is(core.SFTP) {
+		destDir, err = sftp.NewDir(dest.RemotePath().Base(), dest.Addr(), dest.SSHConfig(), opt.Retry, opt.TranRate, logger)
+	} else if dest.Is(core.MinIO) {
+		user := opt.Users.First()
+		if user == nil {
+			return nil, errors.New("a user is required for MinIO server")
+		}
+		destDir, err = minio.NewDir(dest.RemotePath().Bucket(), dest.Addr(), dest.Secure(), user.UserName(), user.Password(), opt.Retry, opt.TranRate, logger)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return destDir, nil
+}
+
+// newNameDestDir show the decrypted file names of the dest if the name encryption is enabled and the decrypt secret is specified
+func newNameDestDir(opt server.Option, destDir http.FileSystem) (http.FileSystem, error) {
+	if opt.EncryptName && len(opt.DecryptSecret) > 0 {
+		return encrypt.NewNameDir(destDir, []byte(opt.DecryptSecret), opt.KeyFile)
+	}
+	return destDir, nil
+}
+
+// initDecryptRoute serve the decrypted content of the dest to the users that provide the decrypt secret for the session
+func initDecryptRoute(opt server.Option, logger *logger.Logger, rootGroup *gin.RouterGroup, destDir http.FileSystem) error {
+	if destDir == nil {
+		logger.Warn("the decrypt route is disabled, because the dest is unsupported by the file server")
+		return nil
+	}
+	dir, err := encrypt.NewDecryptDir(destDir, opt.KeyFile, opt.DecryptIdentity, opt.DecryptLegacy)
+	if err != nil {
+		return err
+	}
+	rootGroup.GET(server.DecryptRoutePrefix+"*path", handler.NewDecryptHandlerFunc(logger, opt.Users, dir))
+	rootGroup.POST(server.DecryptSecretRoute, handler.NewDecryptSecretHandlerFunc(logger))
+	return nil
+}
+
+func initRouteAuth(opt server.Option, logger *logger.Logger, keys *auth.APIKeyStore, rootGroup, wGroup, manageGroup *gin.RouterGroup) {
+	rootGroup.Use(middleware.NewAuthHandlerFunc(logger, opt.Users, keys, auth.ReadPerm))
+	wGroup.Use(middleware.NewAuthHandlerFunc(logger, opt.Users, keys, auth.WritePerm))
+	manageGroup.Use(middleware.NewAuthHandlerFunc(logger, opt.Users, keys, auth.ExecutePerm))
+	if opt.Users.Anonymous() {
 		logger.Warn("the file server allows anonymous access, you should set some server users by the -users or -rand_user_count flag for security reasons")
 	}
 }
 
-func initManageRoute(opt server.Option, logger *logger.Logger, manageGroup *gin.RouterGroup, reporter report.Reporter) {
+func initManageRoute(opt server.Option, logger *logger.Logger, manageGroup *gin.RouterGroup, reporter report.Reporter, keys *auth.APIKeyStore) {
 	if opt.EnableManage {
 		if opt.ManagePrivate {
 			manageGroup.Use(middleware.NewPrivateAccessHandlerFunc(logger))
 		}
 		pprof.RouteRegister(manageGroup, server.PProfRoutePrefix)
 		manageGroup.GET(server.ManageConfigRoute, handler.NewManageHandlerFunc(logger, opt.Config))
+		if opt.Reload != nil {
+			manageGroup.POST(server.ManageReloadRoute, handler.NewReloadHandlerFunc(logger, opt.Reload))
+		}
+		if opt.Jobs != nil {
+			manageGroup.POST(server.ManageJobStartRoute, handler.NewJobStartHandlerFunc(logger, opt.Jobs))
+			manageGroup.POST(server.ManageJobStopRoute, handler.NewJobStopHandlerFunc(logger, opt.Jobs))
+		}
+		manageGroup.GET(server.ManageAPIKeyRoute, handler.NewAPIKeyListHandlerFunc(logger, keys))
+		manageGroup.POST(server.ManageAPIKeyCreateRoute, handler.NewAPIKeyCreateHandlerFunc(logger, opt.Users, keys))
+		manageGroup.POST(server.ManageAPIKeyRevokeRoute, handler.NewAPIKeyRevokeHandlerFunc(logger, keys))
+		manageGroup.POST(server.ManageLoginUnlockRoute, handler.NewUnlockHandlerFunc(logger, opt.Users))
 		if opt.EnableReport {
 			manageGroup.GET(server.ManageReportRoute, handler.NewReportHandlerFunc(logger, reporter))
 			reporter.Enable(true)
@@ -0,0 +1,9 @@
+package server
+
+// JobManager manage the sync jobs that running in the current process
+type JobManager interface {
+	// Start start the specified sync job
+	Start(name string) error
+	// Stop stop the specified sync job
+	Stop(name string) error
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/no-src/gofs/audit"
+	"github.com/no-src/gofs/auth"
+	"github.com/no-src/gofs/contract"
+	"github.com/no-src/gofs/logger"
+	"github.com/no-src/gofs/server"
+)
+
+type auditHandler struct {
+	logger *logger.Logger
+	audit  audit.Logger
+}
+
+// NewAuditHandlerFunc returns a middleware that writes an audit record for every request after it is handled,
+// the handlers can set the server.AuditUser, server.AuditOp, server.AuditPath and server.AuditCode to the context to complete the record
+func NewAuditHandlerFunc(logger *logger.Logger, auditLogger audit.Logger) gin.HandlerFunc {
+	if auditLogger == nil {
+		auditLogger = audit.NewEmptyLogger()
+	}
+	return (&auditHandler{
+		logger: logger,
+		audit:  auditLogger,
+	}).Handle
+}
+
+func (h *auditHandler) Handle(c *gin.Context) {
+	c.Next()
+
+	r := audit.Record{
+		Action: auditAction(c),
+		Op:     c.GetString(server.AuditOp),
+		User:   auditUser(c),
+		IP:     c.ClientIP(),
+		Route:  c.FullPath(),
+		Path:   c.Request.URL.Path,
+		Status: c.Writer.Status(),
+	}
+	if path := c.GetString(server.AuditPath); len(path) > 0 {
+		r.Path = path
+	}
+	if r.Action == audit.ActionPush || r.Action == audit.ActionFile {
+		r.Bytes = c.Request.ContentLength
+	} else if size := c.Writer.Size(); size > 0 {
+		r.Bytes = int64(size)
+	}
+	if code, ok := c.Get(server.AuditCode); ok {
+		if v, ok := code.(contract.Code); ok {
+			r.Code = int(v)
+		}
+	}
+	h.logger.ErrorIf(h.audit.Log(r), "write the audit log error")
+}
+
+// auditAction return the action of the audit record by the route of the request
+func auditAction(c *gin.Context) string {
+	route := c.FullPath()
+	switch route {
+	case server.LoginSignInFullRoute:
+		return audit.ActionLogin
+	case server.LoginTwoFactorFullRoute:
+		return audit.ActionTwoFactor
+	case server.LoginSignOutFullRoute:
+		return audit.ActionLogout
+	case server.PushFullRoute:
+		return audit.ActionPush
+	case server.FileFullRoute:
+		return audit.ActionFile
+	}
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+		for _, prefix := range []string{server.SourceRoutePrefix, server.DestRoutePrefix, server.DecryptRoutePrefix, server.ZipRoutePrefix} {
+			if strings.HasPrefix(route, prefix) {
+				return audit.ActionDownload
+			}
+		}
+	}
+	return audit.ActionAccess
+}
+
+// auditUser return the username of the audit record, it is set by the handlers, or the login user, or the username of the failed Basic authorization
+func auditUser(c *gin.Context) string {
+	if userName := c.GetString(server.AuditUser); len(userName) > 0 {
+		return userName
+	}
+	if obj, ok := c.Get(server.SessionUser); ok {
+		if user, ok := obj.(*auth.SessionUser); ok && user != nil {
+			return user.UserName
+		}
+	}
+	if session, ok := c.Get(sessions.DefaultKey); ok {
+		if user, ok := session.(sessions.Session).Get(server.SessionUser).(auth.SessionUser); ok {
+			return user.UserName
+		}
+	}
+	userName, _, _ := c.Request.BasicAuth()
+	return userName
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
@@ -13,30 +15,59 @@ import (
 	"github.com/no-src/gofs/server"
 )
 
+var (
+	errUnsupportedAuthScheme = errors.New("the authorization scheme is unsupported, current only supports Bearer and Basic")
+	errBasicAuthFailed       = errors.New("the basic authorization is invalid")
+	errTwoFactorRequired     = errors.New("the user requires the two-factor authentication, use an api key instead of the password")
+)
+
 type authHandler struct {
 	logger *logger.Logger
+	users  *auth.UserStore
+	keys   *auth.APIKeyStore
 	perm   auth.Perm
 }
 
-// NewAuthHandlerFunc returns a middleware that checks whether the user is sign in
-func NewAuthHandlerFunc(logger *logger.Logger, perm string) gin.HandlerFunc {
+// NewAuthHandlerFunc returns a middleware that checks whether the user is sign in, allows anonymous access if there is no user,
+// the user can sign in by the client certificate, the Authorization header with the api key or the username and password, or the session
+func NewAuthHandlerFunc(logger *logger.Logger, users *auth.UserStore, keys *auth.APIKeyStore, perm string) gin.HandlerFunc {
 	p := auth.ToPermWithDefault(perm, auth.DefaultPerm)
 	if !p.IsValid() {
 		logger.Warn("the auth middleware get an invalid permission")
 	}
 	return (&authHandler{
 		logger: logger,
+		users:  users,
+		keys:   keys,
 		perm:   p,
 	}).Handle
 }
 
 func (h *authHandler) Handle(c *gin.Context) {
+	if h.users.Anonymous() {
+		return
+	}
+	// the verified client certificate that matches a user takes precedence over the session user
+	user := h.certUser(c)
+	if user == nil {
+		var err error
+		if user, err = h.authorizationUser(c); err != nil {
+			h.logger.Info("auth handler => authorization failed, remote=%s, %v", c.Request.RemoteAddr, err)
+			var lockedErr *auth.LockedError
+			if errors.As(err, &lockedErr) {
+				c.Header("Retry-After", strconv.Itoa(lockedErr.RetryAfterSeconds()))
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, server.NewErrorApiResult(contract.LoginLocked, err.Error()))
+			} else {
+				h.unauthorized(c, err.Error())
+			}
+			return
+		}
+	}
 	session := sessions.Default(c)
 	if session == nil {
 		h.logger.Error(errors.New("session is nil"), "auth handler => get session error, remote=%s", c.Request.RemoteAddr)
 	}
-	var user *auth.SessionUser
-	if session != nil {
+	if user == nil && session != nil {
 		obj := session.Get(server.SessionUser)
 		if obj != nil {
 			tmp := obj.(auth.SessionUser)
@@ -44,10 +75,75 @@ func (h *authHandler) Handle(c *gin.Context) {
 		}
 	}
 	if user == nil {
-		c.Abort()
-		c.Data(http.StatusUnauthorized, "text/html; charset=utf-8", []byte(fmt.Sprintf("<html><head><script>window.location.href='%s';</script></head></html>", server.LoginIndexFullRoute)))
+		if isBrowser(c) {
+			c.Abort()
+			c.Data(http.StatusUnauthorized, "text/html; charset=utf-8", []byte(fmt.Sprintf("<html><head><script>window.location.href='%s';</script></head></html>", server.LoginIndexFullRoute)))
+		} else {
+			h.unauthorized(c, contract.UnauthorizedDesc)
+		}
 	} else if !h.perm.CheckTo(user.Perm) {
 		c.Abort()
 		c.JSON(http.StatusUnauthorized, server.NewApiResult(contract.NoPermission, contract.NoPermissionDesc, nil))
+	} else {
+		// the handlers check the acl with the login user
+		c.Set(server.SessionUser, user)
+	}
+}
+
+// certUser return the user that matches the verified client certificate of the mutual TLS, return nil if there is no matched user
+func (h *authHandler) certUser(c *gin.Context) *auth.SessionUser {
+	state := c.Request.TLS
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.PeerCertificates) == 0 {
+		return nil
+	}
+	return auth.MapperToSessionUser(h.users.CertUser(state.PeerCertificates[0]))
+}
+
+// authorizationUser return the user of the Authorization header, the Bearer scheme carries an api key,
+// and the Basic scheme carries a username and a password or an api key of the user, return nil if there is no Authorization header
+func (h *authHandler) authorizationUser(c *gin.Context) (*auth.SessionUser, error) {
+	authorization := c.GetHeader("Authorization")
+	if len(authorization) == 0 {
+		return nil, nil
+	}
+	scheme, credentials, _ := strings.Cut(authorization, " ")
+	users := h.users.Users()
+	switch strings.ToLower(scheme) {
+	case "bearer":
+		user, err := h.keys.Verify(users, strings.TrimSpace(credentials))
+		if err != nil {
+			return nil, err
+		}
+		return auth.MapperToSessionUser(user), nil
+	case "basic":
+		userName, password, ok := c.Request.BasicAuth()
+		if !ok {
+			return nil, errBasicAuthFailed
+		}
+		if strings.HasPrefix(password, auth.APIKeyPrefix) {
+			if user, err := h.keys.Verify(users, password); err == nil && user.UserName() == userName {
+				return auth.MapperToSessionUser(user), nil
+			}
+		}
+		user, err := h.users.Login(userName, password, c.ClientIP())
+		if err != nil {
+			return nil, err
+		}
+		if h.users.RequireTwoFactor(user) {
+			return nil, errTwoFactorRequired
+		}
+		return auth.MapperToSessionUser(user), nil
 	}
+	return nil, fmt.Errorf("%w => %s", errUnsupportedAuthScheme, scheme)
+}
+
+// unauthorized abort the request with the 401 status and the json result, and challenge the client to use the Bearer or Basic scheme
+func (h *authHandler) unauthorized(c *gin.Context, message string) {
+	c.Header("WWW-Authenticate", `Bearer realm="gofs", Basic realm="gofs"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, server.NewErrorApiResult(contract.Unauthorized, message))
+}
+
+// isBrowser the request is sent by the browser or not, the browser accepts the html page
+func isBrowser(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), "text/html")
 }
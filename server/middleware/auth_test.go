@@ -0,0 +1,237 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/no-src/gofs/auth"
+	"github.com/no-src/gofs/contract"
+	"github.com/no-src/gofs/logger"
+	"github.com/no-src/gofs/server"
+	"github.com/no-src/nscache"
+	_ "github.com/no-src/nscache/memory"
+)
+
+const (
+	testTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	testRoute      = "/test"
+	testSignIn     = "/sign_in"
+)
+
+func TestAuthHandler(t *testing.T) {
+	alice := newTestUser(t, 1, "alice", "alice_password", "rw")
+	bob := newTestUser(t, 2, "bob", "bob_password", "rw")
+	bob, err := bob.WithTwoFactor(testTOTPSecret, nil)
+	if err != nil {
+		t.Fatalf("enable the two-factor authentication error => %v", err)
+	}
+	carol := newTestUser(t, 3, "carol", "carol_password", "r")
+	dave := newTestUser(t, 4, "dave", "dave_password", "rw")
+	users := auth.NewUserStore([]*auth.User{alice, bob, carol, dave})
+	users.SetLoginGuard(newTestLoginGuard(t, auth.LoginGuardOption{MaxFailures: 2, Lockout: time.Minute}))
+	keys, err := auth.NewAPIKeyStore(filepath.Join(t.TempDir(), "api_keys.json"))
+	if err != nil {
+		t.Fatalf("NewAPIKeyStore error => %v", err)
+	}
+	aliceKey, _, err := keys.Create(alice, "ci", "rw", time.Hour)
+	if err != nil {
+		t.Fatalf("create the api key error => %v", err)
+	}
+	l := logger.NewTestLogger()
+	defer l.Close()
+	client := newTestClient(t, users, NewAuthHandlerFunc(l, users, keys, "w"))
+	client.signIn("dave", time.Now())
+
+	testCases := []struct {
+		name          string
+		authorization string
+		withSession   bool
+		expectStatus  int
+		expectCode    contract.Code
+		expectUser    string
+	}{
+		{"bearer", "Bearer " + aliceKey, false, http.StatusOK, contract.Success, "alice"},
+		{"bearer with lower case scheme", "bearer " + aliceKey, false, http.StatusOK, contract.Success, "alice"},
+		{"bearer with invalid api key", "Bearer " + aliceKey + "x", false, http.StatusUnauthorized, contract.Unauthorized, ""},
+		{"basic", basicAuth("alice", "alice_password"), false, http.StatusOK, contract.Success, "alice"},
+		{"basic with api key", basicAuth("alice", aliceKey), false, http.StatusOK, contract.Success, "alice"},
+		{"basic with api key of other user", basicAuth("carol", aliceKey), false, http.StatusUnauthorized, contract.Unauthorized, ""},
+		{"basic with wrong password", basicAuth("alice", "wrong_password"), false, http.StatusUnauthorized, contract.Unauthorized, ""},
+		{"basic with invalid credentials", "Basic !!!", false, http.StatusUnauthorized, contract.Unauthorized, ""},
+		{"basic with two-factor", basicAuth("bob", "bob_password"), false, http.StatusUnauthorized, contract.Unauthorized, ""},
+		{"basic without permission", basicAuth("carol", "carol_password"), false, http.StatusUnauthorized, contract.NoPermission, ""},
+		{"unsupported scheme", "Digest username=alice", false, http.StatusUnauthorized, contract.Unauthorized, ""},
+		{"the authorization takes precedence over the session", basicAuth("alice", "alice_password"), true, http.StatusOK, contract.Success, "alice"},
+		{"session", "", true, http.StatusOK, contract.Success, "dave"},
+		{"without credentials", "", false, http.StatusUnauthorized, contract.Unauthorized, ""},
+		{"first failure", basicAuth("dave", "wrong_password"), false, http.StatusUnauthorized, contract.Unauthorized, ""},
+		{"second failure locks the user", basicAuth("dave", "wrong_password"), false, http.StatusUnauthorized, contract.Unauthorized, ""},
+		{"locked user with correct password", basicAuth("dave", "dave_password"), false, http.StatusTooManyRequests, contract.LoginLocked, ""},
+		{"locked user with session", "", true, http.StatusOK, contract.Success, "dave"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, testRoute, nil)
+			if len(tc.authorization) > 0 {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			resp := client.serve(req, tc.withSession)
+			if resp.Code != tc.expectStatus {
+				t.Errorf("expect to get status code %d, but get %d => %s", tc.expectStatus, resp.Code, resp.Body.String())
+				return
+			}
+			if resp.Code == http.StatusOK {
+				if actual := resp.Body.String(); actual != tc.expectUser {
+					t.Errorf("expect to get user %s, but get %s", tc.expectUser, actual)
+				}
+				return
+			}
+			var result server.ApiResult
+			if err := json.Unmarshal(resp.Body.Bytes(), &result); err != nil {
+				t.Errorf("parse the response error => %v, %s", err, resp.Body.String())
+				return
+			}
+			if result.Code != tc.expectCode {
+				t.Errorf("expect to get code %d, but get %d => %s", tc.expectCode, result.Code, result.Message)
+			}
+			if result.Code == contract.Unauthorized && !strings.Contains(resp.Header().Get("WWW-Authenticate"), "Basic") {
+				t.Errorf("expect to challenge the client by the WWW-Authenticate header, but get %q", resp.Header().Get("WWW-Authenticate"))
+			}
+			if retryAfter, err := strconv.Atoi(resp.Header().Get("Retry-After")); (result.Code == contract.LoginLocked) != (err == nil && retryAfter > 0) {
+				t.Errorf("expect the Retry-After header is set with the locked response only, but get %q", resp.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
+
+func TestAuthHandler_Browser(t *testing.T) {
+	users := auth.NewUserStore([]*auth.User{newTestUser(t, 1, "alice", "alice_password", "rw")})
+	l := logger.NewTestLogger()
+	defer l.Close()
+	client := newTestClient(t, users, NewAuthHandlerFunc(l, users, nil, "r"))
+
+	req := httptest.NewRequest(http.MethodGet, testRoute, nil)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	resp := client.serve(req, false)
+	if resp.Code != http.StatusUnauthorized || !strings.Contains(resp.Body.String(), server.LoginIndexFullRoute) {
+		t.Errorf("expect to redirect the browser to the login page, but get %d => %s", resp.Code, resp.Body.String())
+	}
+	if ct := resp.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("expect to get the html page, but get the content type %s", ct)
+	}
+}
+
+func TestAuthHandler_Anonymous(t *testing.T) {
+	users := auth.NewUserStore(nil)
+	l := logger.NewTestLogger()
+	defer l.Close()
+	client := newTestClient(t, users, NewAuthHandlerFunc(l, users, nil, "rwx"))
+
+	req := httptest.NewRequest(http.MethodPost, testRoute, nil)
+	req.Header.Set("Authorization", basicAuth("alice", "wrong_password"))
+	if resp := client.serve(req, false); resp.Code != http.StatusOK {
+		t.Errorf("expect to allow the anonymous access, but get %d => %s", resp.Code, resp.Body.String())
+	}
+}
+
+// testClient sends the requests to an engine with the memory session store and the middlewares,
+// the testSignIn route signs in the user of the query and returns the CSRF token, and the testRoute returns the username of the request
+type testClient struct {
+	t       *testing.T
+	engine  *gin.Engine
+	cookies map[string]*http.Cookie
+}
+
+func newTestClient(t *testing.T, users *auth.UserStore, handlers ...gin.HandlerFunc) *testClient {
+	gin.SetMode(gin.TestMode)
+	store, err := server.NewSessionStore("memory:")
+	if err != nil {
+		t.Fatalf("create session store error => %v", err)
+	}
+	engine := gin.New()
+	engine.Use(sessions.Sessions(server.SessionName, store))
+	engine.GET(testSignIn, func(c *gin.Context) {
+		at, _ := strconv.ParseInt(c.Query("at"), 10, 64)
+		for _, user := range users.Users() {
+			if user.UserName() == c.Query("user") {
+				server.SignIn(sessions.Default(c), auth.MapperToSessionUser(user), time.Unix(at, 0))
+			}
+		}
+		token, err := server.CSRFToken(c)
+		if err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.String(http.StatusOK, token)
+	})
+	engine.Use(handlers...)
+	engine.Any(testRoute, func(c *gin.Context) {
+		var userName string
+		if user, ok := c.Get(server.SessionUser); ok {
+			userName = user.(*auth.SessionUser).UserName
+		}
+		c.String(http.StatusOK, userName)
+	})
+	return &testClient{
+		t:       t,
+		engine:  engine,
+		cookies: make(map[string]*http.Cookie),
+	}
+}
+
+// signIn sign in the user at the time, keep the session cookie and return the CSRF token of the session
+func (tc *testClient) signIn(userName string, at time.Time) string {
+	req := httptest.NewRequest(http.MethodGet, testSignIn+"?user="+userName+"&at="+strconv.FormatInt(at.Unix(), 10), nil)
+	resp := tc.serve(req, false)
+	if resp.Code != http.StatusOK {
+		tc.t.Fatalf("sign in error => %d %s", resp.Code, resp.Body.String())
+	}
+	return resp.Body.String()
+}
+
+// serve send the request with the cookies of the session if withSession is true, and keep the cookies of the response
+func (tc *testClient) serve(req *http.Request, withSession bool) *httptest.ResponseRecorder {
+	if withSession {
+		for _, cookie := range tc.cookies {
+			req.AddCookie(cookie)
+		}
+	}
+	resp := httptest.NewRecorder()
+	tc.engine.ServeHTTP(resp, req)
+	for _, cookie := range resp.Result().Cookies() {
+		tc.cookies[cookie.Name] = cookie
+	}
+	return resp
+}
+
+func newTestUser(t *testing.T, userId int, userName, password, perm string) *auth.User {
+	user, err := auth.NewUser(userId, userName, password, perm)
+	if err != nil {
+		t.Fatalf("create the user error => %v", err)
+	}
+	return user
+}
+
+func newTestLoginGuard(t *testing.T, opt auth.LoginGuardOption) *auth.LoginGuard {
+	cache, err := nscache.NewCache("memory:")
+	if err != nil {
+		t.Fatalf("create the cache error => %v", err)
+	}
+	t.Cleanup(func() { cache.Close() })
+	return auth.NewLoginGuard(cache, opt)
+}
+
+func basicAuth(userName, password string) string {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.SetBasicAuth(userName, password)
+	return req.Header.Get("Authorization")
+}
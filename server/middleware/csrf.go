@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/no-src/gofs/auth"
+	"github.com/no-src/gofs/contract"
+	"github.com/no-src/gofs/logger"
+	"github.com/no-src/gofs/server"
+)
+
+type csrfHandler struct {
+	logger *logger.Logger
+	users  *auth.UserStore
+}
+
+// NewCSRFHandlerFunc returns a middleware that rejects the state-changing requests of the signed in session and the login routes
+// without the CSRF token of the session, the browsers attach the cached Basic credentials and the client certificate to the cross-site
+// requests automatically, so the requests of the browsers that are authenticated by them require the CSRF token too. The requests
+// with the Bearer api key and the other requests that are not sent by the browsers are skipped, and the anonymous requests are
+// rejected by the auth middleware
+func NewCSRFHandlerFunc(logger *logger.Logger, users *auth.UserStore) gin.HandlerFunc {
+	return (&csrfHandler{
+		logger: logger,
+		users:  users,
+	}).Handle
+}
+
+func (h *csrfHandler) Handle(c *gin.Context) {
+	if h.users.Anonymous() || isSafeMethod(c.Request.Method) || isBearer(c) {
+		return
+	}
+	_, signedIn := sessions.Default(c).Get(server.SessionUser).(auth.SessionUser)
+	ambient := len(c.GetHeader("Authorization")) > 0 || hasVerifiedCert(c)
+	if !signedIn && !strings.HasPrefix(c.Request.URL.Path, server.LoginGroupRoute+"/") && !(ambient && isBrowserRequest(c)) {
+		return
+	}
+	if !server.VerifyCSRFToken(c) {
+		h.logger.Warn("csrf check failed, remote=%s path=%s", c.Request.RemoteAddr, c.Request.URL.Path)
+		c.AbortWithStatusJSON(http.StatusForbidden, server.NewErrorApiResult(contract.InvalidCSRFToken, contract.InvalidCSRFTokenDesc))
+	}
+}
+
+// isBearer report whether the request carries the api key by the Bearer scheme, the browsers never attach it automatically
+func isBearer(c *gin.Context) bool {
+	scheme, _, _ := strings.Cut(c.GetHeader("Authorization"), " ")
+	return strings.EqualFold(scheme, "bearer")
+}
+
+// hasVerifiedCert report whether the request carries a verified client certificate of the mutual TLS
+func hasVerifiedCert(c *gin.Context) bool {
+	state := c.Request.TLS
+	return state != nil && len(state.VerifiedChains) > 0
+}
+
+// isBrowserRequest report whether the request is sent by a browser, the browsers send the Origin or Sec-Fetch-Site header
+// with the state-changing requests
+func isBrowserRequest(c *gin.Context) bool {
+	return len(c.GetHeader("Origin")) > 0 || len(c.GetHeader("Sec-Fetch-Site")) > 0
+}
+
+// isSafeMethod report whether the http method is read-only
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/no-src/gofs/auth"
+	"github.com/no-src/gofs/logger"
+	"github.com/no-src/gofs/server"
+)
+
+func TestCSRFHandler(t *testing.T) {
+	users := auth.NewUserStore([]*auth.User{newTestUser(t, 1, "alice", "alice_password", "rw")})
+	l := logger.NewTestLogger()
+	defer l.Close()
+	client := newTestClient(t, users, NewCSRFHandlerFunc(l, users))
+	client.engine.POST(server.LoginSignInFullRoute, func(c *gin.Context) {
+		c.String(http.StatusOK, "")
+	})
+	token := client.signIn("alice", time.Now())
+	basic := basicAuth("alice", "alice_password")
+	verifiedCert := &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{}}}
+
+	testCases := []struct {
+		name          string
+		method        string
+		path          string
+		withSession   bool
+		headers       map[string]string
+		form          string
+		tls           *tls.ConnectionState
+		expectAllowed bool
+	}{
+		{"safe method", http.MethodGet, testRoute, true, nil, "", nil, true},
+		{"session without token", http.MethodPost, testRoute, true, nil, "", nil, false},
+		{"session with incorrect token", http.MethodPost, testRoute, true, map[string]string{server.HeaderCSRFToken: token + "x"}, "", nil, false},
+		{"session with token in the header", http.MethodPost, testRoute, true, map[string]string{server.HeaderCSRFToken: token}, "", nil, true},
+		{"session with token in the form", http.MethodPost, testRoute, true, nil, token, nil, true},
+		{"session with delete method", http.MethodDelete, testRoute, true, nil, "", nil, false},
+		{"login route without session", http.MethodPost, server.LoginSignInFullRoute, false, nil, "", nil, false},
+		{"login route with token", http.MethodPost, server.LoginSignInFullRoute, true, map[string]string{server.HeaderCSRFToken: token}, "", nil, true},
+		{"without session is checked by the auth middleware", http.MethodPost, testRoute, false, nil, "", nil, true},
+		{"bearer is exempted", http.MethodPost, testRoute, true, map[string]string{"Authorization": "Bearer gofs_key", "Origin": "https://evil.example"}, "", nil, true},
+		{"basic without browser", http.MethodPost, testRoute, false, map[string]string{"Authorization": basic}, "", nil, true},
+		{"basic with origin", http.MethodPost, testRoute, false, map[string]string{"Authorization": basic, "Origin": "https://evil.example"}, "", nil, false},
+		{"basic with sec-fetch-site", http.MethodPost, testRoute, false, map[string]string{"Authorization": basic, "Sec-Fetch-Site": "cross-site"}, "", nil, false},
+		{"basic with origin and token", http.MethodPost, testRoute, true, map[string]string{"Authorization": basic, "Origin": "https://gofs.example", server.HeaderCSRFToken: token}, "", nil, true},
+		{"client certificate without browser", http.MethodPost, testRoute, false, nil, "", verifiedCert, true},
+		{"client certificate with origin", http.MethodPost, testRoute, false, map[string]string{"Origin": "https://evil.example"}, "", verifiedCert, false},
+		{"unverified client certificate with origin", http.MethodPost, testRoute, false, map[string]string{"Origin": "https://evil.example"}, "", &tls.ConnectionState{}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			form := url.Values{}
+			if len(tc.form) > 0 {
+				form.Set(server.ParamCSRFToken, tc.form)
+			}
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			req.TLS = tc.tls
+			resp := client.serve(req, tc.withSession)
+			if allowed := resp.Code == http.StatusOK; allowed != tc.expectAllowed {
+				t.Errorf("expect to allow the request [%v], but get %d => %s", tc.expectAllowed, resp.Code, resp.Body.String())
+			}
+		})
+	}
+}
+
+func TestCSRFHandler_Anonymous(t *testing.T) {
+	users := auth.NewUserStore(nil)
+	l := logger.NewTestLogger()
+	defer l.Close()
+	client := newTestClient(t, users, NewCSRFHandlerFunc(l, users))
+
+	req := httptest.NewRequest(http.MethodPost, testRoute, nil)
+	req.Header.Set("Origin", "https://evil.example")
+	if resp := client.serve(req, true); resp.Code != http.StatusOK {
+		t.Errorf("expect to skip the csrf check for the anonymous access, but get %d => %s", resp.Code, resp.Body.String())
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/no-src/gofs/contract"
+	"github.com/no-src/gofs/ipfilter"
+	"github.com/no-src/gofs/logger"
+	"github.com/no-src/gofs/server"
+)
+
+type ipFilterHandler struct {
+	logger *logger.Logger
+	filter *ipfilter.Filter
+}
+
+// NewIPFilterHandlerFunc returns a middleware that rejects the client ip that is not allowed by the allow list or is in the deny list,
+// the client ip is resolved from the X-Forwarded-For or X-Real-IP header only if the request comes from a trusted proxy
+func NewIPFilterHandlerFunc(logger *logger.Logger, filter *ipfilter.Filter) gin.HandlerFunc {
+	return (&ipFilterHandler{
+		logger: logger,
+		filter: filter,
+	}).Handle
+}
+
+func (h *ipFilterHandler) Handle(c *gin.Context) {
+	if ip := c.ClientIP(); !h.filter.Allow(ip) {
+		h.logger.Warn("access deny by the ip filter, client ip is [%s], path is [%s]", ip, c.Request.URL.Path)
+		c.AbortWithStatusJSON(http.StatusForbidden, server.NewErrorApiResult(contract.AccessDeny, contract.AccessDenyDesc))
+	}
+}
+
+type ipLimitHandler struct {
+	logger  *logger.Logger
+	limiter *ipfilter.Limiter
+}
+
+// NewIPLimitHandlerFunc returns a middleware that limits the concurrent requests and the request rate per client ip
+func NewIPLimitHandlerFunc(logger *logger.Logger, limiter *ipfilter.Limiter) gin.HandlerFunc {
+	return (&ipLimitHandler{
+		logger:  logger,
+		limiter: limiter,
+	}).Handle
+}
+
+func (h *ipLimitHandler) Handle(c *gin.Context) {
+	ip := c.ClientIP()
+	release, err := h.limiter.Acquire(ip)
+	if err != nil {
+		h.logger.Warn("the request is rejected by the ip limiter, client ip is [%s], path is [%s], %v", ip, c.Request.URL.Path, err)
+		c.Header("Retry-After", "1")
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, server.NewErrorApiResult(contract.TooManyRequests, err.Error()))
+		return
+	}
+	defer release()
+	c.Next()
+}
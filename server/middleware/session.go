@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/no-src/gofs/auth"
+	"github.com/no-src/gofs/logger"
+	"github.com/no-src/gofs/server"
+)
+
+// sessionActiveInterval the min interval to update the active time of the session, avoid to save the session for every request
+const sessionActiveInterval = time.Minute
+
+type sessionHandler struct {
+	logger          *logger.Logger
+	users           *auth.UserStore
+	idleTimeout     time.Duration
+	absoluteTimeout time.Duration
+}
+
+// NewSessionHandlerFunc returns a middleware that signs out the session if it is expired by the idle timeout or the absolute timeout,
+// or the user of the session is removed or the password of the user is changed, and applies the current permission of the user to the session
+func NewSessionHandlerFunc(logger *logger.Logger, users *auth.UserStore, idleTimeout, absoluteTimeout time.Duration) gin.HandlerFunc {
+	return (&sessionHandler{
+		logger:          logger,
+		users:           users,
+		idleTimeout:     idleTimeout,
+		absoluteTimeout: absoluteTimeout,
+	}).Handle
+}
+
+func (h *sessionHandler) Handle(c *gin.Context) {
+	session := sessions.Default(c)
+	sessionUser, ok := session.Get(server.SessionUser).(auth.SessionUser)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	signedInAt, _ := session.Get(server.SessionSignedInAt).(int64)
+	activeAt, _ := session.Get(server.SessionActiveAt).(int64)
+	var reason string
+	user := h.users.VerifySessionUser(sessionUser)
+	if user == nil {
+		reason = "the user is removed or the password is changed"
+	} else if server.SessionExpired(time.Unix(signedInAt, 0), time.Unix(activeAt, 0), h.idleTimeout, h.absoluteTimeout, now) {
+		reason = "the session is expired"
+	}
+	if len(reason) > 0 {
+		server.SignOut(session)
+		h.logger.ErrorIf(session.Save(), "save session error, remote=%s", c.Request.RemoteAddr)
+		h.logger.Info("session signed out, username=%s remote=%s, %s", sessionUser.UserName, c.Request.RemoteAddr, reason)
+		return
+	}
+	changed := false
+	if user.Perm() != sessionUser.Perm {
+		session.Set(server.SessionUser, *auth.MapperToSessionUser(user))
+		changed = true
+	}
+	if now.Sub(time.Unix(activeAt, 0)) >= sessionActiveInterval {
+		session.Set(server.SessionActiveAt, now.Unix())
+		changed = true
+	}
+	if changed {
+		h.logger.ErrorIf(session.Save(), "save session error, remote=%s", c.Request.RemoteAddr)
+	}
+}
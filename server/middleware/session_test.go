@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/no-src/gofs/auth"
+	"github.com/no-src/gofs/contract"
+	"github.com/no-src/gofs/logger"
+	"github.com/no-src/gofs/server"
+)
+
+func TestSessionHandler(t *testing.T) {
+	testCases := []struct {
+		name            string
+		signInAgo       time.Duration
+		idleTimeout     time.Duration
+		absoluteTimeout time.Duration
+		replace         func(t *testing.T) []*auth.User
+		expectStatus    int
+		expectCode      contract.Code
+	}{
+		{"active session", time.Minute, 30 * time.Minute, time.Hour, nil, http.StatusOK, contract.Success},
+		{"without timeout", 24 * time.Hour, 0, 0, nil, http.StatusOK, contract.Success},
+		{"idle timeout", 31 * time.Minute, 30 * time.Minute, 0, nil, http.StatusUnauthorized, contract.Unauthorized},
+		{"absolute timeout", 2 * time.Hour, 0, time.Hour, nil, http.StatusUnauthorized, contract.Unauthorized},
+		{"password is changed", time.Minute, 0, 0, func(t *testing.T) []*auth.User {
+			return []*auth.User{newTestUser(t, 1, "alice", "alice_new_password", "rw")}
+		}, http.StatusUnauthorized, contract.Unauthorized},
+		{"user is removed", time.Minute, 0, 0, func(t *testing.T) []*auth.User {
+			return []*auth.User{newTestUser(t, 2, "bob", "bob_password", "rw")}
+		}, http.StatusUnauthorized, contract.Unauthorized},
+		{"permission is changed", time.Minute, 0, 0, func(t *testing.T) []*auth.User {
+			return []*auth.User{newTestUser(t, 1, "alice", "alice_password", "r")}
+		}, http.StatusUnauthorized, contract.NoPermission},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			users := auth.NewUserStore([]*auth.User{newTestUser(t, 1, "alice", "alice_password", "rw")})
+			l := logger.NewTestLogger()
+			defer l.Close()
+			client := newTestClient(t, users, NewSessionHandlerFunc(l, users, tc.idleTimeout, tc.absoluteTimeout),
+				NewAuthHandlerFunc(l, users, nil, "w"))
+			client.signIn("alice", time.Now().Add(-tc.signInAgo))
+			if tc.replace != nil {
+				if err := users.Replace(tc.replace(t)); err != nil {
+					t.Fatalf("replace the users error => %v", err)
+				}
+			}
+
+			// the second request checks that the signed out session is saved
+			for i := 0; i < 2; i++ {
+				resp := client.serve(httptest.NewRequest(http.MethodGet, testRoute, nil), true)
+				if resp.Code != tc.expectStatus {
+					t.Errorf("expect to get status code %d, but get %d => %s", tc.expectStatus, resp.Code, resp.Body.String())
+					return
+				}
+				if resp.Code == http.StatusOK {
+					if actual := resp.Body.String(); actual != "alice" {
+						t.Errorf("expect to get user alice, but get %s", actual)
+					}
+					continue
+				}
+				var result server.ApiResult
+				if err := json.Unmarshal(resp.Body.Bytes(), &result); err != nil {
+					t.Errorf("parse the response error => %v, %s", err, resp.Body.String())
+					return
+				}
+				if result.Code != tc.expectCode {
+					t.Errorf("expect to get code %d, but get %d => %s", tc.expectCode, result.Code, result.Message)
+				}
+			}
+		})
+	}
+}
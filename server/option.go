@@ -1,8 +1,10 @@
 package server
 
 import (
+	"github.com/no-src/gofs/audit"
 	"github.com/no-src/gofs/auth"
 	"github.com/no-src/gofs/conf"
+	"github.com/no-src/gofs/internal/rate"
 	"github.com/no-src/gofs/logger"
 	"github.com/no-src/gofs/report"
 	"github.com/no-src/gofs/retry"
@@ -14,21 +16,29 @@ type Option struct {
 	conf.Config
 
 	Init     wait.Done
-	Users    []*auth.User
+	Users    *auth.UserStore
 	Logger   *logger.Logger
+	Audit    audit.Logger
 	Retry    retry.Retry
+	TranRate *rate.Limit
 	Reporter report.Reporter
+	Reload   ReloadFunc
+	Jobs     JobManager
 }
 
 // NewServerOption create an instance of the Option, store all the web server options
-func NewServerOption(c conf.Config, init wait.Done, users []*auth.User, logger *logger.Logger, r retry.Retry, reporter report.Reporter) Option {
+func NewServerOption(c conf.Config, init wait.Done, users *auth.UserStore, logger *logger.Logger, auditLogger audit.Logger, r retry.Retry, tranRate *rate.Limit, reporter report.Reporter, reload ReloadFunc, jobs JobManager) Option {
 	opt := Option{
 		Config:   c,
 		Init:     init,
 		Users:    users,
 		Logger:   logger,
+		Audit:    auditLogger,
 		Retry:    r,
+		TranRate: tranRate,
 		Reporter: reporter,
+		Reload:   reload,
+		Jobs:     jobs,
 	}
 	return opt
 }
@@ -12,7 +12,7 @@ import (
 
 func TestNewServerOption(t *testing.T) {
 	retryWait := time.Second
-	opt := NewServerOption(conf.Config{}, wait.NewWaitDone(), nil, nil, retry.New(1, retryWait, false, nil), report.NewReporter())
+	opt := NewServerOption(conf.Config{}, wait.NewWaitDone(), nil, nil, nil, retry.New(1, retryWait, false, nil), nil, report.NewReporter(), nil, nil)
 	if opt.Users != nil || opt.Logger != nil || opt.Retry.WaitTime() != retryWait {
 		t.Errorf("NewServerOption() error, option => %v", opt)
 	}
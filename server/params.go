@@ -5,8 +5,40 @@ const (
 	ParamUserName = "username"
 	// ParamPassword the parameter name of password
 	ParamPassword = "password"
+	// ParamSecret the parameter name of the decrypt secret
+	ParamSecret = "secret"
+	// ParamCode the parameter name of the TOTP code or the recovery code of the two-factor authentication
+	ParamCode = "code"
+	// ParamCSRFToken the parameter name of the CSRF token of the state-changing form
+	ParamCSRFToken = "csrf_token"
 	// ParamReturnUrl the parameter name of return url
 	ParamReturnUrl = "return_url"
 	// ParamFormat the format of config file, support json and yaml currently
 	ParamFormat = "format"
+	// ParamJob the parameter name of the job name
+	ParamJob = "job"
+	// ParamUser the parameter name of the username, like the owner of the api key
+	ParamUser = "user"
+	// ParamName the parameter name of the api key name
+	ParamName = "name"
+	// ParamPerm the parameter name of the api key permission
+	ParamPerm = "perm"
+	// ParamExpires the parameter name of the api key lifetime, like 720h
+	ParamExpires = "expires"
+	// ParamId the parameter name of the api key id
+	ParamId = "id"
+	// ParamIP the parameter name of the client ip
+	ParamIP = "ip"
+	// ParamOp the parameter name of the operation of the file management api
+	ParamOp = "op"
+	// ParamPath the parameter name of the file path that is relative to the source
+	ParamPath = "path"
+	// ParamTo the parameter name of the new file path of the rename operation
+	ParamTo = "to"
+	// ParamOffset the parameter name of the offset of the uploaded chunk
+	ParamOffset = "offset"
+	// ParamSize the parameter name of the total size of the uploaded file
+	ParamSize = "size"
+	// ParamMTime the parameter name of the modification time of the uploaded file, unix sec
+	ParamMTime = "mtime"
 )
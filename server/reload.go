@@ -0,0 +1,12 @@
+package server
+
+// ReloadResult the result of reloading the config
+type ReloadResult struct {
+	// Applied the flag names of the changes that are applied at runtime
+	Applied []string `json:"applied"`
+	// RestartRequired the flag names of the changes that require a restart to take effect
+	RestartRequired []string `json:"restart_required"`
+}
+
+// ReloadFunc re-read the config and apply the changes that can be applied at runtime
+type ReloadFunc func() (ReloadResult, error)
@@ -14,6 +14,10 @@ const (
 	SourceRoutePrefix = "/source/"
 	// DestRoutePrefix the route prefix of dest
 	DestRoutePrefix = "/dest/"
+	// DecryptRoutePrefix the route prefix of the decrypted dest
+	DecryptRoutePrefix = "/decrypt/"
+	// DecryptSecretRoute the route of the api that sets the decrypt secret of the session
+	DecryptSecretRoute = "/decrypt_secret"
 	// QueryRoute the route of query api
 	QueryRoute = "/query"
 	// LoginGroupRoute the group route of login
@@ -26,22 +30,63 @@ const (
 	LoginSignInRoute = "/signin"
 	// LoginSignInFullRoute the full route of sign in api
 	LoginSignInFullRoute = LoginGroupRoute + LoginSignInRoute
+	// LoginTwoFactorRoute the route of the two-factor authentication api
+	LoginTwoFactorRoute = "/2fa"
+	// LoginTwoFactorFullRoute the full route of the two-factor authentication api
+	LoginTwoFactorFullRoute = LoginGroupRoute + LoginTwoFactorRoute
+	// LoginSignOutRoute the route of sign out api
+	LoginSignOutRoute = "/signout"
+	// LoginSignOutFullRoute the full route of sign out api
+	LoginSignOutFullRoute = LoginGroupRoute + LoginSignOutRoute
 	// WriteGroupRoute the group route of write api
 	WriteGroupRoute = "/w"
 	// PushRoute the route of push api
 	PushRoute = "/push"
 	// PushFullRoute the full route of push api
 	PushFullRoute = WriteGroupRoute + PushRoute
+	// FileRoute the route of the file management api of the web UI, the op is one of the mkdir, rename, delete and upload
+	FileRoute = "/file/:op"
+	// FileFullRoute the full route of the file management api of the web UI
+	FileFullRoute = WriteGroupRoute + FileRoute
+	// FileRoutePrefix the route prefix of the file management api of the web UI
+	FileRoutePrefix = WriteGroupRoute + "/file/"
+	// ZipRoutePrefix the route prefix of downloading the directory as a zip file
+	ZipRoutePrefix = "/zip/"
 	// ManageGroupRoute the group route of manage api
 	ManageGroupRoute = "/manage"
 	// ManageConfigRoute the route of manage config api
 	ManageConfigRoute = "/config"
 	// ManageReportRoute the route of report api
 	ManageReportRoute = "/report"
+	// ManageReloadRoute the route of reload api
+	ManageReloadRoute = "/reload"
+	// ManageJobStartRoute the route of start job api
+	ManageJobStartRoute = "/job/start"
+	// ManageJobStopRoute the route of stop job api
+	ManageJobStopRoute = "/job/stop"
+	// ManageAPIKeyRoute the route of list api keys api
+	ManageAPIKeyRoute = "/apikey"
+	// ManageAPIKeyCreateRoute the route of create api key api
+	ManageAPIKeyCreateRoute = "/apikey/create"
+	// ManageAPIKeyRevokeRoute the route of revoke api key api
+	ManageAPIKeyRevokeRoute = "/apikey/revoke"
+	// ManageLoginUnlockRoute the route of unlock login api
+	ManageLoginUnlockRoute = "/login/unlock"
 	// PProfRoutePrefix the route prefix of pprof
 	PProfRoutePrefix = "pprof"
 )
 
+const (
+	// FileOpMkdir the op of the file management api that creates a directory
+	FileOpMkdir = "mkdir"
+	// FileOpRename the op of the file management api that renames a file or a directory
+	FileOpRename = "rename"
+	// FileOpDelete the op of the file management api that deletes a file or a directory
+	FileOpDelete = "delete"
+	// FileOpUpload the op of the file management api that uploads a file by chunks, query the uploaded offset by GET to resume the upload
+	FileOpUpload = "upload"
+)
+
 const (
 	// DefaultAddrHttps the default https address
 	DefaultAddrHttps = ":443"
@@ -62,6 +107,35 @@ const (
 	SessionName = "session_id"
 	// SessionUser the key of the session user
 	SessionUser = "user"
+	// SessionDecryptSecret the key of the decrypt secret of the session
+	SessionDecryptSecret = "decrypt_secret"
+	// SessionTwoFactorUser the key of the user that has passed the password verification and waits for the two-factor authentication
+	SessionTwoFactorUser = "two_factor_user"
+	// SessionTwoFactorExpires the key of the unix time that the two-factor authentication of the session expires at
+	SessionTwoFactorExpires = "two_factor_expires"
+	// SessionTwoFactorReturnUrl the key of the return url after the two-factor authentication
+	SessionTwoFactorReturnUrl = "two_factor_return_url"
+	// SessionSignedInAt the key of the unix time that the user of the session signs in at
+	SessionSignedInAt = "signed_in_at"
+	// SessionActiveAt the key of the unix time that the session is active at last
+	SessionActiveAt = "active_at"
+	// SessionCSRFToken the key of the CSRF token of the session
+	SessionCSRFToken = "csrf_token"
+	// CSRFCookieName the name of the cookie that carries the CSRF token for the clients
+	CSRFCookieName = "csrf_token"
+	// HeaderCSRFToken the request header that carries the CSRF token
+	HeaderCSRFToken = "X-CSRF-Token"
+)
+
+const (
+	// AuditUser the context key of the username of the audit record, it is set by the handlers that the user is not signed in yet, like the login
+	AuditUser = "audit_user"
+	// AuditOp the context key of the detail of the audit record, like the action of the pushed file change
+	AuditOp = "audit_op"
+	// AuditPath the context key of the path of the audit record that replaces the request path, like the path of the pushed file
+	AuditPath = "audit_path"
+	// AuditCode the context key of the result code of the audit record
+	AuditCode = "audit_code"
 )
 
 const (
@@ -4,15 +4,29 @@ import (
 	"crypto/rand"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-contrib/sessions/memstore"
 	"github.com/gin-contrib/sessions/redis"
+	"github.com/no-src/gofs/auth"
 	"github.com/no-src/log"
 	"github.com/no-src/nscache"
+	_ "github.com/no-src/nscache/all"
+)
+
+const (
+	// DefaultSessionIdleTimeout the default timeout of the inactive web session
+	DefaultSessionIdleTimeout = 30 * time.Minute
+	// DefaultSessionAbsoluteTimeout the default timeout of the web session since the user signs in
+	DefaultSessionAbsoluteTimeout = 24 * time.Hour
+
+	// defaultSessionMaxAge the max age of the session cookie if the absolute timeout is disabled, the same as the default of the redis store
+	defaultSessionMaxAge = 30 * 24 * time.Hour
 )
 
 var (
@@ -43,6 +57,60 @@ func NewSessionStore(sessionConnection string) (sessions.Store, error) {
 	}
 }
 
+// NewSessionCache create a cache that shares the same memory or redis storage as the session store,
+// it is used to share the server state like the login failures between multiple servers
+func NewSessionCache(sessionConnection string) (nscache.NSCache, error) {
+	connUrl, err := url.Parse(sessionConnection)
+	if err != nil {
+		return nil, fmt.Errorf("%w => %s", errors.Join(errInvalidSession, err), sessionConnection)
+	}
+	switch strings.ToLower(connUrl.Scheme) {
+	case "memory":
+		return nscache.NewCache("memory:")
+	case "redis":
+		_, _, address, password, db, _, err := parseRedisConnection(connUrl)
+		if err != nil {
+			return nil, err
+		}
+		return nscache.NewCache(redisCacheConnection(address, password, db))
+	default:
+		return nil, fmt.Errorf("%w => %s", errUnsupportedSession, sessionConnection)
+	}
+}
+
+// SessionCookieOptions return the options of the session cookie, the cookie is not accessible to the scripts and not sent by the cross-site requests,
+// the Secure flag is set if the server enables TLS, and the cookie expires with the absolute timeout
+func SessionCookieOptions(secure bool, absoluteTimeout time.Duration) sessions.Options {
+	maxAge := defaultSessionMaxAge
+	if absoluteTimeout > 0 {
+		maxAge = absoluteTimeout
+	}
+	return sessions.Options{
+		Path:     "/",
+		MaxAge:   int(maxAge.Seconds()),
+		Secure:   secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// SignIn set the user to the session and record the sign in time, the session is saved by the caller
+func SignIn(session sessions.Session, user *auth.SessionUser, now time.Time) {
+	session.Set(SessionUser, user)
+	session.Set(SessionSignedInAt, now.Unix())
+	session.Set(SessionActiveAt, now.Unix())
+}
+
+// SignOut remove the user and all the other state of the session, the session is saved by the caller
+func SignOut(session sessions.Session) {
+	session.Clear()
+}
+
+// SessionExpired report whether the signed in session is expired by the idle timeout or the absolute timeout, the zero timeout means never
+func SessionExpired(signedInAt, activeAt time.Time, idleTimeout, absoluteTimeout time.Duration, now time.Time) bool {
+	return (absoluteTimeout > 0 && now.Sub(signedInAt) >= absoluteTimeout) || (idleTimeout > 0 && now.Sub(activeAt) >= idleTimeout)
+}
+
 func redisSessionStore(redisUrl *url.URL, secret []byte) (sessions.Store, error) {
 	maxIdle, network, address, password, db, redisSecret, err := parseRedisConnection(redisUrl)
 	if err != nil {
@@ -58,7 +126,7 @@ func redisSessionStore(redisUrl *url.URL, secret []byte) (sessions.Store, error)
 }
 
 func getOrSetStoreSecret(address, password string, db int, newSecret []byte) (secret []byte) {
-	conn := fmt.Sprintf("redis://:%s@%s/%d", password, address, db)
+	conn := redisCacheConnection(address, password, db)
 	key := "nosrc-gofs-session-secret"
 	c, err := nscache.NewCache(conn)
 	if err != nil {
@@ -77,6 +145,11 @@ func getOrSetStoreSecret(address, password string, db int, newSecret []byte) (se
 	return secret
 }
 
+// redisCacheConnection return the nscache connection string of the redis
+func redisCacheConnection(address, password string, db int) string {
+	return fmt.Sprintf("redis://:%s@%s/%d", password, address, db)
+}
+
 // parseRedisConnection parse the redis connection string
 // for example => redis://127.0.0.1:6379?password=redis_password&db=10&max_idle=10&secret=redis_secret
 func parseRedisConnection(u *url.URL) (maxIdle int, network, address, password string, db int, secret []byte, err error) {
@@ -2,7 +2,9 @@ package server
 
 import (
 	"errors"
+	"net/http"
 	"testing"
+	"time"
 )
 
 func TestNewSessionStore(t *testing.T) {
@@ -83,3 +85,93 @@ func TestNewSessionStore_Invalid(t *testing.T) {
 		})
 	}
 }
+
+func TestNewSessionCache(t *testing.T) {
+	testCases := []struct {
+		conn string
+	}{
+		{"memory:"},
+		//{"redis://127.0.0.1:6379?password=&db=10&max_idle=10&secret=redis_secret"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.conn, func(t *testing.T) {
+			c, err := NewSessionCache(tc.conn)
+			if err != nil {
+				t.Errorf("create session cache error => %s", err)
+				return
+			}
+			defer c.Close()
+			if err = c.Set("key", "value", 0); err != nil {
+				t.Errorf("set the session cache error => %s", err)
+			}
+		})
+	}
+}
+
+func TestNewSessionCache_ReturnError(t *testing.T) {
+	testCases := []struct {
+		conn      string
+		expectErr error
+	}{
+		{"redis://127.0.0.1:6379?db=x", errInvalidRedisDB},
+		{"hello://127.0.0.1:8888", errUnsupportedSession},
+		{"\t", errInvalidSession},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.conn, func(t *testing.T) {
+			_, err := NewSessionCache(tc.conn)
+			if !errors.Is(err, tc.expectErr) {
+				t.Errorf("expect to get error [%s], but actual get error [%s]", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestSessionExpired(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		name            string
+		signedInAt      time.Time
+		activeAt        time.Time
+		idleTimeout     time.Duration
+		absoluteTimeout time.Duration
+		expect          bool
+	}{
+		{"active session", now.Add(-time.Hour), now.Add(-time.Minute), 30 * time.Minute, 24 * time.Hour, false},
+		{"idle timeout", now.Add(-time.Hour), now.Add(-30 * time.Minute), 30 * time.Minute, 24 * time.Hour, true},
+		{"absolute timeout", now.Add(-24 * time.Hour), now, 30 * time.Minute, 24 * time.Hour, true},
+		{"disable the idle timeout", now.Add(-time.Hour), now.Add(-time.Hour), 0, 24 * time.Hour, false},
+		{"disable the absolute timeout", now.Add(-48 * time.Hour), now, 30 * time.Minute, 0, false},
+		{"disable all the timeouts", time.Unix(0, 0), time.Unix(0, 0), 0, 0, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := SessionExpired(tc.signedInAt, tc.activeAt, tc.idleTimeout, tc.absoluteTimeout, now); actual != tc.expect {
+				t.Errorf("expect the session is expired [%v], but actual get %v", tc.expect, actual)
+			}
+		})
+	}
+}
+
+func TestSessionCookieOptions(t *testing.T) {
+	testCases := []struct {
+		name            string
+		secure          bool
+		absoluteTimeout time.Duration
+		expectMaxAge    int
+	}{
+		{"with tls", true, time.Hour, 3600},
+		{"without tls", false, time.Hour, 3600},
+		{"disable the absolute timeout", true, 0, int(defaultSessionMaxAge.Seconds())},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opt := SessionCookieOptions(tc.secure, tc.absoluteTimeout)
+			if opt.Secure != tc.secure || !opt.HttpOnly || opt.SameSite != http.SameSiteLaxMode || opt.MaxAge != tc.expectMaxAge {
+				t.Errorf("get unexpected session cookie options => %+v", opt)
+			}
+		})
+	}
+}
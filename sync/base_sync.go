@@ -1,6 +1,8 @@
 package sync
 
 import (
+	"io/fs"
+
 	"github.com/no-src/gofs/core"
 	"github.com/no-src/gofs/logger"
 )
@@ -29,3 +31,12 @@ func (s *baseSync) Dest() core.VFS {
 
 func (s *baseSync) Close() {
 }
+
+// dirEntryInfo returns the file info of the dir entry, returns nil if getting the file info fails
+func dirEntryInfo(d fs.DirEntry) fs.FileInfo {
+	fi, err := d.Info()
+	if err != nil {
+		return nil
+	}
+	return fi
+}
@@ -27,7 +27,7 @@ type diskSync struct {
 	enableLogicallyDelete bool
 	forceChecksum         bool
 	progress              bool
-	maxTranRate           int64
+	maxTranRate           *rate.Limit
 	enc                   *encrypt.Encrypt
 	hash                  hashutil.Hash
 	pi                    ignore.PathIgnore
@@ -263,8 +263,9 @@ func (s *diskSync) write(path, dest string) error {
 		return err
 	}
 
-	reader := bufio.NewReader(rate.NewReader(sourceFile, s.maxTranRate, s.logger))
-	writer, err := s.enc.NewWriter(destFile, path, destStat.Name())
+	reader := bufio.NewReader(rate.NewReader(sourceFile, s.maxTranRate.Bytes(), s.logger))
+	// use the source file name, the dest file name may be encrypted
+	writer, err := s.enc.NewWriter(destFile, path, filepath.Base(path))
 	if err != nil {
 		return err
 	}
@@ -342,6 +343,11 @@ func (s *diskSync) buildDestAbsFile(sourceFileAbs string) (string, error) {
 		s.logger.Error(err, "parse rel path error, basePath=%s destPath=%s", s.sourceAbsPath, sourceFileRel)
 		return "", err
 	}
+	if s.enc.NeedEncrypt(sourceFileAbs) {
+		if sourceFileRel, err = s.enc.EncryptName(sourceFileRel); err != nil {
+			return "", err
+		}
+	}
 	return filepath.Join(s.destAbsPath, sourceFileRel), nil
 }
 
@@ -359,7 +365,7 @@ func (s *diskSync) SyncOnce(path string) error {
 		if err != nil {
 			return err
 		}
-		if s.pi.MatchPath(currentPath, "disk sync", "sync once") {
+		if s.pi.MatchFileInfo(currentPath, dirEntryInfo(d), "disk sync", "sync once") {
 			return nil
 		}
 		return s.syncWalk(currentPath, d, s, fsutil.Readlink)
@@ -452,7 +458,7 @@ func (s *diskSync) deepCopy(source, dest string) error {
 			return s.symlink(realPath, dest)
 		}
 
-		if s.pi.MatchPath(source, "local disk deep copy", "the symlink's real path") {
+		if s.pi.MatchFileInfo(source, sourceStat, "local disk deep copy", "the symlink's real path") {
 			return nil
 		}
 		// check unsafe link
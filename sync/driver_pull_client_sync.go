@@ -138,7 +138,7 @@ func (s *driverPullClientSync) SyncOnce(path string) error {
 		if err != nil {
 			return err
 		}
-		if s.pi.MatchPath(currentPath, s.driver.DriverName()+" pull client sync", "sync once") {
+		if s.pi.MatchFileInfo(currentPath, dirEntryInfo(d), s.driver.DriverName()+" pull client sync", "sync once") {
 			return nil
 		}
 		return s.syncWalk(currentPath, d, s, s.driver.ReadLink)
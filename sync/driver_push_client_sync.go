@@ -2,6 +2,7 @@ package sync
 
 import (
 	"errors"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -98,14 +99,14 @@ func (s *driverPushClientSync) Write(path string) error {
 		return err
 	}
 
-	encryptPath, removeTemp, err := s.enc.CreateEncryptTemp(path)
-	if err != nil {
-		return err
+	if s.enc.NeedEncrypt(path) {
+		// encrypt the file on the fly while uploading
+		err = s.driver.WriteStream(destPath, func() (io.ReadCloser, error) {
+			return s.enc.NewReader(path)
+		})
+	} else {
+		err = s.driver.Write(path, destPath)
 	}
-	// remove the temporary file
-	defer removeTemp()
-
-	err = s.driver.Write(encryptPath, destPath)
 	if err == nil {
 		s.logger.Info("[%s-driver-push] [write] [success] => %s", s.driver.DriverName(), path)
 		if _, aTime, mTime, err := fsutil.GetFileTime(path); err == nil {
@@ -180,7 +181,7 @@ func (s *driverPushClientSync) SyncOnce(path string) error {
 		if err != nil {
 			return err
 		}
-		if s.pi.MatchPath(currentPath, s.driver.DriverName()+" push client sync", "sync once") {
+		if s.pi.MatchFileInfo(currentPath, dirEntryInfo(d), s.driver.DriverName()+" push client sync", "sync once") {
 			return nil
 		}
 		return s.syncWalk(currentPath, d, s, fsutil.Readlink)
@@ -193,6 +194,11 @@ func (s *driverPushClientSync) buildDestAbsFile(sourceFileAbs string) (string, e
 		s.logger.Error(err, "parse rel path error, basePath=%s destPath=%s", s.sourceAbsPath, sourceFileRel)
 		return "", err
 	}
+	if s.enc.NeedEncrypt(sourceFileAbs) {
+		if sourceFileRel, err = s.enc.EncryptName(sourceFileRel); err != nil {
+			return "", err
+		}
+	}
 
 	return filepath.ToSlash(filepath.Join(s.basePath, sourceFileRel)), nil
 }
@@ -18,7 +18,7 @@ type minIOPullClientSync struct {
 func NewMinIOPullClientSync(opt Option) (Sync, error) {
 	// the fields of option
 	source := opt.Source
-	users := opt.Users
+	users := opt.Users.Users()
 	chunkSize := opt.ChunkSize
 	maxTranRate := opt.MaxTranRate
 	r := opt.Retry
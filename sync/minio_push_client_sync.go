@@ -18,7 +18,7 @@ type minIOPushClientSync struct {
 func NewMinIOPushClientSync(opt Option) (Sync, error) {
 	// the fields of option
 	dest := opt.Dest
-	users := opt.Users
+	users := opt.Users.Users()
 	chunkSize := opt.ChunkSize
 	maxTranRate := opt.MaxTranRate
 	r := opt.Retry
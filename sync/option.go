@@ -1,11 +1,15 @@
 package sync
 
 import (
+	"time"
+
+	"github.com/no-src/gofs/audit"
 	"github.com/no-src/gofs/auth"
 	"github.com/no-src/gofs/conf"
 	"github.com/no-src/gofs/core"
 	"github.com/no-src/gofs/encrypt"
 	"github.com/no-src/gofs/ignore"
+	"github.com/no-src/gofs/internal/rate"
 	"github.com/no-src/gofs/logger"
 	"github.com/no-src/gofs/report"
 	"github.com/no-src/gofs/retry"
@@ -21,30 +25,48 @@ type Option struct {
 	TLSCertFile           string
 	TLSKeyFile            string
 	TLSInsecureSkipVerify bool
+	TLSClientCAFile       string
+	TLSClientCertFile     string
+	TLSClientKeyFile      string
+	TLSPin                string
+	TLSKnownHostsFile     string
 	EnableLogicallyDelete bool
 	ChunkSize             int64
 	CheckpointCount       int
 	ForceChecksum         bool
 	ChecksumAlgorithm     string
 	Progress              bool
-	MaxTranRate           int64
+	MaxTranRate           *rate.Limit
 	DryRun                bool
 	CopyLink              bool
 	CopyUnsafeLink        bool
 	TokenSecret           string
-	Users                 []*auth.User
+	TokenAlgorithm        string
+	TokenKeyFile          string
+	TokenExpires          time.Duration
+	TokenRevocation       string
+	KeyFile               string
+	KDF                   string
+	Users                 *auth.UserStore
+	PushUserRoot          bool
 	Retry                 retry.Retry
 	EncOpt                encrypt.Option
 	PathIgnore            ignore.PathIgnore
 	Reporter              report.Reporter
 	TaskConf              string
+	GRPCAllowIP           string
+	GRPCDenyIP            string
+	IPMaxConns            int
+	IPMaxRate             float64
+	IPRateBurst           int
 	Logger                *logger.Logger
+	Audit                 audit.Logger
 	SyncOnce              bool
 	SyncCron              string
 }
 
 // NewSyncOption create an instance of the Option, store all the sync component options
-func NewSyncOption(config conf.Config, users []*auth.User, r retry.Retry, pi ignore.PathIgnore, reporter report.Reporter, logger *logger.Logger) Option {
+func NewSyncOption(config conf.Config, users *auth.UserStore, r retry.Retry, maxTranRate *rate.Limit, pi ignore.PathIgnore, reporter report.Reporter, auditLogger audit.Logger, logger *logger.Logger) Option {
 	opt := Option{
 		Source:                config.Source,
 		Dest:                  config.Dest,
@@ -54,24 +76,42 @@ func NewSyncOption(config conf.Config, users []*auth.User, r retry.Retry, pi ign
 		TLSCertFile:           config.TLSCertFile,
 		TLSKeyFile:            config.TLSKeyFile,
 		TLSInsecureSkipVerify: config.TLSInsecureSkipVerify,
+		TLSClientCAFile:       config.TLSClientCAFile,
+		TLSClientCertFile:     config.TLSClientCertFile,
+		TLSClientKeyFile:      config.TLSClientKeyFile,
+		TLSPin:                config.TLSPin,
+		TLSKnownHostsFile:     config.TLSKnownHostsFile,
 		EnableLogicallyDelete: config.EnableLogicallyDelete,
 		ChunkSize:             config.ChunkSize.Bytes(),
 		CheckpointCount:       config.CheckpointCount,
 		ForceChecksum:         config.ForceChecksum,
 		ChecksumAlgorithm:     config.ChecksumAlgorithm,
 		Progress:              config.Progress,
-		MaxTranRate:           config.MaxTranRate.Bytes(),
+		MaxTranRate:           maxTranRate,
 		DryRun:                config.DryRun,
 		CopyLink:              config.CopyLink,
 		CopyUnsafeLink:        config.CopyUnsafeLink,
 		TokenSecret:           config.TokenSecret,
+		TokenAlgorithm:        config.TokenAlgorithm,
+		TokenKeyFile:          config.TokenKeyFile,
+		TokenExpires:          config.TokenExpires.Duration(),
+		TokenRevocation:       config.TokenRevocation,
+		KeyFile:               config.KeyFile,
+		KDF:                   config.KDF,
 		Users:                 users,
+		PushUserRoot:          config.PushUserRoot,
 		Retry:                 r,
 		EncOpt:                encrypt.NewOption(config, logger),
 		PathIgnore:            pi,
 		Reporter:              reporter,
 		TaskConf:              config.TaskConf,
+		GRPCAllowIP:           config.GRPCAllowIP,
+		GRPCDenyIP:            config.GRPCDenyIP,
+		IPMaxConns:            config.IPMaxConns,
+		IPMaxRate:             config.IPMaxRate,
+		IPRateBurst:           config.IPRateBurst,
 		Logger:                logger,
+		Audit:                 auditLogger,
 		SyncOnce:              config.SyncOnce,
 		SyncCron:              config.SyncCron,
 	}
@@ -19,6 +19,7 @@ import (
 	"github.com/no-src/gofs/internal/rate"
 	"github.com/no-src/gofs/server"
 	"github.com/no-src/gofs/server/client"
+	"github.com/no-src/gofs/tlsutil"
 	"github.com/no-src/nsgo/fsutil"
 	"github.com/no-src/nsgo/hashutil"
 	"github.com/no-src/nsgo/httputil"
@@ -48,7 +49,10 @@ func NewPushClientSync(opt Option) (Sync, error) {
 	enableTLS := opt.EnableTLS
 	certFile := opt.TLSCertFile
 	insecureSkipVerify := opt.TLSInsecureSkipVerify
-	users := opt.Users
+	clientCertFile := opt.TLSClientCertFile
+	clientKeyFile := opt.TLSClientKeyFile
+	pin := tlsutil.Pin{Fingerprint: opt.TLSPin, KnownHostsFile: opt.TLSKnownHostsFile, Host: dest.Host()}
+	users := opt.Users.Users()
 	chunkSize := opt.ChunkSize
 
 	if chunkSize <= 0 {
@@ -60,7 +64,7 @@ func NewPushClientSync(opt Option) (Sync, error) {
 		return nil, err
 	}
 
-	httpClient, err := httputil.NewHttpClient(insecureSkipVerify, certFile, enableHTTP3)
+	httpClient, err := tlsutil.NewHttpClient(insecureSkipVerify, certFile, clientCertFile, clientKeyFile, pin, enableHTTP3)
 	if err != nil {
 		return nil, err
 	}
@@ -71,7 +75,7 @@ func NewPushClientSync(opt Option) (Sync, error) {
 	}
 	s := &pushClientSync{
 		diskSync:    *ds,
-		client:      apiclient.New(dest.Host(), dest.Port(), enableTLS, certFile, user),
+		client:      apiclient.New(dest.Host(), dest.Port(), enableTLS, certFile, clientCertFile, clientKeyFile, pin, user),
 		currentUser: user,
 		httpClient:  httpClient,
 	}
@@ -174,7 +178,7 @@ func (pcs *pushClientSync) SyncOnce(path string) error {
 		if err != nil {
 			return err
 		}
-		if pcs.pi.MatchPath(currentPath, "push client sync", "sync once") {
+		if pcs.pi.MatchFileInfo(currentPath, dirEntryInfo(d), "push client sync", "sync once") {
 			return nil
 		}
 		return pcs.syncWalk(currentPath, d, pcs, fsutil.Readlink)
@@ -197,9 +201,12 @@ func (pcs *pushClientSync) send(act action.Action, path string) (err error) {
 	aTime := time.Now()
 	mTime := time.Now()
 	if pcs.needGetFileSizeAndHash(isDir, act) {
-		size, hash, hvs, err = pcs.hash.GetFileSizeAndHashCheckpoints(path, pcs.chunkSize, pcs.checkpointCount)
-		if err != nil {
-			return err
+		// the encrypted content is different every time, the hash of the source file is useless to compare
+		if !pcs.enc.NeedEncrypt(path) {
+			size, hash, hvs, err = pcs.hash.GetFileSizeAndHashCheckpoints(path, pcs.chunkSize, pcs.checkpointCount)
+			if err != nil {
+				return err
+			}
 		}
 	} else if pcs.needIgnoreDirWrite(isDir, act) {
 		return nil
@@ -218,11 +225,10 @@ func (pcs *pushClientSync) send(act action.Action, path string) (err error) {
 		isDirValue = contract.FsIsDir
 	}
 
-	relPath, err := filepath.Rel(pcs.sourceAbsPath, path)
+	relPath, err := pcs.buildRelPath(path)
 	if err != nil {
 		return err
 	}
-	relPath = filepath.ToSlash(relPath)
 	pd := push.PushData{
 		Action: act,
 		FileInfo: contract.FileInfo{
@@ -246,11 +252,10 @@ func (pcs *pushClientSync) sendSymlink(oldname, newname string) (err error) {
 		return timeErr
 	}
 
-	relPath, err := filepath.Rel(pcs.sourceAbsPath, newname)
+	relPath, err := pcs.buildRelPath(newname)
 	if err != nil {
 		return err
 	}
-	relPath = filepath.ToSlash(relPath)
 	pd := push.PushData{
 		Action: action.SymlinkAction,
 		FileInfo: contract.FileInfo{
@@ -267,6 +272,21 @@ func (pcs *pushClientSync) sendSymlink(oldname, newname string) (err error) {
 	return pcs.sendPushData(pd, pd.Action, newname)
 }
 
+// buildRelPath returns the slash-separated path relative to the source path, the names under the encrypt path
+// are encrypted if the name encryption is enabled
+func (pcs *pushClientSync) buildRelPath(path string) (string, error) {
+	relPath, err := filepath.Rel(pcs.sourceAbsPath, path)
+	if err != nil {
+		return "", err
+	}
+	if pcs.enc.NeedEncrypt(path) {
+		if relPath, err = pcs.enc.EncryptName(relPath); err != nil {
+			return "", err
+		}
+	}
+	return filepath.ToSlash(relPath), nil
+}
+
 func (pcs *pushClientSync) needCheckDir(act action.Action) bool {
 	return act != action.RemoveAction && act != action.RenameAction
 }
@@ -285,6 +305,9 @@ func (pcs *pushClientSync) needGetFileTime(act action.Action) bool {
 
 func (pcs *pushClientSync) sendPushData(pd push.PushData, act action.Action, path string) error {
 	if act == action.WriteAction {
+		if pcs.enc.NeedEncrypt(path) {
+			return pcs.sendEncryptFileChunk(path, pd)
+		}
 		return pcs.sendFileChunk(path, pd)
 	}
 	resp, err := pcs.httpPostWithAuth(pcs.pushAddr, act, push.ParamUpFile, path, pd, nil)
@@ -310,7 +333,7 @@ func (pcs *pushClientSync) sendFileChunk(path string, pd push.PushData) error {
 	// if loopCount == 1 means read an empty file maybe, send it
 	loopCount := -1
 	checkChunkHash := false
-	ra := rate.NewReaderAt(f, pcs.maxTranRate, pcs.logger)
+	ra := rate.NewReaderAt(f, pcs.maxTranRate.Bytes(), pcs.logger)
 	for {
 		loopCount++
 		n, err := ra.ReadAt(chunk, offset)
@@ -349,6 +372,46 @@ func (pcs *pushClientSync) sendFileChunk(path string, pd push.PushData) error {
 	}
 }
 
+// sendEncryptFileChunk encrypt the file on the fly and upload the encrypted stream chunk by chunk, the file and chunk
+// comparison is skipped because the encrypted content is different every time
+func (pcs *pushClientSync) sendEncryptFileChunk(path string, pd push.PushData) error {
+	r, err := pcs.enc.NewReader(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	reader := rate.NewReader(r, pcs.maxTranRate.Bytes(), pcs.logger)
+	chunk := make([]byte, pcs.chunkSize)
+	var offset int64
+	// never expose the source path, the dest name may be encrypted
+	fileName := pd.FileInfo.Path
+	pd.PushAction = push.WritePushAction
+	for {
+		n, err := io.ReadFull(reader, chunk)
+		if fsutil.IsNonEOF(err) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return err
+		}
+		if n > 0 {
+			pd.Chunk.Offset = offset
+			pd.Chunk.Size = int64(n)
+			resp, err := pcs.httpPostWithAuth(pcs.pushAddr, action.WriteAction, push.ParamUpFile, fileName, pd, chunk[:n])
+			if err != nil {
+				return err
+			}
+			_, _, err = pcs.checkApiResult(resp)
+			resp.Body.Close()
+			if err != nil {
+				return err
+			}
+			offset += int64(n)
+		}
+		if err != nil {
+			// read to end, send a truncate request finally
+			return pcs.sendTruncate(fileName, pd, offset)
+		}
+	}
+}
+
 func (pcs *pushClientSync) sendChunkRequest(path string, pd *push.PushData, offset *int64, chunkSize int, checkChunkHash *bool, chunk []byte, n int, isEnd *bool) (broken bool, err error) {
 	defer func() {
 		// only send HashValues once
@@ -463,6 +526,7 @@ func (pcs *pushClientSync) httpPostWithAuth(rawURL string, act action.Action, fi
 	}
 	data := url.Values{}
 	data.Set(push.ParamPushData, string(pdData))
+	data.Set(server.ParamCSRFToken, client.CSRFToken(pcs.cookies))
 
 	sendFile := false
 	if act == action.WriteAction {
@@ -490,6 +554,7 @@ func (pcs *pushClientSync) httpPostWithAuth(rawURL string, act action.Action, fi
 		}
 		if len(cookies) > 0 {
 			pcs.cookies = cookies
+			data.Set(server.ParamCSRFToken, client.CSRFToken(pcs.cookies))
 			pcs.logger.Debug("try to auto login file server success maybe, retry to get resource => %s", rawURL)
 			if sendFile {
 				return pcs.httpClient.HttpPostFileChunkWithCookie(rawURL, fieldName, fileName, data, chunk, pcs.cookies...)
@@ -20,6 +20,7 @@ import (
 	"github.com/no-src/gofs/internal/rate"
 	"github.com/no-src/gofs/server"
 	"github.com/no-src/gofs/server/client"
+	"github.com/no-src/gofs/tlsutil"
 	"github.com/no-src/nsgo/fsutil"
 	"github.com/no-src/nsgo/hashutil"
 	"github.com/no-src/nsgo/httputil"
@@ -41,7 +42,7 @@ type remoteClientSync struct {
 	enableLogicallyDelete bool
 	forceChecksum         bool
 	hash                  hashutil.Hash
-	maxTranRate           int64
+	maxTranRate           *rate.Limit
 	httpClient            httputil.HttpClient
 	pi                    ignore.PathIgnore
 }
@@ -55,7 +56,10 @@ func NewRemoteClientSync(opt Option) (Sync, error) {
 	enableHTTP3 := opt.EnableHTTP3
 	certFile := opt.TLSCertFile
 	insecureSkipVerify := opt.TLSInsecureSkipVerify
-	users := opt.Users
+	clientCertFile := opt.TLSClientCertFile
+	clientKeyFile := opt.TLSClientKeyFile
+	pin := tlsutil.Pin{Fingerprint: opt.TLSPin, KnownHostsFile: opt.TLSKnownHostsFile, Host: source.Host()}
+	users := opt.Users.Users()
 	chunkSize := opt.ChunkSize
 	forceChecksum := opt.ForceChecksum
 	checksumAlgorithm := opt.ChecksumAlgorithm
@@ -72,7 +76,7 @@ func NewRemoteClientSync(opt Option) (Sync, error) {
 		return nil, err
 	}
 
-	httpClient, err := httputil.NewHttpClient(insecureSkipVerify, certFile, enableHTTP3)
+	httpClient, err := tlsutil.NewHttpClient(insecureSkipVerify, certFile, clientCertFile, clientKeyFile, pin, enableHTTP3)
 	if err != nil {
 		return nil, err
 	}
@@ -224,7 +228,7 @@ func (rs *remoteClientSync) write(path, dest string) error {
 		return err
 	}
 
-	reader := bufio.NewReader(rate.NewReader(resp.Body, rs.maxTranRate, rs.logger))
+	reader := bufio.NewReader(rate.NewReader(resp.Body, rs.maxTranRate.Bytes(), rs.logger))
 	writer := bufio.NewWriter(destFile)
 
 	// truncate first before write to file
@@ -354,7 +358,7 @@ func (rs *remoteClientSync) sync(serverAddr, path string) error {
 		return err
 	}
 	defer resp.Body.Close()
-	data, err := io.ReadAll(rate.NewReader(resp.Body, rs.maxTranRate, rs.logger))
+	data, err := io.ReadAll(rate.NewReader(resp.Body, rs.maxTranRate.Bytes(), rs.logger))
 	if err != nil {
 		return err
 	}
@@ -387,7 +391,7 @@ func (rs *remoteClientSync) sync(serverAddr, path string) error {
 
 func (rs *remoteClientSync) syncFiles(files []contract.FileInfo, serverAddr, path string) {
 	for _, file := range files {
-		if rs.pi.MatchPath(file.Path, "remote client sync", "sync once") {
+		if rs.pi.MatchFileInfo(file.Path, file.Stat(), "remote client sync", "sync once") {
 			continue
 		}
 		currentPath := path + "/" + file.Path
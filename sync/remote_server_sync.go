@@ -10,8 +10,11 @@ import (
 
 	"github.com/no-src/gofs/action"
 	"github.com/no-src/gofs/api/apiserver"
+	authapi "github.com/no-src/gofs/api/auth"
 	"github.com/no-src/gofs/api/monitor"
 	"github.com/no-src/gofs/contract"
+	"github.com/no-src/gofs/ipfilter"
+	"github.com/no-src/gofs/keyring"
 	"github.com/no-src/gofs/server"
 	"github.com/no-src/nsgo/fsutil"
 	"github.com/no-src/nsgo/hashutil"
@@ -37,8 +40,16 @@ func NewRemoteServerSync(opt Option) (Sync, error) {
 	enableTLS := opt.EnableTLS
 	certFile := opt.TLSCertFile
 	keyFile := opt.TLSKeyFile
-	tokenSecret := opt.TokenSecret
+	clientCAFile := opt.TLSClientCAFile
+	tokenOpt := authapi.TokenOption{
+		Secret:     opt.TokenSecret,
+		Algorithm:  opt.TokenAlgorithm,
+		KeyFile:    opt.TokenKeyFile,
+		Expires:    opt.TokenExpires,
+		Revocation: opt.TokenRevocation,
+	}
 	users := opt.Users
+	userRoot := opt.PushUserRoot
 	taskConf := opt.TaskConf
 	logger := opt.Logger
 
@@ -76,7 +87,21 @@ func NewRemoteServerSync(opt Option) (Sync, error) {
 		rs.logger.Warn("create remote server sync warning, you should enable the file server with -server and -server_addr flags")
 	}
 
-	rs.server, err = apiserver.New(source.Host(), source.Port(), enableTLS, certFile, keyFile, tokenSecret, users, opt.Reporter, rs.serverAddr, logger, taskConf)
+	// derive the token secret from the passphrase if the keyfile is specified
+	if len(opt.KeyFile) > 0 {
+		tokenOpt.Secret, err = keyring.TokenSecret(opt.KeyFile, opt.KDF, tokenOpt.Secret)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	filter, err := ipfilter.NewFilter(opt.GRPCAllowIP, opt.GRPCDenyIP)
+	if err != nil {
+		return nil, err
+	}
+	limiter := ipfilter.NewLimiter(opt.IPMaxConns, opt.IPMaxRate, opt.IPRateBurst)
+
+	rs.server, err = apiserver.New(source.Host(), source.Port(), enableTLS, certFile, keyFile, clientCAFile, tokenOpt, users, userRoot, opt.Reporter, rs.serverAddr, logger, opt.Audit, filter, limiter, taskConf)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,200 @@
+package tlsutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// CACertFileName the file name of the local CA certificate that is generated by AutoCert
+	CACertFileName = "gofs_ca.pem"
+	// CAKeyFileName the file name of the local CA private key that is generated by AutoCert
+	CAKeyFileName = "gofs_ca.key"
+
+	caValidity     = 10 * 365 * 24 * time.Hour
+	serverValidity = 365 * 24 * time.Hour
+	renewBefore    = 30 * 24 * time.Hour
+)
+
+var errInvalidPEM = errors.New("no valid certificate or ECDSA private key is found in the pem file")
+
+// AutoCert generate a local CA and a server certificate for the hosts that is signed by the CA if the certFile or the keyFile does not exist,
+// and re-issue the server certificate if it is signed by the local CA and expires within 30 days. The local CA is stored in the same
+// directory as the certFile, return the fingerprint of the root-most certificate in the certFile and whether the server certificate is issued
+func AutoCert(certFile string, keyFile string, hosts []string) (fingerprint string, issued bool, err error) {
+	caCertFile := filepath.Join(filepath.Dir(certFile), CACertFileName)
+	caKeyFile := filepath.Join(filepath.Dir(certFile), CAKeyFileName)
+	if issued, err = needIssue(certFile, keyFile, caCertFile, caKeyFile); err != nil {
+		return "", false, err
+	}
+	if issued {
+		caCert, caKey, err := loadOrCreateCA(caCertFile, caKeyFile)
+		if err != nil {
+			return "", false, err
+		}
+		if err = issueServerCert(certFile, keyFile, hosts, caCert, caKey); err != nil {
+			return "", false, err
+		}
+	}
+	certs, err := loadCerts(certFile)
+	if err != nil {
+		return "", false, err
+	}
+	return Fingerprint(certs[len(certs)-1]), issued, nil
+}
+
+// needIssue report whether the server certificate is not found or is issued by the local CA and about to expire
+func needIssue(certFile, keyFile, caCertFile, caKeyFile string) (bool, error) {
+	for _, name := range []string{certFile, keyFile} {
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			return true, nil
+		} else if err != nil {
+			return false, err
+		}
+	}
+	for _, name := range []string{caCertFile, caKeyFile} {
+		if _, err := os.Stat(name); err != nil {
+			// the server certificate is not managed by the local CA
+			return false, nil
+		}
+	}
+	certs, err := loadCerts(certFile)
+	if err != nil {
+		return false, err
+	}
+	caCerts, err := loadCerts(caCertFile)
+	if err != nil {
+		return false, err
+	}
+	if certs[0].CheckSignatureFrom(caCerts[0]) != nil {
+		return false, nil
+	}
+	return time.Until(certs[0].NotAfter) < renewBefore, nil
+}
+
+func loadOrCreateCA(caCertFile, caKeyFile string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	if pair, err := tls.LoadX509KeyPair(caCertFile, caKeyFile); err == nil {
+		key, ok := pair.PrivateKey.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, nil, errInvalidPEM
+		}
+		cert, err := x509.ParseCertificate(pair.Certificate[0])
+		return cert, key, err
+	} else if !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+	hostname, _ := os.Hostname()
+	template := &x509.Certificate{
+		Subject:               pkix.Name{Organization: []string{"gofs"}, CommonName: "gofs local CA " + hostname},
+		NotAfter:              time.Now().Add(caValidity),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		MaxPathLenZero:        true,
+	}
+	key, cert, err := createCert(template, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = writeKey(caKeyFile, key); err != nil {
+		return nil, nil, err
+	}
+	return cert, key, writeCerts(caCertFile, cert)
+}
+
+func issueServerCert(certFile, keyFile string, hosts []string, caCert *x509.Certificate, caKey *ecdsa.PrivateKey) error {
+	template := &x509.Certificate{
+		Subject:     pkix.Name{Organization: []string{"gofs"}, CommonName: "gofs server"},
+		NotAfter:    time.Now().Add(serverValidity),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if len(hosts) > 0 {
+		template.Subject.CommonName = hosts[0]
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else if len(host) > 0 {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	key, cert, err := createCert(template, caCert, caKey)
+	if err != nil {
+		return err
+	}
+	if err = writeKey(keyFile, key); err != nil {
+		return err
+	}
+	// present the CA certificate in the chain, so the client can pin the fingerprint of the CA
+	return writeCerts(certFile, cert, caCert)
+}
+
+// createCert create a certificate by the template and sign it by the parent, the certificate is self-signed if the parent is nil
+func createCert(template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*ecdsa.PrivateKey, *x509.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	if template.SerialNumber, err = rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128)); err != nil {
+		return nil, nil, err
+	}
+	template.NotBefore = time.Now().Add(-time.Hour)
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	return key, cert, err
+}
+
+func loadCerts(name string) (certs []*x509.Certificate, err error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errInvalidPEM
+	}
+	return certs, nil
+}
+
+func writeCerts(name string, certs ...*x509.Certificate) error {
+	var data []byte
+	for _, cert := range certs {
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+	return os.WriteFile(name, data, 0644)
+}
+
+func writeKey(name string, key *ecdsa.PrivateKey) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600)
+}
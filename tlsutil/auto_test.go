@@ -0,0 +1,121 @@
+package tlsutil
+
+import (
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAutoCert(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "gofs.pem")
+	keyFile := filepath.Join(dir, "gofs.key")
+	hosts := []string{"127.0.0.1", "localhost"}
+
+	fingerprint, issued, err := AutoCert(certFile, keyFile, hosts)
+	if err != nil {
+		t.Fatalf("AutoCert error => %v", err)
+	}
+	if !issued {
+		t.Errorf("AutoCert expect to issue the server certificate on first use")
+	}
+	caCerts, err := loadCerts(filepath.Join(dir, CACertFileName))
+	if err != nil {
+		t.Fatalf("load the CA certificate error => %v", err)
+	}
+	if expect := Fingerprint(caCerts[0]); fingerprint != expect {
+		t.Errorf("AutoCert expect to get the CA fingerprint %s, but get %s", expect, fingerprint)
+	}
+	certs, err := loadCerts(certFile)
+	if err != nil {
+		t.Fatalf("load the server certificate error => %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(caCerts[0])
+	for _, host := range hosts {
+		if _, err = certs[0].Verify(x509.VerifyOptions{Roots: roots, DNSName: host}); err != nil {
+			t.Errorf("verify the server certificate for %s error => %v", host, err)
+		}
+	}
+
+	testCases := []struct {
+		name   string
+		remove bool
+		issued bool
+	}{
+		{"keep the existing certificate", false, false},
+		{"re-issue the removed certificate by the same CA", true, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.remove {
+				os.Remove(certFile)
+				os.Remove(keyFile)
+			}
+			actual, issued, err := AutoCert(certFile, keyFile, hosts)
+			if err != nil {
+				t.Errorf("AutoCert error => %v", err)
+				return
+			}
+			if issued != tc.issued {
+				t.Errorf("AutoCert expect to get issued %v, but get %v", tc.issued, issued)
+			}
+			if actual != fingerprint {
+				t.Errorf("AutoCert expect to get the fingerprint %s, but get %s", fingerprint, actual)
+			}
+		})
+	}
+}
+
+func TestAutoCert_Renew(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "gofs.pem")
+	keyFile := filepath.Join(dir, "gofs.key")
+	caCert, caKey, err := loadOrCreateCA(filepath.Join(dir, CACertFileName), filepath.Join(dir, CAKeyFileName))
+	if err != nil {
+		t.Fatalf("create the CA error => %v", err)
+	}
+	key, cert, err := createCert(&x509.Certificate{NotAfter: time.Now().Add(24 * time.Hour)}, caCert, caKey)
+	if err != nil {
+		t.Fatalf("create the server certificate error => %v", err)
+	}
+	if err = writeKey(keyFile, key); err != nil {
+		t.Fatalf("write the key file error => %v", err)
+	}
+	if err = writeCerts(certFile, cert, caCert); err != nil {
+		t.Fatalf("write the cert file error => %v", err)
+	}
+
+	if _, issued, err := AutoCert(certFile, keyFile, []string{"127.0.0.1"}); err != nil || !issued {
+		t.Errorf("AutoCert expect to renew the expiring certificate, but get issued %v, %v", issued, err)
+	}
+	certs, err := loadCerts(certFile)
+	if err != nil {
+		t.Fatalf("load the server certificate error => %v", err)
+	}
+	if time.Until(certs[0].NotAfter) < renewBefore {
+		t.Errorf("AutoCert expect to renew the certificate, but it expires at %v", certs[0].NotAfter)
+	}
+}
+
+func TestAutoCert_UnmanagedCert(t *testing.T) {
+	certs := newTestCerts(t)
+	before, err := os.ReadFile(certs.serverCertFile)
+	if err != nil {
+		t.Fatalf("read the cert file error => %v", err)
+	}
+	fingerprint, issued, err := AutoCert(certs.serverCertFile, certs.serverKeyFile, nil)
+	if err != nil {
+		t.Fatalf("AutoCert error => %v", err)
+	}
+	after, _ := os.ReadFile(certs.serverCertFile)
+	if issued || string(before) != string(after) {
+		t.Errorf("AutoCert expect to keep the certificate that is not issued by the local CA")
+	}
+	serverCerts, _ := loadCerts(certs.serverCertFile)
+	if expect := Fingerprint(serverCerts[0]); fingerprint != expect {
+		t.Errorf("AutoCert expect to get the fingerprint %s, but get %s", expect, fingerprint)
+	}
+}
@@ -0,0 +1,75 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var (
+	errAppendCertsFromPemFailed = errors.New("append certs from pem failed")
+	errClientCertPairRequired   = errors.New("the client cert file and the client key file must be specified together")
+)
+
+// ServerConfig create a tls config for the server, require and verify the client certificates by the client CA bundle if the clientCAFile is not empty,
+// the certificate is reloaded when the certFile or the keyFile is changed
+func ServerConfig(certFile string, keyFile string, clientCAFile string) (*tls.Config, error) {
+	reloader, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		GetCertificate: reloader.GetCertificate,
+	}
+	if len(clientCAFile) > 0 {
+		if config.ClientCAs, err = loadCertPool(clientCAFile); err != nil {
+			return nil, err
+		}
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// ClientConfig create a tls config for the client, verify the server certificate by the pin if it is enabled, otherwise verify it by the certFile
+// if the insecureSkipVerify is false, and present the client certificate to the server if the clientCertFile and clientKeyFile are not empty
+func ClientConfig(insecureSkipVerify bool, certFile string, clientCertFile string, clientKeyFile string, pin Pin) (*tls.Config, error) {
+	config := &tls.Config{
+		InsecureSkipVerify: insecureSkipVerify,
+	}
+	var err error
+	if pin.Enabled() {
+		// the certificate chain and host name are verified by the pin instead
+		config.InsecureSkipVerify = true
+		config.VerifyConnection = pin.verifyConnection
+	} else if !insecureSkipVerify {
+		if config.RootCAs, err = loadCertPool(certFile); err != nil {
+			return nil, err
+		}
+	}
+	if len(clientCertFile) == 0 && len(clientKeyFile) == 0 {
+		return config, nil
+	}
+	if len(clientCertFile) == 0 || len(clientKeyFile) == 0 {
+		return nil, errClientCertPairRequired
+	}
+	cert, err := tls.LoadX509KeyPair(clientCertFile, clientKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	config.Certificates = []tls.Certificate{cert}
+	return config, nil
+}
+
+func loadCertPool(certFile string) (*x509.CertPool, error) {
+	pemCerts, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pemCerts) {
+		return nil, fmt.Errorf("%w => %s", errAppendCertsFromPemFailed, certFile)
+	}
+	return pool, nil
+}
@@ -0,0 +1,202 @@
+package tlsutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCerts struct {
+	caFile         string
+	serverCertFile string
+	serverKeyFile  string
+	clientCertFile string
+	clientKeyFile  string
+}
+
+func TestServerConfig(t *testing.T) {
+	certs := newTestCerts(t)
+	testCases := []struct {
+		name         string
+		clientCAFile string
+		clientAuth   tls.ClientAuthType
+	}{
+		{"without client CA", "", tls.NoClientCert},
+		{"with client CA", certs.caFile, tls.RequireAndVerifyClientCert},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config, err := ServerConfig(certs.serverCertFile, certs.serverKeyFile, tc.clientCAFile)
+			if err != nil {
+				t.Errorf("ServerConfig error => %v", err)
+				return
+			}
+			if config.ClientAuth != tc.clientAuth {
+				t.Errorf("ServerConfig expect to get client auth %v, but get %v", tc.clientAuth, config.ClientAuth)
+			}
+			if cert, err := config.GetCertificate(nil); err != nil || cert == nil {
+				t.Errorf("ServerConfig expect to get the certificate, but get %v, %v", cert, err)
+			}
+		})
+	}
+}
+
+func TestServerConfig_ReturnError(t *testing.T) {
+	certs := newTestCerts(t)
+	notFound := filepath.Join(t.TempDir(), "not_found.pem")
+	testCases := []struct {
+		name         string
+		certFile     string
+		keyFile      string
+		clientCAFile string
+		expect       error
+	}{
+		{"cert file not found", notFound, certs.serverKeyFile, "", os.ErrNotExist},
+		{"client CA file not found", certs.serverCertFile, certs.serverKeyFile, notFound, os.ErrNotExist},
+		{"invalid client CA file", certs.serverCertFile, certs.serverKeyFile, certs.serverKeyFile, errAppendCertsFromPemFailed},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ServerConfig(tc.certFile, tc.keyFile, tc.clientCAFile); !errors.Is(err, tc.expect) {
+				t.Errorf("ServerConfig expect to get error %v, but get %v", tc.expect, err)
+			}
+		})
+	}
+}
+
+func TestClientConfig(t *testing.T) {
+	certs := newTestCerts(t)
+	testCases := []struct {
+		name               string
+		insecureSkipVerify bool
+		clientCertFile     string
+		clientKeyFile      string
+		certificates       int
+	}{
+		{"insecure skip verify", true, "", "", 0},
+		{"verify server", false, "", "", 0},
+		{"with client cert", false, certs.clientCertFile, certs.clientKeyFile, 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config, err := ClientConfig(tc.insecureSkipVerify, certs.caFile, tc.clientCertFile, tc.clientKeyFile, Pin{})
+			if err != nil {
+				t.Errorf("ClientConfig error => %v", err)
+				return
+			}
+			if !tc.insecureSkipVerify && config.RootCAs == nil {
+				t.Errorf("ClientConfig expect to get the root CAs, but get nil")
+			}
+			if len(config.Certificates) != tc.certificates {
+				t.Errorf("ClientConfig expect to get %d certificates, but get %d", tc.certificates, len(config.Certificates))
+			}
+		})
+	}
+}
+
+func TestClientConfig_ReturnError(t *testing.T) {
+	certs := newTestCerts(t)
+	notFound := filepath.Join(t.TempDir(), "not_found.pem")
+	testCases := []struct {
+		name           string
+		certFile       string
+		clientCertFile string
+		clientKeyFile  string
+		expect         error
+	}{
+		{"cert file not found", notFound, "", "", os.ErrNotExist},
+		{"invalid cert file", certs.serverKeyFile, "", "", errAppendCertsFromPemFailed},
+		{"client cert without key", certs.caFile, certs.clientCertFile, "", errClientCertPairRequired},
+		{"client key without cert", certs.caFile, "", certs.clientKeyFile, errClientCertPairRequired},
+		{"client cert not found", certs.caFile, notFound, certs.clientKeyFile, os.ErrNotExist},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ClientConfig(false, tc.certFile, tc.clientCertFile, tc.clientKeyFile, Pin{}); !errors.Is(err, tc.expect) {
+				t.Errorf("ClientConfig expect to get error %v, but get %v", tc.expect, err)
+			}
+		})
+	}
+}
+
+// newTestCerts generate a CA, a server certificate for 127.0.0.1 and a client certificate for the user alice that are signed by the CA
+func newTestCerts(t *testing.T) testCerts {
+	dir := t.TempDir()
+	caKey, caCert := newTestCert(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "gofs test ca"},
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}, nil, nil)
+	serverKey, serverCert := newTestCert(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, caCert, caKey)
+	clientKey, clientCert := newTestCert(t, &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "alice"},
+		EmailAddresses: []string{"alice@example.com"},
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, caCert, caKey)
+
+	certs := testCerts{
+		caFile:         filepath.Join(dir, "ca.pem"),
+		serverCertFile: filepath.Join(dir, "server.pem"),
+		serverKeyFile:  filepath.Join(dir, "server.key"),
+		clientCertFile: filepath.Join(dir, "client.pem"),
+		clientKeyFile:  filepath.Join(dir, "client.key"),
+	}
+	writeTestPEM(t, certs.caFile, "CERTIFICATE", caCert.Raw)
+	writeTestPEM(t, certs.serverCertFile, "CERTIFICATE", serverCert.Raw)
+	writeTestKey(t, certs.serverKeyFile, serverKey)
+	writeTestPEM(t, certs.clientCertFile, "CERTIFICATE", clientCert.Raw)
+	writeTestKey(t, certs.clientKeyFile, clientKey)
+	return certs
+}
+
+func newTestCert(t *testing.T, template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*ecdsa.PrivateKey, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key error => %v", err)
+	}
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("create certificate error => %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate error => %v", err)
+	}
+	return key, cert
+}
+
+func writeTestKey(t *testing.T, path string, key *ecdsa.PrivateKey) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key error => %v", err)
+	}
+	writeTestPEM(t, path, "PRIVATE KEY", der)
+}
+
+func writeTestPEM(t *testing.T, path string, blockType string, data []byte) {
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data}), 0600); err != nil {
+		t.Fatalf("write pem file error => %v", err)
+	}
+}
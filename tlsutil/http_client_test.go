@@ -0,0 +1,72 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewHttpClient_MutualTLS(t *testing.T) {
+	certs := newTestCerts(t)
+	serverConfig, err := ServerConfig(certs.serverCertFile, certs.serverKeyFile, certs.caFile)
+	if err != nil {
+		t.Fatalf("ServerConfig error => %v", err)
+	}
+	srvURL := newTestTLSServer(t, serverConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+
+	testCases := []struct {
+		name           string
+		clientCertFile string
+		clientKeyFile  string
+		expect         string
+		expectErr      bool
+	}{
+		{"with client cert", certs.clientCertFile, certs.clientKeyFile, "alice", false},
+		{"without client cert", "", "", "", true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewHttpClient(false, certs.caFile, tc.clientCertFile, tc.clientKeyFile, Pin{}, false)
+			if err != nil {
+				t.Errorf("NewHttpClient error => %v", err)
+				return
+			}
+			resp, err := c.HttpGet(srvURL)
+			if tc.expectErr {
+				if err == nil {
+					resp.Body.Close()
+					t.Errorf("HttpGet expect to get an error without the client certificate, but get nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("HttpGet error => %v", err)
+				return
+			}
+			defer resp.Body.Close()
+			data, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Errorf("read the response body error => %v", err)
+				return
+			}
+			if string(data) != tc.expect {
+				t.Errorf("HttpGet expect to get %s, but get %s", tc.expect, string(data))
+			}
+		})
+	}
+}
+
+// newTestTLSServer start a https server with the tls config as it is, the httptest.Server.StartTLS replaces the config without any certificate
+// by its own certificate, return the url of the server
+func newTestTLSServer(t *testing.T, config *tls.Config, handler http.Handler) string {
+	srv := httptest.NewUnstartedServer(handler)
+	srv.Listener = tls.NewListener(srv.Listener, config)
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return strings.Replace(srv.URL, "http://", "https://", 1)
+}
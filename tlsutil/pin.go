@@ -0,0 +1,144 @@
+package tlsutil
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	errInvalidFingerprint  = errors.New("the fingerprint must be the SHA-256 of the certificate in hex")
+	errFingerprintMismatch = errors.New("the server certificate does not match the pinned fingerprint")
+	errNoPeerCertificate   = errors.New("the server does not present any certificate")
+
+	knownHostsMu sync.Mutex
+)
+
+// Pin verify the server certificate by the pinned SHA-256 fingerprint instead of the certificate chain and host name,
+// or trust the server certificate on first use and record its fingerprint into the known hosts file
+type Pin struct {
+	// Fingerprint the SHA-256 fingerprint of the server certificate or one of its CA certificates
+	Fingerprint string
+	// KnownHostsFile the file that records the trusted fingerprint of every host
+	KnownHostsFile string
+	// Host the server host that the fingerprint is recorded for
+	Host string
+}
+
+// Enabled report whether the server certificate is verified by the fingerprint
+func (p Pin) Enabled() bool {
+	return len(p.Fingerprint) > 0 || len(p.KnownHostsFile) > 0
+}
+
+// verifyConnection verify the certificates that the server presents by the pinned or the known fingerprint
+func (p Pin) verifyConnection(cs tls.ConnectionState) error {
+	certs := cs.PeerCertificates
+	if len(certs) == 0 {
+		return errNoPeerCertificate
+	}
+	if len(p.Fingerprint) > 0 {
+		return verifyPin(certs, p.Fingerprint)
+	}
+	knownHostsMu.Lock()
+	defer knownHostsMu.Unlock()
+	hosts, err := readKnownHosts(p.KnownHostsFile)
+	if err != nil {
+		return err
+	}
+	if fingerprint, ok := hosts[p.Host]; ok {
+		return verifyPin(certs, fingerprint)
+	}
+	// trust on first use, record the root-most certificate that the server presents, so the re-issued server
+	// certificate signed by the same CA is still trusted
+	return appendKnownHost(p.KnownHostsFile, p.Host, Fingerprint(certs[len(certs)-1]))
+}
+
+// verifyPin verify the leaf certificate is the pinned certificate or is signed by the pinned CA certificate
+func verifyPin(certs []*x509.Certificate, fingerprint string) error {
+	expect, err := ParseFingerprint(fingerprint)
+	if err != nil {
+		return err
+	}
+	for i, cert := range certs {
+		if Fingerprint(cert) != expect {
+			continue
+		}
+		if i == 0 {
+			return nil
+		}
+		opts := x509.VerifyOptions{
+			Roots:         x509.NewCertPool(),
+			Intermediates: x509.NewCertPool(),
+		}
+		opts.Roots.AddCert(cert)
+		for _, intermediate := range certs[1:i] {
+			opts.Intermediates.AddCert(intermediate)
+		}
+		_, err = certs[0].Verify(opts)
+		return err
+	}
+	return fmt.Errorf("%w => %s", errFingerprintMismatch, Fingerprint(certs[0]))
+}
+
+// Fingerprint return the SHA-256 fingerprint of the certificate, format like AB:CD:EF
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return formatFingerprint(sum[:])
+}
+
+// ParseFingerprint parse the SHA-256 fingerprint in hex that the colons are optional, return the fingerprint in the Fingerprint format
+func ParseFingerprint(s string) (string, error) {
+	b, err := hex.DecodeString(strings.ReplaceAll(strings.TrimSpace(s), ":", ""))
+	if err != nil || len(b) != sha256.Size {
+		return "", fmt.Errorf("%w => %s", errInvalidFingerprint, s)
+	}
+	return formatFingerprint(b), nil
+}
+
+func formatFingerprint(b []byte) string {
+	parts := make([]string, len(b))
+	for i, v := range b {
+		parts[i] = fmt.Sprintf("%02X", v)
+	}
+	return strings.Join(parts, ":")
+}
+
+// readKnownHosts read the known hosts file, every line is a host and its fingerprint separated by the space
+func readKnownHosts(name string) (map[string]string, error) {
+	hosts := make(map[string]string)
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return hosts, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && !strings.HasPrefix(fields[0], "#") {
+			hosts[fields[0]] = fields[1]
+		}
+	}
+	return hosts, scanner.Err()
+}
+
+func appendKnownHost(name string, host string, fingerprint string) error {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = fmt.Fprintf(f, "%s %s\n", host, fingerprint); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,130 @@
+package tlsutil
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseFingerprint(t *testing.T) {
+	expect := strings.TrimSuffix(strings.Repeat("AB:", 32), ":")
+	testCases := []struct {
+		name        string
+		fingerprint string
+	}{
+		{"with colons", expect},
+		{"without colons", strings.Repeat("ab", 32)},
+		{"with spaces", " " + strings.ToLower(expect) + " "},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := ParseFingerprint(tc.fingerprint)
+			if err != nil {
+				t.Errorf("ParseFingerprint error => %v", err)
+				return
+			}
+			if actual != expect {
+				t.Errorf("ParseFingerprint expect to get %s, but get %s", expect, actual)
+			}
+		})
+	}
+}
+
+func TestParseFingerprint_ReturnError(t *testing.T) {
+	testCases := []struct {
+		name        string
+		fingerprint string
+	}{
+		{"empty", ""},
+		{"not hex", strings.Repeat("zz", 32)},
+		{"sha1", strings.Repeat("ab", 20)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ParseFingerprint(tc.fingerprint); !errors.Is(err, errInvalidFingerprint) {
+				t.Errorf("ParseFingerprint expect to get error %v, but get %v", errInvalidFingerprint, err)
+			}
+		})
+	}
+}
+
+func TestPin(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "gofs.pem")
+	keyFile := filepath.Join(dir, "gofs.key")
+	caFingerprint, _, err := AutoCert(certFile, keyFile, []string{"localhost"})
+	if err != nil {
+		t.Fatalf("AutoCert error => %v", err)
+	}
+	certs, err := loadCerts(certFile)
+	if err != nil {
+		t.Fatalf("load the server certificate error => %v", err)
+	}
+	serverConfig, err := ServerConfig(certFile, keyFile, "")
+	if err != nil {
+		t.Fatalf("ServerConfig error => %v", err)
+	}
+	srvURL := newTestTLSServer(t, serverConfig, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	knownHostsFile := filepath.Join(dir, "known_hosts")
+	mismatchFile := filepath.Join(dir, "mismatch_known_hosts")
+	if err = appendKnownHost(mismatchFile, "127.0.0.1", strings.Repeat("ab", 32)); err != nil {
+		t.Fatalf("write the known hosts file error => %v", err)
+	}
+
+	testCases := []struct {
+		name      string
+		pin       Pin
+		expectErr bool
+	}{
+		{"pin the CA certificate", Pin{Fingerprint: caFingerprint}, false},
+		{"pin the server certificate", Pin{Fingerprint: strings.ReplaceAll(Fingerprint(certs[0]), ":", "")}, false},
+		{"pin the mismatched fingerprint", Pin{Fingerprint: strings.Repeat("ab", 32)}, true},
+		{"trust on first use", Pin{KnownHostsFile: knownHostsFile, Host: "127.0.0.1"}, false},
+		{"trust the known host", Pin{KnownHostsFile: knownHostsFile, Host: "127.0.0.1"}, false},
+		{"mismatch the known host", Pin{KnownHostsFile: mismatchFile, Host: "127.0.0.1"}, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewHttpClient(false, "", "", "", tc.pin, false)
+			if err != nil {
+				t.Errorf("NewHttpClient error => %v", err)
+				return
+			}
+			resp, err := c.HttpGet(srvURL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if tc.expectErr != (err != nil) {
+				t.Errorf("HttpGet expect to get an error %v, but get %v", tc.expectErr, err)
+			}
+		})
+	}
+
+	hosts, err := readKnownHosts(knownHostsFile)
+	if err != nil {
+		t.Fatalf("readKnownHosts error => %v", err)
+	}
+	if len(hosts) != 1 || hosts["127.0.0.1"] != caFingerprint {
+		t.Errorf("expect to record the CA fingerprint %s of the first use, but get %v", caFingerprint, hosts)
+	}
+}
+
+func TestVerifyPin_ForgedChain(t *testing.T) {
+	dir := t.TempDir()
+	caCert, _, err := loadOrCreateCA(filepath.Join(dir, CACertFileName), filepath.Join(dir, CAKeyFileName))
+	if err != nil {
+		t.Fatalf("create the CA error => %v", err)
+	}
+	// the CA certificate is public, a forged server presents it with a self-signed certificate
+	_, forged, err := createCert(&x509.Certificate{Subject: pkix.Name{CommonName: "forged"}, NotAfter: caCert.NotAfter}, nil, nil)
+	if err != nil {
+		t.Fatalf("create the forged certificate error => %v", err)
+	}
+	if err = verifyPin([]*x509.Certificate{forged, caCert}, Fingerprint(caCert)); err == nil {
+		t.Errorf("verifyPin expect to reject the certificate that is not signed by the pinned CA")
+	}
+}
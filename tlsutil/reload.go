@@ -0,0 +1,73 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"os"
+	"sync"
+	"time"
+)
+
+// reloadInterval the min interval to check whether the cert file or the key file is changed
+const reloadInterval = time.Second
+
+// certReloader load the certificate and reload it when the cert file or the key file is changed,
+// keep serving the current certificate if the changed files are invalid
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+func newCertReloader(certFile string, keyFile string) (*certReloader, error) {
+	r := &certReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	r.cert, r.modTime, r.checked = &cert, modTime, time.Now()
+	return r, nil
+}
+
+// GetCertificate return the current certificate, it is used as the tls.Config.GetCertificate
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.checked) < reloadInterval {
+		return r.cert, nil
+	}
+	r.checked = time.Now()
+	modTime, err := r.latestModTime()
+	if err != nil || modTime.Equal(r.modTime) {
+		return r.cert, nil
+	}
+	if cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile); err == nil {
+		r.cert, r.modTime = &cert, modTime
+	}
+	return r.cert, nil
+}
+
+func (r *certReloader) latestModTime() (time.Time, error) {
+	certStat, err := os.Stat(r.certFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+	keyStat, err := os.Stat(r.keyFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if keyStat.ModTime().After(certStat.ModTime()) {
+		return keyStat.ModTime(), nil
+	}
+	return certStat.ModTime(), nil
+}
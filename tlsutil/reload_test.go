@@ -0,0 +1,62 @@
+package tlsutil
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCertReloader(t *testing.T) {
+	certs := newTestCerts(t)
+	r, err := newCertReloader(certs.serverCertFile, certs.serverKeyFile)
+	if err != nil {
+		t.Fatalf("newCertReloader error => %v", err)
+	}
+	origin := r.cert
+
+	// the changed files are ignored within the reload interval
+	newCerts := newTestCerts(t)
+	copyTestFile(t, newCerts.serverCertFile, certs.serverCertFile)
+	copyTestFile(t, newCerts.serverKeyFile, certs.serverKeyFile)
+	touchTestFile(t, certs.serverCertFile, time.Now().Add(time.Minute))
+	if cert, _ := r.GetCertificate(nil); cert != origin {
+		t.Errorf("GetCertificate expect to get the origin certificate within the reload interval")
+	}
+
+	r.checked = time.Time{}
+	cert, err := r.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("GetCertificate error => %v", err)
+	}
+	if cert == origin || !bytes.Equal(cert.Certificate[0], r.cert.Certificate[0]) {
+		t.Errorf("GetCertificate expect to get the reloaded certificate")
+	}
+
+	// keep the current certificate if the changed files are invalid
+	current := r.cert
+	if err = os.WriteFile(certs.serverCertFile, []byte("invalid"), 0600); err != nil {
+		t.Fatalf("write cert file error => %v", err)
+	}
+	touchTestFile(t, certs.serverCertFile, time.Now().Add(2*time.Minute))
+	r.checked = time.Time{}
+	if cert, err = r.GetCertificate(nil); err != nil || cert != current {
+		t.Errorf("GetCertificate expect to keep the current certificate, but get %v, %v", cert, err)
+	}
+}
+
+func copyTestFile(t *testing.T, src string, dst string) {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatalf("read file error => %v", err)
+	}
+	if err = os.WriteFile(dst, data, 0600); err != nil {
+		t.Fatalf("write file error => %v", err)
+	}
+}
+
+func touchTestFile(t *testing.T, name string, modTime time.Time) {
+	if err := os.Chtimes(name, modTime, modTime); err != nil {
+		t.Fatalf("change the file times error => %v", err)
+	}
+}